@@ -34,7 +34,7 @@ func Run(args []string) {
 	output, err := cmd.CombinedOutput()
 	if err != nil {
 		styles.ErrorStyle.Printf("🚫 Error: %v\n", err)
-		os.Exit(0)
+		exit(0)
 		return
 	}
 	fmt.Printf("%s\n", output)
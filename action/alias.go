@@ -1,7 +1,6 @@
 package actions
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -9,6 +8,7 @@ import (
 
 	"github.com/tacheraSasi/ellie/configs"
 	"github.com/tacheraSasi/ellie/elliecore"
+	"github.com/tacheraSasi/ellie/store"
 	"github.com/tacheraSasi/ellie/styles"
 )
 
@@ -23,26 +23,24 @@ func init() {
 	loadAliases()
 }
 
-func loadAliases() {
-	aliasFile := filepath.Join(configs.GetEllieDir(), "aliases.json")
-	if _, err := os.Stat(aliasFile); os.IsNotExist(err) {
-		aliases = []Alias{}
-		return
-	}
+// aliasStore is the file aliases are kept in
+func aliasStore() *store.File {
+	return store.New(filepath.Join(configs.GetEllieDir(), "aliases.json"), 1)
+}
 
-	data, err := os.ReadFile(aliasFile)
-	if err != nil {
-		styles.ErrorStyle.Println("Error reading aliases:", err)
-		return
-	}
+func loadAliases() {
+	aliases = []Alias{}
+	loadStore(aliasStore(), &aliases, "aliases")
+}
 
-	if err := json.Unmarshal(data, &aliases); err != nil {
-		styles.ErrorStyle.Println("Error parsing aliases:", err)
-		aliases = []Alias{}
-	}
+// updateAliases applies change to the aliases as they are saved now, and
+// saves them if change returns true
+func updateAliases(change func() bool) bool {
+	return updateStore(aliasStore(), &aliases, "aliases", change)
 }
 
-func saveAliases() {
+// writeShellAliases writes the aliases to the shell configuration file
+func writeShellAliases() {
 	// Get the user's shell configuration file
 	homeDir := os.Getenv("HOME")
 	shell := os.Getenv("SHELL")
@@ -115,19 +113,29 @@ func AliasAdd(args []string) {
 	name := parts[0]
 	command := strings.Trim(parts[1], "\"")
 
-	// Check if alias already exists
-	for i, a := range aliases {
-		if a.Name == name {
-			aliases[i].Command = command
-			saveAliases()
-			styles.SuccessStyle.Printf("Updated alias '%s'\n", name)
-			return
+	updated := false
+	saved := updateAliases(func() bool {
+		// Check if alias already exists
+		for i, a := range aliases {
+			if a.Name == name {
+				aliases[i].Command = command
+				updated = true
+				return true
+			}
 		}
-	}
 
-	// Add new alias
-	aliases = append(aliases, Alias{Name: name, Command: command})
-	saveAliases()
+		// Add new alias
+		aliases = append(aliases, Alias{Name: name, Command: command})
+		return true
+	})
+	if !saved {
+		return
+	}
+	writeShellAliases()
+	if updated {
+		styles.SuccessStyle.Printf("Updated alias '%s'\n", name)
+		return
+	}
 	styles.SuccessStyle.Printf("Added alias '%s'\n", name)
 }
 
@@ -150,16 +158,25 @@ func AliasDelete(args []string) {
 	}
 
 	name := args[1]
-	for i, a := range aliases {
-		if a.Name == name {
-			aliases = append(aliases[:i], aliases[i+1:]...)
-			saveAliases()
-			styles.SuccessStyle.Printf("Deleted alias '%s'\n", name)
-			return
+	found := false
+	saved := updateAliases(func() bool {
+		for i, a := range aliases {
+			if a.Name == name {
+				aliases = append(aliases[:i], aliases[i+1:]...)
+				found = true
+				return true
+			}
 		}
+		return false
+	})
+	if !found {
+		styles.ErrorStyle.Printf("Alias '%s' not found\n", name)
+		return
+	}
+	if saved {
+		writeShellAliases()
+		styles.SuccessStyle.Printf("Deleted alias '%s'\n", name)
 	}
-
-	styles.ErrorStyle.Printf("Alias '%s' not found\n", name)
 }
 
 func ExecuteAlias(name string) bool {
@@ -2,13 +2,21 @@ package actions
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"syscall"
 	"time"
 
+	"github.com/fatih/color"
 	"github.com/tacheraSasi/ellie/configs"
+	"github.com/tacheraSasi/ellie/daemon"
+	"github.com/tacheraSasi/ellie/runlog"
+	"github.com/tacheraSasi/ellie/schedule"
+	"github.com/tacheraSasi/ellie/store"
 	"github.com/tacheraSasi/ellie/styles"
 	"github.com/tacheraSasi/ellie/utils"
 )
@@ -17,98 +25,289 @@ type AutomationTask struct {
 	ID          string    `json:"id"`
 	Name        string    `json:"name"`
 	Command     string    `json:"command"`
-	Schedule    string    `json:"schedule"` // e.g., "daily", "hourly", "weekly", "custom"
-	Time        string    `json:"time"`     // e.g., "09:00" for daily tasks
+	Schedule    string    `json:"schedule"` // e.g., "hourly", "@09:00", "every 15m on weekdays", "0 9 * * 1-5"
 	Enabled     bool      `json:"enabled"`
 	LastRun     time.Time `json:"last_run"`
 	NextRun     time.Time `json:"next_run"`
 	Description string    `json:"description"`
+	Timeout     string    `json:"timeout,omitempty"` // e.g., "5m"; defaults to 10m
+	SkipMissed  bool      `json:"skip_missed,omitempty"`
+	// OnFailure is what happens when a run fails
+	OnFailure []FailureAction `json:"on_failure,omitempty"`
+	// Outcome of the last run
+	LastExitCode int    `json:"last_exit_code"`
+	LastError    string `json:"last_error,omitempty"`
+}
+
+const (
+	// defaultAutomationTimeout bounds tasks that don't set their own timeout
+	defaultAutomationTimeout = 10 * time.Minute
+	// missedGrace is how late a run can start and still count as on time,
+	// leaving room for the daemon's one-minute checks and slow tasks before it
+	missedGrace = 5 * time.Minute
+)
+
+// timeout is how long the task may run before it is reported as timed out
+func (t AutomationTask) timeout() time.Duration {
+	if d, err := time.ParseDuration(t.Timeout); err == nil && d > 0 {
+		return d
+	}
+	return defaultAutomationTimeout
+}
+
+// schedule parses the task's schedule
+func (t AutomationTask) schedule() (*schedule.Schedule, error) {
+	return schedule.Parse(t.Schedule)
+}
+
+// scheduleNext sets when the task runs next after a time. A task whose
+// schedule has no more runs, such as a date that has passed, is disabled.
+func scheduleNext(task *AutomationTask, after time.Time) error {
+	s, err := task.schedule()
+	if err != nil {
+		return err
+	}
+	task.NextRun = s.Next(after)
+	if task.NextRun.IsZero() {
+		task.Enabled = false
+	}
+	return nil
+}
+
+// automationDue says whether an enabled task runs at now. A run that was
+// missed, because nothing was checking when it was due, is made up once
+// however many times it was missed, unless the task skips missed runs.
+func automationDue(task AutomationTask, now time.Time) (due, missed bool) {
+	if !task.Enabled || task.NextRun.IsZero() || now.Before(task.NextRun) {
+		return false, false
+	}
+	missed = now.Sub(task.NextRun) > missedGrace
+	return !missed || !task.SkipMissed, missed
 }
 
 type AutomationData struct {
 	Tasks []AutomationTask `json:"tasks"`
 }
 
-func getAutomationFilePath() string {
-	return filepath.Join(configs.ConfigDir, "automations.json")
+// automationStore is the file automations are kept in. Version 1 moved the
+// time of day of daily and weekly tasks into their schedule.
+func automationStore() *store.File {
+	file := store.New(filepath.Join(configs.ConfigDir, "automations.json"), 1)
+	file.Migrations = map[int]store.Migration{0: migrateAutomationTimes}
+	return file
+}
+
+// migrateAutomationTimes folds the separate time of day that daily and
+// weekly tasks had before version 1 into their schedule, as in "daily at
+// 09:00"
+func migrateAutomationTimes(data json.RawMessage) (json.RawMessage, error) {
+	var old struct {
+		Tasks []map[string]json.RawMessage `json:"tasks"`
+	}
+	if err := json.Unmarshal(data, &old); err != nil {
+		return nil, err
+	}
+	for _, task := range old.Tasks {
+		var spec, at string
+		json.Unmarshal(task["schedule"], &spec)
+		json.Unmarshal(task["time"], &at)
+		if at != "" && (spec == "daily" || spec == "weekly") {
+			task["schedule"], _ = json.Marshal(spec + " at " + at)
+		}
+		delete(task, "time")
+	}
+	return json.Marshal(old)
 }
 
 func loadAutomations() (*AutomationData, error) {
 	data := &AutomationData{Tasks: []AutomationTask{}}
-	
-	filePath := getAutomationFilePath()
-	content, err := os.ReadFile(filePath)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return data, nil
-		}
-		return nil, err
+	err := automationStore().Load(data)
+	var recovered *store.RecoveredError
+	if errors.As(err, &recovered) {
+		styles.GetWarningStyle().Println("⚠️ ", err)
+		err = nil
+	}
+	if data.Tasks == nil {
+		data.Tasks = []AutomationTask{}
 	}
-	
-	err = json.Unmarshal(content, data)
 	return data, err
 }
 
-func saveAutomations(data *AutomationData) error {
-	content, err := json.MarshalIndent(data, "", "  ")
-	if err != nil {
-		return err
+// updateAutomations applies change to the automations as they are saved
+// now, and saves them if change returns true. It reports whether they were
+// saved.
+func updateAutomations(change func(data *AutomationData) bool) bool {
+	data := &AutomationData{Tasks: []AutomationTask{}}
+	return updateStore(automationStore(), data, "automations", func() bool {
+		return change(data)
+	})
+}
+
+// saveAutomationState stores what running or scheduling a task changed on
+// it. The rest of the task is left as it is saved, since it may have been
+// changed while the task ran, and a task deleted meanwhile stays deleted.
+func saveAutomationState(task AutomationTask) {
+	updateAutomations(func(data *AutomationData) bool {
+		i, found := findAutomationID(data, task.ID)
+		if !found {
+			return false
+		}
+		saved := &data.Tasks[i]
+		saved.LastRun, saved.NextRun = task.LastRun, task.NextRun
+		saved.LastExitCode, saved.LastError = task.LastExitCode, task.LastError
+		if !task.Enabled {
+			// It has no more runs
+			saved.Enabled = false
+		}
+		return true
+	})
+}
+
+// automationRuns is the history of every automation's runs
+func automationRuns() *runlog.Log {
+	return runlog.New(filepath.Join(configs.ConfigDir, "automations", "runs"))
+}
+
+// findAutomation finds a task by ID, or by name when no ID matches
+func findAutomation(data *AutomationData, idOrName string) (int, bool) {
+	if i, found := findAutomationID(data, idOrName); found {
+		return i, true
 	}
-	
-	return os.WriteFile(getAutomationFilePath(), content, 0600)
+	for i, task := range data.Tasks {
+		if strings.EqualFold(task.Name, idOrName) {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// newAutomationID is an ID no task has, since run logs are kept by ID
+func newAutomationID(data *AutomationData, prefix string) string {
+	id := fmt.Sprintf("%s_%d", prefix, time.Now().Unix())
+	for n := 2; ; n++ {
+		if _, taken := findAutomationID(data, id); !taken {
+			return id
+		}
+		id = fmt.Sprintf("%s_%d_%d", prefix, time.Now().Unix(), n)
+	}
+}
+
+func findAutomationID(data *AutomationData, id string) (int, bool) {
+	for i, task := range data.Tasks {
+		if task.ID == id {
+			return i, true
+		}
+	}
+	return -1, false
 }
 
 // AutomationAdd adds a new automation task
 func AutomationAdd(args []string) {
 	if len(args) < 3 {
-		styles.GetErrorStyle().Println("Usage: ellie automate add <name> <schedule> <command>")
-		styles.GetInfoStyle().Println("Schedules: daily, hourly, weekly, @time (e.g., @09:00)")
+		styles.GetErrorStyle().Println("Usage: ellie automate add <name> <schedule> [--timeout <duration>] [--skip-missed] [--on-failure <action>]... <command>")
+		styles.GetInfoStyle().Println(scheduleHelp)
 		return
 	}
 	
 	name := args[1]
-	schedule := args[2]
-	command := strings.Join(args[3:], " ")
-	
-	// Validate schedule
-	if !isValidSchedule(schedule) {
-		styles.GetErrorStyle().Println("Invalid schedule. Use: daily, hourly, weekly, or @HH:MM")
+	spec := args[2]
+	rest := args[3:]
+	
+	// Options right after the schedule belong to the automation, not the command
+	timeout := ""
+	skipMissed := false
+	var onFailure []FailureAction
+	for len(rest) > 0 && strings.HasPrefix(rest[0], "--") {
+		switch {
+		case rest[0] == "--skip-missed":
+			skipMissed, rest = true, rest[1:]
+		case rest[0] == "--on-failure" || strings.HasPrefix(rest[0], "--on-failure="):
+			value := ""
+			if v, found := strings.CutPrefix(rest[0], "--on-failure="); found {
+				value, rest = v, rest[1:]
+			} else if len(rest) > 1 {
+				value, rest = rest[1], rest[2:]
+			} else {
+				rest = rest[1:]
+			}
+			action, err := parseFailureAction(value)
+			if err != nil {
+				styles.GetErrorStyle().Printf("Invalid --on-failure: %v\n", err)
+				return
+			}
+			onFailure = append(onFailure, action)
+		case rest[0] == "--timeout" || strings.HasPrefix(rest[0], "--timeout="):
+			if value, found := strings.CutPrefix(rest[0], "--timeout="); found {
+				timeout, rest = value, rest[1:]
+			} else if len(rest) > 1 {
+				timeout, rest = rest[1], rest[2:]
+			} else {
+				rest = rest[1:]
+			}
+			if d, err := time.ParseDuration(timeout); err != nil || d <= 0 {
+				styles.GetErrorStyle().Println("Invalid timeout. Use a duration such as 30s, 5m or 1h")
+				return
+			}
+		default:
+			styles.GetErrorStyle().Printf("Unknown option %s\n", rest[0])
+			return
+		}
+	}
+	command := strings.Join(rest, " ")
+	if command == "" {
+		styles.GetErrorStyle().Println("Missing command, e.g. \"ellie git status\"")
 		return
 	}
 	
-	data, err := loadAutomations()
+	// Validate schedule
+	sched, err := schedule.Parse(spec)
 	if err != nil {
-		styles.GetErrorStyle().Println("Error loading automations:", err)
+		styles.GetErrorStyle().Printf("Invalid schedule: %v\n", err)
+		styles.GetInfoStyle().Println(scheduleHelp)
 		return
 	}
 	
-	// Generate unique ID
-	id := fmt.Sprintf("auto_%d", time.Now().Unix())
-	
 	// Calculate next run time
-	nextRun := calculateNextRun(schedule, "")
+	nextRun := sched.Next(time.Now())
+	if nextRun.IsZero() {
+		styles.GetErrorStyle().Printf("Schedule '%s' doesn't run again\n", spec)
+		return
+	}
 	
 	task := AutomationTask{
-		ID:          id,
 		Name:        name,
 		Command:     command,
-		Schedule:    schedule,
+		Schedule:    sched.String(),
 		Enabled:     true,
 		NextRun:     nextRun,
-		Description: fmt.Sprintf("Runs %s", schedule),
+		Description: fmt.Sprintf("Runs %s", sched),
+		Timeout:     timeout,
+		SkipMissed:  skipMissed,
+		OnFailure:   onFailure,
 	}
 	
-	data.Tasks = append(data.Tasks, task)
-	
-	if err := saveAutomations(data); err != nil {
-		styles.GetErrorStyle().Println("Error saving automation:", err)
+	saved := updateAutomations(func(data *AutomationData) bool {
+		task.ID = newAutomationID(data, "auto")
+		data.Tasks = append(data.Tasks, task)
+		return true
+	})
+	if !saved {
 		return
 	}
 	
 	styles.GetSuccessStyle().Printf("✅ Automation '%s' added successfully!\n", name)
-	fmt.Printf("   ID: %s\n", id)
-	fmt.Printf("   Schedule: %s\n", schedule)
-	fmt.Printf("   Next run: %s\n", nextRun.Format("2006-01-02 15:04:05"))
+	fmt.Printf("   ID: %s\n", task.ID)
+	fmt.Printf("   Schedule: %s\n", sched)
+	if timeout != "" {
+		fmt.Printf("   Timeout: %s\n", timeout)
+	}
+	if skipMissed {
+		fmt.Println("   Missed runs: skipped")
+	}
+	if len(onFailure) > 0 {
+		fmt.Printf("   On failure: %s\n", joinFailureActions(onFailure))
+	}
+	fmt.Printf("   Next run: %s\n", nextRun.Format("2006-01-02 15:04 MST"))
 	styles.GetInfoStyle().Println("\n💡 Tip: Run 'ellie automate run' to execute scheduled tasks")
 }
 
@@ -142,12 +341,22 @@ func AutomationList(args []string) {
 		if !task.NextRun.IsZero() {
 			timeUntil := time.Until(task.NextRun)
 			fmt.Printf("   Next run: %s (in %s)\n", 
-				task.NextRun.Format("2006-01-02 15:04"), 
+				task.NextRun.Local().Format("2006-01-02 15:04"), 
 				formatDuration(timeUntil))
 		}
 		
+		if task.Timeout != "" {
+			fmt.Printf("   Timeout: %s\n", task.Timeout)
+		}
+		if task.SkipMissed {
+			fmt.Println("   Missed runs: skipped")
+		}
+		if len(task.OnFailure) > 0 {
+			fmt.Printf("   On failure: %s\n", joinFailureActions(task.OnFailure))
+		}
+		
 		if !task.LastRun.IsZero() {
-			fmt.Printf("   Last run: %s\n", task.LastRun.Format("2006-01-02 15:04"))
+			fmt.Printf("   Last run: %s (%s)\n", task.LastRun.Format("2006-01-02 15:04"), lastRunStatus(task))
 		}
 	}
 	
@@ -163,35 +372,32 @@ func AutomationDelete(args []string) {
 	
 	id := args[1]
 	
-	data, err := loadAutomations()
-	if err != nil {
-		styles.GetErrorStyle().Println("Error loading automations:", err)
-		return
-	}
-	
-	newTasks := []AutomationTask{}
 	found := false
-	
-	for _, task := range data.Tasks {
-		if task.ID == id {
-			found = true
-			continue
+	saved := updateAutomations(func(data *AutomationData) bool {
+		newTasks := []AutomationTask{}
+		for _, task := range data.Tasks {
+			if task.ID == id {
+				found = true
+				continue
+			}
+			newTasks = append(newTasks, task)
 		}
-		newTasks = append(newTasks, task)
-	}
+		data.Tasks = newTasks
+		return found
+	})
 	
 	if !found {
 		styles.GetErrorStyle().Printf("Automation with ID '%s' not found\n", id)
 		return
 	}
-	
-	data.Tasks = newTasks
-	
-	if err := saveAutomations(data); err != nil {
-		styles.GetErrorStyle().Println("Error saving automations:", err)
+	if !saved {
 		return
 	}
 	
+	if err := automationRuns().Remove(id); err != nil {
+		styles.GetErrorStyle().Println("Error removing the automation's run history:", err)
+	}
+	
 	styles.GetSuccessStyle().Printf("✅ Automation '%s' deleted\n", id)
 }
 
@@ -204,37 +410,31 @@ func AutomationToggle(args []string) {
 	
 	id := args[1]
 	
-	data, err := loadAutomations()
-	if err != nil {
-		styles.GetErrorStyle().Println("Error loading automations:", err)
-		return
-	}
-	
+	var toggled AutomationTask
 	found := false
-	for i, task := range data.Tasks {
-		if task.ID == id {
-			data.Tasks[i].Enabled = !data.Tasks[i].Enabled
-			found = true
-			
-			status := "disabled"
-			if data.Tasks[i].Enabled {
-				status = "enabled"
-			}
-			
-			styles.GetSuccessStyle().Printf("✅ Automation '%s' %s\n", task.Name, status)
-			break
+	saved := updateAutomations(func(data *AutomationData) bool {
+		i, ok := findAutomationID(data, id)
+		if !ok {
+			return false
 		}
-	}
+		data.Tasks[i].Enabled = !data.Tasks[i].Enabled
+		toggled, found = data.Tasks[i], true
+		return true
+	})
 	
 	if !found {
 		styles.GetErrorStyle().Printf("Automation with ID '%s' not found\n", id)
 		return
 	}
-	
-	if err := saveAutomations(data); err != nil {
-		styles.GetErrorStyle().Println("Error saving automations:", err)
+	if !saved {
 		return
 	}
+	
+	status := "disabled"
+	if toggled.Enabled {
+		status = "enabled"
+	}
+	styles.GetSuccessStyle().Printf("✅ Automation '%s' %s\n", toggled.Name, status)
 }
 
 // AutomationRun executes due automation tasks
@@ -256,25 +456,55 @@ func AutomationRun(args []string) {
 	tasksRun := 0
 	
 	for i, task := range data.Tasks {
-		if !task.Enabled {
+		if task.Enabled && task.NextRun.IsZero() {
+			// Not scheduled yet, as tasks from older versions may be
+			if err := scheduleNext(&data.Tasks[i], now); err != nil {
+				styles.GetErrorStyle().Printf("❌ %s: invalid schedule: %v\n", task.Name, err)
+				continue
+			}
+			saveAutomationState(data.Tasks[i])
 			continue
 		}
 		
 		// Check if task is due
-		if task.NextRun.IsZero() || now.After(task.NextRun) {
-			styles.GetHighlightStyle().Printf("\n▶️  Running: %s\n", task.Name)
+		due, missed := automationDue(task, now)
+		if missed && !due {
+			styles.GetWarningStyle().Printf("\n⏭️  Skipped: %s missed its run at %s\n", task.Name, task.NextRun.Format("2006-01-02 15:04"))
+			scheduleNext(&data.Tasks[i], now)
+			saveAutomationState(data.Tasks[i])
+			continue
+		}
+		if due {
+			if missed {
+				styles.GetHighlightStyle().Printf("\n▶️  Running: %s (missed at %s)\n", task.Name, task.NextRun.Format("2006-01-02 15:04"))
+			} else {
+				styles.GetHighlightStyle().Printf("\n▶️  Running: %s\n", task.Name)
+			}
 			
 			// Execute the command
-			err := executeAutomationCommand(task.Command)
-			if err != nil {
-				styles.GetErrorStyle().Printf("❌ Error: %v\n", err)
+			result, problems := runAutomation(&data.Tasks[i])
+			fmt.Print(result.Stdout)
+			if result.Stderr != "" {
+				styles.GetErrorStyle().Print(result.Stderr)
+			}
+			if result.Err != nil {
+				styles.GetErrorStyle().Printf("❌ Error: %v\n", result.Err)
 			} else {
-				styles.GetSuccessStyle().Println("✅ Completed")
+				styles.GetSuccessStyle().Printf("✅ Completed in %s\n", result.Duration.Round(time.Millisecond))
+			}
+			for _, problem := range problems {
+				styles.GetWarningStyle().Printf("⚠️  %v\n", problem)
 			}
 			
 			// Update last run and calculate next run
 			data.Tasks[i].LastRun = now
-			data.Tasks[i].NextRun = calculateNextRun(task.Schedule, task.Time)
+			// Runs that came due while the task ran are passed over
+			if err := scheduleNext(&data.Tasks[i], time.Now()); err != nil {
+				styles.GetErrorStyle().Printf("❌ Invalid schedule: %v\n", err)
+			} else if !data.Tasks[i].Enabled {
+				styles.GetInfoStyle().Println("No more runs scheduled; the automation is now disabled")
+			}
+			saveAutomationState(data.Tasks[i])
 			tasksRun++
 		}
 	}
@@ -284,16 +514,41 @@ func AutomationRun(args []string) {
 	} else {
 		styles.GetSuccessStyle().Printf("\n✅ Executed %d task(s)\n", tasksRun)
 	}
-	
-	// Save updated task data
-	if err := saveAutomations(data); err != nil {
-		styles.GetErrorStyle().Println("Error saving automations:", err)
-	}
 }
 
-// AutomationDaemon runs the automation daemon
+// AutomationDaemon runs the automation daemon. Only one runs at a time;
+// --log sends its output to a file, for service managers that drop it.
 func AutomationDaemon(args []string) {
-	styles.GetInfoStyle().Println("🤖 Starting Ellie Automation Daemon")
+	logPath, found, _, err := popValue(args[1:], "log")
+	if err != nil {
+		styles.GetErrorStyle().Println("Error:", err)
+		exit(1)
+	}
+	if found {
+		logFile, err := daemon.OpenLog(logPath)
+		if err != nil {
+			styles.GetErrorStyle().Println("Error opening the log:", err)
+			exit(1)
+		}
+		defer logFile.Close()
+		os.Stdout, os.Stderr = logFile, logFile
+		color.Output, color.Error, color.NoColor = logFile, logFile, true
+	}
+	
+	lock, err := daemon.Acquire(daemonLockPath())
+	if err != nil {
+		styles.GetErrorStyle().Println("Error:", err)
+		exit(1)
+	}
+	defer lock.Release()
+	
+	// Give up the lock when stopped, so status doesn't report a daemon that
+	// is gone
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+	
+	styles.GetInfoStyle().Printf("🤖 Starting Ellie Automation Daemon (PID %d)\n", os.Getpid())
 	styles.GetInfoStyle().Println("Press Ctrl+C to stop")
 	fmt.Println()
 	
@@ -307,6 +562,9 @@ func AutomationDaemon(args []string) {
 		select {
 		case <-ticker.C:
 			checkAndRunAutomations()
+		case <-stop:
+			fmt.Printf("[%s] Stopping\n", time.Now().Format("15:04:05"))
+			return
 		}
 	}
 }
@@ -320,120 +578,131 @@ func checkAndRunAutomations() {
 	now := time.Now()
 	
 	for i, task := range data.Tasks {
-		if !task.Enabled {
+		if task.Enabled && task.NextRun.IsZero() {
+			if err := scheduleNext(&data.Tasks[i], now); err != nil {
+				fmt.Printf("[%s] Invalid schedule: %s: %v\n", now.Format("15:04:05"), task.Name, err)
+				continue
+			}
+			saveAutomationState(data.Tasks[i])
 			continue
 		}
 		
-		if task.NextRun.IsZero() || now.After(task.NextRun) {
-			fmt.Printf("[%s] Running: %s\n", now.Format("15:04:05"), task.Name)
+		due, missed := automationDue(task, now)
+		if missed && !due {
+			fmt.Printf("[%s] Skipped: %s missed its run at %s\n", now.Format("15:04:05"), task.Name, task.NextRun.Format("2006-01-02 15:04"))
+			scheduleNext(&data.Tasks[i], now)
+			saveAutomationState(data.Tasks[i])
+			continue
+		}
+		if due {
+			if missed {
+				fmt.Printf("[%s] Running: %s (missed at %s)\n", now.Format("15:04:05"), task.Name, task.NextRun.Format("2006-01-02 15:04"))
+			} else {
+				fmt.Printf("[%s] Running: %s\n", now.Format("15:04:05"), task.Name)
+			}
 			
-			err := executeAutomationCommand(task.Command)
-			if err != nil {
-				fmt.Printf("[%s] Error: %v\n", now.Format("15:04:05"), err)
+			result, problems := runAutomation(&data.Tasks[i])
+			finished := time.Now().Format("15:04:05")
+			if result.Err != nil {
+				fmt.Printf("[%s] Failed: %s: %v\n", finished, task.Name, result.Err)
+				if line := firstLine(result.Stderr); line != "" {
+					fmt.Printf("[%s]   %s\n", finished, line)
+				}
 			} else {
-				fmt.Printf("[%s] Completed: %s\n", now.Format("15:04:05"), task.Name)
+				fmt.Printf("[%s] Completed: %s in %s\n", finished, task.Name, result.Duration.Round(time.Millisecond))
+			}
+			
+			for _, problem := range problems {
+				fmt.Printf("[%s]   %v\n", finished, problem)
 			}
 			
 			data.Tasks[i].LastRun = now
-			data.Tasks[i].NextRun = calculateNextRun(task.Schedule, task.Time)
+			if err := scheduleNext(&data.Tasks[i], time.Now()); err != nil {
+				fmt.Printf("[%s] Invalid schedule: %s: %v\n", finished, task.Name, err)
+			} else if !data.Tasks[i].Enabled {
+				fmt.Printf("[%s] No more runs: %s is now disabled\n", finished, task.Name)
+			}
+			saveAutomationState(data.Tasks[i])
 		}
 	}
-	
-	saveAutomations(data)
 }
 
-func executeAutomationCommand(command string) error {
-	// Parse and execute the command
-	// For safety, we'll only allow ellie commands
-	if !strings.HasPrefix(command, "ellie ") {
-		return fmt.Errorf("only ellie commands are allowed in automations")
-	}
-	
-	// Remove "ellie " prefix
-	command = strings.TrimPrefix(command, "ellie ")
-	
-	// Split into args
-	args := strings.Fields(command)
-	if len(args) == 0 {
-		return fmt.Errorf("empty command")
-	}
-	
-	// Execute based on command
-	// This is a simplified version - in production, you'd use the command registry
-	fmt.Printf("   Executing: ellie %s\n", command)
-	
-	return nil
+// executeAutomationCommand runs the task's ellie command in-process
+// through the command registry, capturing its output
+func executeAutomationCommand(task AutomationTask) CommandResult {
+	return dispatchCommand(task.Command, task.timeout())
 }
 
-func isValidSchedule(schedule string) bool {
-	validSchedules := []string{"daily", "hourly", "weekly"}
-	
-	for _, valid := range validSchedules {
-		if schedule == valid {
-			return true
+// runAutomation runs a task, stores the outcome on it and in its run
+// history, and carries out its failure actions if it fails. It returns
+// what went wrong besides the run itself.
+func runAutomation(task *AutomationTask) (CommandResult, []error) {
+	start := time.Now()
+	result := executeAutomationCommand(*task)
+	recordAutomationResult(task, result)
+	
+	run := runlog.Run{
+		TaskID:   task.ID,
+		TaskName: task.Name,
+		Command:  task.Command,
+		Start:    start,
+		End:      start.Add(result.Duration),
+		Duration: result.Duration,
+		ExitCode: result.ExitCode,
+		TimedOut: result.TimedOut,
+		Error:    task.LastError,
+		Stdout:   result.Stdout,
+		Stderr:   result.Stderr,
+	}
+	var problems []error
+	if err := automationRuns().Record(run); err != nil {
+		problems = append(problems, err)
+	}
+	if run.Failed() {
+		for _, err := range runFailureActions(task.OnFailure, run) {
+			problems = append(problems, fmt.Errorf("on failure %w", err))
 		}
 	}
-	
-	// Check for @time format
-	if strings.HasPrefix(schedule, "@") && len(schedule) == 6 {
-		// Format: @HH:MM
-		return true
+	return result, problems
+}
+
+// recordAutomationResult stores the outcome of a run on the task
+func recordAutomationResult(task *AutomationTask, result CommandResult) {
+	task.LastExitCode = result.ExitCode
+	task.LastError = ""
+	if result.Err != nil {
+		task.LastError = result.Err.Error()
 	}
-	
-	return false
 }
 
-func calculateNextRun(schedule, timeStr string) time.Time {
-	now := time.Now()
-	
-	switch schedule {
-	case "hourly":
-		return now.Add(1 * time.Hour)
-	
-	case "daily":
-		// If time is specified, use it; otherwise use current time tomorrow
-		if timeStr != "" {
-			// Parse time
-			parts := strings.Split(timeStr, ":")
-			if len(parts) == 2 {
-				var hour, minute int
-				fmt.Sscanf(parts[0], "%d", &hour)
-				fmt.Sscanf(parts[1], "%d", &minute)
-				
-				nextRun := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
-				if nextRun.Before(now) {
-					nextRun = nextRun.Add(24 * time.Hour)
-				}
-				return nextRun
-			}
-		}
-		return now.Add(24 * time.Hour)
-	
-	case "weekly":
-		return now.Add(7 * 24 * time.Hour)
-	
-	default:
-		// Check for @time format
-		if strings.HasPrefix(schedule, "@") {
-			timeStr := strings.TrimPrefix(schedule, "@")
-			parts := strings.Split(timeStr, ":")
-			if len(parts) == 2 {
-				hour := 0
-				minute := 0
-				fmt.Sscanf(parts[0], "%d", &hour)
-				fmt.Sscanf(parts[1], "%d", &minute)
-				
-				nextRun := time.Date(now.Year(), now.Month(), now.Day(), hour, minute, 0, 0, now.Location())
-				if nextRun.Before(now) {
-					nextRun = nextRun.Add(24 * time.Hour)
-				}
-				return nextRun
-			}
+// lastRunStatus describes how the task's last run ended
+func lastRunStatus(task AutomationTask) string {
+	if task.LastError != "" {
+		return task.LastError
+	}
+	return fmt.Sprintf("exit status %d", task.LastExitCode)
+}
+
+// firstLine is the first non-blank line of s
+func firstLine(s string) string {
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
 		}
-		return now.Add(1 * time.Hour)
 	}
+	return ""
 }
 
+// scheduleHelp lists the schedules automate add accepts
+const scheduleHelp = `Schedules:
+  hourly, daily, weekly             every hour, at midnight, on Mondays at midnight
+  every 15m, every 2h               intervals, counted from midnight
+  @09:00, daily at 18:30            a time of day
+  weekdays at 08:00, sat,sun @10:00 days of the week, also "on mon-fri"
+  "30 9 * * 1-5", @monthly          cron expressions and shorthands
+  2026-12-24 09:00                  a date, run once
+Add TZ=<zone> for a time zone, e.g. "daily at 09:00 TZ=Europe/Berlin"`
+
 // QuickAutomations sets up common automation tasks
 func QuickAutomations(args []string) {
 	styles.GetInfoStyle().Println("\n⚡ Quick Automation Setup")
@@ -452,12 +721,6 @@ func QuickAutomations(args []string) {
 		return
 	}
 	
-	data, err := loadAutomations()
-	if err != nil {
-		styles.GetErrorStyle().Println("Error loading automations:", err)
-		return
-	}
-	
 	quickTasks := make(map[string]AutomationTask)
 	quickTasks["1"] = AutomationTask{
 		ID:          fmt.Sprintf("auto_health_%d", time.Now().Unix()),
@@ -465,7 +728,6 @@ func QuickAutomations(args []string) {
 		Command:     "ellie health",
 		Schedule:    "@09:00",
 		Enabled:     true,
-		NextRun:     calculateNextRun("@09:00", ""),
 		Description: "Daily system health check",
 	}
 	
@@ -475,7 +737,6 @@ func QuickAutomations(args []string) {
 		Command:     "ellie git status",
 		Schedule:    "hourly",
 		Enabled:     true,
-		NextRun:     calculateNextRun("hourly", ""),
 		Description: "Check git status every hour",
 	}
 	
@@ -485,7 +746,6 @@ func QuickAutomations(args []string) {
 		Command:     "ellie disk space",
 		Schedule:    "@23:00",
 		Enabled:     true,
-		NextRun:     calculateNextRun("@23:00", ""),
 		Description: "Check disk space daily",
 	}
 	
@@ -493,29 +753,41 @@ func QuickAutomations(args []string) {
 		ID:          fmt.Sprintf("auto_update_%d", time.Now().Unix()),
 		Name:        "Weekly Update Check",
 		Command:     "ellie update",
-		Schedule:    "weekly",
+		Schedule:    "sun at 10:00",
 		Enabled:     true,
-		NextRun:     calculateNextRun("weekly", ""),
 		Description: "Check for system updates weekly",
 	}
 	
+	now := time.Now()
+	for key, task := range quickTasks {
+		scheduleNext(&task, now)
+		quickTasks[key] = task
+	}
+	
+	var chosen []AutomationTask
 	if choice == "5" {
 		for _, task := range quickTasks {
-			data.Tasks = append(data.Tasks, task)
+			chosen = append(chosen, task)
 		}
-		styles.GetSuccessStyle().Println("✅ All automations enabled!")
 	} else if task, ok := quickTasks[choice]; ok {
-		data.Tasks = append(data.Tasks, task)
-		styles.GetSuccessStyle().Printf("✅ Automation '%s' enabled!\n", task.Name)
+		chosen = append(chosen, task)
 	} else {
 		styles.GetErrorStyle().Println("Invalid choice")
 		return
 	}
 	
-	if err := saveAutomations(data); err != nil {
-		styles.GetErrorStyle().Println("Error saving automations:", err)
+	saved := updateAutomations(func(data *AutomationData) bool {
+		data.Tasks = append(data.Tasks, chosen...)
+		return true
+	})
+	if !saved {
 		return
 	}
+	if len(chosen) > 1 {
+		styles.GetSuccessStyle().Println("✅ All automations enabled!")
+	} else {
+		styles.GetSuccessStyle().Printf("✅ Automation '%s' enabled!\n", chosen[0].Name)
+	}
 	
 	styles.GetInfoStyle().Println("\n💡 Run 'ellie automate run' to execute scheduled tasks")
 	styles.GetInfoStyle().Println("💡 Run 'ellie automate daemon' to start the automation daemon")
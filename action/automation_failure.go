@@ -0,0 +1,225 @@
+package actions
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/tacheraSasi/ellie/configs"
+	"github.com/tacheraSasi/ellie/runlog"
+	"github.com/tacheraSasi/ellie/styles"
+	"github.com/tacheraSasi/ellie/utils"
+)
+
+// Failure action types
+const (
+	failureNotify  = "notify"
+	failureEmail   = "email"
+	failureWebhook = "webhook"
+)
+
+// FailureAction is what happens when an automation's run fails
+type FailureAction struct {
+	Type string `json:"type"`
+	// Target is the email address or webhook URL
+	Target string `json:"target,omitempty"`
+}
+
+// String is the action as it is written on the command line
+func (a FailureAction) String() string {
+	if a.Target == "" {
+		return a.Type
+	}
+	return a.Type + ":" + a.Target
+}
+
+// joinFailureActions lists actions as they are written on the command line
+func joinFailureActions(actions []FailureAction) string {
+	names := make([]string, len(actions))
+	for i, action := range actions {
+		names[i] = action.String()
+	}
+	return strings.Join(names, ", ")
+}
+
+// parseFailureAction reads notify, email:<address> or webhook:<url>
+func parseFailureAction(value string) (FailureAction, error) {
+	kind, target, _ := strings.Cut(value, ":")
+	action := FailureAction{Type: strings.ToLower(kind), Target: strings.TrimSpace(target)}
+	switch action.Type {
+	case failureNotify:
+		if action.Target != "" {
+			return action, errors.New("notify takes no target")
+		}
+	case failureEmail:
+		if !strings.Contains(action.Target, "@") {
+			return action, fmt.Errorf("invalid email address %q; use email:you@example.com", action.Target)
+		}
+	case failureWebhook:
+		u, err := url.Parse(action.Target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return action, fmt.Errorf("invalid webhook URL %q; use webhook:https://...", action.Target)
+		}
+	default:
+		return action, fmt.Errorf("unknown failure action %q; use notify, email:<address> or webhook:<url>", value)
+	}
+	return action, nil
+}
+
+// failureSummary is the one-line description of a failed run
+func failureSummary(run runlog.Run) string {
+	reason := run.Error
+	if reason == "" {
+		reason = fmt.Sprintf("exit status %d", run.ExitCode)
+	}
+	return fmt.Sprintf("Automation '%s' failed: %s", run.TaskName, reason)
+}
+
+// outputTail is the last lines of a run's output, for messages that can't
+// hold all of it
+func outputTail(run runlog.Run, lines int) string {
+	out := strings.TrimRight(run.Stdout+run.Stderr, "\n")
+	all := strings.Split(out, "\n")
+	if len(all) > lines {
+		all = append([]string{"..."}, all[len(all)-lines:]...)
+	}
+	return strings.Join(all, "\n")
+}
+
+// runFailureActions tells the task's failure actions about a failed run. It
+// returns the error of each action that couldn't be carried out.
+func runFailureActions(actions []FailureAction, run runlog.Run) []error {
+	var errs []error
+	for _, action := range actions {
+		var err error
+		switch action.Type {
+		case failureNotify:
+			err = utils.NotifyWithTitle("⚠️ Ellie Automation", failureSummary(run))
+		case failureEmail:
+			err = emailFailure(action.Target, run)
+		case failureWebhook:
+			err = postFailure(action.Target, run)
+		default:
+			err = errors.New("unknown action")
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", action, err))
+		}
+	}
+	return errs
+}
+
+// emailFailure sends a failed run's details through the email relay
+func emailFailure(to string, run runlog.Run) error {
+	apiKey := configs.GetEnv("RELAY_API_KEY")
+	if apiKey == "" {
+		return fmt.Errorf("RELAY_API_KEY is not set in %s", configs.ConfigPath)
+	}
+	var message strings.Builder
+	fmt.Fprintf(&message, "%s\n\n", failureSummary(run))
+	fmt.Fprintf(&message, "Command: %s\n", run.Command)
+	fmt.Fprintf(&message, "Started: %s\n", run.Start.Format(time.RFC1123))
+	fmt.Fprintf(&message, "Duration: %s\n", run.Duration.Round(time.Millisecond))
+	if out := outputTail(run, 40); out != "" {
+		fmt.Fprintf(&message, "\nOutput:\n%s\n", out)
+	}
+	return sendEmail(apiKey, to, failureSummary(run), message.String())
+}
+
+// webhookPayload is the JSON body posted to failure webhooks
+type webhookPayload struct {
+	Event    string  `json:"event"`
+	Text     string  `json:"text"`
+	TaskID   string  `json:"task_id"`
+	TaskName string  `json:"task_name"`
+	Command  string  `json:"command"`
+	Start    string  `json:"start"`
+	End      string  `json:"end"`
+	Duration float64 `json:"duration_seconds"`
+	ExitCode int     `json:"exit_code"`
+	TimedOut bool    `json:"timed_out"`
+	Error    string  `json:"error,omitempty"`
+	Output   string  `json:"output,omitempty"`
+}
+
+// postFailure posts a failed run to a webhook. The text field suits chat
+// webhooks such as Slack's; the other fields are for everything else.
+func postFailure(webhookURL string, run runlog.Run) error {
+	body, err := json.Marshal(webhookPayload{
+		Event:    "automation.failed",
+		Text:     failureSummary(run),
+		TaskID:   run.TaskID,
+		TaskName: run.TaskName,
+		Command:  run.Command,
+		Start:    run.Start.Format(time.RFC3339),
+		End:      run.End.Format(time.RFC3339),
+		Duration: run.Duration.Seconds(),
+		ExitCode: run.ExitCode,
+		TimedOut: run.TimedOut,
+		Error:    run.Error,
+		Output:   outputTail(run, 40),
+	})
+	if err != nil {
+		return err
+	}
+
+	client := &http.Client{Timeout: requestTimeout}
+	resp, err := client.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
+
+// AutomationOnFailure sets what happens when an automation fails. With no
+// actions it clears them.
+func AutomationOnFailure(args []string) {
+	if len(args) < 2 {
+		styles.GetErrorStyle().Println("Usage: ellie automate on-failure <id> [notify|email:<address>|webhook:<url>]...")
+		return
+	}
+
+	var onFailure []FailureAction
+	for _, value := range args[2:] {
+		action, err := parseFailureAction(value)
+		if err != nil {
+			styles.GetErrorStyle().Println("Error:", err)
+			return
+		}
+		onFailure = append(onFailure, action)
+	}
+
+	var name string
+	found := false
+	saved := updateAutomations(func(data *AutomationData) bool {
+		i, ok := findAutomation(data, args[1])
+		if !ok {
+			return false
+		}
+		data.Tasks[i].OnFailure = onFailure
+		name, found = data.Tasks[i].Name, true
+		return true
+	})
+	if !found {
+		styles.GetErrorStyle().Printf("Automation with ID '%s' not found\n", args[1])
+		return
+	}
+	if !saved {
+		return
+	}
+
+	if len(onFailure) == 0 {
+		styles.GetSuccessStyle().Printf("✅ Automation '%s' does nothing more when it fails\n", name)
+		return
+	}
+	styles.GetSuccessStyle().Printf("✅ When '%s' fails: %s\n", name, joinFailureActions(onFailure))
+}
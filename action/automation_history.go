@@ -0,0 +1,165 @@
+package actions
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tacheraSasi/ellie/runlog"
+	"github.com/tacheraSasi/ellie/styles"
+)
+
+const (
+	historyUsage = "automate history <id> [-n <runs>]"
+	logsUsage    = "automate logs <id> [-n <runs>] [--tail]"
+	// logsPollInterval is how often logs --tail looks for new runs
+	logsPollInterval = 2 * time.Second
+)
+
+// automationArgs reads the task and the -n count shared by history and logs
+func automationArgs(args []string, usage string, defaultCount int) (AutomationTask, int, bool) {
+	value, found, rest, err := popValue(args[1:], "n")
+	count := defaultCount
+	if err == nil && found {
+		count, err = strconv.Atoi(value)
+		if err == nil && count < 1 {
+			err = fmt.Errorf("-n must be at least 1")
+		}
+	}
+	if err != nil {
+		styles.GetErrorStyle().Println("Error:", err)
+		return AutomationTask{}, 0, false
+	}
+	if len(rest) == 0 || strings.HasPrefix(rest[0], "-") {
+		styles.GetErrorStyle().Println("Usage: ellie " + usage)
+		return AutomationTask{}, 0, false
+	}
+
+	data, err := loadAutomations()
+	if err != nil {
+		styles.GetErrorStyle().Println("Error loading automations:", err)
+		return AutomationTask{}, 0, false
+	}
+	i, ok := findAutomation(data, rest[0])
+	if !ok {
+		styles.GetErrorStyle().Printf("Automation with ID '%s' not found\n", rest[0])
+		return AutomationTask{}, 0, false
+	}
+	return data.Tasks[i], count, true
+}
+
+// runStatus is how a run ended, in a few words
+func runStatus(run runlog.Run) string {
+	switch {
+	case run.TimedOut:
+		return "timed out"
+	case run.Error != "" && !strings.HasPrefix(run.Error, "exit status"):
+		return fmt.Sprintf("exit %d: %s", run.ExitCode, run.Error)
+	case run.ExitCode != 0:
+		return fmt.Sprintf("exit %d", run.ExitCode)
+	}
+	return "ok"
+}
+
+// AutomationHistory lists an automation's recent runs, newest first
+func AutomationHistory(args []string) {
+	task, count, ok := automationArgs(args, historyUsage, 20)
+	if !ok {
+		return
+	}
+	runs, err := automationRuns().Runs(task.ID)
+	if err != nil {
+		styles.GetErrorStyle().Println("Error reading run history:", err)
+		return
+	}
+
+	styles.GetInfoStyle().Printf("\n📜 %s [%s]\n", task.Name, task.ID)
+	if len(runs) == 0 {
+		styles.GetInfoStyle().Println("No runs recorded yet")
+		return
+	}
+
+	failed := 0
+	for _, run := range runs {
+		if run.Failed() {
+			failed++
+		}
+	}
+	fmt.Printf("%-19s  %10s  %s\n", "STARTED", "DURATION", "STATUS")
+	for i := len(runs) - 1; i >= 0 && i >= len(runs)-count; i-- {
+		run := runs[i]
+		line := fmt.Sprintf("%-19s  %10s  ", run.Start.Local().Format("2006-01-02 15:04:05"), run.Duration.Round(time.Millisecond))
+		if run.Failed() {
+			styles.GetErrorStyle().Println(line + runStatus(run))
+		} else {
+			styles.GetSuccessStyle().Println(line + runStatus(run))
+		}
+	}
+	styles.DimText.Printf("%d run(s) recorded, %d failed. See the output with: ellie automate logs %s\n", len(runs), failed, task.ID)
+}
+
+// printRunOutput prints a run's header and captured output
+func printRunOutput(run runlog.Run) {
+	header := fmt.Sprintf("── %s · %s · %s", run.Start.Local().Format("2006-01-02 15:04:05"), run.Duration.Round(time.Millisecond), runStatus(run))
+	if run.Failed() {
+		styles.GetErrorStyle().Println(header)
+	} else {
+		styles.GetInfoStyle().Println(header)
+	}
+	if run.Stdout == "" && run.Stderr == "" {
+		styles.DimText.Println("(no output)")
+	}
+	fmt.Print(run.Stdout)
+	if run.Stderr != "" {
+		styles.GetErrorStyle().Print(run.Stderr)
+	}
+}
+
+// AutomationLogs prints the output of an automation's last runs. With
+// --tail it keeps printing runs as they finish.
+func AutomationLogs(args []string) {
+	tail, args := popFlag(args, "tail")
+	if !tail {
+		tail, args = popFlag(args, "f")
+	}
+	task, count, ok := automationArgs(args, logsUsage, 1)
+	if !ok {
+		return
+	}
+	history := automationRuns()
+	runs, err := history.Runs(task.ID)
+	if err != nil {
+		styles.GetErrorStyle().Println("Error reading run history:", err)
+		return
+	}
+	if len(runs) == 0 && !tail {
+		styles.GetInfoStyle().Printf("No runs of '%s' recorded yet\n", task.Name)
+		return
+	}
+
+	var last time.Time
+	for _, run := range runs[max(len(runs)-count, 0):] {
+		printRunOutput(run)
+		last = run.Start
+	}
+	if !tail {
+		return
+	}
+
+	styles.DimText.Printf("Waiting for runs of '%s'; press Ctrl+C to stop\n", task.Name)
+	for {
+		time.Sleep(logsPollInterval)
+		runs, err := history.Runs(task.ID)
+		if err != nil {
+			styles.GetErrorStyle().Println("Error reading run history:", err)
+			return
+		}
+		for _, run := range runs {
+			if run.Start.After(last) {
+				printRunOutput(run)
+				last = run.Start
+			}
+		}
+	}
+}
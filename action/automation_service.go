@@ -0,0 +1,172 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/tacheraSasi/ellie/configs"
+	"github.com/tacheraSasi/ellie/daemon"
+	"github.com/tacheraSasi/ellie/styles"
+)
+
+const serviceLogsUsage = "automate service logs [-n <lines>] [--tail]"
+
+// daemonLockPath is the PID file of the running automation daemon
+func daemonLockPath() string {
+	return filepath.Join(configs.ConfigDir, "automations", "daemon.pid")
+}
+
+// daemonLogPath is where the daemon's output goes when the service manager
+// doesn't keep it
+func daemonLogPath() string {
+	return filepath.Join(configs.ConfigDir, "automations", "daemon.log")
+}
+
+// daemonService is the service that runs this ellie's automation daemon
+func daemonService() (daemon.Manager, error) {
+	program, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("can't find the ellie executable: %w", err)
+	}
+	if resolved, err := filepath.EvalSymlinks(program); err == nil {
+		program = resolved
+	}
+	return daemon.ForOS(daemon.Spec{
+		Name:        "ellie-automate",
+		Label:       "com.ekilie.ellie.automate",
+		Description: "Ellie automation daemon",
+		Program:     program,
+		Args:        []string{"automate", "daemon"},
+		Path:        os.Getenv("PATH"),
+		LogFile:     daemonLogPath(),
+	})
+}
+
+// AutomationService shows the service subcommands
+func AutomationService(args []string) {
+	styles.GetInfoStyle().Println("Usage: ellie automate service <install|uninstall|status|logs>")
+}
+
+// AutomationServiceInstall installs the daemon as a user service that starts
+// at login, and starts it
+func AutomationServiceInstall(args []string) {
+	service, err := daemonService()
+	if err != nil {
+		styles.GetErrorStyle().Println("Error:", err)
+		return
+	}
+	program, _ := os.Executable()
+	if strings.Contains(program, string(filepath.Separator)+"go-build") {
+		styles.GetErrorStyle().Println("Error: ellie is running from a go run build; install the service from an installed ellie binary")
+		return
+	}
+	if pid, running := daemon.Holder(daemonLockPath()); running {
+		styles.GetWarningStyle().Printf("A daemon is already running (PID %d). The service's daemon waits until it stops.\n", pid)
+	}
+
+	if err := service.Install(); err != nil {
+		styles.GetErrorStyle().Println("Error installing the service:", err)
+		exit(1)
+	}
+	styles.GetSuccessStyle().Printf("✅ Installed the automation daemon as a %s service\n", service.Kind())
+	styles.DimText.Println("Definition:", service.Path())
+	styles.GetInfoStyle().Println("It is running now and starts whenever you log in")
+	if service.Kind() == "systemd" {
+		styles.DimText.Println("To keep it running after you log out: loginctl enable-linger")
+	}
+}
+
+// AutomationServiceUninstall stops the daemon service and removes it
+func AutomationServiceUninstall(args []string) {
+	service, err := daemonService()
+	if err != nil {
+		styles.GetErrorStyle().Println("Error:", err)
+		return
+	}
+	err = service.Uninstall()
+	if errors.Is(err, daemon.ErrNotInstalled) {
+		styles.GetInfoStyle().Println("The automation service isn't installed")
+		return
+	}
+	if err != nil {
+		styles.GetErrorStyle().Println("Error uninstalling the service:", err)
+		exit(1)
+	}
+	styles.GetSuccessStyle().Printf("✅ Uninstalled the %s service\n", service.Kind())
+}
+
+// AutomationServiceStatus shows whether the service is installed and whether
+// a daemon is running
+func AutomationServiceStatus(args []string) {
+	service, err := daemonService()
+	if err != nil {
+		styles.GetErrorStyle().Println("Error:", err)
+		return
+	}
+
+	styles.GetInfoStyle().Println("\n🤖 Automation daemon")
+	if daemon.Installed(service) {
+		fmt.Printf("Service:  %s, %s\n", service.Kind(), service.Path())
+	} else {
+		fmt.Printf("Service:  not installed; install it with: ellie automate service install\n")
+	}
+	if pid, running := daemon.Holder(daemonLockPath()); running && pid == 0 {
+		styles.GetSuccessStyle().Println("Daemon:   starting")
+	} else if running {
+		styles.GetSuccessStyle().Printf("Daemon:   running (PID %d)\n", pid)
+	} else {
+		styles.GetWarningStyle().Println("Daemon:   not running")
+	}
+
+	if !daemon.Installed(service) {
+		return
+	}
+	report, err := service.Status()
+	if err != nil {
+		styles.GetErrorStyle().Println("Error:", err)
+		return
+	}
+	fmt.Println()
+	fmt.Print(report)
+}
+
+// AutomationServiceLogs prints the daemon's output. With --tail it keeps
+// printing it.
+func AutomationServiceLogs(args []string) {
+	tail, args := popFlag(args[1:], "tail")
+	if !tail {
+		tail, args = popFlag(args, "f")
+	}
+	value, found, rest, err := popValue(args, "n")
+	lines := 50
+	if err == nil && found {
+		lines, err = strconv.Atoi(value)
+		if err == nil && lines < 1 {
+			err = fmt.Errorf("-n must be at least 1")
+		}
+	}
+	if err != nil {
+		styles.GetErrorStyle().Println("Error:", err)
+		return
+	}
+	if len(rest) > 0 {
+		styles.GetErrorStyle().Println("Usage: ellie " + serviceLogsUsage)
+		return
+	}
+
+	service, err := daemonService()
+	if err != nil {
+		styles.GetErrorStyle().Println("Error:", err)
+		return
+	}
+	if tail {
+		styles.DimText.Println("Press Ctrl+C to stop")
+	}
+	if err := service.Logs(os.Stdout, lines, tail); err != nil {
+		styles.GetErrorStyle().Println("Error reading the daemon's output:", err)
+	}
+}
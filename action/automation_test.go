@@ -0,0 +1,237 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tacheraSasi/ellie/configs"
+)
+
+func TestAutomationTaskTimeout(t *testing.T) {
+	tests := []struct {
+		timeout string
+		want    time.Duration
+	}{
+		{"", defaultAutomationTimeout},
+		{"90s", 90 * time.Second},
+		{"-1m", defaultAutomationTimeout},
+		{"soon", defaultAutomationTimeout},
+	}
+
+	for _, tt := range tests {
+		if got := (AutomationTask{Timeout: tt.timeout}).timeout(); got != tt.want {
+			t.Errorf("timeout %q = %s, want %s", tt.timeout, got, tt.want)
+		}
+	}
+}
+
+func TestAutomationDue(t *testing.T) {
+	now := time.Date(2026, 3, 4, 10, 0, 0, 0, time.Local)
+	tests := []struct {
+		name       string
+		task       AutomationTask
+		wantDue    bool
+		wantMissed bool
+	}{
+		{"not yet", AutomationTask{Enabled: true, NextRun: now.Add(time.Minute)}, false, false},
+		{"on time", AutomationTask{Enabled: true, NextRun: now}, true, false},
+		{"a little late", AutomationTask{Enabled: true, NextRun: now.Add(-missedGrace)}, true, false},
+		{"missed", AutomationTask{Enabled: true, NextRun: now.Add(-time.Hour)}, true, true},
+		{"missed and skipped", AutomationTask{Enabled: true, SkipMissed: true, NextRun: now.Add(-time.Hour)}, false, true},
+		{"late but skip missed", AutomationTask{Enabled: true, SkipMissed: true, NextRun: now.Add(-time.Minute)}, true, false},
+		{"disabled", AutomationTask{NextRun: now.Add(-time.Hour)}, false, false},
+		{"unscheduled", AutomationTask{Enabled: true}, false, false},
+	}
+
+	for _, tt := range tests {
+		due, missed := automationDue(tt.task, now)
+		if due != tt.wantDue || missed != tt.wantMissed {
+			t.Errorf("%s: automationDue() = %v, %v; want %v, %v", tt.name, due, missed, tt.wantDue, tt.wantMissed)
+		}
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	now := time.Date(2026, 3, 4, 10, 0, 0, 0, time.Local)
+	tests := []struct {
+		task        AutomationTask
+		want        time.Time
+		wantEnabled bool
+	}{
+		{AutomationTask{Schedule: "hourly", Enabled: true}, time.Date(2026, 3, 4, 11, 0, 0, 0, time.Local), true},
+		{AutomationTask{Schedule: "daily at 09:30", Enabled: true}, time.Date(2026, 3, 5, 9, 30, 0, 0, time.Local), true},
+		{AutomationTask{Schedule: "weekly at 08:00", Enabled: true}, time.Date(2026, 3, 9, 8, 0, 0, 0, time.Local), true},
+		// A date that has passed has no more runs
+		{AutomationTask{Schedule: "2026-03-01 09:00", Enabled: true}, time.Time{}, false},
+	}
+
+	for _, tt := range tests {
+		task := tt.task
+		if err := scheduleNext(&task, now); err != nil {
+			t.Fatalf("%s: scheduleNext() error = %v", tt.task.Schedule, err)
+		}
+		if !task.NextRun.Equal(tt.want) || task.Enabled != tt.wantEnabled {
+			t.Errorf("%s: NextRun = %s, enabled %v; want %s, %v", tt.task.Schedule, task.NextRun, task.Enabled, tt.want, tt.wantEnabled)
+		}
+	}
+
+	task := AutomationTask{Schedule: "@ab:cd", Enabled: true}
+	if err := scheduleNext(&task, now); err == nil {
+		t.Error("scheduleNext() accepted @ab:cd")
+	}
+}
+
+func TestParseFailureAction(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    FailureAction
+		wantErr bool
+	}{
+		{"notify", FailureAction{Type: "notify"}, false},
+		{"email:me@example.com", FailureAction{Type: "email", Target: "me@example.com"}, false},
+		{"webhook:https://hooks.example.com/x?a=1", FailureAction{Type: "webhook", Target: "https://hooks.example.com/x?a=1"}, false},
+		{"Notify", FailureAction{Type: "notify"}, false},
+		{"notify:me", FailureAction{}, true},
+		{"email:nobody", FailureAction{}, true},
+		{"webhook:ftp://example.com", FailureAction{}, true},
+		{"webhook:", FailureAction{}, true},
+		{"page", FailureAction{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseFailureAction(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseFailureAction(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("parseFailureAction(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestRunAutomationRecordsAndReportsFailures(t *testing.T) {
+	savedDir := configs.ConfigDir
+	configs.ConfigDir = t.TempDir()
+	t.Cleanup(func() { configs.ConfigDir = savedDir })
+	withResolver(t, map[string]func([]string){
+		"ok":   func(args []string) { fmt.Println("fine") },
+		"fail": func(args []string) { fmt.Fprintln(os.Stderr, "broken"); exit(1) },
+	})
+
+	var posted []webhookPayload
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload webhookPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		posted = append(posted, payload)
+	}))
+	defer server.Close()
+
+	onFailure := []FailureAction{{Type: failureWebhook, Target: server.URL}}
+	good := AutomationTask{ID: "a1", Name: "good", Command: "ellie ok", OnFailure: onFailure}
+	bad := AutomationTask{ID: "a2", Name: "bad", Command: "ellie fail", OnFailure: onFailure}
+	for _, task := range []*AutomationTask{&good, &bad} {
+		if _, problems := runAutomation(task); len(problems) > 0 {
+			t.Fatalf("runAutomation(%s) problems = %v", task.Name, problems)
+		}
+	}
+
+	if good.LastExitCode != 0 || bad.LastExitCode != 1 || bad.LastError != "exit status 1" {
+		t.Errorf("recorded %d, %d %q", good.LastExitCode, bad.LastExitCode, bad.LastError)
+	}
+	if len(posted) != 1 || posted[0].TaskID != "a2" || posted[0].ExitCode != 1 || posted[0].Output != "broken" {
+		t.Errorf("webhook got %+v", posted)
+	}
+
+	runs, err := automationRuns().Runs("a2")
+	if err != nil || len(runs) != 1 || runs[0].Stderr != "broken\n" || !runs[0].Failed() {
+		t.Errorf("Runs() = %+v, %v", runs, err)
+	}
+	if runs, _ := automationRuns().Runs("a1"); len(runs) != 1 || runs[0].Stdout != "fine\n" {
+		t.Errorf("Runs() = %+v", runs)
+	}
+}
+
+func TestLoadAutomationsMigratesTimes(t *testing.T) {
+	savedDir := configs.ConfigDir
+	configs.ConfigDir = t.TempDir()
+	t.Cleanup(func() { configs.ConfigDir = savedDir })
+
+	// Tasks saved before versioning kept the time of day of daily and
+	// weekly tasks apart
+	legacy := `{"tasks": [
+		{"id": "a1", "schedule": "daily", "time": "09:30", "enabled": true},
+		{"id": "a2", "schedule": "weekly", "time": "08:00", "enabled": true},
+		{"id": "a3", "schedule": "hourly", "time": "", "enabled": false}
+	]}`
+	if err := os.WriteFile(automationStore().Path(), []byte(legacy), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := loadAutomations()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, task := range data.Tasks {
+		got = append(got, task.Schedule)
+	}
+	if fmt.Sprint(got) != "[daily at 09:30 weekly at 08:00 hourly]" {
+		t.Errorf("schedules = %q", got)
+	}
+	if data.Tasks[0].Enabled != true || data.Tasks[2].Enabled != false {
+		t.Error("migration lost other fields")
+	}
+
+	// Saving writes the current version, without the old field
+	if !updateAutomations(func(data *AutomationData) bool { return true }) {
+		t.Fatal("updateAutomations() didn't save")
+	}
+	saved, _ := os.ReadFile(automationStore().Path())
+	if !strings.Contains(string(saved), `"version": 1`) || strings.Contains(string(saved), `"time"`) {
+		t.Errorf("saved automations:\n%s", saved)
+	}
+}
+
+func TestSaveAutomationStateKeepsOtherChanges(t *testing.T) {
+	savedDir := configs.ConfigDir
+	configs.ConfigDir = t.TempDir()
+	t.Cleanup(func() { configs.ConfigDir = savedDir })
+
+	updateAutomations(func(data *AutomationData) bool {
+		data.Tasks = []AutomationTask{
+			{ID: "a1", Name: "backup", Schedule: "hourly", Enabled: true},
+			{ID: "a2", Name: "pull", Schedule: "hourly", Enabled: true},
+		}
+		return true
+	})
+	data, _ := loadAutomations()
+	running := data.Tasks[0]
+
+	// While a1 runs, someone renames it and deletes a2
+	updateAutomations(func(data *AutomationData) bool {
+		data.Tasks[0].Name = "nightly backup"
+		data.Tasks = data.Tasks[:1]
+		return true
+	})
+
+	now := time.Now()
+	running.LastRun, running.LastExitCode, running.LastError = now, 1, "exit status 1"
+	saveAutomationState(running)
+	saveAutomationState(data.Tasks[1])
+
+	data, _ = loadAutomations()
+	if len(data.Tasks) != 1 {
+		t.Fatalf("tasks = %+v, want the deleted one to stay deleted", data.Tasks)
+	}
+	task := data.Tasks[0]
+	if task.Name != "nightly backup" || !task.LastRun.Equal(now) || task.LastExitCode != 1 || !task.Enabled {
+		t.Errorf("task = %+v", task)
+	}
+}
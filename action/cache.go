@@ -0,0 +1,175 @@
+package actions
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tacheraSasi/ellie/cache"
+	"github.com/tacheraSasi/ellie/configs"
+	"github.com/tacheraSasi/ellie/llm"
+	"github.com/tacheraSasi/ellie/styles"
+	"github.com/tacheraSasi/ellie/utils"
+)
+
+// defaultCacheTTL is used when ELLIE_CACHE_TTL is not set
+const defaultCacheTTL = 7 * 24 * time.Hour
+
+// parseTTL parses a Go duration such as "12h" or a number of days such as
+// "7d". "off" disables the cache; "0" keeps entries forever.
+func parseTTL(raw string) (ttl time.Duration, enabled bool, err error) {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+	switch raw {
+	case "":
+		return defaultCacheTTL, true, nil
+	case "off", "false", "no":
+		return 0, false, nil
+	case "0":
+		return 0, true, nil
+	}
+
+	if days, ok := strings.CutSuffix(raw, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, false, fmt.Errorf("invalid cache TTL %q", raw)
+		}
+		return time.Duration(n) * 24 * time.Hour, true, nil
+	}
+
+	ttl, err = time.ParseDuration(raw)
+	if err != nil || ttl < 0 {
+		return 0, false, fmt.Errorf("invalid cache TTL %q", raw)
+	}
+	return ttl, true, nil
+}
+
+// responseCache returns the cache at ~/ellie/cache, or nil when it is turned
+// off with ELLIE_CACHE_TTL=off
+func responseCache() *cache.Store {
+	ttl, enabled, err := parseTTL(configs.GetEnv("ELLIE_CACHE_TTL"))
+	if err != nil {
+		styles.WarningStyle.Fprintf(os.Stderr, "⚠️ %v; using %s\n", err, defaultCacheTTL)
+		ttl, enabled = defaultCacheTTL, true
+	}
+	if !enabled {
+		return nil
+	}
+	return cache.NewStore(filepath.Join(configs.GetEllieDir(), "cache"), ttl)
+}
+
+// cachedRequest identifies a deterministic AI request in the response cache
+type cachedRequest struct {
+	command  string
+	provider string
+	model    string
+	subject  string
+	key      string
+}
+
+// newCachedRequest keys a request on the command, provider, model, prompt
+// and the hash of the content being sent
+func newCachedRequest(command, providerType string, provider llm.Provider, subject, prompt string, content []byte) cachedRequest {
+	model := provider.GetModel()
+	return cachedRequest{
+		command:  command,
+		provider: providerType,
+		model:    model,
+		subject:  subject,
+		key:      cache.Key(command, providerType, model, prompt, cache.HashContent(content)),
+	}
+}
+
+// get returns the cached answer for the request, if there is one
+func (r cachedRequest) get() (*cache.Entry, bool) {
+	store := responseCache()
+	if store == nil {
+		return nil, false
+	}
+	return store.Get(r.key)
+}
+
+// lookup prints the cached answer for the request, if there is one
+func (r cachedRequest) lookup() bool {
+	entry, ok := r.get()
+	if !ok {
+		return false
+	}
+
+	styles.DimText.Printf("⚡ Cached answer from %s (add --no-cache to ask again)\n", entry.CreatedAt.Format("2006-01-02 15:04"))
+	rendered, err := utils.RenderMarkdown(entry.Response)
+	if err != nil {
+		fmt.Println(entry.Response)
+		return true
+	}
+	fmt.Println(rendered)
+	return true
+}
+
+// save stores a fresh answer; failing to cache it isn't fatal
+func (r cachedRequest) save(response string, usage llm.Usage) {
+	store := responseCache()
+	if store == nil || strings.TrimSpace(response) == "" {
+		return
+	}
+	err := store.Put(&cache.Entry{
+		Key:      r.key,
+		Command:  r.command,
+		Provider: r.provider,
+		Model:    r.model,
+		Subject:  r.subject,
+		Response: response,
+		Usage:    usage,
+	})
+	if err != nil {
+		styles.WarningStyle.Fprintf(os.Stderr, "⚠️ Could not cache the answer: %v\n", err)
+	}
+}
+
+// CacheStats shows how many answers are cached and what the hits saved
+func CacheStats(_ []string) {
+	store := responseCache()
+	if store == nil {
+		styles.InfoStyle.Println("The response cache is turned off (ELLIE_CACHE_TTL=off)")
+		return
+	}
+
+	stats, err := store.Stats()
+	if err != nil {
+		styles.ErrorStyle.Printf("Error reading cache: %v\n", err)
+		return
+	}
+
+	prices := usagePrices()
+	var saved float64
+	for _, e := range stats.Saved {
+		saved += float64(e.Hits) * prices.Cost(e.Model, e.Usage.PromptTokens, e.Usage.CompletionTokens)
+	}
+
+	styles.InfoStyle.Println("⚡ Response cache")
+	fmt.Printf("  Entries:      %s (%s on disk)\n", styles.Cyan.Sprint(stats.Entries), formatBytes(uint64(stats.Bytes)))
+	if stats.Expired > 0 {
+		fmt.Printf("  Expired:      %d (remove with 'ellie cache clear --expired')\n", stats.Expired)
+	}
+	fmt.Printf("  Hits:         %s\n", styles.Cyan.Sprint(stats.Hits))
+	fmt.Printf("  Tokens saved: %s (~$%.4f)\n", styles.Cyan.Sprint(stats.SavedTokens), saved)
+}
+
+// CacheClear removes all cached answers, or only expired ones with --expired
+func CacheClear(args []string) {
+	store := responseCache()
+	if store == nil {
+		styles.InfoStyle.Println("The response cache is turned off (ELLIE_CACHE_TTL=off)")
+		return
+	}
+
+	expiredOnly, _ := popFlag(args, "expired")
+	removed, err := store.Clear(expiredOnly)
+	if err != nil {
+		styles.ErrorStyle.Printf("Error clearing cache: %v\n", err)
+		return
+	}
+	styles.SuccessStyle.Printf("Removed %d cached answer(s)\n", removed)
+}
@@ -1,243 +1,240 @@
 package actions
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/tacheraSasi/ellie/chat"
 	"github.com/tacheraSasi/ellie/configs"
-	"github.com/tacheraSasi/ellie/llm"
 	"github.com/tacheraSasi/ellie/static"
 	"github.com/tacheraSasi/ellie/styles"
 	"github.com/tacheraSasi/ellie/types"
 	"github.com/tacheraSasi/ellie/utils"
 )
 
-// Chat starts an interactive chat session with the AI
-func Chat(openaiApikey string) {
-	// Validate API key
-	if openaiApikey == "" {
-		styles.ErrorStyle.Println("Error: OpenAI API key is required. Please set your OPENAI_API_KEY ellie config file.", configs.ConfigDirName)
+// chatStore returns the store holding saved chat sessions (~/ellie/chats)
+func chatStore() *chat.Store {
+	return chat.NewStore(filepath.Join(configs.GetEllieDir(), "chats"))
+}
+
+// Chat starts an interactive chat session with the AI. The provider and
+// model come from the Ellie config unless overridden with --provider/--model.
+// The conversation is saved so it can be picked up again with "chat resume".
+func Chat(args []string) {
+	opts, _, err := parseAIFlags(args)
+	if err != nil {
+		styles.ErrorStyle.Printf("Error: %v\n", err)
 		return
 	}
 
-	// Create a new LLM provider (EllieAPI by default)
-	config := llm.Config{
-		APIKey: openaiApikey,
-		Model:  "gpt-3.5-turbo",
-		// Model:   "gpt-4", // Uncomment for GPT-4
-		// Model:   "gpt-4o", // Uncomment for GPT-4o
-		// Model:   "gpt-4o-mini", // Uncomment for GPT-4o-mini
-		// Model:   "gpt-3.5-turbo-16k", // Uncomment for 16k context length
-		// BaseURL: "http://localhost:8000", // Default EllieAPI server URL
-		Timeout: 30,
+	provider, err := newProvider("chat", opts, 30*time.Second)
+	if err != nil {
+		styles.ErrorStyle.Printf("Error creating provider: %v\n", err)
+		return
+	}
+
+	// Create a new chat session
+	session := chat.NewChatSession(provider)
+	transcript := chat.NewTranscript(providerName(opts), provider.GetModel())
+
+	styles.InfoStyle.Println("Welcome to Ellie! Type 'exit' to quit.")
+	runChat(session, transcript)
+}
+
+// ChatResume continues a saved chat session with its original provider and
+// model unless --provider/--model say otherwise
+func ChatResume(args []string) {
+	opts, rest, err := parseAIFlags(args)
+	if err != nil {
+		styles.ErrorStyle.Printf("Error: %v\n", err)
+		return
+	}
+	if len(rest) < 2 {
+		styles.ErrorStyle.Println("Usage: ellie chat resume <id> [--provider <name>] [--model <name>]")
+		return
+	}
+
+	transcript, err := chatStore().Load(rest[1])
+	if err != nil {
+		styles.ErrorStyle.Printf("Error: %v\n", err)
+		return
+	}
+
+	if opts.Provider == "" {
+		opts.Provider = transcript.Provider
+	}
+	if opts.Model == "" && strings.EqualFold(opts.Provider, transcript.Provider) {
+		opts.Model = transcript.Model
 	}
 
-	provider, err := llm.NewProvider("ellieapi", config)
+	provider, err := newProvider("chat", opts, 30*time.Second)
 	if err != nil {
 		styles.ErrorStyle.Printf("Error creating provider: %v\n", err)
 		return
 	}
+	transcript.Provider = providerName(opts)
+	transcript.Model = provider.GetModel()
 
-	// Create a new chat session
 	session := chat.NewChatSession(provider)
+	session.Restore(transcript.Messages, transcript.Usage)
+
+	styles.InfoStyle.Printf("Resuming \"%s\" (%d messages). Type 'exit' to quit.\n", transcript.Title, len(transcript.Messages))
+	runChat(session, transcript)
+}
+
+// runChat runs the interactive loop, saving the transcript after every answer
+func runChat(session *chat.ChatSession, transcript *chat.Transcript) {
+	store := chatStore()
 
 	// Create user context
 	userCtx := types.NewUserContext()
 
-	// Add system message with instructions and context
-	instructions := fmt.Sprintf(`!!!!!!!!!!!!!!!!!!!!!IMPORTANT YOU ARE ELLIE note: %s %s`,
+	// Instructions and context go in the system prompt so they are sent with
+	// every request without taking up a turn of the conversation
+	session.SetSystemPrompt(fmt.Sprintf("You are Ellie.\n\n%s\n\n%s",
 		getReadmeContent(),
-		static.Instructions(*userCtx))
-
-	if _, err := session.SendMessage(instructions); err != nil {
-		styles.ErrorStyle.Printf("Error setting up initial instructions: %v\n", err)
-		return
-	}
+		static.Instructions(*userCtx)))
+	session.SetTools(toolDefinitions(), runTool)
 
-	styles.InfoStyle.Println("Welcome to Ellie! Type 'exit' to quit.")
+	styles.DimText.Printf("Model: %s  Session: %s\n", transcript.Model, transcript.ID)
 	styles.DimText.Println("----------------------------------------")
 
 	for {
 		msg, err := utils.GetInput("Talk to me: ")
+		stopOnClosedInput(err)
 		if err != nil {
 			styles.ErrorStyle.Printf("Error reading input: %v\n", err)
 			continue
 		}
 
 		if strings.EqualFold(msg, "exit") {
+			if len(transcript.Messages) > 0 {
+				styles.DimText.Printf("Saved as %s. Continue later with: ellie chat resume %s\n", transcript.ID, transcript.ID)
+			}
 			styles.InfoStyle.Println("Goodbye!")
 			break
 		}
 
-		// Update context before processing the message
+		// Update context before processing the message. The session decides
+		// whether it changed enough to be sent again.
 		userCtx.UpdateContext()
 		userCtx.LastCommand = msg
 		userCtx.CommandCount++
+		session.SetUserContext(userCtx.GetContextString())
 
-		// Add context to the message
-		contextualMsg := fmt.Sprintf("%s\n\nCurrent Context:\n%s", msg, userCtx.GetContextString())
-
-		done := make(chan bool)
-		errorChan := make(chan error)
-		responseChan := make(chan string)
-
-		// Start loading spinner in a goroutine
-		go utils.ShowLoadingSpinner("Thinking...", done)
-
-		// Send the message and get the response
-		go func() {
-			response, err := session.SendMessage(contextualMsg)
-			if err != nil {
-				errorChan <- err
-				return
-			}
-			responseChan <- response
-		}()
-
-		// Wait for either response or error
-		select {
-		case err := <-errorChan:
-			done <- true // Stop the spinner
-			styles.ErrorStyle.Printf("\nError: %v\n", err)
+		// Stream the answer as it is generated; Ctrl+C stops it early
+		response, interrupted, err := streamAnswer(session, msg, "Thinking...")
+		if err != nil {
+			styles.ErrorStyle.Printf("\nError: %s\n", chat.DescribeError(err))
 			styles.DimText.Println("----------------------------------------")
 			continue
+		}
 
-		case response := <-responseChan:
-			done <- true // Stop the spinner
-
-			// Check if response is empty
-			if strings.TrimSpace(response) == "" {
-				styles.WarningStyle.Println("\nNo response received from AI.")
-				styles.DimText.Println("----------------------------------------")
-				continue
-			}
-
-			// Try to render markdown
-			renderedOutput, err := utils.RenderMarkdown(response)
-			if err != nil {
-				styles.ErrorStyle.Println("\nRaw response:", response)
-				styles.ErrorStyle.Println("Error rendering Markdown:", err)
-				styles.DimText.Println("----------------------------------------")
-				continue
-			}
+		if !interrupted && strings.TrimSpace(response) == "" {
+			styles.WarningStyle.Println("\nNo response received from AI.")
+		}
 
-			// Display the response
-			fmt.Println("\n" + renderedOutput)
-			styles.DimText.Println("----------------------------------------")
+		transcript.Capture(session)
+		if err := store.Save(transcript); err != nil {
+			styles.WarningStyle.Printf("⚠️ Could not save chat session: %v\n", err)
 		}
+		styles.DimText.Println("----------------------------------------")
 	}
 }
 
-// ChatWithGemini starts an interactive chat session with Gemini
-func ChatWithGemini(geminiApikey string) {
-	// Validate API key
-	if geminiApikey == "" {
-		styles.ErrorStyle.Println("Error: Gemini API key is required. Please set your GEMINI_API_KEY ellie config file.", configs.ConfigDirName)
+// ChatList shows the saved chat sessions, most recent first
+func ChatList(args []string) {
+	transcripts, err := chatStore().List()
+	var unreadable *chat.UnreadableError
+	if errors.As(err, &unreadable) {
+		styles.WarningStyle.Println("⚠️ ", err)
+	} else if err != nil {
+		styles.ErrorStyle.Printf("Error listing chat sessions: %v\n", err)
 		return
 	}
-
-	// Create a new LLM provider (Gemini)
-	config := llm.Config{
-		APIKey:  geminiApikey,
-		Model:   "gemini-1.5-flash",
-		Timeout: 30,
-	}
-
-	provider, err := llm.NewProvider("gemini", config)
-	if err != nil {
-		styles.ErrorStyle.Printf("Error creating provider: %v\n", err)
+	if len(transcripts) == 0 {
+		styles.InfoStyle.Println("No saved chat sessions")
 		return
 	}
 
-	// Create a new chat session
-	session := chat.NewChatSession(provider)
-
-	// Create user context
-	userCtx := types.NewUserContext()
+	styles.InfoStyle.Println("Saved chat sessions:")
+	for _, t := range transcripts {
+		fmt.Printf("  💬 %s  %s\n", styles.Cyan.Sprint(t.ID), t.Title)
+		styles.DimText.Printf("     %s · %s/%s · %d messages · %d tokens\n",
+			t.UpdatedAt.Format("2006-01-02 15:04"), t.Provider, t.Model, len(t.Messages), t.Usage.TotalTokens)
+	}
+}
 
-	// Add system message with instructions and context
-	instructions := fmt.Sprintf(`!!!!!!!!!!!!!!!!!!!!!IMPORTANT YOU ARE ELLIE note: %s %s`,
-		getReadmeContent(),
-		static.Instructions(*userCtx))
+// ChatExport prints a saved chat session as Markdown (default) or JSON
+func ChatExport(args []string) {
+	format := "md"
+	var rest []string
+	for i := 0; i < len(args); i++ {
+		switch {
+		case args[i] == "--format" && i+1 < len(args):
+			i++
+			format = args[i]
+		case strings.HasPrefix(args[i], "--format="):
+			format = strings.TrimPrefix(args[i], "--format=")
+		default:
+			rest = append(rest, args[i])
+		}
+	}
 
-	if _, err := session.SendMessage(instructions); err != nil {
-		styles.ErrorStyle.Printf("Error setting up initial instructions: %v\n", err)
+	if len(rest) < 2 {
+		styles.ErrorStyle.Println("Usage: ellie chat export <id> [--format md|json]")
 		return
 	}
 
-	styles.InfoStyle.Println("Welcome to Ellie (Gemini)! Type 'exit' to quit.")
-	styles.DimText.Println("----------------------------------------")
+	transcript, err := chatStore().Load(rest[1])
+	if err != nil {
+		styles.ErrorStyle.Printf("Error: %v\n", err)
+		return
+	}
 
-	for {
-		msg, err := utils.GetInput("Talk to me: ")
+	switch strings.ToLower(format) {
+	case "md", "markdown":
+		fmt.Print(transcript.Markdown())
+	case "json":
+		data, err := json.MarshalIndent(transcript, "", "  ")
 		if err != nil {
-			styles.ErrorStyle.Printf("Error reading input: %v\n", err)
-			continue
+			styles.ErrorStyle.Printf("Error encoding chat session: %v\n", err)
+			return
 		}
+		fmt.Println(string(data))
+	default:
+		styles.ErrorStyle.Printf("Unknown format %q, use md or json\n", format)
+	}
+}
 
-		if strings.EqualFold(msg, "exit") {
-			styles.InfoStyle.Println("Goodbye!")
-			break
-		}
-
-		// Update context before processing the message
-		userCtx.UpdateContext()
-		userCtx.LastCommand = msg
-		userCtx.CommandCount++
-
-		// Add context to the message
-		contextualMsg := fmt.Sprintf("%s\n\nCurrent Context:\n%s", msg, userCtx.GetContextString())
-
-		done := make(chan bool)
-		errorChan := make(chan error)
-		responseChan := make(chan string)
-
-		// Start loading spinner in a goroutine
-		go utils.ShowLoadingSpinner("Thinking...", done)
-
-		// Send the message and get the response
-		go func() {
-			response, err := session.SendMessage(contextualMsg)
-			if err != nil {
-				errorChan <- err
-				return
-			}
-			responseChan <- response
-		}()
-
-		// Wait for either response or error
-		select {
-		case err := <-errorChan:
-			done <- true // Stop the spinner
-			styles.ErrorStyle.Printf("\nError: %v\n", err)
-			styles.DimText.Println("----------------------------------------")
-			continue
-
-		case response := <-responseChan:
-			done <- true // Stop the spinner
+// ChatDelete removes a saved chat session after confirmation
+func ChatDelete(args []string) {
+	if len(args) < 2 {
+		styles.ErrorStyle.Println("Usage: ellie chat delete <id>")
+		return
+	}
 
-			// Check if response is empty
-			if strings.TrimSpace(response) == "" {
-				styles.WarningStyle.Println("\nNo response received from AI.")
-				styles.DimText.Println("----------------------------------------")
-				continue
-			}
+	store := chatStore()
+	transcript, err := store.Load(args[1])
+	if err != nil {
+		styles.ErrorStyle.Printf("Error: %v\n", err)
+		return
+	}
 
-			// Try to render markdown
-			renderedOutput, err := utils.RenderMarkdown(response)
-			if err != nil {
-				styles.ErrorStyle.Println("\nRaw response:", response)
-				styles.ErrorStyle.Println("Error rendering Markdown:", err)
-				styles.DimText.Println("----------------------------------------")
-				continue
-			}
+	if !utils.AskForConfirmation(fmt.Sprintf("Delete chat \"%s\" (%s)?", transcript.Title, transcript.ID)) {
+		styles.InfoStyle.Println("Cancelled")
+		return
+	}
 
-			// Display the response
-			fmt.Println("\n" + renderedOutput)
-			styles.DimText.Println("----------------------------------------")
-		}
+	if err := store.Delete(transcript.ID); err != nil {
+		styles.ErrorStyle.Printf("Error: %v\n", err)
+		return
 	}
+	styles.SuccessStyle.Printf("Deleted chat session '%s'\n", transcript.ID)
 }
 
 func getReadmeContent() string {
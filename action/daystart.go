@@ -1,13 +1,14 @@
 package actions
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
 	"path/filepath"
 	"runtime"
+	"time"
 
 	"github.com/tacheraSasi/ellie/configs"
+	"github.com/tacheraSasi/ellie/repos"
+	"github.com/tacheraSasi/ellie/store"
 	"github.com/tacheraSasi/ellie/styles"
 )
 
@@ -23,44 +24,18 @@ func init() {
 	loadDayStartConfig()
 }
 
-func loadDayStartConfig() {
-	configFile := filepath.Join(configs.GetEllieDir(), "day-start.json")
-	if _, err := os.Stat(configFile); os.IsNotExist(err) {
-		dayStartConfig = DayStartConfig{
-			Apps:     []string{},
-			Services: []string{},
-			GitRepos: []string{},
-		}
-		return
-	}
-
-	data, err := os.ReadFile(configFile)
-	if err != nil {
-		styles.ErrorStyle.Println("Error reading day-start config:", err)
-		return
-	}
-
-	if err := json.Unmarshal(data, &dayStartConfig); err != nil {
-		styles.ErrorStyle.Println("Error parsing day-start config:", err)
-		dayStartConfig = DayStartConfig{
-			Apps:     []string{},
-			Services: []string{},
-			GitRepos: []string{},
-		}
-	}
+// dayStartStore is the file the day-start configuration is kept in
+func dayStartStore() *store.File {
+	return store.New(filepath.Join(configs.GetEllieDir(), "day-start.json"), 1)
 }
 
-func saveDayStartConfig() {
-	configFile := filepath.Join(configs.GetEllieDir(), "day-start.json")
-	data, err := json.MarshalIndent(dayStartConfig, "", "  ")
-	if err != nil {
-		styles.ErrorStyle.Println("Error saving day-start config:", err)
-		return
-	}
-
-	if err := os.WriteFile(configFile, data, 0644); err != nil {
-		styles.ErrorStyle.Println("Error writing day-start config:", err)
+func loadDayStartConfig() {
+	dayStartConfig = DayStartConfig{
+		Apps:     []string{},
+		Services: []string{},
+		GitRepos: []string{},
 	}
+	loadStore(dayStartStore(), &dayStartConfig, "day-start config")
 }
 
 func StartDay(args []string) {
@@ -82,12 +57,15 @@ func StartDay(args []string) {
 		}
 	}
 
-	// Check Git status for configured repos
+	// Check Git status for configured repos, all at once
 	if len(dayStartConfig.GitRepos) > 0 {
 		styles.InfoStyle.Println("Checking Git repositories...")
+		var targets []repos.Repo
 		for _, repo := range dayStartConfig.GitRepos {
-			checkGitRepo(repo)
+			path := expandHome(repo)
+			targets = append(targets, repos.Repo{Name: filepath.Base(path), Path: path})
 		}
+		printRepoStatus(repos.Each(targets, repoWorkers, repos.Inspect), time.Now())
 	}
 
 	// Show pending todos
@@ -114,29 +92,6 @@ func openApp(app string) {
 	Run([]string{"run", cmd})
 }
 
-func checkGitRepo(repo string) {
-	// Save current directory
-	originalDir, err := os.Getwd()
-	if err != nil {
-		styles.ErrorStyle.Printf("Failed to get current directory: %s\n", err)
-		return
-	}
-
-	// Change to repo directory
-	if err := os.Chdir(repo); err != nil {
-		styles.ErrorStyle.Printf("Failed to change to repo %s: %s\n", repo, err)
-		return
-	}
-
-	// Check Git status
-	GitStatus()
-
-	// Return to original directory
-	if err := os.Chdir(originalDir); err != nil {
-		styles.ErrorStyle.Printf("Failed to return to original directory: %s\n", err)
-	}
-}
-
 func DayStartConfigAdd(args []string) {
 	if len(args) < 3 {
 		styles.ErrorStyle.Println("Usage: ellie day-start add <type> <value>")
@@ -148,18 +103,26 @@ func DayStartConfigAdd(args []string) {
 	value := args[2]
 
 	switch configType {
-	case "apps":
-		dayStartConfig.Apps = append(dayStartConfig.Apps, value)
-	case "services":
-		dayStartConfig.Services = append(dayStartConfig.Services, value)
-	case "git_repos":
-		dayStartConfig.GitRepos = append(dayStartConfig.GitRepos, value)
+	case "apps", "services", "git_repos":
 	default:
 		styles.ErrorStyle.Println("Invalid config type. Use: apps, services, git_repos")
 		return
 	}
 
-	saveDayStartConfig()
+	saved := updateStore(dayStartStore(), &dayStartConfig, "day-start config", func() bool {
+		switch configType {
+		case "apps":
+			dayStartConfig.Apps = append(dayStartConfig.Apps, value)
+		case "services":
+			dayStartConfig.Services = append(dayStartConfig.Services, value)
+		case "git_repos":
+			dayStartConfig.GitRepos = append(dayStartConfig.GitRepos, value)
+		}
+		return true
+	})
+	if !saved {
+		return
+	}
 	styles.SuccessStyle.Printf("Added %s to %s\n", value, configType)
 }
 
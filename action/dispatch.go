@@ -0,0 +1,327 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/tacheraSasi/ellie/styles"
+)
+
+const (
+	// maxCapturedOutput caps what is kept of each stream of a dispatched
+	// command
+	maxCapturedOutput = 1 << 20
+	// exitTimedOut is the exit status of a command that ran out of time, as
+	// with timeout(1)
+	exitTimedOut = 124
+	// exitBadCommand is the exit status of a command line that doesn't name
+	// a command
+	exitBadCommand = 2
+)
+
+// CommandResult is the outcome of an ellie command run in-process
+type CommandResult struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+	TimedOut bool
+	Duration time.Duration
+	// Err says why the command couldn't run or didn't finish
+	Err error
+}
+
+// resolveCommand finds the handler of a command line in the command
+// registry. The command package sets it, since it imports this one.
+var resolveCommand func(args []string) (func([]string), []string, error)
+
+// SetCommandResolver lets the command package provide the registry lookup
+// used by dispatchCommand
+func SetCommandResolver(resolve func(args []string) (func([]string), []string, error)) {
+	resolveCommand = resolve
+}
+
+// exitStatus is what exit panics with while a command runs in-process
+type exitStatus int
+
+// Progress of a dispatched handler, see dispatchCommand
+const (
+	handlerRunning int32 = iota
+	handlerFinished
+	handlerAbandoned
+)
+
+var (
+	// abandoned counts handlers that timed out and are still running. They
+	// may print at any time, so nothing else is dispatched until they end.
+	abandoned atomic.Int32
+	// dispatchMu allows one dispatched command at a time, since they share
+	// the process's stdout and stderr
+	dispatchMu sync.Mutex
+)
+
+// exit ends the command with a status. A command dispatched in-process
+// stops without taking the process with it; anywhere else, such as in the
+// daemon itself, the process exits.
+func exit(code int) {
+	if inDispatchedHandler() {
+		panic(exitStatus(code))
+	}
+	os.Exit(code)
+}
+
+// runHandler runs a dispatched handler. exit looks for it on the stack.
+func runHandler(handler func([]string), args []string) {
+	handler(args)
+}
+
+// runHandlerName is the symbol name of runHandler
+var runHandlerName = runtime.FuncForPC(reflect.ValueOf(runHandler).Pointer()).Name()
+
+// inDispatchedHandler reports whether the calling goroutine is running a
+// dispatched handler, including one that timed out
+func inDispatchedHandler() bool {
+	pcs := make([]uintptr, 512)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(2, pcs)])
+	for {
+		frame, more := frames.Next()
+		if frame.Function == runHandlerName {
+			return true
+		}
+		if !more {
+			return false
+		}
+	}
+}
+
+// stopOnClosedInput ends the command when there is no more input to read,
+// as when it runs from an automation, rather than prompting forever
+func stopOnClosedInput(err error) {
+	if errors.Is(err, io.EOF) {
+		styles.ErrorStyle.Println("\n🚫 No more input to read")
+		exit(1)
+	}
+}
+
+// splitCommandLine splits a command line into words, honoring single and
+// double quotes and backslash escapes
+func splitCommandLine(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+	for _, r := range line {
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inWord = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inWord {
+				words = append(words, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if escaped {
+		word.WriteRune('\\')
+	}
+	if inWord {
+		words = append(words, word.String())
+	}
+	return words, nil
+}
+
+// cappedBuffer keeps the first maxCapturedOutput bytes written to it. It is
+// safe to read while a command is still writing.
+type cappedBuffer struct {
+	mu        sync.Mutex
+	buf       strings.Builder
+	truncated bool
+}
+
+func (b *cappedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if room := maxCapturedOutput - b.buf.Len(); room < len(p) {
+		b.buf.Write(p[:max(room, 0)])
+		b.truncated = true
+	} else {
+		b.buf.Write(p)
+	}
+	return len(p), nil
+}
+
+func (b *cappedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.truncated {
+		return b.buf.String() + "\n... (output truncated)\n"
+	}
+	return b.buf.String()
+}
+
+// pipeTo returns a file that copies what is written to it into buf, and a
+// channel closed once the copying stops
+func pipeTo(buf io.Writer) (reader, writer *os.File, done chan struct{}, err error) {
+	reader, writer, err = os.Pipe()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	done = make(chan struct{})
+	go func() {
+		io.Copy(buf, reader)
+		close(done)
+	}()
+	return reader, writer, done, nil
+}
+
+// dispatchCommand runs an ellie command line, such as "ellie git status",
+// through the command registry inside this process. Its stdout and stderr
+// are captured without colors, stdin reads as empty so nothing waits for an
+// answer, and exit statuses are recorded instead of ending the process.
+//
+// A command still running after timeout is left behind and reported as
+// timed out; Go can't stop it, so it may keep printing to the terminal.
+// Until it ends, further commands are refused rather than mixing its output
+// into theirs.
+func dispatchCommand(line string, timeout time.Duration) (result CommandResult) {
+	start := time.Now()
+	defer func() { result.Duration = time.Since(start) }()
+
+	args, err := splitCommandLine(line)
+	if err == nil && (len(args) == 0 || args[0] != "ellie") {
+		err = errors.New("only ellie commands are allowed in automations")
+	}
+	if err == nil && len(args) == 1 {
+		err = errors.New("empty command")
+	}
+	if err != nil {
+		return CommandResult{ExitCode: exitBadCommand, Err: err}
+	}
+	if resolveCommand == nil {
+		return CommandResult{ExitCode: exitBadCommand, Err: errors.New("the command registry isn't available")}
+	}
+	handler, handlerArgs, err := resolveCommand(args[1:])
+	if err != nil {
+		return CommandResult{ExitCode: exitBadCommand, Err: err}
+	}
+	if !dispatchMu.TryLock() {
+		return CommandResult{ExitCode: exitBadCommand, Err: errors.New("another ellie command is already running in this process")}
+	}
+	defer dispatchMu.Unlock()
+	if n := abandoned.Load(); n > 0 {
+		return CommandResult{ExitCode: exitBadCommand, Err: fmt.Errorf("%d command(s) that timed out are still running; try again once they finish", n)}
+	}
+
+	var stdoutBuf, stderrBuf cappedBuffer
+	stdoutReader, stdoutWriter, stdoutDone, err := pipeTo(&stdoutBuf)
+	if err != nil {
+		return CommandResult{ExitCode: 1, Err: err}
+	}
+	stderrReader, stderrWriter, stderrDone, err := pipeTo(&stderrBuf)
+	if err != nil {
+		stdoutWriter.Close()
+		stdoutReader.Close()
+		return CommandResult{ExitCode: 1, Err: err}
+	}
+	stdin, err := os.Open(os.DevNull)
+	if err != nil {
+		stdin = os.Stdin
+	}
+
+	savedStdin, savedStdout, savedStderr := os.Stdin, os.Stdout, os.Stderr
+	savedColorOutput, savedColorError, savedNoColor := color.Output, color.Error, color.NoColor
+	os.Stdin, os.Stdout, os.Stderr = stdin, stdoutWriter, stderrWriter
+	color.Output, color.Error, color.NoColor = stdoutWriter, stderrWriter, true
+
+	codes := make(chan int, 1)
+	var progress atomic.Int32
+	go func() {
+		code := 0
+		defer func() {
+			if r := recover(); r != nil {
+				if status, ok := r.(exitStatus); ok {
+					code = int(status)
+				} else {
+					fmt.Fprintf(stderrWriter, "panic: %v\n", r)
+					code = exitBadCommand
+				}
+			}
+			if !progress.CompareAndSwap(handlerRunning, handlerFinished) {
+				abandoned.Add(-1)
+			}
+			codes <- code
+		}()
+		runHandler(handler, handlerArgs)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case result.ExitCode = <-codes:
+	case <-timer.C:
+		abandoned.Add(1)
+		if progress.CompareAndSwap(handlerRunning, handlerAbandoned) {
+			result.TimedOut = true
+			result.ExitCode = exitTimedOut
+			result.Err = fmt.Errorf("timed out after %s", timeout)
+		} else {
+			// It finished just now
+			abandoned.Add(-1)
+			result.ExitCode = <-codes
+		}
+	}
+
+	os.Stdin, os.Stdout, os.Stderr = savedStdin, savedStdout, savedStderr
+	color.Output, color.Error, color.NoColor = savedColorOutput, savedColorError, savedNoColor
+	stdoutWriter.Close()
+	stderrWriter.Close()
+	if stdin != savedStdin {
+		stdin.Close()
+	}
+
+	// Programs the command started in the background keep the pipes open,
+	// so only wait briefly for the rest of the output
+	grace := time.After(2 * time.Second)
+	for _, done := range []chan struct{}{stdoutDone, stderrDone} {
+		select {
+		case <-done:
+		case <-grace:
+		}
+	}
+	stdoutReader.Close()
+	stderrReader.Close()
+
+	result.Stdout = stdoutBuf.String()
+	result.Stderr = stderrBuf.String()
+	if result.Err == nil && result.ExitCode != 0 {
+		result.Err = fmt.Errorf("exit status %d", result.ExitCode)
+	}
+	return result
+}
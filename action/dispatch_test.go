@@ -0,0 +1,143 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tacheraSasi/ellie/styles"
+)
+
+func TestSplitCommandLine(t *testing.T) {
+	tests := []struct {
+		line    string
+		want    []string
+		wantErr bool
+	}{
+		{"ellie git status", []string{"ellie", "git", "status"}, false},
+		{"  ellie   todo\tlist ", []string{"ellie", "todo", "list"}, false},
+		{`ellie todo add "ship v2" work`, []string{"ellie", "todo", "add", "ship v2", "work"}, false},
+		{`ellie run 'it''s'`, []string{"ellie", "run", "its"}, false},
+		{`ellie run 'a \ b'`, []string{"ellie", "run", `a \ b`}, false},
+		{`ellie run a\ b ""`, []string{"ellie", "run", "a b", ""}, false},
+		{"", nil, false},
+		{`ellie "open`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			got, err := splitCommandLine(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("splitCommandLine(%q) error = %v, wantErr %v", tt.line, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitCommandLine(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+		})
+	}
+}
+
+// withResolver replaces the command resolver with one backed by handlers
+func withResolver(t *testing.T, handlers map[string]func([]string)) {
+	t.Helper()
+	saved := resolveCommand
+	t.Cleanup(func() { resolveCommand = saved })
+	resolveCommand = func(args []string) (func([]string), []string, error) {
+		handler, ok := handlers[args[0]]
+		if !ok {
+			return nil, nil, fmt.Errorf("unknown command: %s", args[0])
+		}
+		return handler, args, nil
+	}
+}
+
+func TestDispatchCommand(t *testing.T) {
+	withResolver(t, map[string]func([]string){
+		"echo": func(args []string) {
+			fmt.Println(strings.Join(args[1:], " "))
+			styles.ErrorStyle.Println("without colors")
+			fmt.Fprintln(os.Stderr, "to stderr")
+		},
+		"fail": func(args []string) {
+			fmt.Fprintln(os.Stderr, "something broke")
+			exit(3)
+		},
+		"panic": func(args []string) { panic(errors.New("boom")) },
+	})
+
+	tests := []struct {
+		line         string
+		wantStdout   string
+		wantStderr   string
+		wantExitCode int
+		wantTimedOut bool
+	}{
+		{`ellie echo "hello world"`, "hello world\nwithout colors\n", "to stderr\n", 0, false},
+		{"ellie fail", "", "something broke\n", 3, false},
+		{"ellie panic", "", "panic: boom\n", exitBadCommand, false},
+		{"ellie missing", "", "", exitBadCommand, false},
+		{"ls -la", "", "", exitBadCommand, false},
+		{"ellie", "", "", exitBadCommand, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			stdout := os.Stdout
+			got := dispatchCommand(tt.line, 200*time.Millisecond)
+			if os.Stdout != stdout {
+				t.Fatal("os.Stdout was not restored")
+			}
+			if got.Stdout != tt.wantStdout || got.Stderr != tt.wantStderr {
+				t.Errorf("output = %q, %q; want %q, %q", got.Stdout, got.Stderr, tt.wantStdout, tt.wantStderr)
+			}
+			if got.ExitCode != tt.wantExitCode || got.TimedOut != tt.wantTimedOut {
+				t.Errorf("exit code = %d, timed out = %v; want %d, %v", got.ExitCode, got.TimedOut, tt.wantExitCode, tt.wantTimedOut)
+			}
+			if (got.Err != nil) != (tt.wantExitCode != 0) {
+				t.Errorf("err = %v with exit code %d", got.Err, got.ExitCode)
+			}
+		})
+	}
+}
+
+func TestDispatchCommand_TimedOutHandler(t *testing.T) {
+	release := make(chan struct{})
+	finished := make(chan struct{})
+	withResolver(t, map[string]func([]string){
+		"hang": func(args []string) {
+			defer close(finished)
+			<-release
+			fmt.Println("late output")
+			exit(4)
+		},
+		"echo": func(args []string) { fmt.Println("echo") },
+	})
+
+	got := dispatchCommand("ellie hang", 50*time.Millisecond)
+	if !got.TimedOut || got.ExitCode != exitTimedOut {
+		t.Fatalf("dispatchCommand(hang) = %+v, want a timeout", got)
+	}
+	if inDispatchedHandler() {
+		t.Error("the caller counts as a dispatched handler after a timeout")
+	}
+
+	// Nothing runs while the timed-out handler may still print
+	if got := dispatchCommand("ellie echo", time.Second); got.Err == nil || got.Stdout != "" {
+		t.Errorf("dispatchCommand(echo) while hang runs = %+v, want it refused", got)
+	}
+
+	// Its exit ends only the handler
+	close(release)
+	<-finished
+	deadline := time.Now().Add(time.Second)
+	for abandoned.Load() > 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := dispatchCommand("ellie echo", time.Second); got.Err != nil || got.Stdout != "echo\n" {
+		t.Errorf("dispatchCommand(echo) after hang ended = %+v", got)
+	}
+}
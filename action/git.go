@@ -3,10 +3,12 @@ package actions
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strings"
 
+	"github.com/tacheraSasi/ellie/backup"
 	"github.com/tacheraSasi/ellie/styles"
 )
 
@@ -14,28 +16,58 @@ var (
 	allowedTypes = []string{"feat", "fix", "docs", "style", "refactor", "perf", "test", "chore", "revert"}
 )
 
-// GitConventionalCommit
-func GitConventionalCommit() {
-	reader := bufio.NewReader(os.Stdin)
+const conventionalCommitUsage = "git commit [--type <type>] [--scope <scope>] [-m <description>] [--body <text>] [--breaking <details>] [--issue <number>] [--trailer 'Key: Value']... [--yes]"
+
+// GitConventionalCommit builds a conventional commit from flags, prompting
+// for whatever is missing. Once --type or -m is given the optional parts are
+// no longer asked for.
+func GitConventionalCommit(args []string) {
+	in := newGitInput(args, conventionalCommitUsage,
+		"type|t=", "scope|s=", "message|m=", "body|b=", "breaking=", "issue=", "trailer=")
+	reader := in.reader
+	ask := in.interactive && !in.has("type") && !in.has("message")
 
 	styles.Cyan.Println("\nConventional Commit Builder")
 	styles.Cyan.Println("─────────────────────────────")
 
-	commitType := getCommitType(reader)
-	scope := getScope(reader)
-	description := getRequiredInput(reader, "Description")
-	body := getMultilineInput(reader, "Body (optional)")
-	breakingDetail, isBreaking := getBreakingChange(reader)
-	issueRef := getIssueReference(reader)
-	trailers := getTrailers(reader)
+	commitType := in.flag("type")
+	if commitType == "" && in.interactive {
+		commitType = getCommitType(reader)
+	}
+	if !isValidCommitType(commitType) {
+		in.fail(fmt.Sprintf("Invalid type %q: use one of %s", commitType, strings.Join(allowedTypes, ", ")))
+	}
+
+	description := in.flag("message")
+	if description == "" && in.interactive {
+		description = getRequiredInput(reader, "Description")
+	}
+	in.required(description, "Description")
+
+	scope, body := in.flag("scope"), in.flag("body")
+	breakingDetail, isBreaking := in.flag("breaking"), in.has("breaking")
+	issueRef := issueReference(in.flag("issue"))
+	trailers := in.flags["trailer"]
+	for _, trailer := range trailers {
+		if !isValidTrailer(trailer) {
+			in.fail(fmt.Sprintf("Invalid trailer %q: use 'Key: Value'", trailer))
+		}
+	}
+	if ask {
+		scope = getScope(reader)
+		body = getMultilineInput(reader, "Body (optional)")
+		breakingDetail, isBreaking = getBreakingChange(reader)
+		issueRef = getIssueReference(reader)
+		trailers = getTrailers(reader)
+	}
 
 	header := buildHeader(commitType, scope, description)
 	commitMessage := buildCommitMessage(header, body, breakingDetail, isBreaking, issueRef, trailers)
 
 	displayCommitPreview(commitMessage)
-	if !confirmAction("Commit with this message?") {
+	if !in.confirm("Commit with this message?") {
 		styles.ErrorStyle.Println("Commit canceled")
-		os.Exit(0)
+		exit(0)
 	}
 
 	executeGitWorkflow(commitMessage)
@@ -58,10 +90,13 @@ func getScope(reader *bufio.Reader) string {
 
 func getRequiredInput(reader *bufio.Reader, label string) string {
 	for {
-		input := promptInput(reader, label, "")
+		input, ok := promptLine(reader, label, "")
 		if input != "" {
 			return input
 		}
+		if !ok {
+			stopOnClosedInput(io.EOF)
+		}
 		styles.ErrorStyle.Println("This field is required")
 	}
 }
@@ -71,9 +106,12 @@ func getMultilineInput(reader *bufio.Reader, label string) string {
 	styles.Yellow.Println("Press Enter twice to finish")
 	var lines []string
 	for {
-		line, _ := reader.ReadString('\n')
+		line, err := reader.ReadString('\n')
 		line = strings.TrimSpace(line)
-		if line == "" && len(lines) > 0 {
+		if (line == "" && len(lines) > 0) || err != nil {
+			if line != "" {
+				lines = append(lines, line)
+			}
 			break
 		}
 		if line != "" {
@@ -84,18 +122,22 @@ func getMultilineInput(reader *bufio.Reader, label string) string {
 }
 
 func getBreakingChange(reader *bufio.Reader) (string, bool) {
-	if !confirmAction("Breaking change?") {
+	if !confirmAction(reader, "Breaking change?") {
 		return "", false
 	}
 	return getRequiredInput(reader, "Breaking change details"), true
 }
 
 func getIssueReference(reader *bufio.Reader) string {
-	input := promptInput(reader, "Issue number (optional)", "e.g., 123")
-	if input == "" {
+	return issueReference(promptInput(reader, "Issue number (optional)", "e.g., 123"))
+}
+
+func issueReference(issue string) string {
+	issue = strings.TrimPrefix(issue, "#")
+	if issue == "" {
 		return ""
 	}
-	return fmt.Sprintf("Refs #%s", input)
+	return fmt.Sprintf("Refs #%s", issue)
 }
 
 func getTrailers(reader *bufio.Reader) []string {
@@ -165,23 +207,29 @@ func runGitCommand(args ...string) {
 	cmd.Stderr = os.Stderr
 	if err := cmd.Run(); err != nil {
 		styles.ErrorStyle.Printf("Git error: %v\n", err)
-		os.Exit(1)
+		exit(1)
 	}
 }
 
 // Helper functions
 func promptInput(reader *bufio.Reader, label string, placeholder string) string {
+	input, _ := promptLine(reader, label, placeholder)
+	return input
+}
+
+// promptLine is promptInput that also reports false once input has ended
+func promptLine(reader *bufio.Reader, label string, placeholder string) (string, bool) {
 	styles.Cyan.Printf("%s ", label)
 	if placeholder != "" {
 		styles.Yellow.Printf("(%s) ", placeholder)
 	}
 	fmt.Print("➜ ")
-	input, _ := reader.ReadString('\n')
-	return strings.TrimSpace(input)
+	input, err := reader.ReadString('\n')
+	return strings.TrimSpace(input), err == nil || input != ""
 }
 
-func confirmAction(question string) bool {
-	input := promptInput(bufio.NewReader(os.Stdin), question, "Y/n")
+func confirmAction(reader *bufio.Reader, question string) bool {
+	input := promptInput(reader, question, "Y/n")
 	return strings.ToLower(input) != "n"
 }
 
@@ -198,324 +246,399 @@ func isValidTrailer(trailer string) bool {
 	return strings.Contains(trailer, ":") && len(strings.Split(trailer, ":")) >= 2
 }
 
-// GitPush handles standard push workflow
-func GitPush() {
-	reader := bufio.NewReader(os.Stdin)
+// GitPush stages everything, commits with the message and pushes
+func GitPush(args []string) {
+	in := newGitInput(args, "git push [message...] [-m <message>]", "message|m=")
 	styles.Cyan.Println("\nQuick Push")
 	styles.Cyan.Println("─────────────")
 
-	message := promptInput(reader, "Message", "")
+	message := in.flag("message")
 	if message == "" {
-		styles.ErrorStyle.Println("Commit message required")
-		os.Exit(1)
+		message = in.requireText(0, "Message", "")
 	}
 
 	executeGitWorkflow("Ellie: " + message)
 }
 
 // GitPull executes git pull with feedback
-func GitPull() {
+func GitPull(args []string) {
+	in := newGitInput(args, "git pull [remote] [branch] [--rebase]", "rebase")
 	styles.Cyan.Println("\nPulling Changes")
 	styles.Cyan.Println("─────────────────")
-	runGitCommand("pull")
+	gitArgs := []string{"pull"}
+	if in.has("rebase") {
+		gitArgs = append(gitArgs, "--rebase")
+	}
+	runGitCommand(append(gitArgs, in.words(0)...)...)
 	styles.SuccessStyle.Println("Pull completed")
 }
 
 // GitStatus shows enhanced status output
-func GitStatus() {
+func GitStatus(args []string) {
+	in := newGitInput(args, "git status [path...]")
 	styles.Cyan.Println("\nRepository Status")
 	styles.Cyan.Println("───────────────────")
-	runGitCommand("status", "-sb")
+	runGitCommand(withPaths([]string{"status", "-sb"}, in.words(0))...)
+}
+
+// withPaths appends paths to a git command after "--"
+func withPaths(gitArgs, paths []string) []string {
+	if len(paths) == 0 {
+		return gitArgs
+	}
+	return append(append(gitArgs, "--"), paths...)
 }
 
 // GitBranchCreate creates a new branch
-func GitBranchCreate() {
-	reader := bufio.NewReader(os.Stdin)
+func GitBranchCreate(args []string) {
+	in := newGitInput(args, "git branch-create <name> [--from <ref>]", "from=")
 	styles.Cyan.Println("\nCreate New Branch")
 	styles.Cyan.Println("────────────────────")
-	branch := promptInput(reader, "Branch name", "feature/my-feature")
-	if branch == "" {
-		styles.ErrorStyle.Println("Branch name required")
-		return
+	branch := in.require(0, "Branch name", "feature/my-feature")
+	gitArgs := []string{"checkout", "-b", branch}
+	if from := in.flag("from"); from != "" {
+		gitArgs = append(gitArgs, from)
 	}
-	runGitCommand("checkout", "-b", branch)
+	runGitCommand(gitArgs...)
 	styles.SuccessStyle.Printf("Created and switched to branch '%s'\n", branch)
 }
 
 // GitBranchSwitch switches to an existing branch
-func GitBranchSwitch() {
-	reader := bufio.NewReader(os.Stdin)
+func GitBranchSwitch(args []string) {
+	in := newGitInput(args, "git branch-switch <name>")
 	styles.Cyan.Println("\nSwitch Branch")
 	styles.Cyan.Println("────────────────")
-	branch := promptInput(reader, "Branch name", "main")
-	if branch == "" {
-		styles.ErrorStyle.Println("Branch name required")
-		return
-	}
+	branch := in.require(0, "Branch name", "main")
 	runGitCommand("checkout", branch)
 	styles.SuccessStyle.Printf("Switched to branch '%s'\n", branch)
 }
 
-// GitBranchDelete deletes a branch
-func GitBranchDelete() {
-	reader := bufio.NewReader(os.Stdin)
+// GitBranchDelete deletes a branch. --force deletes it even if it isn't
+// merged, after confirmation.
+func GitBranchDelete(args []string) {
+	in := newGitInput(args, "git branch-delete <name> [--force] [--yes]", "force|f")
 	styles.Cyan.Println("\nDelete Branch")
 	styles.Cyan.Println("────────────────")
-	branch := promptInput(reader, "Branch name", "feature/my-feature")
-	if branch == "" {
-		styles.ErrorStyle.Println("Branch name required")
-		return
+	branch := in.require(0, "Branch name", "feature/my-feature")
+	flag := "-d"
+	if in.has("force") {
+		if !in.confirm(fmt.Sprintf("Delete '%s' even if it isn't merged?", branch)) {
+			styles.ErrorStyle.Println("Delete canceled")
+			return
+		}
+		flag = "-D"
 	}
-	runGitCommand("branch", "-d", branch)
+	runGitCommand("branch", flag, branch)
 	styles.SuccessStyle.Printf("Deleted branch '%s'\n", branch)
 }
 
 // GitStashSave saves changes to a new stash
-func GitStashSave() {
-	reader := bufio.NewReader(os.Stdin)
+func GitStashSave(args []string) {
+	in := newGitInput(args, "git stash-save [message...] [--include-untracked]", "include-untracked|u")
 	styles.Cyan.Println("\nStash Changes")
 	styles.Cyan.Println("────────────────")
-	msg := promptInput(reader, "Stash message (optional)", "WIP")
-	if msg == "" {
-		runGitCommand("stash", "save")
-	} else {
-		runGitCommand("stash", "save", msg)
+	gitArgs := []string{"stash", "push"}
+	if in.has("include-untracked") {
+		gitArgs = append(gitArgs, "--include-untracked")
+	}
+	msg := strings.Join(in.words(0), " ")
+	if msg == "" && in.bare() {
+		msg = in.prompt("Stash message (optional)", "WIP")
+	}
+	if msg != "" {
+		gitArgs = append(gitArgs, "-m", msg)
 	}
+	runGitCommand(gitArgs...)
 	styles.SuccessStyle.Println("Changes stashed")
 }
 
-// GitStashPop pops the latest stash
-func GitStashPop() {
+// GitStashPop pops the latest stash, or the one given
+func GitStashPop(args []string) {
+	in := newGitInput(args, "git stash-pop [stash]")
 	styles.Cyan.Println("\nPop Stash")
 	styles.Cyan.Println("────────────")
-	runGitCommand("stash", "pop")
+	runGitCommand(append([]string{"stash", "pop"}, in.words(0)...)...)
 	styles.SuccessStyle.Println("Stash applied")
 }
 
 // GitStashList lists all stashes
-func GitStashList() {
+func GitStashList(args []string) {
+	newGitInput(args, "git stash-list")
 	styles.Cyan.Println("\nStash List")
 	styles.Cyan.Println("─────────────")
 	runGitCommand("stash", "list")
 }
 
-// GitTagCreate creates a new tag
-func GitTagCreate() {
-	reader := bufio.NewReader(os.Stdin)
+// GitTagCreate creates a new tag, annotated when a message is given
+func GitTagCreate(args []string) {
+	in := newGitInput(args, "git tag-create <name> [-m <message>] [--ref <ref>]", "message|m=", "ref=")
 	styles.Cyan.Println("\nCreate Tag")
 	styles.Cyan.Println("─────────────")
-	tag := promptInput(reader, "Tag name", "v1.0.0")
-	if tag == "" {
-		styles.ErrorStyle.Println("Tag name required")
-		return
+	tag := in.require(0, "Tag name", "v1.0.0")
+	gitArgs := []string{"tag", tag}
+	if message := in.flag("message"); message != "" {
+		gitArgs = []string{"tag", "-a", tag, "-m", message}
+	}
+	if ref := in.flag("ref"); ref != "" {
+		gitArgs = append(gitArgs, ref)
 	}
-	runGitCommand("tag", tag)
+	runGitCommand(gitArgs...)
 	styles.SuccessStyle.Printf("Tag '%s' created\n", tag)
 }
 
-// GitTagList lists all tags
-func GitTagList() {
+// GitTagList lists all tags, or those matching a pattern
+func GitTagList(args []string) {
+	in := newGitInput(args, "git tag-list [pattern]")
 	styles.Cyan.Println("\nTag List")
 	styles.Cyan.Println("───────────")
-	runGitCommand("tag", "--list")
+	runGitCommand(append([]string{"tag", "--list"}, in.words(0)...)...)
 }
 
 // GitTagDelete deletes a tag
-func GitTagDelete() {
-	reader := bufio.NewReader(os.Stdin)
+func GitTagDelete(args []string) {
+	in := newGitInput(args, "git tag-delete <name>")
 	styles.Cyan.Println("\nDelete Tag")
 	styles.Cyan.Println("────────────")
-	tag := promptInput(reader, "Tag name", "v1.0.0")
-	if tag == "" {
-		styles.ErrorStyle.Println("Tag name required")
-		return
-	}
+	tag := in.require(0, "Tag name", "v1.0.0")
 	runGitCommand("tag", "-d", tag)
 	styles.SuccessStyle.Printf("Tag '%s' deleted\n", tag)
 }
 
 // GitLogPretty prints a pretty git log
-func GitLogPretty() {
+func GitLogPretty(args []string) {
+	in := newGitInput(args, "git log [-n <count>] [ref]", "n|max-count=")
 	styles.Cyan.Println("\nGit Log")
 	styles.Cyan.Println("──────────")
-	runGitCommand("log", "--oneline", "--graph", "--decorate", "--all")
+	gitArgs := []string{"log", "--oneline", "--graph", "--decorate"}
+	if count := in.flag("n"); count != "" {
+		gitArgs = append(gitArgs, "-n", count)
+	}
+	if refs := in.words(0); len(refs) > 0 {
+		gitArgs = append(gitArgs, refs...)
+	} else {
+		gitArgs = append(gitArgs, "--all")
+	}
+	runGitCommand(gitArgs...)
 }
 
 // GitDiff shows the diff
-func GitDiff() {
+func GitDiff(args []string) {
+	in := newGitInput(args, "git diff [path...]")
 	styles.Cyan.Println("\nGit Diff")
 	styles.Cyan.Println("───────────")
-	runGitCommand("diff")
+	runGitCommand(withPaths([]string{"diff"}, in.words(0))...)
 }
 
 // GitMerge merges a branch into the current branch
-func GitMerge() {
-	reader := bufio.NewReader(os.Stdin)
+func GitMerge(args []string) {
+	in := newGitInput(args, "git merge <branch> [--no-ff] [--squash]", "no-ff", "squash")
 	styles.Cyan.Println("\nMerge Branch")
 	styles.Cyan.Println("───────────────")
-	branch := promptInput(reader, "Branch to merge", "feature/my-feature")
-	if branch == "" {
-		styles.ErrorStyle.Println("Branch name required")
-		return
+	branch := in.require(0, "Branch to merge", "feature/my-feature")
+	gitArgs := []string{"merge"}
+	for _, flag := range []string{"no-ff", "squash"} {
+		if in.has(flag) {
+			gitArgs = append(gitArgs, "--"+flag)
+		}
 	}
-	runGitCommand("merge", branch)
+	runGitCommand(append(gitArgs, branch)...)
 	styles.SuccessStyle.Printf("Merged branch '%s'\n", branch)
 }
 
-// GitRebase rebases the current branch onto another
-func GitRebase() {
-	reader := bufio.NewReader(os.Stdin)
+// GitRebase rebases the current branch onto another, after saving a
+// snapshot for git undo
+func GitRebase(args []string) {
+	in := newGitInput(args, "git rebase <branch>")
 	styles.Cyan.Println("\nRebase Branch")
 	styles.Cyan.Println("────────────────")
-	branch := promptInput(reader, "Branch to rebase onto", "main")
-	if branch == "" {
-		styles.ErrorStyle.Println("Branch name required")
-		return
+	branch := in.require(0, "Branch to rebase onto", "main")
+	if _, ok := snapshotBefore(backup.Snapshot{Operation: "rebase", Description: branch}); !ok {
+		exit(1)
 	}
 	runGitCommand("rebase", branch)
 	styles.SuccessStyle.Printf("Rebased onto '%s'\n", branch)
 }
 
-// GitCherryPick cherry-picks a commit
-func GitCherryPick() {
-	reader := bufio.NewReader(os.Stdin)
+// GitCherryPick cherry-picks one or more commits
+func GitCherryPick(args []string) {
+	in := newGitInput(args, "git cherry-pick <commit>...")
 	styles.Cyan.Println("\nCherry-pick Commit")
 	styles.Cyan.Println("─────────────────────")
-	commit := promptInput(reader, "Commit hash", "abc1234")
-	if commit == "" {
-		styles.ErrorStyle.Println("Commit hash required")
-		return
+	commits := in.words(0)
+	if len(commits) == 0 {
+		commits = []string{in.require(0, "Commit hash", "abc1234")}
 	}
-	runGitCommand("cherry-pick", commit)
-	styles.SuccessStyle.Printf("Cherry-picked commit '%s'\n", commit)
+	runGitCommand(append([]string{"cherry-pick"}, commits...)...)
+	styles.SuccessStyle.Printf("Cherry-picked commit '%s'\n", strings.Join(commits, "', '"))
 }
 
-// GitReset resets the current branch to a commit
-func GitReset() {
-	reader := bufio.NewReader(os.Stdin)
+// GitReset resets the current branch to a commit. A hard reset, the
+// default, discards local changes and asks for confirmation first. A
+// snapshot is saved for git undo either way.
+func GitReset(args []string) {
+	in := newGitInput(args, "git reset <ref> [--soft|--mixed|--hard] [--yes]", "soft", "mixed", "hard")
 	styles.Cyan.Println("\nReset Branch")
 	styles.Cyan.Println("───────────────")
-	commit := promptInput(reader, "Commit hash or ref", "HEAD~1")
-	if commit == "" {
-		styles.ErrorStyle.Println("Commit hash or ref required")
+	commit := in.require(0, "Commit hash or ref", "HEAD~1")
+	mode := "hard"
+	for _, m := range []string{"soft", "mixed"} {
+		if in.has(m) {
+			mode = m
+		}
+	}
+	if mode == "hard" && !in.confirm(fmt.Sprintf("Discard all local changes and reset to '%s'?", commit)) {
+		styles.ErrorStyle.Println("Reset canceled")
 		return
 	}
-	runGitCommand("reset", "--hard", commit)
+	if _, ok := snapshotBefore(backup.Snapshot{Operation: "reset", Description: "--" + mode + " " + commit}); !ok {
+		exit(1)
+	}
+	runGitCommand("reset", "--"+mode, commit)
 	styles.SuccessStyle.Printf("Reset to '%s'\n", commit)
 }
 
-// GitBisect starts a bisect session
-func GitBisect() {
+// GitBisect starts a bisect session, optionally with the bad and good
+// commits already known
+func GitBisect(args []string) {
+	in := newGitInput(args, "git bisect [bad [good...]]")
 	styles.Cyan.Println("\nGit Bisect")
 	styles.Cyan.Println("─────────────")
 	styles.Yellow.Println("This will help you find the commit that introduced a bug.")
 	styles.Yellow.Println("Use 'git bisect good' and 'git bisect bad' as prompted.")
-	runGitCommand("bisect", "start")
+	runGitCommand(append([]string{"bisect", "start"}, in.words(0)...)...)
 }
 
-// GitBisectGood marks the current commit as good
-func GitBisectGood() {
+// GitBisectGood marks the current commit, or the one given, as good
+func GitBisectGood(args []string) {
+	in := newGitInput(args, "git bisect-good [commit]")
 	styles.Cyan.Println("\nMark Good Commit")
 	styles.Cyan.Println("──────────────────")
-	runGitCommand("bisect", "good")
+	runGitCommand(append([]string{"bisect", "good"}, in.words(0)...)...)
 }
 
-// GitBisectBad marks the current commit as bad
-func GitBisectBad() {
+// GitBisectBad marks the current commit, or the one given, as bad
+func GitBisectBad(args []string) {
+	in := newGitInput(args, "git bisect-bad [commit]")
 	styles.Cyan.Println("\nMark Bad Commit")
 	styles.Cyan.Println("─────────────────")
-	runGitCommand("bisect", "bad")
+	runGitCommand(append([]string{"bisect", "bad"}, in.words(0)...)...)
 }
 
 // GitBisectReset ends the bisect session
-func GitBisectReset() {
+func GitBisectReset(args []string) {
+	newGitInput(args, "git bisect-reset")
 	styles.Cyan.Println("\nReset Bisect")
 	styles.Cyan.Println("────────────────")
 	runGitCommand("bisect", "reset")
 }
 
 // GitRemoteList lists all remotes
-func GitRemoteList() {
+func GitRemoteList(args []string) {
+	newGitInput(args, "git remote-list")
 	styles.Cyan.Println("\nRemote List")
 	styles.Cyan.Println("───────────")
 	runGitCommand("remote", "-v")
 }
 
 // GitRemoteAdd adds a new remote
-func GitRemoteAdd() {
-	reader := bufio.NewReader(os.Stdin)
+func GitRemoteAdd(args []string) {
+	in := newGitInput(args, "git remote-add <name> <url>")
 	styles.Cyan.Println("\nAdd Remote")
 	styles.Cyan.Println("──────────")
-	name := promptInput(reader, "Remote name", "origin")
-	if name == "" {
-		styles.ErrorStyle.Println("Remote name required")
-		return
-	}
-	url := promptInput(reader, "Remote URL", "https://github.com/user/repo.git")
-	if url == "" {
-		styles.ErrorStyle.Println("Remote URL required")
-		return
-	}
+	name := in.require(0, "Remote name", "origin")
+	url := in.require(1, "Remote URL", "https://github.com/user/repo.git")
 	runGitCommand("remote", "add", name, url)
 	styles.SuccessStyle.Printf("Remote '%s' added\n", name)
 }
 
 // GitRemoteRemove removes a remote
-func GitRemoteRemove() {
-	reader := bufio.NewReader(os.Stdin)
+func GitRemoteRemove(args []string) {
+	in := newGitInput(args, "git remote-remove <name>")
 	styles.Cyan.Println("\nRemove Remote")
 	styles.Cyan.Println("─────────────")
-	name := promptInput(reader, "Remote name", "origin")
-	if name == "" {
-		styles.ErrorStyle.Println("Remote name required")
-		return
-	}
+	name := in.require(0, "Remote name", "origin")
 	runGitCommand("remote", "remove", name)
 	styles.SuccessStyle.Printf("Remote '%s' removed\n", name)
 }
 
-// GitFetch fetches from a remote
-func GitFetch() {
+// GitFetch fetches from a remote, or from all of them
+func GitFetch(args []string) {
+	in := newGitInput(args, "git fetch [remote] [--prune]", "prune|p")
 	styles.Cyan.Println("\nFetch Changes")
 	styles.Cyan.Println("─────────────")
-	runGitCommand("fetch", "--all")
+	gitArgs := []string{"fetch"}
+	if in.has("prune") {
+		gitArgs = append(gitArgs, "--prune")
+	}
+	if remotes := in.words(0); len(remotes) > 0 {
+		gitArgs = append(gitArgs, remotes...)
+	} else {
+		gitArgs = append(gitArgs, "--all")
+	}
+	runGitCommand(gitArgs...)
 	styles.SuccessStyle.Println("Fetch completed")
 }
 
 // GitReflog shows the reflog
-func GitReflog() {
+func GitReflog(args []string) {
+	in := newGitInput(args, "git reflog [-n <count>]", "n|max-count=")
 	styles.Cyan.Println("\nReflog")
 	styles.Cyan.Println("──────")
-	runGitCommand("reflog", "--oneline")
+	gitArgs := []string{"reflog", "--oneline"}
+	if count := in.flag("n"); count != "" {
+		gitArgs = append(gitArgs, "-n", count)
+	}
+	runGitCommand(gitArgs...)
 }
 
-// GitClean removes untracked files
-func GitClean() {
+// GitClean removes untracked files. They are moved into a stash and a
+// snapshot is saved for git undo first. --dry-run lists them without asking.
+func GitClean(args []string) {
+	in := newGitInput(args, "git clean [--dry-run] [--yes]", "dry-run|n")
 	styles.Cyan.Println("\nClean Untracked Files")
 	styles.Cyan.Println("─────────────────────")
+	if in.has("dry-run") {
+		runGitCommand("clean", "-nd")
+		return
+	}
 	styles.Yellow.Println("This will remove untracked files. Use with caution!")
-	if !confirmAction("Continue with clean?") {
+	if !in.confirm("Continue with clean?") {
 		styles.ErrorStyle.Println("Clean canceled")
 		return
 	}
+	if _, ok := snapshotBefore(backup.Snapshot{Operation: "clean"}); !ok {
+		exit(1)
+	}
+	stashed, err := backup.StashUntracked("ellie: untracked files before clean")
+	if err != nil {
+		styles.ErrorStyle.Printf("Could not stash the untracked files, so nothing was removed: %v\n", err)
+		exit(1)
+	}
+	if stashed {
+		// Cleaning again could remove ignored files whose .gitignore was
+		// just stashed
+		styles.SuccessStyle.Println("Untracked files moved to a stash; 'git stash pop' brings them back")
+		return
+	}
+	// Only empty directories are left to remove
 	runGitCommand("clean", "-fd")
 	styles.SuccessStyle.Println("Clean completed")
 }
 
 // GitArchive creates an archive of the repository
-func GitArchive() {
-	reader := bufio.NewReader(os.Stdin)
+func GitArchive(args []string) {
+	in := newGitInput(args, "git archive [ref] [--format <tar|zip|tar.gz>] [--output <file>]", "format=", "output|o=")
 	styles.Cyan.Println("\nCreate Archive")
 	styles.Cyan.Println("──────────────")
-	format := promptInput(reader, "Format (tar/zip)", "tar.gz")
+	format := in.option("format", "Format (tar/zip)", "tar.gz")
 	if format == "" {
 		format = "tar.gz"
 	}
-	filename := promptInput(reader, "Output filename", "archive.tar.gz")
+	filename := in.option("output", "Output filename", "archive."+format)
 	if filename == "" {
-		filename = "archive.tar.gz"
+		filename = "archive." + format
 	}
-	ref := promptInput(reader, "Reference (branch/tag/commit)", "HEAD")
+	ref := in.optional(0, "Reference (branch/tag/commit)", "HEAD")
 	if ref == "" {
 		ref = "HEAD"
 	}
@@ -524,39 +647,28 @@ func GitArchive() {
 }
 
 // GitBlame shows blame information for a file
-func GitBlame() {
-	reader := bufio.NewReader(os.Stdin)
+func GitBlame(args []string) {
+	in := newGitInput(args, "git blame <file>")
 	styles.Cyan.Println("\nBlame File")
 	styles.Cyan.Println("──────────")
-	file := promptInput(reader, "File path", "main.go")
-	if file == "" {
-		styles.ErrorStyle.Println("File path required")
-		return
-	}
+	file := in.require(0, "File path", "main.go")
 	runGitCommand("blame", file)
 }
 
 // GitSubmoduleAdd adds a submodule
-func GitSubmoduleAdd() {
-	reader := bufio.NewReader(os.Stdin)
+func GitSubmoduleAdd(args []string) {
+	in := newGitInput(args, "git submodule-add <url> <path>")
 	styles.Cyan.Println("\nAdd Submodule")
 	styles.Cyan.Println("─────────────")
-	url := promptInput(reader, "Submodule URL", "https://github.com/user/repo.git")
-	if url == "" {
-		styles.ErrorStyle.Println("Submodule URL required")
-		return
-	}
-	path := promptInput(reader, "Local path", "submodules/repo")
-	if path == "" {
-		styles.ErrorStyle.Println("Local path required")
-		return
-	}
+	url := in.require(0, "Submodule URL", "https://github.com/user/repo.git")
+	path := in.require(1, "Local path", "submodules/repo")
 	runGitCommand("submodule", "add", url, path)
 	styles.SuccessStyle.Printf("Submodule added at %s\n", path)
 }
 
 // GitSubmoduleUpdate updates all submodules
-func GitSubmoduleUpdate() {
+func GitSubmoduleUpdate(args []string) {
+	newGitInput(args, "git submodule-update")
 	styles.Cyan.Println("\nUpdate Submodules")
 	styles.Cyan.Println("─────────────────")
 	runGitCommand("submodule", "update", "--init", "--recursive")
@@ -564,100 +676,93 @@ func GitSubmoduleUpdate() {
 }
 
 // GitSubmoduleStatus shows submodule status
-func GitSubmoduleStatus() {
+func GitSubmoduleStatus(args []string) {
+	newGitInput(args, "git submodule-status")
 	styles.Cyan.Println("\nSubmodule Status")
 	styles.Cyan.Println("────────────────")
 	runGitCommand("submodule", "status", "--recursive")
 }
 
+// configArgs starts a git config command, for every repository with --global
+func configArgs(in *gitInput) []string {
+	if in.has("global") {
+		return []string{"config", "--global"}
+	}
+	return []string{"config"}
+}
+
 // GitConfigSetUser sets user name and email
-func GitConfigSetUser() {
-	reader := bufio.NewReader(os.Stdin)
+func GitConfigSetUser(args []string) {
+	in := newGitInput(args, "git config-set-user <name> <email> [--global]", "global")
 	styles.Cyan.Println("\nSet User Configuration")
 	styles.Cyan.Println("──────────────────────")
-	name := promptInput(reader, "User name", "John Doe")
-	if name == "" {
-		styles.ErrorStyle.Println("User name required")
-		return
-	}
-	email := promptInput(reader, "User email", "john@example.com")
-	if email == "" {
-		styles.ErrorStyle.Println("User email required")
-		return
-	}
-	runGitCommand("config", "user.name", name)
-	runGitCommand("config", "user.email", email)
+	name := in.require(0, "User name", "John Doe")
+	email := in.require(1, "User email", "john@example.com")
+	runGitCommand(append(configArgs(in), "user.name", name)...)
+	runGitCommand(append(configArgs(in), "user.email", email)...)
 	styles.SuccessStyle.Println("User configuration updated")
 }
 
 // GitConfigList lists all configuration
-func GitConfigList() {
+func GitConfigList(args []string) {
+	in := newGitInput(args, "git config-list [--global]", "global")
 	styles.Cyan.Println("\nGit Configuration")
 	styles.Cyan.Println("─────────────────")
-	runGitCommand("config", "--list")
+	runGitCommand(append(configArgs(in), "--list")...)
 }
 
 // GitConfigSetAlias sets a Git alias
-func GitConfigSetAlias() {
-	reader := bufio.NewReader(os.Stdin)
+func GitConfigSetAlias(args []string) {
+	in := newGitInput(args, "git config-set-alias <alias> <command...> [--global]", "global")
 	styles.Cyan.Println("\nSet Git Alias")
 	styles.Cyan.Println("─────────────")
-	alias := promptInput(reader, "Alias name", "st")
-	if alias == "" {
-		styles.ErrorStyle.Println("Alias name required")
-		return
-	}
-	command := promptInput(reader, "Git command", "status")
-	if command == "" {
-		styles.ErrorStyle.Println("Git command required")
-		return
-	}
-	runGitCommand("config", "alias."+alias, command)
+	alias := in.require(0, "Alias name", "st")
+	command := in.requireText(1, "Git command", "status")
+	runGitCommand(append(configArgs(in), "alias."+alias, command)...)
 	styles.SuccessStyle.Printf("Alias '%s' set to '%s'\n", alias, command)
 }
 
 // GitWorktreeAdd adds a new worktree
-func GitWorktreeAdd() {
-	reader := bufio.NewReader(os.Stdin)
+func GitWorktreeAdd(args []string) {
+	in := newGitInput(args, "git worktree-add <path> <branch>")
 	styles.Cyan.Println("\nAdd Worktree")
 	styles.Cyan.Println("────────────")
-	path := promptInput(reader, "Worktree path", "../feature-branch")
-	if path == "" {
-		styles.ErrorStyle.Println("Worktree path required")
-		return
-	}
-	branch := promptInput(reader, "Branch name", "feature/new-feature")
-	if branch == "" {
-		styles.ErrorStyle.Println("Branch name required")
-		return
-	}
+	path := in.require(0, "Worktree path", "../feature-branch")
+	branch := in.require(1, "Branch name", "feature/new-feature")
 	runGitCommand("worktree", "add", path, branch)
 	styles.SuccessStyle.Printf("Worktree added at %s\n", path)
 }
 
 // GitWorktreeList lists all worktrees
-func GitWorktreeList() {
+func GitWorktreeList(args []string) {
+	newGitInput(args, "git worktree-list")
 	styles.Cyan.Println("\nWorktree List")
 	styles.Cyan.Println("─────────────")
 	runGitCommand("worktree", "list")
 }
 
-// GitWorktreeRemove removes a worktree
-func GitWorktreeRemove() {
-	reader := bufio.NewReader(os.Stdin)
+// GitWorktreeRemove removes a worktree. --force also removes one with
+// local changes, after confirmation.
+func GitWorktreeRemove(args []string) {
+	in := newGitInput(args, "git worktree-remove <path> [--force] [--yes]", "force|f")
 	styles.Cyan.Println("\nRemove Worktree")
 	styles.Cyan.Println("───────────────")
-	path := promptInput(reader, "Worktree path", "../feature-branch")
-	if path == "" {
-		styles.ErrorStyle.Println("Worktree path required")
-		return
+	path := in.require(0, "Worktree path", "../feature-branch")
+	gitArgs := []string{"worktree", "remove", path}
+	if in.has("force") {
+		if !in.confirm(fmt.Sprintf("Remove %s even if it has local changes?", path)) {
+			styles.ErrorStyle.Println("Remove canceled")
+			return
+		}
+		gitArgs = append(gitArgs, "--force")
 	}
-	runGitCommand("worktree", "remove", path)
+	runGitCommand(gitArgs...)
 	styles.SuccessStyle.Printf("Worktree removed: %s\n", path)
 }
 
 // GitWorktreePrune cleans up worktree information
-func GitWorktreePrune() {
+func GitWorktreePrune(args []string) {
+	newGitInput(args, "git worktree-prune")
 	styles.Cyan.Println("\nPrune Worktrees")
 	styles.Cyan.Println("───────────────")
 	runGitCommand("worktree", "prune")
@@ -665,38 +770,37 @@ func GitWorktreePrune() {
 }
 
 // GitInit initializes a new Git repository
-func GitInit() {
+func GitInit(args []string) {
+	in := newGitInput(args, "git init [dir]")
 	styles.Cyan.Println("\nInitialize Repository")
 	styles.Cyan.Println("─────────────────────")
-	runGitCommand("init")
+	runGitCommand(append([]string{"init"}, in.words(0)...)...)
 	styles.SuccessStyle.Println("Git repository initialized")
 }
 
 // GitClone clones a repository
-func GitClone() {
-	reader := bufio.NewReader(os.Stdin)
+func GitClone(args []string) {
+	in := newGitInput(args, "git clone <url> [dir] [--branch <name>]", "branch|b=")
 	styles.Cyan.Println("\nClone Repository")
 	styles.Cyan.Println("────────────────")
-	url := promptInput(reader, "Repository URL", "https://github.com/user/repo.git")
-	if url == "" {
-		styles.ErrorStyle.Println("Repository URL required")
-		return
+	url := in.require(0, "Repository URL", "https://github.com/user/repo.git")
+	gitArgs := []string{"clone", url}
+	if branch := in.flag("branch"); branch != "" {
+		gitArgs = append(gitArgs, "--branch", branch)
 	}
-	dir := promptInput(reader, "Directory name (optional)", "")
-	if dir == "" {
-		runGitCommand("clone", url)
-	} else {
-		runGitCommand("clone", url, dir)
+	if dir := in.optional(1, "Directory name (optional)", ""); dir != "" {
+		gitArgs = append(gitArgs, dir)
 	}
+	runGitCommand(gitArgs...)
 	styles.SuccessStyle.Println("Repository cloned")
 }
 
 // GitShow shows information about a commit
-func GitShow() {
-	reader := bufio.NewReader(os.Stdin)
+func GitShow(args []string) {
+	in := newGitInput(args, "git show [commit]")
 	styles.Cyan.Println("\nShow Commit")
 	styles.Cyan.Println("───────────")
-	commit := promptInput(reader, "Commit hash (optional)", "HEAD")
+	commit := in.optional(0, "Commit hash (optional)", "HEAD")
 	if commit == "" {
 		commit = "HEAD"
 	}
@@ -704,21 +808,22 @@ func GitShow() {
 }
 
 // GitRevert reverts a commit
-func GitRevert() {
-	reader := bufio.NewReader(os.Stdin)
+func GitRevert(args []string) {
+	in := newGitInput(args, "git revert <commit> [--no-edit]", "no-edit")
 	styles.Cyan.Println("\nRevert Commit")
 	styles.Cyan.Println("─────────────")
-	commit := promptInput(reader, "Commit hash", "abc1234")
-	if commit == "" {
-		styles.ErrorStyle.Println("Commit hash required")
-		return
+	commit := in.require(0, "Commit hash", "abc1234")
+	gitArgs := []string{"revert", commit}
+	if in.has("no-edit") || !in.interactive {
+		gitArgs = append(gitArgs, "--no-edit")
 	}
-	runGitCommand("revert", commit)
+	runGitCommand(gitArgs...)
 	styles.SuccessStyle.Printf("Reverted commit '%s'\n", commit)
 }
 
 // GitGC performs garbage collection
-func GitGC() {
+func GitGC(args []string) {
+	newGitInput(args, "git gc")
 	styles.Cyan.Println("\nGarbage Collection")
 	styles.Cyan.Println("──────────────────")
 	runGitCommand("gc", "--aggressive", "--prune=now")
@@ -726,178 +831,171 @@ func GitGC() {
 }
 
 // GitFsck checks repository integrity
-func GitFsck() {
+func GitFsck(args []string) {
+	newGitInput(args, "git fsck")
 	styles.Cyan.Println("\nCheck Repository Integrity")
 	styles.Cyan.Println("──────────────────────────")
 	runGitCommand("fsck", "--full")
 }
 
-// GitPushTags pushes all tags to remote
-func GitPushTags() {
+// GitPushTags pushes all tags to a remote
+func GitPushTags(args []string) {
+	in := newGitInput(args, "git push-tags [remote]")
 	styles.Cyan.Println("\nPush All Tags")
 	styles.Cyan.Println("─────────────")
-	runGitCommand("push", "--tags")
+	runGitCommand(append([]string{"push"}, append(in.words(0), "--tags")...)...)
 	styles.SuccessStyle.Println("All tags pushed to remote")
 }
 
-// GitPushForce force pushes changes
-func GitPushForce() {
+// GitPushForce force pushes with --force-with-lease, which refuses to
+// overwrite commits you haven't fetched; --no-lease overwrites them too. The
+// remote branch is saved in a snapshot for git undo first.
+func GitPushForce(args []string) {
+	in := newGitInput(args, "git push-force [remote] [branch] [--no-lease] [--yes]", "no-lease")
 	styles.Cyan.Println("\nForce Push")
 	styles.Cyan.Println("──────────")
 	styles.Yellow.Println("WARNING: Force push can overwrite remote history!")
-	if !confirmAction("Are you sure you want to force push?") {
+	if in.has("no-lease") {
+		styles.Yellow.Println("Without a lease, commits others pushed since your last fetch are lost too.")
+	}
+	if !in.confirm("Are you sure you want to force push?") {
 		styles.ErrorStyle.Println("Force push canceled")
 		return
 	}
-	runGitCommand("push", "--force-with-lease")
+	remote, branch := pushTarget(in.words(0))
+	if _, ok := snapshotBefore(backup.Snapshot{Operation: "push-force", Description: remote + " " + branch, Remote: remote, RemoteBranch: branch}); !ok {
+		exit(1)
+	}
+	force := "--force-with-lease"
+	if in.has("no-lease") {
+		force = "--force"
+	}
+	runGitCommand(append([]string{"push", force}, in.words(0)...)...)
 	styles.SuccessStyle.Println("Force push completed")
 }
 
 // GitPushUpstream pushes and sets upstream branch
-func GitPushUpstream() {
-	reader := bufio.NewReader(os.Stdin)
+func GitPushUpstream(args []string) {
+	in := newGitInput(args, "git push-upstream [branch] [--remote <name>]", "remote=")
 	styles.Cyan.Println("\nPush and Set Upstream")
 	styles.Cyan.Println("─────────────────────")
-	branch := promptInput(reader, "Branch name (current if empty)", "")
+	remote := in.flag("remote")
+	if remote == "" {
+		remote = "origin"
+	}
+	branch := in.optional(0, "Branch name (current if empty)", "")
 	if branch == "" {
-		runGitCommand("push", "-u", "origin", "HEAD")
-	} else {
-		runGitCommand("push", "-u", "origin", branch)
+		branch = "HEAD"
 	}
+	runGitCommand("push", "-u", remote, branch)
 	styles.SuccessStyle.Println("Push with upstream completed")
 }
 
 // GitBranchList lists all branches
-func GitBranchList() {
+func GitBranchList(args []string) {
+	newGitInput(args, "git branch-list")
 	styles.Cyan.Println("\nBranch List")
 	styles.Cyan.Println("───────────")
 	runGitCommand("branch", "-a")
 }
 
 // GitBranchListRemote lists remote branches
-func GitBranchListRemote() {
+func GitBranchListRemote(args []string) {
+	newGitInput(args, "git branch-list-remote")
 	styles.Cyan.Println("\nRemote Branches")
 	styles.Cyan.Println("───────────────")
 	runGitCommand("branch", "-r")
 }
 
 // GitBranchRename renames a branch
-func GitBranchRename() {
-	reader := bufio.NewReader(os.Stdin)
+func GitBranchRename(args []string) {
+	in := newGitInput(args, "git branch-rename <old> <new>")
 	styles.Cyan.Println("\nRename Branch")
 	styles.Cyan.Println("─────────────")
-	oldName := promptInput(reader, "Current branch name", "old-feature")
-	if oldName == "" {
-		styles.ErrorStyle.Println("Current branch name required")
-		return
-	}
-	newName := promptInput(reader, "New branch name", "new-feature")
-	if newName == "" {
-		styles.ErrorStyle.Println("New branch name required")
-		return
-	}
+	oldName := in.require(0, "Current branch name", "old-feature")
+	newName := in.require(1, "New branch name", "new-feature")
 	runGitCommand("branch", "-m", oldName, newName)
 	styles.SuccessStyle.Printf("Branch renamed from '%s' to '%s'\n", oldName, newName)
 }
 
 // GitLogSearch searches commit history
-func GitLogSearch() {
-	reader := bufio.NewReader(os.Stdin)
+func GitLogSearch(args []string) {
+	in := newGitInput(args, "git log-search <term...>")
 	styles.Cyan.Println("\nSearch Commit History")
 	styles.Cyan.Println("─────────────────────")
-	query := promptInput(reader, "Search term", "bug fix")
-	if query == "" {
-		styles.ErrorStyle.Println("Search term required")
-		return
-	}
+	query := in.requireText(0, "Search term", "bug fix")
 	runGitCommand("log", "--grep="+query, "--oneline")
 }
 
 // GitLogAuthor shows commits by author
-func GitLogAuthor() {
-	reader := bufio.NewReader(os.Stdin)
+func GitLogAuthor(args []string) {
+	in := newGitInput(args, "git log-author <author...>")
 	styles.Cyan.Println("\nCommits by Author")
 	styles.Cyan.Println("─────────────────")
-	author := promptInput(reader, "Author name or email", "john@example.com")
-	if author == "" {
-		styles.ErrorStyle.Println("Author required")
-		return
-	}
+	author := in.requireText(0, "Author name or email", "john@example.com")
 	runGitCommand("log", "--author="+author, "--oneline")
 }
 
 // GitLogSince shows commits since a date
-func GitLogSince() {
-	reader := bufio.NewReader(os.Stdin)
+func GitLogSince(args []string) {
+	in := newGitInput(args, "git log-since <date>")
 	styles.Cyan.Println("\nCommits Since Date")
 	styles.Cyan.Println("──────────────────")
-	date := promptInput(reader, "Date (YYYY-MM-DD)", "2023-01-01")
-	if date == "" {
-		styles.ErrorStyle.Println("Date required")
-		return
-	}
+	date := in.requireText(0, "Date (YYYY-MM-DD)", "2023-01-01")
 	runGitCommand("log", "--since="+date, "--oneline")
 }
 
 // GitDiffStaged shows staged changes
-func GitDiffStaged() {
+func GitDiffStaged(args []string) {
+	in := newGitInput(args, "git diff-staged [path...]")
 	styles.Cyan.Println("\nStaged Changes")
 	styles.Cyan.Println("──────────────")
-	runGitCommand("diff", "--staged")
+	runGitCommand(withPaths([]string{"diff", "--staged"}, in.words(0))...)
 }
 
 // GitDiffBranch compares two branches
-func GitDiffBranch() {
-	reader := bufio.NewReader(os.Stdin)
+func GitDiffBranch(args []string) {
+	in := newGitInput(args, "git diff-branch <first> <second>")
 	styles.Cyan.Println("\nCompare Branches")
 	styles.Cyan.Println("────────────────")
-	branch1 := promptInput(reader, "First branch", "main")
-	if branch1 == "" {
-		styles.ErrorStyle.Println("First branch required")
-		return
-	}
-	branch2 := promptInput(reader, "Second branch", "feature")
-	if branch2 == "" {
-		styles.ErrorStyle.Println("Second branch required")
-		return
-	}
+	branch1 := in.require(0, "First branch", "main")
+	branch2 := in.require(1, "Second branch", "feature")
 	runGitCommand("diff", branch1+".."+branch2)
 }
 
+// stashRef returns the stash named on the command line, prompting for it
+// when there are no arguments, and the latest stash otherwise
+func stashRef(in *gitInput) string {
+	if stash := in.optional(0, "Stash reference (optional)", "stash@{0}"); stash != "" {
+		return stash
+	}
+	return "stash@{0}"
+}
+
 // GitStashShow shows stash contents
-func GitStashShow() {
-	reader := bufio.NewReader(os.Stdin)
+func GitStashShow(args []string) {
+	in := newGitInput(args, "git stash-show [stash]")
 	styles.Cyan.Println("\nShow Stash Contents")
 	styles.Cyan.Println("───────────────────")
-	stash := promptInput(reader, "Stash reference (optional)", "stash@{0}")
-	if stash == "" {
-		stash = "stash@{0}"
-	}
-	runGitCommand("stash", "show", "-p", stash)
+	runGitCommand("stash", "show", "-p", stashRef(in))
 }
 
 // GitStashDrop drops a stash
-func GitStashDrop() {
-	reader := bufio.NewReader(os.Stdin)
+func GitStashDrop(args []string) {
+	in := newGitInput(args, "git stash-drop [stash]")
 	styles.Cyan.Println("\nDrop Stash")
 	styles.Cyan.Println("──────────")
-	stash := promptInput(reader, "Stash reference (optional)", "stash@{0}")
-	if stash == "" {
-		stash = "stash@{0}"
-	}
+	stash := stashRef(in)
 	runGitCommand("stash", "drop", stash)
 	styles.SuccessStyle.Printf("Stash %s dropped\n", stash)
 }
 
 // GitStashApply applies a stash without removing it
-func GitStashApply() {
-	reader := bufio.NewReader(os.Stdin)
+func GitStashApply(args []string) {
+	in := newGitInput(args, "git stash-apply [stash]")
 	styles.Cyan.Println("\nApply Stash")
 	styles.Cyan.Println("───────────")
-	stash := promptInput(reader, "Stash reference (optional)", "stash@{0}")
-	if stash == "" {
-		stash = "stash@{0}"
-	}
+	stash := stashRef(in)
 	runGitCommand("stash", "apply", stash)
 	styles.SuccessStyle.Printf("Stash %s applied\n", stash)
 }
@@ -0,0 +1,292 @@
+package actions
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/tacheraSasi/ellie/chat"
+	"github.com/tacheraSasi/ellie/styles"
+	"github.com/tacheraSasi/ellie/utils"
+)
+
+const commitUsage = "Usage: ellie git commit [--ai] [--provider <name>] [--model <name>] [--yes]"
+
+// maxHeaderLength keeps generated commit headers readable in git log
+const maxHeaderLength = 100
+
+// GitCommit builds a conventional commit from flags and prompts, or with
+// --ai has the LLM write it from the staged changes
+func GitCommit(args []string) {
+	withAI, rest := popFlag(args, "ai")
+	if !withAI {
+		GitConventionalCommit(args)
+		return
+	}
+	GitAICommit(rest)
+}
+
+// commitSuggestion is the JSON object the AI is asked to reply with
+type commitSuggestion struct {
+	Type           string   `json:"type"`
+	Scope          string   `json:"scope"`
+	Description    string   `json:"description"`
+	Body           string   `json:"body"`
+	BreakingChange string   `json:"breaking_change"`
+	Trailers       []string `json:"trailers"`
+}
+
+// parseCommitSuggestion extracts the suggestion from an AI answer and builds
+// the commit message with the same rules as GitConventionalCommit. The error
+// lists every problem so it can be sent back to the model.
+func parseCommitSuggestion(answer string) (string, error) {
+	start := strings.Index(answer, "{")
+	end := strings.LastIndex(answer, "}")
+	if start < 0 || end < start {
+		return "", errors.New("no JSON object found")
+	}
+
+	var s commitSuggestion
+	if err := json.Unmarshal([]byte(answer[start:end+1]), &s); err != nil {
+		return "", fmt.Errorf("invalid JSON: %w", err)
+	}
+	return s.message()
+}
+
+// message builds the commit message, listing every problem in the error
+func (s commitSuggestion) message() (string, error) {
+	s.Type = strings.ToLower(strings.TrimSpace(s.Type))
+	s.Scope = strings.TrimSpace(s.Scope)
+	s.Description = strings.TrimSuffix(strings.TrimSpace(s.Description), ".")
+	s.Body = strings.TrimSpace(s.Body)
+	s.BreakingChange = strings.TrimSpace(s.BreakingChange)
+
+	var problems []string
+	if !isValidCommitType(s.Type) {
+		problems = append(problems, fmt.Sprintf("type %q must be one of %s", s.Type, strings.Join(allowedTypes, ", ")))
+	}
+	if s.Description == "" || strings.Contains(s.Description, "\n") {
+		problems = append(problems, "description must be a single non-empty line")
+	}
+	header := buildHeader(s.Type, s.Scope, s.Description)
+	if len(header) > maxHeaderLength {
+		problems = append(problems, fmt.Sprintf("the header %q is longer than %d characters", header, maxHeaderLength))
+	}
+	var trailers []string
+	for _, t := range s.Trailers {
+		t = strings.TrimSpace(t)
+		if !isValidTrailer(t) {
+			problems = append(problems, fmt.Sprintf("trailer %q must look like 'Key: Value'", t))
+		}
+		trailers = append(trailers, t)
+	}
+	if len(problems) > 0 {
+		return "", errors.New(strings.Join(problems, "; "))
+	}
+
+	message := buildCommitMessage(header, s.Body, s.BreakingChange, s.BreakingChange != "", "", trailers)
+	if err := validateCommitMessage(message); err != nil {
+		return "", err
+	}
+	return message, nil
+}
+
+// stagedDiff returns the staged changes and their summary
+func stagedDiff() (diff, stat string, err error) {
+	out, err := exec.Command("git", "diff", "--staged", "--no-color", "--no-ext-diff").Output()
+	if err != nil {
+		return "", "", errors.New("git diff --staged failed; is this a git repository?")
+	}
+	statOut, _ := exec.Command("git", "diff", "--staged", "--stat", "--no-color").Output()
+	return string(out), string(statOut), nil
+}
+
+// commitPrompt asks for a commit message describing the diff, cutting the
+// diff to maxTokens so it fits the model's context
+func commitPrompt(diff, stat string, maxTokens int) string {
+	if chat.EstimateTokens(diff) > maxTokens {
+		runes := []rune(diff)
+		if limit := maxTokens * 4; len(runes) > limit {
+			diff = string(runes[:limit]) + "\n[diff truncated; use the summary above for the rest]\n"
+		}
+	}
+
+	return fmt.Sprintf(
+		`You are an expert software engineer. Write a Conventional Commits message for the staged changes below.
+Reply with ONLY a JSON object, without Markdown or code fences, in this format:
+{
+  "type": "one of %s",
+  "scope": "optional area of the code, e.g. auth; empty if none",
+  "description": "imperative summary in lower case without a trailing period, under 72 characters",
+  "body": "optional explanation of what changed and why, wrapped at 72 characters; empty if the description says it all",
+  "breaking_change": "what breaks and how to migrate; empty if nothing breaks",
+  "trailers": ["optional git trailers as 'Key: Value'"]
+}
+
+Summary:
+%s
+Diff:
+%s
+`, strings.Join(allowedTypes, ", "), stat, diff)
+}
+
+// suggestCommit asks for a commit message, and once more with the errors if
+// the first answer doesn't validate
+func suggestCommit(session *chat.ChatSession, prompt string) (string, error) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	done := make(chan bool)
+	go utils.ShowLoadingSpinner("Writing commit message...", done)
+	defer func() { done <- true }()
+
+	answer, err := session.SendMessageStream(ctx, prompt, nil)
+	if err != nil {
+		return "", errors.New(chat.DescribeError(err))
+	}
+	message, err := parseCommitSuggestion(answer)
+	if err == nil {
+		return message, nil
+	}
+
+	retry := fmt.Sprintf("Your answer did not match the required format: %v\nReply again with only the corrected JSON object.", err)
+	if answer, err = session.SendMessageStream(ctx, retry, nil); err != nil {
+		return "", errors.New(chat.DescribeError(err))
+	}
+	if message, err = parseCommitSuggestion(answer); err != nil {
+		return "", fmt.Errorf("the AI did not return a valid commit message: %w", err)
+	}
+	return message, nil
+}
+
+// editCommitMessage opens the message in the editor git uses and returns it
+// without comment lines
+func editCommitMessage(message string) (string, error) {
+	out, err := exec.Command("git", "var", "GIT_EDITOR").Output()
+	editor := strings.Fields(strings.TrimSpace(string(out)))
+	if err != nil || len(editor) == 0 {
+		return "", errors.New("no editor configured; set core.editor or $EDITOR")
+	}
+
+	file, err := os.CreateTemp("", "ellie-commit-*.txt")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(file.Name())
+	_, err = fmt.Fprintf(file, "%s\n\n# Edit the commit message. Lines starting with # are ignored.\n", message)
+	file.Close()
+	if err != nil {
+		return "", err
+	}
+
+	cmd := exec.Command(editor[0], append(editor[1:], file.Name())...)
+	cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor failed: %w", err)
+	}
+
+	data, err := os.ReadFile(file.Name())
+	if err != nil {
+		return "", err
+	}
+	return stripCommitComments(string(data)), nil
+}
+
+// stripCommitComments drops comment lines and surrounding blank lines
+func stripCommitComments(message string) string {
+	var lines []string
+	for _, line := range strings.Split(message, "\n") {
+		if !strings.HasPrefix(line, "#") {
+			lines = append(lines, strings.TrimRight(line, " \t\r"))
+		}
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// GitAICommit writes a conventional commit message for the staged changes
+// with the LLM, then lets the user accept, edit or regenerate it before
+// committing and pushing. --yes accepts the first message.
+func GitAICommit(args []string) {
+	yes, args := popFlag(args, "yes")
+	y, args := popFlag(args, "y")
+	opts, rest, err := parseAIFlags(args)
+	if err != nil || len(rest) > 1 {
+		if err != nil {
+			styles.ErrorStyle.Printf("Error: %v\n", err)
+		}
+		styles.InfoStyle.Println(commitUsage)
+		return
+	}
+
+	diff, stat, err := stagedDiff()
+	if err != nil {
+		styles.ErrorStyle.Printf("Error: %v\n", err)
+		return
+	}
+	if strings.TrimSpace(diff) == "" {
+		styles.WarningStyle.Println("Nothing is staged. Stage your changes with git add first.")
+		return
+	}
+
+	provider, err := newProvider("git-commit", opts, 60*time.Second)
+	if err != nil {
+		styles.ErrorStyle.Printf("Error creating provider: %v\n", err)
+		return
+	}
+	session := chat.NewChatSession(provider)
+
+	message, err := suggestCommit(session, commitPrompt(diff, stat, reviewChunkBudget(provider.GetModel())))
+	if err != nil {
+		styles.ErrorStyle.Printf("Error: %v\n", err)
+		return
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		displayCommitPreview(message)
+		choice, ok := "a", true
+		if !yes && !y {
+			choice, ok = promptLine(reader, "Commit with this message?", "[a]ccept, [e]dit, [r]egenerate, [q]uit")
+		}
+		if !ok {
+			choice = "q"
+		}
+		switch strings.ToLower(choice) {
+		case "", "a", "accept", "y", "yes":
+			runGitCommand("commit", "-m", message)
+			runGitCommand("push")
+			styles.SuccessStyle.Println("Successfully committed and pushed!")
+			return
+		case "e", "edit":
+			edited, err := editCommitMessage(message)
+			if err != nil {
+				styles.ErrorStyle.Printf("Error: %v\n", err)
+				continue
+			}
+			if err := validateCommitMessage(edited); err != nil {
+				styles.ErrorStyle.Printf("Keeping the previous message: %v\n", err)
+				continue
+			}
+			message = edited
+		case "r", "regenerate":
+			regenerated, err := suggestCommit(session, "Write a different commit message for the same changes. Reply with only the JSON object.")
+			if err != nil {
+				styles.ErrorStyle.Printf("Error: %v\n", err)
+				continue
+			}
+			message = regenerated
+		case "q", "quit", "n", "no":
+			styles.ErrorStyle.Println("Commit canceled")
+			return
+		default:
+			styles.WarningStyle.Println("Please answer a, e, r or q")
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package actions
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseCommitSuggestion(t *testing.T) {
+	answer := "```json\n" + `{
+  "type": "Feat",
+  "scope": "hooks",
+  "description": "install chained git hooks.",
+  "body": "Existing hooks keep running first.",
+  "breaking_change": "",
+  "trailers": ["Refs: #42"]
+}` + "\n```"
+
+	message, err := parseCommitSuggestion(answer)
+	if err != nil {
+		t.Fatalf("parseCommitSuggestion() error = %v", err)
+	}
+	want := "feat(hooks): install chained git hooks\n\nExisting hooks keep running first.\n\nRefs: #42"
+	if message != want {
+		t.Errorf("parseCommitSuggestion() = %q, want %q", message, want)
+	}
+
+	breaking, err := parseCommitSuggestion(`{"type": "refactor", "description": "drop v1", "breaking_change": "v1 routes are gone"}`)
+	if err != nil || !strings.HasSuffix(breaking, "\n\nBREAKING CHANGE: v1 routes are gone") {
+		t.Errorf("parseCommitSuggestion() breaking = %q, %v", breaking, err)
+	}
+}
+
+func TestParseCommitSuggestion_Invalid(t *testing.T) {
+	tests := []struct {
+		answer string
+		want   string
+	}{
+		{"Add hooks", "no JSON object"},
+		{`{"type": "feature", "description": "x"}`, `type "feature" must be one of`},
+		{`{"type": "fix", "description": ""}`, "description must be a single non-empty line"},
+		{`{"type": "fix", "description": "x", "trailers": ["no colon"]}`, `trailer "no colon"`},
+		{`{"type": "fix", "description": "` + strings.Repeat("x", 120) + `"}`, "longer than 100"},
+	}
+	for _, tt := range tests {
+		_, err := parseCommitSuggestion(tt.answer)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseCommitSuggestion(%q) error = %v, want %q", tt.answer, err, tt.want)
+		}
+	}
+}
+
+func TestCommitPrompt_TruncatesDiff(t *testing.T) {
+	diff := strings.Repeat("+line\n", 1000)
+	prompt := commitPrompt(diff, " a.go | 1000 +\n", 100)
+	if !strings.Contains(prompt, "[diff truncated") || strings.Count(prompt, "+line") > 100 {
+		t.Errorf("commitPrompt() did not truncate the diff")
+	}
+	if !strings.Contains(prompt, "a.go | 1000") || !strings.Contains(prompt, "feat, fix") {
+		t.Errorf("commitPrompt() is missing the summary or the allowed types")
+	}
+}
+
+func TestStripCommitComments(t *testing.T) {
+	got := stripCommitComments("feat: x\n\nBody  \n\n# Edit the commit message\n")
+	if got != "feat: x\n\nBody" {
+		t.Errorf("stripCommitComments() = %q", got)
+	}
+}
@@ -0,0 +1,212 @@
+package actions
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/mattn/go-isatty"
+	"github.com/tacheraSasi/ellie/styles"
+)
+
+// stdinIsTerminal reports whether prompts can be answered. Tests replace it.
+var stdinIsTerminal = func() bool {
+	return isatty.IsTerminal(os.Stdin.Fd())
+}
+
+// gitInput holds a git subcommand's arguments. Values missing from the
+// command line are prompted for only when stdin is a terminal, so every
+// subcommand can also run from scripts and automations.
+type gitInput struct {
+	usage       string
+	positional  []string
+	flags       map[string][]string
+	yes         bool
+	interactive bool
+	reader      *bufio.Reader
+}
+
+// parseGitInput splits args, whose first element is the subcommand, into
+// positional arguments and flags. Each spec names a flag and its aliases,
+// such as "message|m=": a trailing "=" means the flag takes a value, given
+// as "--message hi" or "--message=hi". --yes (-y) is always accepted.
+func parseGitInput(args []string, specs ...string) (*gitInput, error) {
+	names := map[string]string{}
+	takesValue := map[string]bool{}
+	for _, spec := range specs {
+		value := strings.HasSuffix(spec, "=")
+		aliases := strings.Split(strings.TrimSuffix(spec, "="), "|")
+		for _, alias := range aliases {
+			names[alias] = aliases[0]
+		}
+		takesValue[aliases[0]] = value
+	}
+
+	in := &gitInput{flags: map[string][]string{}}
+	if len(args) > 0 {
+		args = args[1:]
+	}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			in.positional = append(in.positional, args[i+1:]...)
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			in.positional = append(in.positional, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if name == "yes" || name == "y" {
+			in.yes = true
+			continue
+		}
+		canonical, ok := names[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown flag %s", arg)
+		}
+		if !takesValue[canonical] {
+			in.flags[canonical] = append(in.flags[canonical], "true")
+			continue
+		}
+		if !hasValue {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("flag %s needs a value", arg)
+			}
+			i++
+			value = args[i]
+		}
+		in.flags[canonical] = append(in.flags[canonical], value)
+	}
+	return in, nil
+}
+
+// newGitInput parses the arguments of a git subcommand, exiting with the
+// usage line if they are invalid
+func newGitInput(args []string, usage string, specs ...string) *gitInput {
+	in, err := parseGitInput(args, specs...)
+	if err != nil {
+		styles.ErrorStyle.Printf("Error: %v\n", err)
+		styles.InfoStyle.Println("Usage: ellie " + usage)
+		exit(1)
+	}
+	in.usage = usage
+	in.interactive = stdinIsTerminal()
+	in.reader = bufio.NewReader(os.Stdin)
+	return in
+}
+
+// arg returns the i-th positional argument, prompting for it if it is
+// missing and stdin is a terminal
+func (in *gitInput) arg(i int, label, placeholder string) string {
+	if i < len(in.positional) {
+		return in.positional[i]
+	}
+	return in.prompt(label, placeholder)
+}
+
+// text is arg for a value that may span the rest of the positional
+// arguments, such as a commit message
+func (in *gitInput) text(i int, label, placeholder string) string {
+	if words := in.words(i); len(words) > 0 {
+		return strings.Join(words, " ")
+	}
+	return in.prompt(label, placeholder)
+}
+
+// require is arg for values the subcommand can't run without
+func (in *gitInput) require(i int, label, placeholder string) string {
+	return in.required(in.arg(i, label, placeholder), label)
+}
+
+// requireText is text for values the subcommand can't run without
+func (in *gitInput) requireText(i int, label, placeholder string) string {
+	return in.required(in.text(i, label, placeholder), label)
+}
+
+func (in *gitInput) required(value, label string) string {
+	if value == "" {
+		in.fail(label + " required")
+	}
+	return value
+}
+
+// optional returns the i-th positional argument. Optional values are only
+// prompted for when the subcommand was run without any arguments.
+func (in *gitInput) optional(i int, label, placeholder string) string {
+	if i < len(in.positional) {
+		return in.positional[i]
+	}
+	if !in.bare() {
+		return ""
+	}
+	return in.prompt(label, placeholder)
+}
+
+// option is optional for a flag's value
+func (in *gitInput) option(name, label, placeholder string) string {
+	if value := in.flag(name); value != "" {
+		return value
+	}
+	if !in.bare() {
+		return ""
+	}
+	return in.prompt(label, placeholder)
+}
+
+// words returns the positional arguments from i on
+func (in *gitInput) words(i int) []string {
+	if i >= len(in.positional) {
+		return nil
+	}
+	return in.positional[i:]
+}
+
+// bare reports whether the subcommand was run without arguments
+func (in *gitInput) bare() bool {
+	return len(in.positional) == 0 && len(in.flags) == 0
+}
+
+// flag returns the last value given for a flag
+func (in *gitInput) flag(name string) string {
+	values := in.flags[name]
+	if len(values) == 0 {
+		return ""
+	}
+	return values[len(values)-1]
+}
+
+// has reports whether a flag was given
+func (in *gitInput) has(name string) bool {
+	return len(in.flags[name]) > 0
+}
+
+func (in *gitInput) prompt(label, placeholder string) string {
+	if !in.interactive {
+		return ""
+	}
+	return promptInput(in.reader, label, placeholder)
+}
+
+// confirm asks a yes/no question. --yes answers it, and without a terminal
+// a missing --yes is an error.
+func (in *gitInput) confirm(question string) bool {
+	if in.yes {
+		return true
+	}
+	if !in.interactive {
+		in.fail(question + " Pass --yes to confirm without a terminal.")
+	}
+	return confirmAction(in.reader, question)
+}
+
+// fail prints the error and the usage line and exits
+func (in *gitInput) fail(message string) {
+	styles.ErrorStyle.Println(message)
+	if in.usage != "" {
+		styles.InfoStyle.Println("Usage: ellie " + in.usage)
+	}
+	exit(1)
+}
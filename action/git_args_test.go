@@ -0,0 +1,91 @@
+package actions
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseGitInput(t *testing.T) {
+	in, err := parseGitInput(
+		[]string{"commit", "-t", "feat", "--message=add x", "--trailer", "A: b", "--trailer", "C: d", "--breaking", "old API", "-y", "extra"},
+		"type|t=", "message|m=", "trailer=", "breaking=",
+	)
+	if err != nil {
+		t.Fatalf("parseGitInput() error = %v", err)
+	}
+	if in.flag("type") != "feat" || in.flag("message") != "add x" || in.flag("breaking") != "old API" {
+		t.Errorf("flags = %v", in.flags)
+	}
+	if got := in.flags["trailer"]; !reflect.DeepEqual(got, []string{"A: b", "C: d"}) {
+		t.Errorf("repeated flag = %v", got)
+	}
+	if !in.yes || !reflect.DeepEqual(in.positional, []string{"extra"}) {
+		t.Errorf("yes = %v, positional = %v", in.yes, in.positional)
+	}
+}
+
+func TestParseGitInput_Positional(t *testing.T) {
+	in, err := parseGitInput([]string{"branch-create", "feature/x", "--from", "main", "--", "--odd-name"}, "from=", "force|f")
+	if err != nil {
+		t.Fatalf("parseGitInput() error = %v", err)
+	}
+	if !reflect.DeepEqual(in.positional, []string{"feature/x", "--odd-name"}) || in.flag("from") != "main" || in.has("force") {
+		t.Errorf("parseGitInput() = %+v", in)
+	}
+}
+
+func TestParseGitInput_Errors(t *testing.T) {
+	tests := map[string][]string{
+		"unknown flag --nope":   {"push", "--nope"},
+		"flag -m needs a value": {"push", "-m"},
+	}
+	for want, args := range tests {
+		_, err := parseGitInput(args, "message|m=")
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("parseGitInput(%v) error = %v, want %q", args, err, want)
+		}
+	}
+}
+
+func TestGitInput_NoPromptWithoutTerminal(t *testing.T) {
+	in, _ := parseGitInput([]string{"show"})
+	if got := in.arg(0, "Commit", ""); got != "" {
+		t.Errorf("arg() = %q, want no prompt without a terminal", got)
+	}
+	if got := in.optional(0, "Commit", ""); got != "" {
+		t.Errorf("optional() = %q", got)
+	}
+
+	in, _ = parseGitInput([]string{"log-search", "bug", "fix"})
+	if got := in.text(0, "Search term", ""); got != "bug fix" {
+		t.Errorf("text() = %q, want the words joined", got)
+	}
+
+	in, _ = parseGitInput([]string{"clean", "--yes"})
+	if !in.confirm("Continue?") {
+		t.Error("confirm() should accept --yes")
+	}
+}
+
+func TestIssueReference(t *testing.T) {
+	for issue, want := range map[string]string{"": "", "12": "Refs #12", "#12": "Refs #12"} {
+		if got := issueReference(issue); got != want {
+			t.Errorf("issueReference(%q) = %q, want %q", issue, got, want)
+		}
+	}
+}
+
+func TestPushTarget(t *testing.T) {
+	tests := map[string][2]string{
+		"origin main":                  {"origin", "main"},
+		"upstream HEAD:release":        {"upstream", "release"},
+		"origin +feature:refs/heads/x": {"origin", "x"},
+	}
+	for words, want := range tests {
+		remote, branch := pushTarget(strings.Fields(words))
+		if remote != want[0] || branch != want[1] {
+			t.Errorf("pushTarget(%s) = %s, %s, want %s, %s", words, remote, branch, want[0], want[1])
+		}
+	}
+}
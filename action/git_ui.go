@@ -0,0 +1,32 @@
+package actions
+
+import (
+	"github.com/tacheraSasi/ellie/gitui"
+	"github.com/tacheraSasi/ellie/styles"
+)
+
+// GitUI opens the full-screen git UI for staging, branches, stashes and
+// conventional commits
+func GitUI(args []string) {
+	newGitInput(args, "git ui")
+	if !stdinIsTerminal() {
+		styles.ErrorStyle.Println("git ui needs a terminal")
+		return
+	}
+
+	err := gitui.Run(gitui.Options{
+		CommitTypes: allowedTypes,
+		BuildMessage: func(commitType, scope, description, body, breaking string) (string, error) {
+			return commitSuggestion{
+				Type:           commitType,
+				Scope:          scope,
+				Description:    description,
+				Body:           body,
+				BreakingChange: breaking,
+			}.message()
+		},
+	})
+	if err != nil {
+		styles.ErrorStyle.Printf("Error: %v\n", err)
+	}
+}
@@ -0,0 +1,145 @@
+package actions
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/tacheraSasi/ellie/backup"
+	"github.com/tacheraSasi/ellie/repos"
+	"github.com/tacheraSasi/ellie/styles"
+)
+
+// snapshotBefore saves the repository before a destructive operation and
+// says how to get it back. The operation must not run if it returns false.
+func snapshotBefore(s backup.Snapshot) (backup.Snapshot, bool) {
+	s, err := backup.Create(s)
+	if err != nil {
+		styles.ErrorStyle.Printf("Could not save a snapshot, so nothing was changed: %v\n", err)
+		return s, false
+	}
+	styles.DimText.Printf("Snapshot saved as %s; restore it with 'ellie git undo %s'\n", s.Name, s.Name)
+	return s, true
+}
+
+// pushTarget is the remote and branch a push goes to: the ones given, or
+// the current branch's upstream, or the branch of the same name on origin
+func pushTarget(words []string) (remote, branch string) {
+	if len(words) > 0 {
+		remote = words[0]
+	}
+	if len(words) > 1 {
+		// A refspec like local:remote pushes to the part after the colon
+		branch = words[1]
+		if _, dst, found := strings.Cut(branch, ":"); found {
+			branch = dst
+		}
+		return remote, strings.TrimPrefix(strings.TrimPrefix(branch, "+"), "refs/heads/")
+	}
+	if out, err := exec.Command("git", "rev-parse", "--abbrev-ref", "--symbolic-full-name", "@{upstream}").Output(); err == nil {
+		upstreamRemote, upstreamBranch, found := strings.Cut(strings.TrimSpace(string(out)), "/")
+		if found && (remote == "" || remote == upstreamRemote) {
+			return upstreamRemote, upstreamBranch
+		}
+	}
+	if remote == "" {
+		remote = "origin"
+	}
+	if out, err := exec.Command("git", "branch", "--show-current").Output(); err == nil {
+		branch = strings.TrimSpace(string(out))
+	}
+	return remote, branch
+}
+
+// describeSnapshot is a snapshot's line in the undo list
+func describeSnapshot(s backup.Snapshot) string {
+	what := s.Operation
+	if s.Description != "" {
+		what += " " + s.Description
+	}
+	where := s.Branch
+	if s.IsRemote() {
+		where = s.Remote + "/" + s.RemoteBranch
+	} else if where == "" && len(s.Head) >= 7 {
+		where = s.Head[:7]
+	}
+	return fmt.Sprintf("%-32s %-5s %-40s %s", s.Name, repos.Age(s.Created, time.Now()), what, where)
+}
+
+// GitUndo lists the snapshots taken before destructive git commands and
+// restores one. The current state is saved first, so an undo can be undone.
+func GitUndo(args []string) {
+	in := newGitInput(args, "git undo [number|name] [--list] [--yes]", "list|l")
+	styles.Cyan.Println("\nUndo")
+	styles.Cyan.Println("────")
+
+	snapshots, err := backup.List()
+	if err != nil {
+		styles.ErrorStyle.Printf("Error: %v\n", err)
+		exit(1)
+	}
+	if len(snapshots) == 0 {
+		styles.InfoStyle.Println("No snapshots yet. Ellie saves one before reset, clean, rebase, push-force and undo.")
+		return
+	}
+
+	id := strings.Join(in.words(0), " ")
+	if id == "" || in.has("list") {
+		for i, s := range snapshots {
+			fmt.Printf("%3d  %s\n", i+1, describeSnapshot(s))
+		}
+		if in.has("list") {
+			return
+		}
+		id = in.optional(0, "\nSnapshot to restore", "1")
+		if id == "" {
+			id = "1"
+		}
+	}
+
+	s, err := backup.Find(id)
+	if err != nil {
+		in.fail(err.Error())
+	}
+
+	if s.IsRemote() {
+		if s.RemoteHead == "" {
+			in.fail(fmt.Sprintf("%s/%s didn't exist before the force push, so there is nothing to restore", s.Remote, s.RemoteBranch))
+		}
+		question := fmt.Sprintf("Force push %s back to %s/%s?", s.RemoteHead[:7], s.Remote, s.RemoteBranch)
+		if !in.confirm(question) {
+			styles.ErrorStyle.Println("Undo canceled")
+			return
+		}
+		if _, ok := snapshotBefore(backup.Snapshot{Operation: "undo", Description: s.Name, Remote: s.Remote, RemoteBranch: s.RemoteBranch}); !ok {
+			exit(1)
+		}
+		if err := backup.RestoreRemote(s); err != nil {
+			styles.ErrorStyle.Printf("Error: %v\n", err)
+			exit(1)
+		}
+		styles.SuccessStyle.Printf("Restored %s/%s to %s\n", s.Remote, s.RemoteBranch, s.RemoteHead[:7])
+		return
+	}
+
+	target := "the worktree"
+	if s.Branch != "" && len(s.Head) >= 7 {
+		target = fmt.Sprintf("%s at %s and the worktree", s.Branch, s.Head[:7])
+	}
+	if err := backup.CheckRestore(s); err != nil {
+		in.fail(err.Error())
+	}
+	if !in.confirm(fmt.Sprintf("Restore %s as they were before %s?", target, s.Operation)) {
+		styles.ErrorStyle.Println("Undo canceled")
+		return
+	}
+	if _, ok := snapshotBefore(backup.Snapshot{Operation: "undo", Description: s.Name}); !ok {
+		exit(1)
+	}
+	if err := backup.Restore(s); err != nil {
+		styles.ErrorStyle.Printf("Error: %v\n", err)
+		exit(1)
+	}
+	styles.SuccessStyle.Printf("Restored the state before %s; changes are unstaged\n", s.Operation)
+}
@@ -22,7 +22,14 @@ func ShowHelp() {
 	fmt.Println("  greet\t\t\tGreet the user")
 	fmt.Println("  send-mail\t\tSend an email")
 	fmt.Println("  focus\t\t\tActivate focus mode")
-	fmt.Println("  review <filename/path>\tReview code or files using LLMs")
+	fmt.Println("  review <file|dir|glob>\tReview code or files using LLMs")
+	fmt.Println("  review --staged\tReview the staged changes (or --diff <ref>)")
+	fmt.Println("  security-check <path>\tStrict AI security audit of a file")
+	fmt.Println("  security-check <path> --format sarif --fail-on high\tMachine-readable audit for hooks and CI")
+	fmt.Println("  security-check <path> --offline\tOnly run the built-in secret and injection scanner")
+	fmt.Println("  hooks install [--review]\tCheck commits and pushes with Ellie's git hooks")
+	fmt.Println("  hooks uninstall|status\tRemove or inspect Ellie's git hooks")
+	fmt.Println("  :: <request>\t\tAsk Ellie to figure out and run commands")
 	fmt.Println("  md <filename>\t\tRender markdown files in terminal")
 	fmt.Println("  run <command>\t\tExecute system commands")
 	fmt.Println("  pwd\t\t\tPrint working directory")
@@ -37,7 +44,16 @@ func ShowHelp() {
 	fmt.Println("  create-file <path>\tCreate new files")
 	fmt.Println("  network-status\tShow network information")
 	fmt.Println("  connect-wifi <SSID> <password>\tConnect to WiFi network")
-	fmt.Println("  chat\t\t\tStart interactive AI chat session")
+	fmt.Println("  chat [--provider <p>] [--model <m>]\tStart interactive AI chat session")
+	fmt.Println("  chat list\t\tList saved chat sessions")
+	fmt.Println("  chat resume <id>\tContinue a saved chat session")
+	fmt.Println("  chat export <id>\tExport a chat session (--format md|json)")
+	fmt.Println("  chat delete <id>\tDelete a saved chat session")
+	fmt.Println("  usage\t\t\tShow AI token usage, cost and budget")
+	fmt.Println("  usage daily|weekly\tUsage per day or week")
+	fmt.Println("  usage commands|models|users\tUsage per command, model or user")
+	fmt.Println("  cache stats\t\tShow cached AI answers and the tokens they saved")
+	fmt.Println("  cache clear\t\tRemove cached AI answers (--expired for old ones only)")
 	fmt.Println("  history\t\tShow recent commands")
 	fmt.Println("  start-day\t\tStart your dev day (apps, services, git)")
 	fmt.Println("  weather\t\tShow weather info")
@@ -49,13 +65,18 @@ func ShowHelp() {
 	fmt.Println("  git status\t\tShow Git status")
 	fmt.Println("  git push\t\tPush commits")
 	fmt.Println("  git commit\t\tCreate Conventional Commit")
+	fmt.Println("  git commit --ai\tWrite the commit message from the staged diff with AI")
+	fmt.Println("  git <cmd> [args] --yes\tRun any git command without prompts or confirmations")
 	fmt.Println("  git pull\t\tPull latest changes")
-	fmt.Println("  git branch-create\tCreate a new branch")
+	fmt.Println("  git ui\t\tStage hunks, manage branches and stashes, and commit in a TUI")
+	fmt.Println("  git branch-create <name> [--from <ref>]\tCreate a new branch")
 	fmt.Println("  git branch-switch\tSwitch to an existing branch")
 	fmt.Println("  git branch-delete\tDelete a branch")
 	fmt.Println("  git stash-save\tSave changes to a new stash")
 	fmt.Println("  git stash-pop\tApply the latest stash")
 	fmt.Println("  git stash-list\tList all stashes")
+	fmt.Println("  release [--dry-run]\tBump the version, update CHANGELOG.md and tag from conventional commits")
+	fmt.Println("  git undo [number|name]\tRestore the snapshot taken before reset, clean, rebase or push-force")
 	fmt.Println("  git tag-create\tCreate a new tag")
 	fmt.Println("  git tag-list\tList all tags")
 	fmt.Println("  git tag-delete\tDelete a tag")
@@ -96,6 +117,8 @@ func ShowHelp() {
 	fmt.Println("  project delete <name>\tDelete a project")
 	fmt.Println("  project search <query>\tSearch projects")
 	fmt.Println("  switch <project-name>\tSwitch to a project")
+	fmt.Println("  repos [status]\t\tStatus of every project and day-start repo")
+	fmt.Println("  repos fetch|pull\tFetch or fast-forward them all at once")
 
 	styles.GetHeaderStyle().Println("Day Start Configuration:")
 	fmt.Println("  day-start add <type> <value>\tAdd to daily setup (apps/services/git_repos)")
@@ -118,12 +141,17 @@ func ShowHelp() {
 	fmt.Println("  time-suggest\t\tGet time-based suggestions")
 
 	styles.GetHeaderStyle().Println("Automation:")
-	fmt.Println("  automate add <name> <schedule> <command>\tAdd automation task")
+	fmt.Println("  automate add <name> <schedule> [--timeout 5m] <command>\tAdd automation task")
 	fmt.Println("  automate list\t\tList all automation tasks")
 	fmt.Println("  automate delete <id>\tDelete automation task")
 	fmt.Println("  automate toggle <id>\tEnable/disable automation task")
 	fmt.Println("  automate run\t\tExecute due automation tasks")
+	fmt.Println("  automate history <id>\tList an automation's recent runs")
+	fmt.Println("  automate logs <id> [--tail]\tShow the output of its last runs")
+	fmt.Println("  automate on-failure <id> <action>\tNotify, email or call a webhook on failure")
 	fmt.Println("  automate daemon\tStart automation daemon")
+	fmt.Println("  automate service install\tRun the daemon as a user service")
+	fmt.Println("  automate service <uninstall|status|logs>\tManage the daemon service")
 	fmt.Println("  automate quick\t\tQuick setup for common automations")
 
 	styles.DimText.Println("\n💡 For detailed command help, use 'ellie <command> --help'")
@@ -0,0 +1,515 @@
+package actions
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/tacheraSasi/ellie/configs"
+	"github.com/tacheraSasi/ellie/review"
+	"github.com/tacheraSasi/ellie/security"
+	"github.com/tacheraSasi/ellie/styles"
+)
+
+// hookNames are the git hooks Ellie manages
+var hookNames = []string{"pre-commit", "commit-msg", "pre-push"}
+
+const (
+	// hookMarker identifies scripts written by Ellie
+	hookMarker = "# ellie-managed hook"
+	// chainedSuffix is added to a hook that was there before Ellie's
+	chainedSuffix = ".ellie-chained"
+	// defaultHookFailOn is the severity that blocks a commit or push
+	defaultHookFailOn = security.High
+)
+
+const hooksInstallUsage = "Usage: ellie hooks install [--fail-on critical|high|medium|low] [--review]"
+
+// hookScript is the shell script installed for a hook. The hook that was
+// there before runs first, with the same arguments and input; if it fails,
+// Ellie's checks don't run.
+const hookScript = `#!/bin/sh
+{{marker}}: {{name}}
+# Runs the previous hook, kept as {{name}}{{suffix}}, then Ellie's checks.
+# Remove with: ellie hooks uninstall
+hook="$(dirname "$0")/{{name}}{{suffix}}"
+ellie={{ellie}}
+[ -x "$ellie" ] || ellie=ellie
+{{read}}
+if [ -x "$hook" ]; then
+	{{pipe}}"$hook" "$@" || exit $?
+fi
+if ! command -v "$ellie" >/dev/null 2>&1; then
+	echo "ellie not found; skipping Ellie's {{name}} checks" >&2
+	exit 0
+fi
+{{pipe}}"$ellie" hooks run {{name}}{{flags}} "$@"
+`
+
+// renderHook fills in the hook script. Only pre-push gets input from git,
+// which both hooks need to read.
+func renderHook(name, ellie string, flags []string) string {
+	read, pipe := "", ""
+	if name == "pre-push" {
+		read = "input=$(cat)"
+		pipe = `printf '%s\n' "$input" | `
+	}
+	var quoted strings.Builder
+	for _, f := range flags {
+		quoted.WriteString(" " + shellQuote(f))
+	}
+	return strings.NewReplacer(
+		"{{marker}}", hookMarker,
+		"{{name}}", name,
+		"{{suffix}}", chainedSuffix,
+		"{{ellie}}", shellQuote(ellie),
+		"{{read}}", read,
+		"{{pipe}}", pipe,
+		"{{flags}}", quoted.String(),
+	).Replace(hookScript)
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// hooksDir is where git looks for hooks in the current repository,
+// honouring core.hooksPath
+func hooksDir() (string, error) {
+	output, err := exec.Command("git", "rev-parse", "--git-path", "hooks").Output()
+	if err != nil {
+		return "", errors.New("not a git repository")
+	}
+	return filepath.Abs(strings.TrimSpace(string(output)))
+}
+
+// isEllieHook reports whether the hook at path was written by Ellie
+func isEllieHook(path string) bool {
+	data, err := os.ReadFile(path)
+	return err == nil && bytes.Contains(data, []byte(hookMarker))
+}
+
+func fileExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+// installHook writes Ellie's script for a hook. An existing hook that isn't
+// Ellie's is kept next to it and chained. Reinstalling updates the script.
+func installHook(dir, name, script string) (chained bool, err error) {
+	path := filepath.Join(dir, name)
+	previous := path + chainedSuffix
+
+	if fileExists(path) && !isEllieHook(path) {
+		if fileExists(previous) {
+			return false, fmt.Errorf("%s and %s both exist; move one of them first", name, filepath.Base(previous))
+		}
+		if err := os.Rename(path, previous); err != nil {
+			return false, err
+		}
+	}
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		return false, err
+	}
+	// WriteFile keeps the mode of a file that already exists
+	if err := os.Chmod(path, 0o755); err != nil {
+		return false, err
+	}
+	return fileExists(previous), nil
+}
+
+// uninstallHook removes Ellie's script for a hook and puts back the hook it
+// chained. Hooks that aren't Ellie's are left alone.
+func uninstallHook(dir, name string) (restored bool, err error) {
+	path := filepath.Join(dir, name)
+	if !isEllieHook(path) {
+		return false, nil
+	}
+	if err := os.Remove(path); err != nil {
+		return false, err
+	}
+
+	previous := path + chainedSuffix
+	if !fileExists(previous) {
+		return false, nil
+	}
+	return true, os.Rename(previous, path)
+}
+
+// hookStatus describes a hook for `ellie hooks status`
+func hookStatus(dir, name string) string {
+	path := filepath.Join(dir, name)
+	switch {
+	case isEllieHook(path):
+		data, _ := os.ReadFile(path)
+		status := "installed"
+		if bytes.Contains(data, []byte("--review")) {
+			status += ", with AI review"
+		}
+		if fileExists(path + chainedSuffix) {
+			status += ", runs the previous hook first"
+		}
+		return status
+	case fileExists(path):
+		return "not installed (another hook is there; install chains it)"
+	default:
+		return "not installed"
+	}
+}
+
+// HooksInstall installs Ellie's pre-commit, commit-msg and pre-push hooks in
+// the current repository
+func HooksInstall(args []string) {
+	failOn := defaultHookFailOn
+	value, found, rest, err := popValue(args, "fail-on")
+	if err == nil && found {
+		failOn, err = security.ParseSeverity(value)
+	}
+	withReview, rest := popFlag(rest, "review")
+	if err != nil || len(rest) > 1 {
+		if err != nil {
+			styles.ErrorStyle.Printf("Error: %v\n", err)
+		}
+		styles.InfoStyle.Println(hooksInstallUsage)
+		return
+	}
+
+	dir, err := hooksDir()
+	if err != nil {
+		styles.ErrorStyle.Printf("Error: %v\n", err)
+		return
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		styles.ErrorStyle.Printf("Error: %v\n", err)
+		return
+	}
+
+	ellie, err := os.Executable()
+	if err != nil {
+		ellie = "ellie"
+	}
+
+	for _, name := range hookNames {
+		flags := []string{}
+		switch name {
+		case "pre-commit":
+			flags = append(flags, "--fail-on", string(failOn))
+		case "pre-push":
+			flags = append(flags, "--fail-on", string(failOn))
+			if withReview {
+				flags = append(flags, "--review")
+			}
+		}
+
+		chained, err := installHook(dir, name, renderHook(name, ellie, flags))
+		if err != nil {
+			styles.ErrorStyle.Printf("❌ %s: %v\n", name, err)
+			continue
+		}
+		if chained {
+			styles.SuccessStyle.Printf("✅ %s installed; your existing hook still runs first\n", name)
+		} else {
+			styles.SuccessStyle.Printf("✅ %s installed\n", name)
+		}
+	}
+	styles.DimText.Println("Skip the hooks once with git commit --no-verify or git push --no-verify.")
+}
+
+// HooksUninstall removes Ellie's hooks and restores the ones they chained
+func HooksUninstall(_ []string) {
+	dir, err := hooksDir()
+	if err != nil {
+		styles.ErrorStyle.Printf("Error: %v\n", err)
+		return
+	}
+
+	for _, name := range hookNames {
+		if !isEllieHook(filepath.Join(dir, name)) {
+			styles.DimText.Printf("%s: not installed by Ellie, left alone\n", name)
+			continue
+		}
+		restored, err := uninstallHook(dir, name)
+		switch {
+		case err != nil:
+			styles.ErrorStyle.Printf("❌ %s: %v\n", name, err)
+		case restored:
+			styles.SuccessStyle.Printf("✅ %s removed; your previous hook is back\n", name)
+		default:
+			styles.SuccessStyle.Printf("✅ %s removed\n", name)
+		}
+	}
+}
+
+// HooksStatus shows which hooks Ellie manages in the current repository
+func HooksStatus(_ []string) {
+	dir, err := hooksDir()
+	if err != nil {
+		styles.ErrorStyle.Printf("Error: %v\n", err)
+		return
+	}
+
+	styles.InfoStyle.Printf("Git hooks in %s\n", dir)
+	for _, name := range hookNames {
+		fmt.Printf("  %-11s %s\n", name, hookStatus(dir, name))
+	}
+}
+
+// HooksRun runs Ellie's checks for a hook. The installed scripts call it;
+// a non-zero exit stops the commit or push.
+func HooksRun(args []string) {
+	if len(args) < 2 {
+		styles.InfoStyle.Println("Usage: ellie hooks run pre-commit|commit-msg|pre-push [args]")
+		exit(1)
+	}
+
+	failOn := defaultHookFailOn
+	value, found, rest, err := popValue(args[2:], "fail-on")
+	if err == nil && found {
+		failOn, err = security.ParseSeverity(value)
+	}
+	withReview, rest := popFlag(rest, "review")
+	if err != nil {
+		styles.ErrorStyle.Fprintf(os.Stderr, "ellie %s: %v\n", args[1], err)
+		exit(1)
+	}
+
+	var ok bool
+	switch args[1] {
+	case "pre-commit":
+		ok = runPreCommit(failOn)
+	case "commit-msg":
+		ok = runCommitMsg(rest)
+	case "pre-push":
+		ok = runPrePush(os.Stdin, failOn, withReview)
+	default:
+		styles.ErrorStyle.Fprintf(os.Stderr, "Unknown hook: %s\n", args[1])
+		exit(1)
+	}
+	if !ok {
+		exit(1)
+	}
+}
+
+// runCommitMsg checks the message file git passes to commit-msg
+func runCommitMsg(args []string) bool {
+	if len(args) == 0 {
+		styles.ErrorStyle.Fprintln(os.Stderr, "ellie commit-msg: no message file")
+		return false
+	}
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		styles.ErrorStyle.Fprintf(os.Stderr, "ellie commit-msg: %v\n", err)
+		return false
+	}
+	if err := validateCommitMessage(string(data)); err != nil {
+		styles.ErrorStyle.Fprintf(os.Stderr, "❌ %v\n", err)
+		styles.InfoStyle.Fprintln(os.Stderr, "Expected <type>(<scope>): <description>, e.g. feat(auth): add token refresh")
+		styles.DimText.Fprintf(os.Stderr, "Types: %s\n", strings.Join(allowedTypes, ", "))
+		return false
+	}
+	return true
+}
+
+// commitHeader matches a conventional-commit header: type(scope)!: description
+var commitHeader = regexp.MustCompile(`^([a-z]+)(\([^()\s][^()]*\))?(!)?: \S`)
+
+// validateCommitMessage checks that a commit message starts with a
+// conventional-commit header using the types GitConventionalCommit offers.
+// Comment lines are ignored, and merge, revert, fixup and squash messages
+// written by git are accepted as they are.
+func validateCommitMessage(message string) error {
+	header := ""
+	for _, line := range strings.Split(message, "\n") {
+		if strings.HasPrefix(line, "# ------------------------ >8") {
+			break
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if header = strings.TrimSpace(line); header != "" {
+			break
+		}
+	}
+
+	if header == "" {
+		return errors.New("the commit message is empty")
+	}
+	for _, prefix := range []string{"Merge ", "Revert \"", "fixup! ", "squash! ", "amend! "} {
+		if strings.HasPrefix(header, prefix) {
+			return nil
+		}
+	}
+
+	m := commitHeader.FindStringSubmatch(header)
+	if m == nil {
+		return fmt.Errorf("%q is not a conventional commit header", header)
+	}
+	if !isValidCommitType(m[1]) {
+		return fmt.Errorf("unknown commit type %q", m[1])
+	}
+	return nil
+}
+
+// gitBlob is a file's content as git will record it
+type gitBlob struct {
+	path    string
+	content []byte
+}
+
+// readBlobs reads the paths at rev ("" for the index), skipping files that
+// are gone, binary or too large to scan
+func readBlobs(rev string, paths []string) []gitBlob {
+	var blobs []gitBlob
+	for _, path := range paths {
+		content, err := exec.Command("git", "cat-file", "blob", rev+":"+path).Output()
+		if err != nil || len(content) > review.MaxFileSize || review.IsBinary(content) {
+			continue
+		}
+		blobs = append(blobs, gitBlob{path: path, content: content})
+	}
+	return blobs
+}
+
+// gitPaths runs a git command that prints NUL-separated paths
+func gitPaths(args ...string) ([]string, error) {
+	output, err := exec.Command("git", args...).Output()
+	if err != nil {
+		return nil, fmt.Errorf("git %s failed: %w", args[0], err)
+	}
+	seen := map[string]bool{}
+	var paths []string
+	for _, p := range strings.Split(string(output), "\x00") {
+		if p = strings.TrimSpace(p); p != "" && !seen[p] {
+			seen[p] = true
+			paths = append(paths, p)
+		}
+	}
+	return paths, nil
+}
+
+// scanBlobs runs the offline scanner over blobs and prints what it finds.
+// It reports false if a finding is at least failOn.
+func scanBlobs(blobs []gitBlob, failOn security.Severity) bool {
+	rules, err := security.LoadRules(filepath.Join(configs.GetEllieDir(), "rules"))
+	if err != nil {
+		styles.WarningStyle.Fprintf(os.Stderr, "⚠️ Some rules were skipped: %v\n", err)
+	}
+
+	var findings []security.Finding
+	for _, b := range blobs {
+		findings = append(findings, rules.Scan(b.path, string(b.content))...)
+	}
+	if len(findings) == 0 {
+		return true
+	}
+
+	security.Sort(findings)
+	for _, f := range findings {
+		style := styles.WarningStyle
+		if f.Severity.AtLeast(failOn) {
+			style = styles.ErrorStyle
+		}
+		style.Fprintf(os.Stderr, "%-8s %s:%d %s: %s\n", strings.ToUpper(string(f.Severity)), f.File, f.StartLine, f.Rule, f.Description)
+	}
+	if !security.Exceeds(findings, failOn) {
+		return true
+	}
+	styles.ErrorStyle.Fprintf(os.Stderr, "❌ Ellie found issues of severity %s or higher.\n", failOn)
+	styles.DimText.Fprintf(os.Stderr, "Fix them, add %q to a line that is safe, or skip the check with --no-verify.\n", security.IgnoreMarker)
+	return false
+}
+
+// runPreCommit scans the staged files for secrets and injection sinks
+func runPreCommit(failOn security.Severity) bool {
+	paths, err := gitPaths("diff", "--cached", "--name-only", "--diff-filter=ACMR", "-z")
+	if err != nil {
+		styles.ErrorStyle.Fprintf(os.Stderr, "ellie pre-commit: %v\n", err)
+		return false
+	}
+	return scanBlobs(readBlobs("", paths), failOn)
+}
+
+// zeroSHA is what git sends for a ref that doesn't exist on one side
+var zeroSHA = regexp.MustCompile(`^0+$`)
+
+// pushUpdate is one line of pre-push input
+type pushUpdate struct {
+	localRef, localSHA, remoteRef, remoteSHA string
+}
+
+// parsePushUpdates reads the refs git is about to push
+func parsePushUpdates(r io.Reader) []pushUpdate {
+	var updates []pushUpdate
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 4 {
+			continue
+		}
+		updates = append(updates, pushUpdate{fields[0], fields[1], fields[2], fields[3]})
+	}
+	return updates
+}
+
+// base is what the pushed commits are compared with: the remote's commit,
+// or for a new branch every commit already on a remote
+func (u pushUpdate) base() []string {
+	if !zeroSHA.MatchString(u.remoteSHA) && exec.Command("git", "cat-file", "-e", u.remoteSHA+"^{commit}").Run() == nil {
+		return []string{u.remoteSHA}
+	}
+	return []string{"--remotes"}
+}
+
+// runPrePush scans the files changed by the pushed commits, as they are in
+// the pushed commit, and optionally asks for an AI review of the changes
+func runPrePush(input io.Reader, failOn security.Severity, withReview bool) bool {
+	ok := true
+	for _, u := range parsePushUpdates(input) {
+		if zeroSHA.MatchString(u.localSHA) {
+			continue // deleting a ref
+		}
+
+		args := append([]string{"log", "--format=", "--name-only", "--diff-filter=ACMR", "-z", u.localSHA, "--not"}, u.base()...)
+		paths, err := gitPaths(args...)
+		if err != nil {
+			styles.ErrorStyle.Fprintf(os.Stderr, "ellie pre-push: %v\n", err)
+			return false
+		}
+		if !scanBlobs(readBlobs(u.localSHA, paths), failOn) {
+			ok = false
+		}
+
+		if withReview && ok {
+			reviewPush(u)
+		}
+	}
+	return ok
+}
+
+// reviewPush prints an AI review of the changes a push brings in. It is
+// advice only and never stops the push.
+func reviewPush(u pushUpdate) {
+	base := u.remoteSHA
+	if u.base()[0] == "--remotes" {
+		// Review from the parent of the oldest commit not on a remote
+		output, err := exec.Command("git", "rev-list", "--reverse", u.localSHA, "--not", "--remotes").Output()
+		commits := strings.Fields(string(output))
+		if err != nil || len(commits) == 0 {
+			return
+		}
+		if exec.Command("git", "cat-file", "-e", commits[0]+"^").Run() != nil {
+			styles.DimText.Fprintln(os.Stderr, "Skipping the AI review: the push starts at a root commit.")
+			return
+		}
+		base = commits[0] + "^"
+	}
+	Review([]string{"review", "--diff", base + ".." + u.localSHA})
+}
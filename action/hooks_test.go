@@ -0,0 +1,135 @@
+package actions
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestValidateCommitMessage(t *testing.T) {
+	valid := []string{
+		"feat: add hooks",
+		"fix(auth): refresh tokens\n\nLonger body.",
+		"refactor(api)!: drop v1 endpoints",
+		"# Please enter the commit message\n\ndocs: explain hooks\n",
+		"Merge branch 'main' into feature",
+		`Revert "feat: add hooks"`,
+		"fixup! feat: add hooks",
+	}
+	for _, msg := range valid {
+		if err := validateCommitMessage(msg); err != nil {
+			t.Errorf("validateCommitMessage(%q) = %v", msg, err)
+		}
+	}
+
+	invalid := map[string]string{
+		"added hooks":          "not a conventional commit header",
+		"feature: add hooks":   `unknown commit type "feature"`,
+		"feat:add hooks":       "not a conventional commit header",
+		"feat(): add hooks":    "not a conventional commit header",
+		"# only a comment\n\n": "empty",
+		"Feat: add hooks":      "not a conventional commit header",
+		"\n# ------------------------ >8 ------------------------\nfeat: x": "empty",
+	}
+	for msg, want := range invalid {
+		err := validateCommitMessage(msg)
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("validateCommitMessage(%q) = %v, want %q", msg, err, want)
+		}
+	}
+}
+
+func TestInstallHook_ChainsExisting(t *testing.T) {
+	dir := t.TempDir()
+	existing := "#!/bin/sh\necho mine\n"
+	if err := os.WriteFile(filepath.Join(dir, "pre-commit"), []byte(existing), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	script := renderHook("pre-commit", "/usr/local/bin/ellie", []string{"--fail-on", "high"})
+	chained, err := installHook(dir, "pre-commit", script)
+	if err != nil || !chained {
+		t.Fatalf("installHook() = %v, %v, want the existing hook chained", chained, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "pre-commit"+chainedSuffix)); string(data) != existing {
+		t.Errorf("chained hook = %q, want the original", data)
+	}
+	if status := hookStatus(dir, "pre-commit"); !strings.Contains(status, "previous hook") {
+		t.Errorf("hookStatus() = %q", status)
+	}
+
+	// Reinstalling updates Ellie's script and keeps the chain
+	if _, err := installHook(dir, "pre-commit", script); err != nil {
+		t.Fatalf("reinstall: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "pre-commit"+chainedSuffix)); string(data) != existing {
+		t.Errorf("reinstalling clobbered the chained hook: %q", data)
+	}
+
+	restored, err := uninstallHook(dir, "pre-commit")
+	if err != nil || !restored {
+		t.Fatalf("uninstallHook() = %v, %v", restored, err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "pre-commit")); string(data) != existing {
+		t.Errorf("uninstall left %q, want the original hook back", data)
+	}
+	if fileExists(filepath.Join(dir, "pre-commit"+chainedSuffix)) {
+		t.Error("uninstall left the chained copy behind")
+	}
+}
+
+func TestUninstallHook_LeavesOthersAlone(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "pre-push")
+	os.WriteFile(path, []byte("#!/bin/sh\nexit 0\n"), 0o755)
+
+	if restored, err := uninstallHook(dir, "pre-push"); err != nil || restored {
+		t.Errorf("uninstallHook() = %v, %v", restored, err)
+	}
+	if !fileExists(path) {
+		t.Error("uninstallHook() removed a hook Ellie didn't install")
+	}
+	if status := hookStatus(dir, "commit-msg"); status != "not installed" {
+		t.Errorf("hookStatus() = %q", status)
+	}
+}
+
+func TestRenderHook_PrePushPassesInput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks are shell scripts")
+	}
+	dir := t.TempDir()
+	out := filepath.Join(dir, "seen")
+	previous := "#!/bin/sh\necho \"$1 $2\" > " + shellQuote(out) + "\ncat >> " + shellQuote(out) + "\n"
+	os.WriteFile(filepath.Join(dir, "pre-push"), []byte(previous), 0o755)
+
+	if _, err := installHook(dir, "pre-push", renderHook("pre-push", filepath.Join(dir, "no-ellie"), nil)); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(filepath.Join(dir, "pre-push"), "origin", "git@example.com:x.git")
+	cmd.Stdin = strings.NewReader("refs/heads/main abc refs/heads/main def\n")
+	cmd.Env = append(os.Environ(), "PATH=/usr/bin:/bin")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("hook failed: %v\n%s", err, output)
+	}
+	if !strings.Contains(string(output), "ellie not found") {
+		t.Errorf("hook output = %q, want a note that ellie is missing", output)
+	}
+
+	data, _ := os.ReadFile(out)
+	if want := "origin git@example.com:x.git\nrefs/heads/main abc refs/heads/main def\n"; string(data) != want {
+		t.Errorf("previous hook saw %q, want %q", data, want)
+	}
+}
+
+func TestParsePushUpdates(t *testing.T) {
+	updates := parsePushUpdates(strings.NewReader("refs/heads/main 1111 refs/heads/main 0000\n\nbad line\n"))
+	if len(updates) != 1 || updates[0].localSHA != "1111" || !zeroSHA.MatchString(updates[0].remoteSHA) {
+		t.Errorf("parsePushUpdates() = %+v", updates)
+	}
+}
@@ -3,6 +3,7 @@ package actions
 import (
 	"bytes"
 	"encoding/json"
+	"fmt"
 	"net/http"
 
 	"github.com/tacheraSasi/ellie/configs"
@@ -10,6 +11,8 @@ import (
 	"github.com/tacheraSasi/ellie/utils"
 )
 
+const relayEndpoint = "https://relay.ekilie.com/api/index.php"
+
 type EmailRequest struct {
 	APIKey  string `json:"apikey"`
 	To      string `json:"to"`
@@ -30,40 +33,47 @@ func Mailer() {
 	to := getEmail()
 	subject := getSubject()
 	message := getMessage()
-	headers := "From: Ellie Mailer"
 
+	if err := sendEmail(apiKey, to, subject, message); err != nil {
+		styles.ErrorStyle.Println("🚫", err)
+		return
+	}
+	styles.SuccessStyle.Println("✅ Email sent successfully!")
+}
+
+// sendEmail sends an email through the relay
+func sendEmail(apiKey, to, subject, message string) error {
 	requestBody := EmailRequest{
 		APIKey:  apiKey,
 		To:      to,
 		Subject: subject,
 		Message: message,
-		Headers: headers,
+		Headers: "From: Ellie Mailer",
 	}
 
 	jsonData, err := json.Marshal(requestBody)
 	if err != nil {
-		styles.ErrorStyle.Println("Error encoding JSON:", err)
-		return
+		return fmt.Errorf("error encoding JSON: %w", err)
 	}
 
-	resp, err := http.Post("https://relay.ekilie.com/api/index.php", "application/json", bytes.NewBuffer(jsonData))
+	client := &http.Client{Timeout: requestTimeout}
+	resp, err := client.Post(relayEndpoint, "application/json", bytes.NewBuffer(jsonData))
 	if err != nil {
-		styles.ErrorStyle.Println("Error sending email:", err)
-		return
+		return fmt.Errorf("error sending email: %w", err)
 	}
 	// logger.Info("MAILER:response:%s",resp)
 	defer resp.Body.Close()
 
-	if resp.StatusCode == http.StatusOK {
-		styles.SuccessStyle.Println("✅ Email sent successfully!")
-	} else {
-		styles.ErrorStyle.Println("🚫 Failed to send email. Status:", resp.Status)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to send email. Status: %s", resp.Status)
 	}
+	return nil
 }
 
 func getSubject() string {
 	for {
 		subject, err := utils.GetInput("Enter the subject")
+		stopOnClosedInput(err)
 		if err == nil && subject != "" {
 			return subject
 		}
@@ -74,6 +84,7 @@ func getSubject() string {
 func getEmail() string {
 	for {
 		email, err := utils.GetInput("Enter the recipient email")
+		stopOnClosedInput(err)
 		if err == nil && email != "" {
 			return email
 		}
@@ -84,6 +95,7 @@ func getEmail() string {
 func getMessage() string {
 	for {
 		message, err := utils.GetInput("Enter the message")
+		stopOnClosedInput(err)
 		if err == nil && message != "" {
 			return message
 		}
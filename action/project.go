@@ -1,7 +1,6 @@
 package actions
 
 import (
-	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -10,6 +9,7 @@ import (
 	"time"
 
 	"github.com/tacheraSasi/ellie/configs"
+	"github.com/tacheraSasi/ellie/store"
 	"github.com/tacheraSasi/ellie/styles"
 )
 
@@ -27,36 +27,20 @@ func init() {
 	loadProjects()
 }
 
-func loadProjects() {
-	projectFile := filepath.Join(configs.GetEllieDir(), "projects.json")
-	if _, err := os.Stat(projectFile); os.IsNotExist(err) {
-		projects = []Project{}
-		return
-	}
-
-	data, err := os.ReadFile(projectFile)
-	if err != nil {
-		styles.ErrorStyle.Println("Error reading projects:", err)
-		return
-	}
-
-	if err := json.Unmarshal(data, &projects); err != nil {
-		styles.ErrorStyle.Println("Error parsing projects:", err)
-		projects = []Project{}
-	}
+// projectStore is the file projects are kept in
+func projectStore() *store.File {
+	return store.New(filepath.Join(configs.GetEllieDir(), "projects.json"), 1)
 }
 
-func saveProjects() {
-	projectFile := filepath.Join(configs.GetEllieDir(), "projects.json")
-	data, err := json.MarshalIndent(projects, "", "  ")
-	if err != nil {
-		styles.ErrorStyle.Println("Error saving projects:", err)
-		return
-	}
+func loadProjects() {
+	projects = []Project{}
+	loadStore(projectStore(), &projects, "projects")
+}
 
-	if err := os.WriteFile(projectFile, data, 0644); err != nil {
-		styles.ErrorStyle.Println("Error writing projects:", err)
-	}
+// updateProjects applies change to the projects as they are saved now, and
+// saves them if change returns true
+func updateProjects(change func() bool) bool {
+	return updateStore(projectStore(), &projects, "projects", change)
 }
 
 func ProjectAdd(args []string) {
@@ -84,27 +68,36 @@ func ProjectAdd(args []string) {
 		return
 	}
 
-	// Check if project already exists
-	for i, p := range projects {
-		if p.Name == name {
-			projects[i].Path = absPath
-			projects[i].Description = description
-			projects[i].Tags = tags
-			saveProjects()
-			styles.SuccessStyle.Printf("Updated project '%s'\n", name)
-			return
+	updated := false
+	saved := updateProjects(func() bool {
+		// Check if project already exists
+		for i, p := range projects {
+			if p.Name == name {
+				projects[i].Path = absPath
+				projects[i].Description = description
+				projects[i].Tags = tags
+				updated = true
+				return true
+			}
 		}
-	}
 
-	// Add new project
-	projects = append(projects, Project{
-		Name:        name,
-		Path:        absPath,
-		Description: description,
-		Tags:        tags,
-		LastUsed:    "",
+		// Add new project
+		projects = append(projects, Project{
+			Name:        name,
+			Path:        absPath,
+			Description: description,
+			Tags:        tags,
+			LastUsed:    "",
+		})
+		return true
 	})
-	saveProjects()
+	if !saved {
+		return
+	}
+	if updated {
+		styles.SuccessStyle.Printf("Updated project '%s'\n", name)
+		return
+	}
 	styles.SuccessStyle.Printf("Added project '%s'\n", name)
 }
 
@@ -142,16 +135,24 @@ func ProjectDelete(args []string) {
 	}
 
 	name := args[1]
-	for i, p := range projects {
-		if p.Name == name {
-			projects = append(projects[:i], projects[i+1:]...)
-			saveProjects()
-			styles.SuccessStyle.Printf("Deleted project '%s'\n", name)
-			return
+	found := false
+	saved := updateProjects(func() bool {
+		for i, p := range projects {
+			if p.Name == name {
+				projects = append(projects[:i], projects[i+1:]...)
+				found = true
+				return true
+			}
 		}
+		return false
+	})
+	if !found {
+		styles.ErrorStyle.Printf("Project '%s' not found\n", name)
+		return
+	}
+	if saved {
+		styles.SuccessStyle.Printf("Deleted project '%s'\n", name)
 	}
-
-	styles.ErrorStyle.Printf("Project '%s' not found\n", name)
 }
 
 func ProjectSwitch(args []string) {
@@ -161,15 +162,22 @@ func ProjectSwitch(args []string) {
 	}
 
 	name := args[1]
-	for i, p := range projects {
+	for _, p := range projects {
 		if p.Name == name {
 			if err := os.Chdir(p.Path); err != nil {
 				styles.ErrorStyle.Printf("Failed to switch to project '%s': %s\n", name, err)
 				return
 			}
 			// Update last used timestamp
-			projects[i].LastUsed = time.Now().Format("2006-01-02 15:04:05")
-			saveProjects()
+			updateProjects(func() bool {
+				for i := range projects {
+					if projects[i].Name == name {
+						projects[i].LastUsed = time.Now().Format("2006-01-02 15:04:05")
+						return true
+					}
+				}
+				return false
+			})
 			styles.SuccessStyle.Printf("Switched to project '%s'\n", name)
 			return
 		}
@@ -0,0 +1,212 @@
+package actions
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tacheraSasi/ellie/configs"
+	"github.com/tacheraSasi/ellie/llm"
+	"github.com/tacheraSasi/ellie/styles"
+	"github.com/tacheraSasi/ellie/usage"
+)
+
+// defaultProvider is used when neither --provider nor ELLIE_PROVIDER is set
+const defaultProvider = "ellieapi"
+
+// providerAPIKeys maps each provider to the config key holding its API key.
+// Providers missing from the map don't need a key.
+var providerAPIKeys = map[string]string{
+	"openai":    "OPENAI_API_KEY",
+	"gemini":    "GEMINI_API_KEY",
+	"anthropic": "ANTHROPIC_API_KEY",
+}
+
+// aiOptions holds the per-invocation overrides shared by every AI command
+type aiOptions struct {
+	Provider string
+	Model    string
+}
+
+// parseAIFlags pulls --provider and --model out of args, accepting both the
+// "--flag value" and "--flag=value" forms anywhere on the command line. It
+// returns the options and the remaining arguments in their original order.
+func parseAIFlags(args []string) (aiOptions, []string, error) {
+	var opts aiOptions
+	rest := make([]string, 0, len(args))
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		var target *string
+		name := strings.TrimLeft(arg, "-")
+		value := ""
+		hasValue := false
+		if eq := strings.Index(name, "="); eq >= 0 {
+			name, value, hasValue = name[:eq], name[eq+1:], true
+		}
+
+		if strings.HasPrefix(arg, "-") {
+			switch name {
+			case "provider":
+				target = &opts.Provider
+			case "model":
+				target = &opts.Model
+			}
+		}
+
+		if target == nil {
+			rest = append(rest, arg)
+			continue
+		}
+
+		if !hasValue {
+			if i+1 >= len(args) {
+				return opts, nil, fmt.Errorf("flag --%s needs a value", name)
+			}
+			i++
+			value = args[i]
+		}
+		*target = strings.TrimSpace(value)
+	}
+
+	return opts, rest, nil
+}
+
+// popFlag removes a boolean flag from args and reports whether it was there
+func popFlag(args []string, name string) (bool, []string) {
+	found := false
+	rest := make([]string, 0, len(args))
+	for _, arg := range args {
+		if arg == "--"+name || arg == "-"+name {
+			found = true
+			continue
+		}
+		rest = append(rest, arg)
+	}
+	return found, rest
+}
+
+// popValue removes a "--name value" or "--name=value" flag from args and
+// returns its value
+func popValue(args []string, name string) (value string, found bool, rest []string, err error) {
+	rest = make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case arg == "--"+name || arg == "-"+name:
+			if i+1 >= len(args) {
+				return "", true, nil, fmt.Errorf("flag --%s needs a value", name)
+			}
+			i++
+			value, found = args[i], true
+		case strings.HasPrefix(arg, "--"+name+"="):
+			value, found = strings.TrimPrefix(arg, "--"+name+"="), true
+		default:
+			rest = append(rest, arg)
+		}
+	}
+	return value, found, rest, nil
+}
+
+// configuredProvider returns the provider set in ELLIE_PROVIDER, or the default
+func configuredProvider() string {
+	configured := strings.ToLower(strings.TrimSpace(configs.GetEnv("ELLIE_PROVIDER")))
+	if configured == "" {
+		return defaultProvider
+	}
+	return configured
+}
+
+// providerName returns the provider newProvider will use for opts
+func providerName(opts aiOptions) string {
+	if opts.Provider != "" {
+		return strings.ToLower(opts.Provider)
+	}
+	return configuredProvider()
+}
+
+// newProvider builds the LLM provider for an AI command. Flags win over the
+// ELLIE_PROVIDER, ELLIE_MODEL and ELLIE_BASE_URL config keys. The configured
+// model and base URL only apply to the configured provider, so
+// "--provider gemini" doesn't inherit an OpenAI gateway URL. Every request
+// is recorded in the usage ledger under the given command name.
+func newProvider(command string, opts aiOptions, timeout time.Duration) (llm.Provider, error) {
+	configured := configuredProvider()
+	providerType := providerName(opts)
+
+	config := llm.Config{
+		Model:   opts.Model,
+		Timeout: timeout,
+	}
+	if providerType == configured {
+		if config.Model == "" {
+			config.Model = configs.GetEnv("ELLIE_MODEL")
+		}
+		config.BaseURL = configs.GetEnv("ELLIE_BASE_URL")
+	}
+
+	if keyName, ok := providerAPIKeys[providerType]; ok {
+		config.APIKey = configs.GetEnv(keyName)
+		if config.APIKey == "" {
+			return nil, fmt.Errorf("%s is not set; add it to %s to use the %s provider", keyName, configs.ConfigPath, providerType)
+		}
+	}
+
+	provider, err := llm.NewProvider(providerType, config)
+	if err != nil {
+		return nil, err
+	}
+	return usage.Track(provider, newTracker(command, providerType)), nil
+}
+
+// usageLedger returns the ledger at ~/ellie/usage.jsonl
+func usageLedger() *usage.Ledger {
+	return usage.NewLedger(filepath.Join(configs.GetEllieDir(), "usage.jsonl"))
+}
+
+// usagePrices returns the default prices merged with ~/ellie/prices.json
+func usagePrices() usage.PriceTable {
+	prices, err := usage.LoadPrices(filepath.Join(configs.GetEllieDir(), "prices.json"))
+	if err != nil {
+		styles.WarningStyle.Fprintf(os.Stderr, "⚠️ %v; using default prices\n", err)
+	}
+	return prices
+}
+
+// monthlyBudget reads ELLIE_MONTHLY_BUDGET (USD) and ELLIE_BUDGET_ACTION
+// (warn or block)
+func monthlyBudget() usage.Budget {
+	var budget usage.Budget
+	if raw := strings.TrimSpace(configs.GetEnv("ELLIE_MONTHLY_BUDGET")); raw != "" {
+		amount, err := strconv.ParseFloat(strings.TrimPrefix(raw, "$"), 64)
+		if err != nil || amount < 0 {
+			styles.WarningStyle.Fprintf(os.Stderr, "⚠️ Ignoring invalid ELLIE_MONTHLY_BUDGET %q\n", raw)
+		} else {
+			budget.Monthly = amount
+		}
+	}
+	budget.Block = strings.EqualFold(strings.TrimSpace(configs.GetEnv("ELLIE_BUDGET_ACTION")), "block")
+	return budget
+}
+
+// newTracker records an AI command's requests in the usage ledger
+func newTracker(command, providerType string) *usage.Tracker {
+	return &usage.Tracker{
+		Ledger:   usageLedger(),
+		Prices:   usagePrices(),
+		Budget:   monthlyBudget(),
+		User:     configs.GetEnv("USERNAME"),
+		Command:  command,
+		Provider: providerType,
+		OnBudgetExceeded: func(spent, budget float64) {
+			styles.WarningStyle.Fprintf(os.Stderr, "\n⚠️ Monthly AI budget reached: $%.2f of $%.2f spent. Run 'ellie usage' for details.\n", spent, budget)
+		},
+		OnError: func(err error) {
+			styles.WarningStyle.Fprintf(os.Stderr, "⚠️ Could not record usage: %v\n", err)
+		},
+	}
+}
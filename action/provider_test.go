@@ -0,0 +1,142 @@
+package actions
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseAIFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantOpts aiOptions
+		wantRest []string
+		wantErr  bool
+	}{
+		{
+			name:     "no flags",
+			args:     []string{"review", "main.go"},
+			wantRest: []string{"review", "main.go"},
+		},
+		{
+			name:     "separate values after positional",
+			args:     []string{"review", "main.go", "--provider", "openai", "--model", "gpt-4o"},
+			wantOpts: aiOptions{Provider: "openai", Model: "gpt-4o"},
+			wantRest: []string{"review", "main.go"},
+		},
+		{
+			name:     "inline values before positional",
+			args:     []string{"::", "-provider=gemini", "list", "files"},
+			wantOpts: aiOptions{Provider: "gemini"},
+			wantRest: []string{"::", "list", "files"},
+		},
+		{
+			name:     "unrelated flags are kept",
+			args:     []string{"::", "run", "ls", "--all"},
+			wantRest: []string{"::", "run", "ls", "--all"},
+		},
+		{
+			name:    "missing value",
+			args:    []string{"chat", "--model"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, rest, err := parseAIFlags(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAIFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if opts != tt.wantOpts {
+				t.Errorf("parseAIFlags() opts = %+v, want %+v", opts, tt.wantOpts)
+			}
+			if !reflect.DeepEqual(rest, tt.wantRest) {
+				t.Errorf("parseAIFlags() rest = %q, want %q", rest, tt.wantRest)
+			}
+		})
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	t.Setenv("ELLIE_PROVIDER", "openai")
+	t.Setenv("ELLIE_MODEL", "gpt-4o-mini")
+	t.Setenv("ELLIE_BASE_URL", "http://gateway.local/v1")
+	t.Setenv("OPENAI_API_KEY", "sk-test")
+	t.Setenv("GEMINI_API_KEY", "")
+
+	provider, err := newProvider("test", aiOptions{}, 0)
+	if err != nil {
+		t.Fatalf("newProvider() error = %v", err)
+	}
+	if got := provider.GetModel(); got != "gpt-4o-mini" {
+		t.Errorf("newProvider() model = %q, want configured ELLIE_MODEL", got)
+	}
+
+	provider, err = newProvider("test", aiOptions{Model: "gpt-4o"}, 0)
+	if err != nil {
+		t.Fatalf("newProvider() error = %v", err)
+	}
+	if got := provider.GetModel(); got != "gpt-4o" {
+		t.Errorf("newProvider() model = %q, want --model override", got)
+	}
+
+	if _, err := newProvider("test", aiOptions{Provider: "gemini"}, 0); err == nil {
+		t.Error("newProvider() should fail when the provider's API key is missing")
+	}
+
+	t.Setenv("GEMINI_API_KEY", "g-test")
+	provider, err = newProvider("test", aiOptions{Provider: "gemini"}, 0)
+	if err != nil {
+		t.Fatalf("newProvider() error = %v", err)
+	}
+	if got := provider.GetModel(); got == "gpt-4o-mini" {
+		t.Error("newProvider() should not apply ELLIE_MODEL to a different provider")
+	}
+
+	if _, err := newProvider("test", aiOptions{Provider: "nope"}, 0); err == nil {
+		t.Error("newProvider() should reject unknown providers")
+	}
+}
+
+func TestParseTTL(t *testing.T) {
+	tests := []struct {
+		raw         string
+		want        time.Duration
+		wantEnabled bool
+		wantErr     bool
+	}{
+		{raw: "", want: defaultCacheTTL, wantEnabled: true},
+		{raw: "12h", want: 12 * time.Hour, wantEnabled: true},
+		{raw: "30d", want: 30 * 24 * time.Hour, wantEnabled: true},
+		{raw: "0", want: 0, wantEnabled: true},
+		{raw: "off"},
+		{raw: "soon", wantErr: true},
+		{raw: "-1h", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, enabled, err := parseTTL(tt.raw)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseTTL(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want || enabled != tt.wantEnabled {
+			t.Errorf("parseTTL(%q) = %v, %v, want %v, %v", tt.raw, got, enabled, tt.want, tt.wantEnabled)
+		}
+	}
+}
+
+func TestPopFlag(t *testing.T) {
+	found, rest := popFlag([]string{"review", "--no-cache", "main.go"}, "no-cache")
+	if !found || !reflect.DeepEqual(rest, []string{"review", "main.go"}) {
+		t.Errorf("popFlag() = %v, %v", found, rest)
+	}
+	if found, _ := popFlag([]string{"review", "main.go"}, "no-cache"); found {
+		t.Error("popFlag() found a missing flag")
+	}
+}
@@ -0,0 +1,140 @@
+package actions
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/tacheraSasi/ellie/release"
+	"github.com/tacheraSasi/ellie/styles"
+)
+
+const (
+	releaseUsage  = "release [--dry-run] [--as major|minor|patch] [--version <v>] [--yes]"
+	changelogFile = "CHANGELOG.md"
+)
+
+// releasePlan is what a release will do
+type releasePlan struct {
+	previous string
+	next     release.Version
+	bump     release.Bump
+	commits  []release.Commit
+	skipped  int
+	section  string
+	// changelog is the CHANGELOG.md at the top of the work tree
+	changelog string
+}
+
+// planRelease reads the conventional commits since the last version tag and
+// works out the next version and its changelog section. as and version
+// override the computed bump and version.
+func planRelease(as, version string, today time.Time) (releasePlan, error) {
+	var plan releasePlan
+	root, err := release.Root()
+	if err != nil {
+		return plan, err
+	}
+	plan.changelog = filepath.Join(root, changelogFile)
+	tag, err := release.LastTag()
+	if err != nil {
+		return plan, err
+	}
+	plan.previous = tag
+	plan.commits, plan.skipped, err = release.Commits(tag)
+	if err != nil {
+		return plan, err
+	}
+	if len(plan.commits) == 0 {
+		if tag == "" {
+			return plan, errors.New("no conventional commits to release")
+		}
+		return plan, fmt.Errorf("no conventional commits since %s", tag)
+	}
+
+	current := release.Version{Prefix: "v"}
+	if tag != "" {
+		if current, err = release.ParseVersion(tag); err != nil {
+			return plan, err
+		}
+	}
+	plan.bump = release.BumpFor(plan.commits)
+	if as != "" {
+		if plan.bump, err = release.ParseBump(as); err != nil {
+			return plan, err
+		}
+	}
+	plan.next = current.Next(plan.bump)
+	if version != "" {
+		if plan.next, err = release.ParseVersion(version); err != nil {
+			return plan, err
+		}
+		if plan.next.Prefix == "" {
+			plan.next.Prefix = current.Prefix
+		}
+	}
+	// Checked before anything is written, so a taken version leaves no
+	// release commit behind
+	if release.TagExists(plan.next.String()) {
+		return plan, fmt.Errorf("tag %s already exists", plan.next)
+	}
+
+	plan.section = release.Section(plan.next, today.Format("2006-01-02"), plan.commits)
+	return plan, nil
+}
+
+// Release computes the next semantic version from the conventional commits
+// since the last tag, adds a section to CHANGELOG.md, commits it and creates
+// an annotated tag. --dry-run only shows what would happen.
+func Release(args []string) {
+	in := newGitInput(args, releaseUsage, "dry-run|n", "as=", "version=")
+	styles.Cyan.Println("\nRelease")
+	styles.Cyan.Println("───────")
+
+	plan, err := planRelease(in.flag("as"), in.flag("version"), time.Now())
+	if err != nil {
+		styles.ErrorStyle.Printf("Error: %v\n", err)
+		exit(1)
+	}
+
+	from := plan.previous
+	if from == "" {
+		from = "the first commit"
+	}
+	styles.InfoStyle.Printf("%d commit(s) since %s: %s release %s\n", len(plan.commits), from, plan.bump, plan.next)
+	if plan.skipped > 0 {
+		styles.DimText.Printf("%d commit(s) without a conventional header are left out\n", plan.skipped)
+	}
+	styles.Magenta.Println("\nChangelog:")
+	fmt.Println("──────────────────")
+	fmt.Print(plan.section)
+	fmt.Println("──────────────────")
+
+	if in.has("dry-run") {
+		styles.DimText.Println("Dry run: nothing was written")
+		return
+	}
+	if !in.confirm(fmt.Sprintf("Update %s, commit and tag %s?", changelogFile, plan.next)) {
+		styles.ErrorStyle.Println("Release canceled")
+		return
+	}
+
+	existing, err := os.ReadFile(plan.changelog)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		styles.ErrorStyle.Printf("Error reading %s: %v\n", changelogFile, err)
+		exit(1)
+	}
+	if err := os.WriteFile(plan.changelog, []byte(release.UpdateChangelog(string(existing), plan.section)), 0644); err != nil {
+		styles.ErrorStyle.Printf("Error writing %s: %v\n", changelogFile, err)
+		exit(1)
+	}
+
+	tag := plan.next.String()
+	runGitCommand("add", "--", plan.changelog)
+	runGitCommand("commit", "--quiet", "-m", "chore(release): "+tag, "--", plan.changelog)
+	GitTagCreate([]string{"tag-create", tag, "-m", "Release " + tag})
+	styles.InfoStyle.Println("Publish it with: git push --follow-tags")
+}
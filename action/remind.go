@@ -32,6 +32,7 @@ func Remind() {
 func getReminderTitle() string {
 	for {
 		title, err := utils.GetInput("What do you want to remind yourself?")
+		stopOnClosedInput(err)
 		if err == nil && title != "" {
 			return title
 		}
@@ -42,6 +43,7 @@ func getReminderTitle() string {
 func getReminderDuration() time.Duration {
 	for {
 		input, err := utils.GetInput("⏳ When should I remind you? (e.g., 10s, 5m, 2h, 3d, 1w)")
+		stopOnClosedInput(err)
 		if err != nil {
 			styles.ErrorStyle.Println("🚫 Failed to read input. Please try again.")
 			continue
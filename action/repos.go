@@ -0,0 +1,256 @@
+package actions
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/tacheraSasi/ellie/repos"
+	"github.com/tacheraSasi/ellie/styles"
+)
+
+// repoWorkers is how many repositories are inspected or updated at once
+const repoWorkers = 8
+
+// repoTargets lists the registered projects and day-start repos, once per
+// path and sorted by name. Names and --tag narrow the list; day-start repos
+// have no tags, so --tag only matches projects.
+func repoTargets(names []string, tag string) []repos.Repo {
+	seen := map[string]bool{}
+	var targets []repos.Repo
+	add := func(name, path string, tags []string) {
+		path = expandHome(path)
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		if seen[path] {
+			return
+		}
+		if tag != "" && !containsTag(tags, strings.ToLower(tag)) {
+			return
+		}
+		if len(names) > 0 && !containsName(names, name) {
+			return
+		}
+		seen[path] = true
+		targets = append(targets, repos.Repo{Name: name, Path: path})
+	}
+
+	for _, p := range projects {
+		add(p.Name, p.Path, p.Tags)
+	}
+	for _, path := range dayStartConfig.GitRepos {
+		add(filepath.Base(expandHome(path)), path, nil)
+	}
+	sort.SliceStable(targets, func(i, j int) bool {
+		return strings.ToLower(targets[i].Name) < strings.ToLower(targets[j].Name)
+	})
+	return targets
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// expandHome replaces a leading ~ with the home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
+
+// repoArgs reads the repository names and --tag, plus --prune if allowed,
+// and lists the matching repositories. ok is false after printing usage or
+// when nothing matched.
+func repoArgs(args []string, usage string, allowPrune bool) (targets []repos.Repo, prune, ok bool) {
+	rest := args
+	if allowPrune {
+		prune, rest = popFlag(rest, "prune")
+	}
+	tag, _, rest, err := popValue(rest, "tag")
+	if err == nil {
+		for _, arg := range rest[1:] {
+			if strings.HasPrefix(arg, "-") {
+				err = fmt.Errorf("unknown flag %s", arg)
+				break
+			}
+		}
+	}
+	if err != nil {
+		styles.ErrorStyle.Printf("Error: %v\n", err)
+		styles.InfoStyle.Println("Usage: ellie " + usage)
+		return nil, false, false
+	}
+
+	targets = repoTargets(rest[1:], tag)
+	if len(targets) == 0 {
+		styles.InfoStyle.Println("No repositories found. Add some with 'ellie project add' or 'ellie day-start add git_repos'.")
+		return nil, false, false
+	}
+	return targets, prune, true
+}
+
+// ReposStatus shows the branch, changes, upstream, stashes and last commit
+// of every registered project and day-start repo in one table. They are
+// inspected concurrently.
+func ReposStatus(args []string) {
+	targets, _, ok := repoArgs(args, "repos status [name...] [--tag <tag>]", false)
+	if !ok {
+		return
+	}
+	printRepoStatus(repos.Each(targets, repoWorkers, repos.Inspect), time.Now())
+}
+
+// ReposFetch fetches every repository concurrently and reports each result
+func ReposFetch(args []string) {
+	targets, prune, ok := repoArgs(args, "repos fetch [name...] [--tag <tag>] [--prune]", true)
+	if !ok {
+		return
+	}
+	styles.InfoStyle.Printf("Fetching %d repositories...\n", len(targets))
+	reportRepoUpdates(repos.Each(targets, repoWorkers, func(r repos.Repo) repos.Result {
+		return repos.Fetch(r, prune)
+	}))
+}
+
+// ReposPull fast-forwards every repository concurrently and reports each
+// result
+func ReposPull(args []string) {
+	targets, _, ok := repoArgs(args, "repos pull [name...] [--tag <tag>]", false)
+	if !ok {
+		return
+	}
+	styles.InfoStyle.Printf("Pulling %d repositories...\n", len(targets))
+	reportRepoUpdates(repos.Each(targets, repoWorkers, repos.Pull))
+}
+
+// repoStatusRow is a repository's status as table cells
+func repoStatusRow(s repos.Status, now time.Time) []string {
+	if s.Err != nil {
+		return []string{s.Repo.Name, "error: " + s.Err.Error()}
+	}
+	branch := s.Branch
+	if branch == "" {
+		branch = "(detached)"
+	}
+	state := "clean"
+	if s.Dirty() {
+		state = fmt.Sprintf("%d changed", s.Changed)
+	}
+	return []string{s.Repo.Name, branch, state, s.Sync(), fmt.Sprint(s.Stashes), repos.Age(s.LastCommit, now)}
+}
+
+// printRepoStatus prints one row per repository, coloring dirty trees and
+// branches that are behind
+func printRepoStatus(statuses []repos.Status, now time.Time) {
+	header := []string{"REPO", "BRANCH", "STATE", "UPSTREAM", "STASHES", "LAST COMMIT"}
+	rows := make([][]string, len(statuses))
+	widths := make([]int, len(header))
+	for i, cell := range header {
+		widths[i] = utf8.RuneCountInString(cell)
+	}
+	for i, s := range statuses {
+		rows[i] = repoStatusRow(s, now)
+		cells := rows[i]
+		if s.Err != nil {
+			// The error spans the columns after the name
+			cells = cells[:1]
+		}
+		for j, cell := range cells {
+			widths[j] = max(widths[j], utf8.RuneCountInString(cell))
+		}
+	}
+	pad := func(cell string, col int) string {
+		if col == len(widths)-1 {
+			return cell
+		}
+		return cell + strings.Repeat(" ", widths[col]-utf8.RuneCountInString(cell)+2)
+	}
+
+	var b strings.Builder
+	for i, cell := range header {
+		b.WriteString(pad(cell, i))
+	}
+	styles.HeaderStyle.Println(b.String())
+
+	dirty, behind, failed := 0, 0, 0
+	for i, row := range rows {
+		s := statuses[i]
+		fmt.Print(pad(row[0], 0))
+		if s.Err != nil {
+			failed++
+			styles.Red.Println(row[1])
+			continue
+		}
+
+		stateColor := styles.Green
+		if s.Dirty() {
+			dirty++
+			stateColor = styles.Yellow
+		}
+		syncColor := styles.DimText
+		switch {
+		case s.Behind > 0:
+			behind++
+			syncColor = styles.Yellow
+		case s.Ahead > 0:
+			syncColor = styles.Cyan
+		}
+		fmt.Print(pad(row[1], 1))
+		stateColor.Print(pad(row[2], 2))
+		syncColor.Print(pad(row[3], 3))
+		fmt.Print(pad(row[4], 4))
+		fmt.Println(pad(row[5], 5))
+	}
+
+	summary := fmt.Sprintf("\n%d repositories: %d dirty, %d behind upstream", len(rows), dirty, behind)
+	if failed > 0 {
+		summary += fmt.Sprintf(", %d not readable", failed)
+	}
+	styles.DimText.Println(summary)
+}
+
+// reportRepoUpdates prints each repository's fetch or pull result and exits
+// non-zero if any failed
+func reportRepoUpdates(results []repos.Result) {
+	width := 0
+	for _, r := range results {
+		width = max(width, utf8.RuneCountInString(r.Repo.Name))
+	}
+
+	failed := 0
+	for _, r := range results {
+		style, mark := styles.SuccessStyle, "✓"
+		if r.Err != nil {
+			failed++
+			style, mark = styles.ErrorStyle, "✗"
+		}
+		style.Printf("%s ", mark)
+		fmt.Printf("%-*s  ", width, r.Repo.Name)
+		if r.Err != nil {
+			style.Println(r.Summary)
+		} else {
+			styles.DimText.Println(r.Summary)
+		}
+	}
+
+	if failed > 0 {
+		styles.ErrorStyle.Printf("\n%d of %d repositories failed\n", failed, len(results))
+		exit(1)
+	}
+	styles.SuccessStyle.Printf("\nAll %d repositories updated\n", len(results))
+}
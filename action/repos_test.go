@@ -0,0 +1,33 @@
+package actions
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestRepoTargets(t *testing.T) {
+	savedProjects, savedConfig := projects, dayStartConfig
+	t.Cleanup(func() { projects, dayStartConfig = savedProjects, savedConfig })
+
+	dir := t.TempDir()
+	projects = []Project{
+		{Name: "web", Path: filepath.Join(dir, "web"), Tags: []string{"frontend"}},
+		{Name: "api", Path: filepath.Join(dir, "api"), Tags: []string{"backend"}},
+	}
+	dayStartConfig.GitRepos = []string{filepath.Join(dir, "api"), filepath.Join(dir, "docs")}
+
+	var names []string
+	for _, r := range repoTargets(nil, "") {
+		names = append(names, r.Name)
+	}
+	if len(names) != 3 || names[0] != "api" || names[1] != "docs" || names[2] != "web" {
+		t.Errorf("repoTargets() = %v, want api, docs, web once each", names)
+	}
+
+	if got := repoTargets(nil, "FrontEnd"); len(got) != 1 || got[0].Name != "web" {
+		t.Errorf("repoTargets(--tag) = %+v", got)
+	}
+	if got := repoTargets([]string{"docs"}, ""); len(got) != 1 || got[0].Path != filepath.Join(dir, "docs") {
+		t.Errorf("repoTargets(docs) = %+v", got)
+	}
+}
@@ -1,84 +1,295 @@
 package actions
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"os/exec"
+	"os/signal"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/tacheraSasi/ellie/chat"
 	"github.com/tacheraSasi/ellie/llm"
+	"github.com/tacheraSasi/ellie/review"
 	"github.com/tacheraSasi/ellie/styles"
 	"github.com/tacheraSasi/ellie/utils"
 )
 
-// Review reads a file and asks the LLM to review the code.
-func Review(file string) {
-	openaiApikey := os.Getenv("OPENAI_API_KEY")
-	if openaiApikey == "" {
-		styles.ErrorStyle.Println("Error: OpenAI API key is required. Please set your OPENAI_API_KEY environment variable.")
+const (
+	// reviewWorkers is how many chunks are reviewed at once
+	reviewWorkers = 4
+	// maxReviewChunk caps a chunk's tokens even for huge context windows, so
+	// each review stays focused
+	maxReviewChunk = 24000
+	// reviewPromptOverhead leaves room for the instructions around a chunk
+	reviewPromptOverhead = 1000
+)
+
+const reviewUsage = "Usage: ellie review <file|dir|glob>... [--staged | --diff <ref>] [--provider <name>] [--model <name>] [--no-cache]"
+
+// Review asks the LLM to review files, directories and globs, or with
+// --staged or --diff <ref> only the changed hunks from git. Large inputs are
+// split into chunks that are reviewed concurrently and merged into one report.
+func Review(args []string) {
+	opts, rest, err := parseAIFlags(args)
+	noCache, rest := popFlag(rest, "no-cache")
+	staged, rest := popFlag(rest, "staged")
+	ref, diff, rest, flagErr := popValue(rest, "diff")
+	if err == nil {
+		err = flagErr
+	}
+	if err == nil && staged && diff {
+		err = fmt.Errorf("use either --staged or --diff, not both")
+	}
+	if err != nil || (len(rest) < 2 && !staged && !diff) {
+		if err != nil {
+			styles.ErrorStyle.Printf("Error: %v\n", err)
+		}
+		styles.InfoStyle.Println(reviewUsage)
 		return
 	}
 
-	code, err := os.ReadFile(file)
+	provider, err := newProvider("review", opts, 60*time.Second)
 	if err != nil {
-		styles.ErrorStyle.Printf("Error reading file: %v\n", err)
+		styles.ErrorStyle.Printf("Error creating provider: %v\n", err)
 		return
 	}
+	maxTokens := reviewChunkBudget(provider.GetModel())
 
-	config := llm.Config{
-		Timeout: 60,
+	var chunks []review.Chunk
+	if staged || diff {
+		chunks, err = diffChunks(staged, ref, rest[1:], maxTokens)
+	} else {
+		chunks, err = fileChunks(rest[1:], maxTokens)
 	}
-
-	provider, err := llm.NewProvider("ellieapi", config)
 	if err != nil {
-		styles.ErrorStyle.Printf("Error creating provider: %v\n", err)
+		styles.ErrorStyle.Printf("Error: %v\n", err)
 		return
 	}
-	
-	// Create a new chat session
-	session := chat.NewChatSession(provider)
+	if len(chunks) == 0 {
+		styles.InfoStyle.Println("Nothing to review.")
+		return
+	}
+
+	if len(chunks) == 1 {
+		reviewChunk(provider, providerName(opts), chunks[0], noCache)
+		return
+	}
+	reviewChunks(provider, providerName(opts), chunks, noCache)
+}
+
+// reviewChunkBudget is how many tokens of code fit in one review request
+func reviewChunkBudget(model string) int {
+	budget := chat.ContextWindow(model)/2 - reviewPromptOverhead
+	if budget > maxReviewChunk {
+		budget = maxReviewChunk
+	}
+	return budget
+}
+
+// sourceFile is a collected file. Binary and oversized files are kept, for
+// checks that don't read them, but have no text.
+type sourceFile struct {
+	path    string
+	mode    fs.FileMode
+	content string
+	text    bool
+}
+
+// collectSources collects the targets and reads their text
+func collectSources(targets []string) ([]sourceFile, error) {
+	files, err := review.Collect(targets)
+	if err != nil {
+		return nil, err
+	}
+
+	var sources []sourceFile
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return nil, err
+		}
+		src := sourceFile{path: file, mode: info.Mode()}
+		if info.Size() > review.MaxFileSize {
+			styles.DimText.Fprintf(os.Stderr, "Skipping %s: larger than %s\n", file, formatBytes(review.MaxFileSize))
+			sources = append(sources, src)
+			continue
+		}
+
+		code, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading file: %w", err)
+		}
+		if review.IsBinary(code) {
+			if len(files) == 1 {
+				return nil, fmt.Errorf("%s is a binary file", file)
+			}
+			sources = append(sources, src)
+			continue
+		}
+		src.content, src.text = string(code), true
+		sources = append(sources, src)
+	}
+	return sources, nil
+}
+
+// chunkSources splits each text file into chunks
+func chunkSources(sources []sourceFile, maxTokens int) []review.Chunk {
+	var chunks []review.Chunk
+	for _, src := range sources {
+		if src.text {
+			chunks = append(chunks, review.SplitFile(src.path, src.content, maxTokens, chat.EstimateTokens)...)
+		}
+	}
+	return chunks
+}
+
+// fileChunks collects the targets and splits each file into chunks, skipping
+// binary and oversized files
+func fileChunks(targets []string, maxTokens int) ([]review.Chunk, error) {
+	sources, err := collectSources(targets)
+	if err != nil {
+		return nil, err
+	}
+	return chunkSources(sources, maxTokens), nil
+}
+
+// diffChunks splits the staged changes, or the changes since ref, into
+// chunks of hunks. Paths limit the diff like git's pathspecs.
+func diffChunks(staged bool, ref string, paths []string, maxTokens int) ([]review.Chunk, error) {
+	gitArgs := []string{"diff", "--no-color", "--no-ext-diff"}
+	if staged {
+		gitArgs = append(gitArgs, "--cached")
+	} else {
+		gitArgs = append(gitArgs, ref)
+	}
+	gitArgs = append(gitArgs, "--")
+	gitArgs = append(gitArgs, paths...)
+
+	output, err := exec.Command("git", gitArgs...).Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return nil, fmt.Errorf("git diff failed: %s", strings.TrimSpace(string(exitErr.Stderr)))
+		}
+		return nil, fmt.Errorf("git diff failed: %w", err)
+	}
+
+	var chunks []review.Chunk
+	for _, fd := range review.ParseDiff(string(output)) {
+		if fd.Deleted || fd.Binary {
+			continue
+		}
+		chunks = append(chunks, review.SplitDiff(fd, maxTokens, chat.EstimateTokens)...)
+	}
+	return chunks, nil
+}
 
-	prompt := fmt.Sprintf(
-		`You are an expert software engineer. Please review the following code for bugs, security issues, code quality, and best practices. Provide actionable suggestions and a summary. 
+// reviewPrompt builds the review request for a chunk
+func reviewPrompt(c review.Chunk) string {
+	if c.Diff {
+		return fmt.Sprintf(
+			`You are an expert software engineer. Please review the following changes for bugs, security issues, code quality, and best practices. Comment only on the added and removed lines; the other lines are context. Provide actionable suggestions and a summary.
+File: %s
+
+Diff:
+%s
+`, c.Path, c.Content)
+	}
+
+	part := ""
+	if c.Partial() {
+		part = fmt.Sprintf("\nThis is lines %d-%d of %d; the rest of the file is reviewed separately.", c.StartLine, c.EndLine, c.Total)
+	}
+	return fmt.Sprintf(
+		`You are an expert software engineer. Please review the following code for bugs, security issues, code quality, and best practices. Provide actionable suggestions and a summary. %s
 File: %s
 
 Code:
 %s
-`, file, string(code))
+`, part, c.Path, c.Content)
+}
+
+// reviewChunk streams the review of a single chunk
+func reviewChunk(provider llm.Provider, providerType string, c review.Chunk, noCache bool) {
+	prompt := reviewPrompt(c)
+	request := newCachedRequest("review", providerType, provider, c.Label(), prompt, []byte(c.Content))
+	if !noCache && request.lookup() {
+		return
+	}
 
 	styles.InfoStyle.Println("Reviewing code with Ellie...")
 
-	done := make(chan bool)
-	responseChan := make(chan string)
-	errorChan := make(chan error)
+	session := chat.NewChatSession(provider)
+	response, interrupted, err := streamAnswer(session, prompt, "Reviewing...")
+	if err != nil {
+		styles.ErrorStyle.Printf("\nError: %s\n", chat.DescribeError(err))
+		return
+	}
+	if interrupted {
+		return
+	}
+	if strings.TrimSpace(response) == "" {
+		styles.WarningStyle.Println("\nNo response received from AI.")
+		return
+	}
+	request.save(response, session.Usage())
+}
 
-	go utils.ShowLoadingSpinner("Reviewing...", done)
+// reviewChunks reviews chunks concurrently and prints the merged report
+func reviewChunks(provider llm.Provider, providerType string, chunks []review.Chunk, noCache bool) {
+	files := map[string]bool{}
+	for _, c := range chunks {
+		files[c.Path] = true
+	}
+	styles.InfoStyle.Printf("Reviewing %d file(s) in %d chunk(s) with Ellie...\n", len(files), len(chunks))
 
-	go func() {
-		response, err := session.SendMessage(prompt)
-		if err != nil {
-			errorChan <- err
-			return
-		}
-		responseChan <- response
-	}()
-
-	select {
-	case err := <-errorChan:
-		done <- true
-		styles.ErrorStyle.Printf("\nError: %v\n", err)
-	case response := <-responseChan:
-		done <- true
-		if strings.TrimSpace(response) == "" {
-			styles.WarningStyle.Println("\nNo response received from AI.")
-			return
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var mu sync.Mutex
+	done := 0
+	progress := func(c review.Chunk, status string) {
+		mu.Lock()
+		defer mu.Unlock()
+		done++
+		styles.DimText.Printf("  [%d/%d] %s%s\n", done, len(chunks), c.Label(), status)
+	}
+
+	results := review.Run(ctx, chunks, reviewWorkers, func(ctx context.Context, c review.Chunk) (string, error) {
+		prompt := reviewPrompt(c)
+		request := newCachedRequest("review", providerType, provider, c.Label(), prompt, []byte(c.Content))
+		if !noCache {
+			if entry, ok := request.get(); ok {
+				progress(c, " (cached)")
+				return entry.Response, nil
+			}
 		}
-		renderedOutput, err := utils.RenderMarkdown(response)
+
+		session := chat.NewChatSession(provider)
+		findings, err := session.SendMessageStream(ctx, prompt, nil)
 		if err != nil {
-			styles.ErrorStyle.Println("\nRaw response:", response)
-			styles.ErrorStyle.Println("Error rendering Markdown:", err)
-			return
+			progress(c, " (failed)")
+			return "", errors.New(chat.DescribeError(err))
 		}
-		fmt.Println("\n" + renderedOutput)
+		request.save(findings, session.Usage())
+		progress(c, "")
+		return findings, nil
+	})
+
+	if ctx.Err() != nil {
+		styles.WarningStyle.Println("⏹  Review interrupted; showing what finished.")
+	}
+
+	report := review.Report("Code review", results)
+	rendered, err := utils.RenderMarkdown(report)
+	if err != nil {
+		fmt.Println(report)
+		return
 	}
+	fmt.Println(rendered)
 }
@@ -1,88 +1,361 @@
 package actions
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/tacheraSasi/ellie/chat"
+	"github.com/tacheraSasi/ellie/configs"
 	"github.com/tacheraSasi/ellie/llm"
+	"github.com/tacheraSasi/ellie/review"
+	"github.com/tacheraSasi/ellie/security"
 	"github.com/tacheraSasi/ellie/styles"
 	"github.com/tacheraSasi/ellie/utils"
 )
 
-// Review reads a file and asks the LLM to perform a strict security audit.
-func SecurityCheck(file string) {
-	openaiApikey := os.Getenv("OPENAI_API_KEY")
-	if openaiApikey == "" {
-		styles.ErrorStyle.Println("Error: OpenAI API key is required. Please set your OPENAI_API_KEY environment variable.")
-		return
+const securityUsage = "Usage: ellie security-check <file|dir|glob>... [--format md|json|sarif] [--fail-on critical|high|medium|low] [--offline] [--provider <name>] [--model <name>] [--no-cache]"
+
+// Exit codes of security-check when --fail-on is set
+const (
+	exitFindings    = 1
+	exitAuditFailed = 2
+)
+
+// securityOptions are the output flags of security-check
+type securityOptions struct {
+	format  string
+	failOn  security.Severity
+	noCache bool
+	offline bool
+}
+
+// parseSecurityFlags pulls --format, --fail-on, --no-cache and --offline out
+// of args
+func parseSecurityFlags(args []string) (securityOptions, []string, error) {
+	opts := securityOptions{format: "md"}
+	opts.noCache, args = popFlag(args, "no-cache")
+	opts.offline, args = popFlag(args, "offline")
+
+	format, _, args, err := popValue(args, "format")
+	if err != nil {
+		return opts, nil, err
+	}
+	switch strings.ToLower(format) {
+	case "", "md", "markdown":
+	case "json", "sarif":
+		opts.format = strings.ToLower(format)
+	default:
+		return opts, nil, fmt.Errorf("unknown format %q, use md, json or sarif", format)
+	}
+
+	failOn, found, args, err := popValue(args, "fail-on")
+	if err != nil {
+		return opts, nil, err
 	}
+	if found && !strings.EqualFold(failOn, "none") {
+		if opts.failOn, err = security.ParseSeverity(failOn); err != nil {
+			return opts, nil, err
+		}
+	}
+	return opts, args, nil
+}
 
-	code, err := os.ReadFile(file)
+// SecurityCheck audits files, directories or globs with the offline rule
+// scanner and asks the LLM for a strict security audit, unless --offline is
+// set. AI findings are validated against a JSON schema, merged with the
+// static ones and printed as Markdown, JSON or SARIF. With --fail-on it exits
+// non-zero when a finding is at least that severe, for use in hooks and CI.
+func SecurityCheck(args []string) {
+	aiOpts, rest, err := parseAIFlags(args)
 	if err != nil {
-		styles.ErrorStyle.Printf("Error reading file: %v\n", err)
+		styles.ErrorStyle.Printf("Error: %v\n", err)
+		styles.InfoStyle.Println(securityUsage)
+		return
+	}
+	opts, rest, err := parseSecurityFlags(rest)
+	if err != nil || len(rest) < 2 {
+		if err != nil {
+			styles.ErrorStyle.Printf("Error: %v\n", err)
+		}
+		styles.InfoStyle.Println(securityUsage)
 		return
 	}
 
-	config := llm.Config{
-		APIKey:  openaiApikey,
-		Timeout: 60,
+	// Progress goes to stderr so JSON and SARIF can be piped
+	status := io.Writer(os.Stdout)
+	if opts.format != "md" {
+		status = os.Stderr
+	}
+	fail := func(format string, a ...interface{}) {
+		styles.ErrorStyle.Fprintf(os.Stderr, format, a...)
+		if opts.failOn != "" {
+			exit(exitAuditFailed)
+		}
 	}
 
-	provider, err := llm.NewProvider("ellieapi", config)
+	sources, err := collectSources(rest[1:])
 	if err != nil {
-		styles.ErrorStyle.Printf("Error creating provider: %v\n", err)
+		fail("Error: %v\n", err)
+		return
+	}
+	if len(sources) == 0 {
+		styles.InfoStyle.Fprintln(status, "Nothing to audit.")
 		return
 	}
-	// Create a new chat session
-	session := chat.NewChatSession(provider)
 
-	prompt := fmt.Sprintf(
-		`You are a senior security engineer. Perform a STRICT security audit of the following code. 
-- Identify ALL possible vulnerabilities, insecure coding patterns, injection risks, privilege escalation, insecure dependencies, and any non-compliance with best security practices or standards (e.g., OWASP Top 10).
-- For each issue, provide a SEVERITY rating (Critical, High, Medium, Low), a clear explanation, and explicit REMEDIATION steps.
-- Summarize the overall security posture and provide a checklist for hardening this code.
-- Do NOT skip minor issues. Be extremely thorough and strict.
+	static := staticScan(sources)
+	failed := 0
+	var aiFindings []security.Finding
+	var summaries []string
+	if !opts.offline {
+		aiFindings, summaries, failed = aiAudit(aiOpts, sources, opts, status)
+	}
+	findings := security.Merge(static, aiFindings)
 
-File: %s
+	if err := printFindings(opts.format, findings, summaries); err != nil {
+		fail("Error: %v\n", err)
+		return
+	}
 
-Code:
-%s
-`, file, string(code))
+	if opts.failOn == "" {
+		return
+	}
+	if security.Exceeds(findings, opts.failOn) {
+		styles.ErrorStyle.Fprintf(os.Stderr, "Found issues of severity %s or higher\n", opts.failOn)
+		exit(exitFindings)
+	}
+	if failed > 0 {
+		exit(exitAuditFailed)
+	}
+}
+
+// staticScan runs the rule pack from ~/ellie/rules over the sources' text
+// and checks every file's permissions
+func staticScan(sources []sourceFile) []security.Finding {
+	rules, err := security.LoadRules(filepath.Join(configs.GetEllieDir(), "rules"))
+	if err != nil {
+		styles.WarningStyle.Fprintf(os.Stderr, "⚠️ Some rules were skipped: %v\n", err)
+	}
+
+	var findings []security.Finding
+	for _, src := range sources {
+		findings = append(findings, rules.ScanPermissions(src.path, src.mode)...)
+		if src.text {
+			findings = append(findings, rules.Scan(src.path, src.content)...)
+		}
+	}
+	return findings
+}
+
+// aiAudit asks the LLM to audit the sources. It returns the findings, the
+// summaries and how many chunks could not be audited. Without a provider the
+// whole audit counts as one failure, so the static findings still print.
+func aiAudit(aiOpts aiOptions, sources []sourceFile, opts securityOptions, status io.Writer) ([]security.Finding, []string, int) {
+	provider, err := newProvider("security-check", aiOpts, 60*time.Second)
+	if err != nil {
+		styles.ErrorStyle.Fprintf(os.Stderr, "Error creating provider: %v; showing offline findings only\n", err)
+		return nil, nil, 1
+	}
+
+	chunks := chunkSources(sources, reviewChunkBudget(provider.GetModel()))
+	if len(chunks) == 0 {
+		return nil, nil, 0
+	}
+
+	styles.InfoStyle.Fprintln(status, "Performing strict security audit with Ellie...")
+	results := auditChunks(provider, providerName(aiOpts), chunks, opts, status)
 
-	styles.InfoStyle.Println("Performing strict security audit with Ellie...")
-	done := make(chan bool)
-	responseChan := make(chan string)
-	errorChan := make(chan error)
+	var findings []security.Finding
+	var summaries []string
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+			styles.ErrorStyle.Fprintf(os.Stderr, "Audit of %s failed: %v\n", r.Chunk.Label(), r.Err)
+			continue
+		}
+		var report security.Report
+		if err := json.Unmarshal([]byte(r.Findings), &report); err != nil {
+			failed++
+			styles.ErrorStyle.Fprintf(os.Stderr, "Audit of %s failed: unreadable findings: %v\n", r.Chunk.Label(), err)
+			continue
+		}
+		for _, f := range report.Findings {
+			f.Source = security.SourceAI
+			findings = append(findings, f)
+		}
+		if report.Summary != "" {
+			summaries = append(summaries, report.Summary)
+		}
+	}
+	return findings, summaries, failed
+}
+
+// printFindings writes the findings to stdout in the requested format
+func printFindings(format string, findings []security.Finding, summaries []string) error {
+	switch format {
+	case "json":
+		data, err := json.MarshalIndent(security.Report{
+			Summary:  strings.Join(summaries, "\n\n"),
+			Findings: append([]security.Finding{}, findings...),
+		}, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	case "sarif":
+		data, err := security.SARIF(findings, "ellie security-check", configs.VERSION)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	default:
+		report := security.Markdown(findings, summaries)
+		rendered, err := utils.RenderMarkdown(report)
+		if err != nil {
+			fmt.Println(report)
+			return nil
+		}
+		fmt.Println(rendered)
+	}
+	return nil
+}
+
+// auditChunks audits chunks concurrently. Each result holds the chunk's
+// validated report as JSON.
+func auditChunks(provider llm.Provider, providerType string, chunks []review.Chunk, opts securityOptions, status io.Writer) []review.Result {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	// A single chunk in the terminal gets a spinner; otherwise print progress
+	var mu sync.Mutex
+	done := 0
+	progress := func(c review.Chunk, note string) {}
+	if len(chunks) == 1 && opts.format == "md" {
+		spinnerDone := make(chan bool)
+		go utils.ShowLoadingSpinner("Reviewing...", spinnerDone)
+		defer func() { spinnerDone <- true }()
+	} else {
+		progress = func(c review.Chunk, note string) {
+			mu.Lock()
+			defer mu.Unlock()
+			done++
+			styles.DimText.Fprintf(status, "  [%d/%d] %s%s\n", done, len(chunks), c.Label(), note)
+		}
+	}
 
-	go utils.ShowLoadingSpinner("Reviewing...", done)
+	return review.Run(ctx, chunks, reviewWorkers, func(ctx context.Context, c review.Chunk) (string, error) {
+		prompt := securityPrompt(c)
+		request := newCachedRequest("security-check", providerType, provider, c.Label(), prompt, []byte(c.Content))
+		if !opts.noCache {
+			if entry, ok := request.get(); ok {
+				if _, err := security.ParseReport(entry.Response); err == nil {
+					progress(c, " (cached)")
+					return entry.Response, nil
+				}
+			}
+		}
 
-	go func() {
-		response, err := session.SendMessage(prompt)
+		session := chat.NewChatSession(provider)
+		report, err := auditChunk(ctx, session, c, prompt)
+		if err != nil {
+			progress(c, " (failed)")
+			return "", err
+		}
+		data, err := json.Marshal(report)
 		if err != nil {
-			errorChan <- err
-			return
-		}
-		responseChan <- response
-	}()
-
-	select {
-	case err := <-errorChan:
-		done <- true
-		styles.ErrorStyle.Printf("\nError: %v\n", err)
-	case response := <-responseChan:
-		done <- true
-		if strings.TrimSpace(response) == "" {
-			styles.WarningStyle.Println("\nNo response received from AI.")
-			return
-		}
-		renderedOutput, err := utils.RenderMarkdown(response)
+			return "", err
+		}
+		request.save(string(data), session.Usage())
+		progress(c, "")
+		return string(data), nil
+	})
+}
+
+// auditChunk asks for the chunk's findings, and once more with the schema
+// errors if the first answer doesn't validate
+func auditChunk(ctx context.Context, session *chat.ChatSession, c review.Chunk, prompt string) (security.Report, error) {
+	answer, err := session.SendMessageStream(ctx, prompt, nil)
+	if err != nil {
+		return security.Report{}, errors.New(chat.DescribeError(err))
+	}
+
+	report, err := security.ParseReport(answer)
+	if err != nil {
+		retry := fmt.Sprintf("Your answer did not match the required JSON format: %v\nReply again with only the corrected JSON object.", err)
+		answer, err = session.SendMessageStream(ctx, retry, nil)
 		if err != nil {
-			styles.ErrorStyle.Println("\nRaw response:", response)
-			styles.ErrorStyle.Println("Error rendering Markdown:", err)
-			return
+			return security.Report{}, errors.New(chat.DescribeError(err))
+		}
+		if report, err = security.ParseReport(answer); err != nil {
+			return security.Report{}, fmt.Errorf("the AI did not return valid findings: %w", err)
 		}
-		fmt.Println("\n" + renderedOutput)
 	}
+
+	report.Place(c.Path, c.StartLine, c.EndLine)
+	return report, nil
+}
+
+// securityPrompt builds the audit request for a chunk. Lines are numbered so
+// findings point at real lines.
+func securityPrompt(c review.Chunk) string {
+	part := ""
+	if c.Partial() {
+		part = fmt.Sprintf(" (lines %d-%d of %d; the rest is audited separately)", c.StartLine, c.EndLine, c.Total)
+	}
+	return fmt.Sprintf(
+		`You are a senior security engineer. Perform a STRICT security audit of the following code.
+- Identify ALL possible vulnerabilities, insecure coding patterns, injection risks, privilege escalation, insecure dependencies, and any non-compliance with best security practices or standards (e.g., OWASP Top 10).
+- For each issue, provide a SEVERITY rating (critical, high, medium, low), a clear explanation, and explicit REMEDIATION steps.
+- Do NOT skip minor issues. Be extremely thorough and strict.
+
+Reply with ONLY a JSON object, without Markdown or code fences, in this format:
+{
+  "summary": "the overall security posture in one or two sentences",
+  "findings": [
+    {
+      "rule": "short kebab-case identifier, e.g. sql-injection or hardcoded-secret",
+      "severity": "critical | high | medium | low",
+      "file": %q,
+      "start_line": 12,
+      "end_line": 14,
+      "description": "what is wrong and why it matters",
+      "remediation": "explicit steps to fix it"
+    }
+  ]
+}
+Line numbers are the numbers before each line of code. Use an empty findings list when there are no issues.
+
+File: %s%s
+
+Code:
+%s
+`, c.Path, c.Path, part, numberLines(c.Content, c.StartLine))
+}
+
+// numberLines prefixes each line with its line number
+func numberLines(content string, first int) string {
+	lines := strings.SplitAfter(content, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	width := len(fmt.Sprint(first + len(lines)))
+
+	var b strings.Builder
+	for i, line := range lines {
+		fmt.Fprintf(&b, "%*d | %s", width, first+i, line)
+	}
+	if !strings.HasSuffix(b.String(), "\n") {
+		b.WriteString("\n")
+	}
+	return b.String()
 }
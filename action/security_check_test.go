@@ -0,0 +1,44 @@
+package actions
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tacheraSasi/ellie/security"
+)
+
+func TestParseSecurityFlags(t *testing.T) {
+	opts, rest, err := parseSecurityFlags([]string{"security-check", "--format", "SARIF", "src", "--fail-on=high", "--no-cache", "--offline"})
+	if err != nil {
+		t.Fatalf("parseSecurityFlags() error = %v", err)
+	}
+	want := securityOptions{format: "sarif", failOn: security.High, noCache: true, offline: true}
+	if opts != want || !reflect.DeepEqual(rest, []string{"security-check", "src"}) {
+		t.Errorf("parseSecurityFlags() = %+v, %v", opts, rest)
+	}
+
+	opts, _, err = parseSecurityFlags([]string{"security-check", "main.go", "--fail-on", "none"})
+	if err != nil || opts.format != "md" || opts.failOn != "" {
+		t.Errorf("parseSecurityFlags() defaults = %+v, %v", opts, err)
+	}
+
+	for _, args := range [][]string{
+		{"security-check", "main.go", "--format", "xml"},
+		{"security-check", "main.go", "--fail-on", "urgent"},
+		{"security-check", "main.go", "--format"},
+	} {
+		if _, _, err := parseSecurityFlags(args); err == nil {
+			t.Errorf("parseSecurityFlags(%v) should fail", args)
+		}
+	}
+}
+
+func TestNumberLines(t *testing.T) {
+	got := numberLines("a\nb\n", 9)
+	if want := " 9 | a\n10 | b\n"; got != want {
+		t.Errorf("numberLines() = %q, want %q", got, want)
+	}
+	if got := numberLines("x", 1); got != "1 | x\n" {
+		t.Errorf("numberLines() without trailing newline = %q", got)
+	}
+}
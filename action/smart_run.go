@@ -3,9 +3,9 @@ package actions
 import (
 	"fmt"
 	"strings"
+	"time"
 
-	"github.com/tacheraSasi/ellie/configs"
-	"github.com/tacheraSasi/ellie/llm"
+	"github.com/tacheraSasi/ellie/chat"
 	"github.com/tacheraSasi/ellie/static"
 	"github.com/tacheraSasi/ellie/styles"
 	"github.com/tacheraSasi/ellie/types"
@@ -14,34 +14,46 @@ import (
 
 // SmartRun uses LLM to process user input and optionally execute commands
 func SmartRun(args []string) {
-	userPrompt := strings.Join(args[1:], " ")
-	if userPrompt == "" {
-		styles.WarningStyle.Println("No prompt provided.")
+	opts, rest, err := parseAIFlags(args)
+	if err != nil {
+		styles.ErrorStyle.Printf("Error: %v\n", err)
 		return
 	}
 
-	openaiApiKey := configs.GetEnv("OPENAI_API_KEY")
-	if openaiApiKey == "" {
-		styles.ErrorStyle.Println("Error: OPENAI_API_KEY not set.")
+	userPrompt := strings.Join(rest[1:], " ")
+	if userPrompt == "" {
+		styles.WarningStyle.Println("No prompt provided.")
 		return
 	}
 
-	provider, err := llm.NewProvider("ellieapi", llm.Config{Timeout: 60})
+	provider, err := newProvider("run", opts, 60*time.Second)
 	if err != nil {
 		styles.ErrorStyle.Printf("Error initializing LLM provider: %v\n", err)
 		return
 	}
 
 	userCtx := types.NewUserContext()
+	session := chat.NewChatSession(provider)
+	session.SetTools(toolDefinitions(), runTool)
 
 	fullPrompt := buildPrompt(userPrompt, userCtx)
-	response, err := provider.Chat([]llm.Message{{Role: "user", Content: fullPrompt}})
+	response, interrupted, err := streamFilteredAnswer(session, fullPrompt, "Thinking...", &executeFilter{})
 	if err != nil {
-		styles.ErrorStyle.Printf("LLM chat error: %v\n", err)
+		styles.ErrorStyle.Printf("LLM chat error: %s\n", chat.DescribeError(err))
+		return
+	}
+	if interrupted {
 		return
 	}
 
-	handleLLMResponse(response.Content)
+	// The explanation was printed while streaming, without the commands,
+	// which are offered one at a time now
+	_, commands := extractContent(response)
+	if len(commands) == 0 {
+		styles.WarningStyle.Println("\n⚠️ No commands to execute.")
+		return
+	}
+	executeCommands(commands)
 }
 
 // buildPrompt constructs the final LLM prompt
@@ -60,23 +72,6 @@ AND ALL THE OTHER OS RESPICTIVELY
 User request: %s`, static.Instructions(*userCtx), userInput)
 }
 
-// handleLLMResponse processes and executes the LLM output
-func handleLLMResponse(response string) {
-	instructions, commands := extractContent(response)
-
-	if instructions != "" {
-		styles.InfoStyle.Println("\nℹ️ Instructions:")
-		fmt.Println(instructions)
-	}
-
-	if len(commands) == 0 {
-		styles.WarningStyle.Println("\n⚠️ No commands to execute.")
-		return
-	}
-
-	executeCommands(commands)
-}
-
 // extractContent parses out <execute> blocks and returns remaining explanation
 func extractContent(response string) (string, []string) {
 	var instructionsBuilder strings.Builder
@@ -110,6 +105,62 @@ func extractContent(response string) (string, []string) {
 	return strings.TrimSpace(instructionsBuilder.String()), commands
 }
 
+// executeFilter hides the <execute> blocks of an answer as it streams, so
+// only the explanation is printed
+type executeFilter struct {
+	// pending is text that may be the start of a tag
+	pending string
+	inBlock bool
+}
+
+func (f *executeFilter) Write(chunk string) string {
+	text := f.pending + chunk
+	f.pending = ""
+	var out strings.Builder
+	for text != "" {
+		tag := "<execute>"
+		if f.inBlock {
+			tag = "</execute>"
+		}
+		if i := strings.Index(text, tag); i >= 0 {
+			if !f.inBlock {
+				out.WriteString(text[:i])
+			}
+			text = text[i+len(tag):]
+			f.inBlock = !f.inBlock
+			continue
+		}
+
+		// A tag may be split across chunks
+		keep := partialTag(text, tag)
+		if !f.inBlock {
+			out.WriteString(text[:len(text)-keep])
+		}
+		f.pending = text[len(text)-keep:]
+		break
+	}
+	return out.String()
+}
+
+func (f *executeFilter) Flush() string {
+	pending := f.pending
+	f.pending = ""
+	if f.inBlock {
+		return ""
+	}
+	return pending
+}
+
+// partialTag returns the length of the longest end of text that starts tag
+func partialTag(text, tag string) int {
+	for n := min(len(text), len(tag)-1); n > 0; n-- {
+		if strings.HasSuffix(text, tag[:n]) {
+			return n
+		}
+	}
+	return 0
+}
+
 // executeCommands prompts the user before running each extracted command
 func executeCommands(commands []string) {
 	for _, cmd := range commands {
@@ -0,0 +1,23 @@
+package actions
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestExecuteFilter(t *testing.T) {
+	answer := "List the files:\n<execute>ls -la</execute>\nThen check git: <execute>git status</execute> done <exe"
+	for _, size := range []int{1, 3, 7, len(answer)} {
+		var f executeFilter
+		var printed strings.Builder
+		for i := 0; i < len(answer); i += size {
+			printed.WriteString(f.Write(answer[i:min(i+size, len(answer))]))
+		}
+		printed.WriteString(f.Flush())
+
+		want := "List the files:\n\nThen check git:  done <exe"
+		if printed.String() != want {
+			t.Errorf("chunks of %d printed %q, want %q", size, printed.String(), want)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package actions
+
+import (
+	"errors"
+
+	"github.com/tacheraSasi/ellie/store"
+	"github.com/tacheraSasi/ellie/styles"
+)
+
+// loadStore reads a store into v, warning when the file had to be
+// recovered. It reports whether v holds the stored data.
+func loadStore(file *store.File, v any, what string) bool {
+	err := file.Load(v)
+	var recovered *store.RecoveredError
+	if errors.As(err, &recovered) {
+		styles.GetWarningStyle().Println("⚠️ ", err)
+		return true
+	}
+	if err != nil {
+		styles.GetErrorStyle().Printf("Error reading %s: %v\n", what, err)
+		return false
+	}
+	return true
+}
+
+// updateStore reloads a store into v, applies change and saves v, all with
+// the file locked so that a change another ellie made meanwhile, such as
+// the automation daemon, isn't lost. change returns false to save nothing.
+// It reports whether the change was saved.
+func updateStore(file *store.File, v any, what string, change func() bool) bool {
+	changed := false
+	err := file.Update(v, func() error {
+		if !change() {
+			return store.ErrUnchanged
+		}
+		changed = true
+		return nil
+	})
+	var recovered *store.RecoveredError
+	if errors.As(err, &recovered) {
+		styles.GetWarningStyle().Println("⚠️ ", err)
+		return changed
+	}
+	if err != nil {
+		styles.GetErrorStyle().Printf("Error saving %s: %v\n", what, err)
+		return false
+	}
+	return changed
+}
@@ -0,0 +1,82 @@
+package actions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+
+	"github.com/tacheraSasi/ellie/chat"
+	"github.com/tacheraSasi/ellie/styles"
+	"github.com/tacheraSasi/ellie/utils"
+)
+
+// streamAnswer sends prompt through the session and prints the answer as it
+// arrives. A spinner is shown until the first token or tool call, and Ctrl+C
+// stops the answer without leaving Ellie. It returns the (possibly partial)
+// answer and whether it was interrupted.
+func streamAnswer(session *chat.ChatSession, prompt, spinnerMessage string) (string, bool, error) {
+	return streamFilteredAnswer(session, prompt, spinnerMessage, nil)
+}
+
+// chunkFilter changes what is printed of an answer as it streams. Write
+// returns the part of a chunk to print, and Flush what it held back once
+// the answer is complete.
+type chunkFilter interface {
+	Write(chunk string) string
+	Flush() string
+}
+
+// streamFilteredAnswer is streamAnswer printing the answer through filter;
+// the whole answer is still returned
+func streamFilteredAnswer(session *chat.ChatSession, prompt, spinnerMessage string, filter chunkFilter) (string, bool, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	go func() {
+		select {
+		case <-interrupt:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	done := make(chan bool)
+	go utils.ShowLoadingSpinner(spinnerMessage, done)
+
+	var stopOnce sync.Once
+	stopSpinner := func() {
+		stopOnce.Do(func() {
+			done <- true
+			fmt.Println()
+		})
+	}
+
+	pauseSpinner = stopSpinner
+	defer func() { pauseSpinner = func() {} }()
+
+	response, err := session.SendMessageStream(ctx, prompt, func(chunk string) {
+		stopSpinner()
+		if filter != nil {
+			chunk = filter.Write(chunk)
+		}
+		fmt.Print(chunk)
+	})
+	stopSpinner()
+	if filter != nil {
+		fmt.Print(filter.Flush())
+	}
+	fmt.Println()
+
+	if errors.Is(err, context.Canceled) {
+		styles.WarningStyle.Println("⏹  Response interrupted.")
+		return response, true, nil
+	}
+	return response, false, err
+}
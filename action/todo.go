@@ -1,14 +1,13 @@
 package actions
 
 import (
-	"encoding/json"
 	"fmt"
-	"os"
 	"path/filepath"
 	"sort"
 	"time"
 
 	"github.com/tacheraSasi/ellie/configs"
+	"github.com/tacheraSasi/ellie/store"
 	"github.com/tacheraSasi/ellie/styles"
 )
 
@@ -49,36 +48,20 @@ func init() {
 	loadTodos()
 }
 
-func loadTodos() {
-	todoFile := filepath.Join(configs.GetEllieDir(), "todos.json")
-	if _, err := os.Stat(todoFile); os.IsNotExist(err) {
-		todos = []Todo{}
-		return
-	}
-
-	data, err := os.ReadFile(todoFile)
-	if err != nil {
-		styles.ErrorStyle.Println("Error reading todos:", err)
-		return
-	}
-
-	if err := json.Unmarshal(data, &todos); err != nil {
-		styles.ErrorStyle.Println("Error parsing todos:", err)
-		todos = []Todo{}
-	}
+// todoStore is the file todos are kept in
+func todoStore() *store.File {
+	return store.New(filepath.Join(configs.GetEllieDir(), "todos.json"), 1)
 }
 
-func saveTodos() {
-	todoFile := filepath.Join(configs.GetEllieDir(), "todos.json")
-	data, err := json.MarshalIndent(todos, "", "  ")
-	if err != nil {
-		styles.ErrorStyle.Println("Error saving todos:", err)
-		return
-	}
+func loadTodos() {
+	todos = []Todo{}
+	loadStore(todoStore(), &todos, "todos")
+}
 
-	if err := os.WriteFile(todoFile, data, 0644); err != nil {
-		styles.ErrorStyle.Println("Error writing todos:", err)
-	}
+// updateTodos applies change to the todos as they are saved now, and saves
+// them if change returns true
+func updateTodos(change func() bool) bool {
+	return updateStore(todoStore(), &todos, "todos", change)
 }
 
 func TodoAdd(args []string) {
@@ -104,13 +87,7 @@ func TodoAdd(args []string) {
 		}
 	}
 
-	newID := 1
-	if len(todos) > 0 {
-		newID = todos[len(todos)-1].ID + 1
-	}
-
 	todo := Todo{
-		ID:        newID,
 		Task:      task,
 		Category:  category,
 		Priority:  priority,
@@ -118,8 +95,17 @@ func TodoAdd(args []string) {
 		Completed: false,
 	}
 
-	todos = append(todos, todo)
-	saveTodos()
+	saved := updateTodos(func() bool {
+		todo.ID = 1
+		if len(todos) > 0 {
+			todo.ID = todos[len(todos)-1].ID + 1
+		}
+		todos = append(todos, todo)
+		return true
+	})
+	if !saved {
+		return
+	}
 	styles.SuccessStyle.Printf("Added todo #%d: %s [%s] %s\n", todo.ID, todo.Task, todo.Category, todo.Priority)
 }
 
@@ -165,17 +151,25 @@ func TodoComplete(args []string) {
 	id := 0
 	fmt.Sscanf(args[1], "%d", &id)
 
-	for i, todo := range todos {
-		if todo.ID == id {
-			todos[i].Completed = true
-			todos[i].CompletedAt = time.Now()
-			saveTodos()
-			styles.SuccessStyle.Printf("Completed todo #%d: %s\n", todo.ID, todo.Task)
-			return
+	var completed *Todo
+	saved := updateTodos(func() bool {
+		for i := range todos {
+			if todos[i].ID == id {
+				todos[i].Completed = true
+				todos[i].CompletedAt = time.Now()
+				completed = &todos[i]
+				return true
+			}
 		}
+		return false
+	})
+	if completed == nil {
+		styles.ErrorStyle.Printf("Todo #%d not found\n", id)
+		return
+	}
+	if saved {
+		styles.SuccessStyle.Printf("Completed todo #%d: %s\n", completed.ID, completed.Task)
 	}
-
-	styles.ErrorStyle.Printf("Todo #%d not found\n", id)
 }
 
 func TodoDelete(args []string) {
@@ -187,16 +181,24 @@ func TodoDelete(args []string) {
 	id := 0
 	fmt.Sscanf(args[1], "%d", &id)
 
-	for i, todo := range todos {
-		if todo.ID == id {
-			todos = append(todos[:i], todos[i+1:]...)
-			saveTodos()
-			styles.SuccessStyle.Printf("Deleted todo #%d: %s\n", todo.ID, todo.Task)
-			return
+	var deleted *Todo
+	saved := updateTodos(func() bool {
+		for i, todo := range todos {
+			if todo.ID == id {
+				todos = append(todos[:i], todos[i+1:]...)
+				deleted = &todo
+				return true
+			}
 		}
+		return false
+	})
+	if deleted == nil {
+		styles.ErrorStyle.Printf("Todo #%d not found\n", id)
+		return
+	}
+	if saved {
+		styles.SuccessStyle.Printf("Deleted todo #%d: %s\n", deleted.ID, deleted.Task)
 	}
-
-	styles.ErrorStyle.Printf("Todo #%d not found\n", id)
 }
 
 func TodoEdit(args []string) {
@@ -209,11 +211,15 @@ func TodoEdit(args []string) {
 	id := 0
 	fmt.Sscanf(args[1], "%d", &id)
 
-	for i, todo := range todos {
-		if todo.ID == id {
-			field := args[2]
-			value := args[3]
-
+	field := args[2]
+	value := args[3]
+	found := false
+	saved := updateTodos(func() bool {
+		for i, todo := range todos {
+			if todo.ID != id {
+				continue
+			}
+			found = true
 			switch field {
 			case "task":
 				todos[i].Task = value
@@ -229,17 +235,22 @@ func TodoEdit(args []string) {
 					todos[i].Priority = High
 				default:
 					styles.ErrorStyle.Println("Invalid priority. Use: low, medium, high")
-					return
+					return false
 				}
 			default:
 				styles.ErrorStyle.Println("Invalid field. Use: task, category, priority")
-				return
+				return false
 			}
-
-			saveTodos()
-			styles.SuccessStyle.Printf("Updated todo #%d\n", id)
-			return
+			return true
 		}
+		return false
+	})
+	if saved {
+		styles.SuccessStyle.Printf("Updated todo #%d\n", id)
+		return
+	}
+	if found {
+		return
 	}
 
 	styles.ErrorStyle.Printf("Todo #%d not found\n", id)
@@ -0,0 +1,258 @@
+package actions
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/tacheraSasi/ellie/llm"
+	"github.com/tacheraSasi/ellie/styles"
+	"github.com/tacheraSasi/ellie/utils"
+)
+
+// maxToolOutput caps how much command output is sent back to the model
+const maxToolOutput = 4000
+
+// chatTool exposes an Ellie command to the model during a chat
+type chatTool struct {
+	llm.Tool
+	// ReadOnly tools only inspect state, so they run without confirmation
+	ReadOnly bool
+	// command turns the call's arguments into the equivalent ellie command
+	// line, which is shown to the user and passed to run
+	command func(raw json.RawMessage) ([]string, error)
+	// run executes the command line in-process; it gets the whole line, so
+	// args[0] is the command name
+	run func(args []string)
+}
+
+// object builds a JSON schema for an arguments object
+func object(properties map[string]interface{}, required ...string) map[string]interface{} {
+	schema := map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// noArgs is the command builder for tools without arguments
+func noArgs(line ...string) func(json.RawMessage) ([]string, error) {
+	return func(json.RawMessage) ([]string, error) { return line, nil }
+}
+
+// chatTools is the curated set of commands the model may call
+var chatTools = []chatTool{
+	{
+		Tool: llm.Tool{
+			Name:        "todo_add",
+			Description: "Add a task to the user's Ellie todo list.",
+			Parameters: object(map[string]interface{}{
+				"task":     map[string]interface{}{"type": "string", "description": "What needs to be done"},
+				"category": map[string]interface{}{"type": "string", "description": "Category such as work or personal (default general)"},
+				"priority": map[string]interface{}{"type": "string", "enum": []string{"low", "medium", "high"}},
+			}, "task"),
+		},
+		command: func(raw json.RawMessage) ([]string, error) {
+			var params struct {
+				Task     string `json:"task"`
+				Category string `json:"category"`
+				Priority string `json:"priority"`
+			}
+			if err := json.Unmarshal(raw, &params); err != nil {
+				return nil, fmt.Errorf("invalid arguments: %w", err)
+			}
+			if strings.TrimSpace(params.Task) == "" {
+				return nil, fmt.Errorf("task is required")
+			}
+			if params.Category == "" {
+				params.Category = "general"
+			}
+			if params.Priority == "" {
+				params.Priority = "medium"
+			}
+			return []string{"todo", "add", params.Task, params.Category, params.Priority}, nil
+		},
+		// todo handlers take the line from the subcommand on
+		run: func(args []string) { TodoAdd(args[1:]) },
+	},
+	{
+		Tool: llm.Tool{
+			Name:        "git_status",
+			Description: "Show the git status of the current directory.",
+			Parameters:  object(map[string]interface{}{}),
+		},
+		ReadOnly: true,
+		command:  noArgs("git", "status"),
+		run: func(_ []string) {
+			// Not GitStatus: runGitCommand exits the process on failure
+			output, err := exec.Command("git", "status", "-sb").CombinedOutput()
+			fmt.Print(string(output))
+			if err != nil {
+				fmt.Printf("git status failed: %v\n", err)
+			}
+		},
+	},
+	{
+		Tool: llm.Tool{
+			Name:        "disk",
+			Description: "Show disk usage for a path.",
+			Parameters: object(map[string]interface{}{
+				"path": map[string]interface{}{"type": "string", "description": "Path to inspect (default current directory)"},
+			}),
+		},
+		ReadOnly: true,
+		command: func(raw json.RawMessage) ([]string, error) {
+			var params struct {
+				Path string `json:"path"`
+			}
+			if err := json.Unmarshal(raw, &params); err != nil {
+				return nil, fmt.Errorf("invalid arguments: %w", err)
+			}
+			if params.Path == "" {
+				params.Path = "."
+			}
+			return []string{"disk", params.Path}, nil
+		},
+		run: Disk,
+	},
+	{
+		Tool: llm.Tool{
+			Name:        "health",
+			Description: "Show the system health dashboard: CPU, memory, disk and network.",
+			Parameters:  object(map[string]interface{}{}),
+		},
+		ReadOnly: true,
+		command:  noArgs("health"),
+		run:      func(_ []string) { SystemHealth() },
+	},
+	{
+		Tool: llm.Tool{
+			Name:        "project_switch",
+			Description: "Switch the working directory to one of the user's saved Ellie projects.",
+			Parameters: object(map[string]interface{}{
+				"name": map[string]interface{}{"type": "string", "description": "Project name"},
+			}, "name"),
+		},
+		command: func(raw json.RawMessage) ([]string, error) {
+			var params struct {
+				Name string `json:"name"`
+			}
+			if err := json.Unmarshal(raw, &params); err != nil {
+				return nil, fmt.Errorf("invalid arguments: %w", err)
+			}
+			if params.Name == "" {
+				return nil, fmt.Errorf("name is required")
+			}
+			return []string{"switch", params.Name}, nil
+		},
+		run: ProjectSwitch,
+	},
+}
+
+// toolDefinitions returns the tools offered to the model
+func toolDefinitions() []llm.Tool {
+	tools := make([]llm.Tool, 0, len(chatTools))
+	for _, tool := range chatTools {
+		tools = append(tools, tool.Tool)
+	}
+	return tools
+}
+
+// confirmTool asks the user before a tool that changes something runs. It is
+// a variable so tests can answer for the user.
+var confirmTool = utils.AskForConfirmation
+
+// pauseSpinner stops the spinner of the answer being streamed, if any, so
+// tool output and confirmation prompts aren't drawn over. streamAnswer sets it.
+var pauseSpinner = func() {}
+
+// runTool executes a tool call from the model and returns its output
+func runTool(call llm.ToolCall) (string, error) {
+	var tool *chatTool
+	for i := range chatTools {
+		if chatTools[i].Name == call.Name {
+			tool = &chatTools[i]
+			break
+		}
+	}
+	if tool == nil {
+		return "", fmt.Errorf("unknown tool %q", call.Name)
+	}
+
+	args := call.Arguments
+	if len(args) == 0 {
+		args = json.RawMessage("{}")
+	}
+	line, err := tool.command(args)
+	if err != nil {
+		return "", err
+	}
+
+	pauseSpinner()
+	commandLine := "ellie " + strings.Join(line, " ")
+	if tool.ReadOnly {
+		styles.DimText.Printf("🔧 Running: %s\n", commandLine)
+	} else if !confirmTool(fmt.Sprintf("🔧 Ellie wants to run: %s. Allow?", commandLine)) {
+		styles.InfoStyle.Println("✅ Skipped.")
+		return "The user declined to run this command.", nil
+	}
+
+	output := captureOutput(func() { tool.run(line) })
+	if output == "" {
+		output = "Done."
+	}
+	return output, nil
+}
+
+// ansiEscape matches terminal color codes
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+// captureOutput runs fn and returns what it printed, without colors. The
+// output is still echoed to the terminal so the user sees what ran.
+func captureOutput(fn func()) string {
+	reader, writer, err := os.Pipe()
+	if err != nil {
+		fn()
+		return ""
+	}
+
+	stdout, colorOutput := os.Stdout, color.Output
+	os.Stdout, color.Output = writer, writer
+
+	var buf bytes.Buffer
+	done := make(chan struct{})
+	go func() {
+		io.Copy(io.MultiWriter(&buf, stdout), reader)
+		close(done)
+	}()
+
+	func() {
+		defer func() {
+			writer.Close()
+			os.Stdout, color.Output = stdout, colorOutput
+		}()
+		fn()
+	}()
+	<-done
+	reader.Close()
+
+	return truncateOutput(ansiEscape.ReplaceAllString(buf.String(), ""))
+}
+
+// truncateOutput trims output to maxToolOutput characters
+func truncateOutput(output string) string {
+	output = strings.TrimSpace(output)
+	if len(output) > maxToolOutput {
+		output = output[:maxToolOutput] + "\n... (output truncated)"
+	}
+	return output
+}
@@ -0,0 +1,146 @@
+package actions
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tacheraSasi/ellie/configs"
+	"github.com/tacheraSasi/ellie/llm"
+	"github.com/tacheraSasi/ellie/styles"
+)
+
+func TestChatToolCommands(t *testing.T) {
+	tests := []struct {
+		tool    string
+		args    string
+		want    []string
+		wantErr bool
+	}{
+		{"todo_add", `{"task":"ship v2"}`, []string{"todo", "add", "ship v2", "general", "medium"}, false},
+		{"todo_add", `{"task":"fix CI","category":"work","priority":"high"}`, []string{"todo", "add", "fix CI", "work", "high"}, false},
+		{"todo_add", `{"task":"  "}`, nil, true},
+		{"todo_add", `not json`, nil, true},
+		{"disk", `{}`, []string{"disk", "."}, false},
+		{"git_status", `{}`, []string{"git", "status"}, false},
+		{"project_switch", `{"name":"ellie"}`, []string{"switch", "ellie"}, false},
+		{"project_switch", `{}`, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tool+" "+tt.args, func(t *testing.T) {
+			var tool *chatTool
+			for i := range chatTools {
+				if chatTools[i].Name == tt.tool {
+					tool = &chatTools[i]
+				}
+			}
+			if tool == nil {
+				t.Fatalf("tool %q not found", tt.tool)
+			}
+
+			got, err := tool.command(json.RawMessage(tt.args))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("command() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("command() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChatToolSchemas(t *testing.T) {
+	for _, tool := range toolDefinitions() {
+		if tool.Name == "" || tool.Description == "" {
+			t.Errorf("tool %+v needs a name and description", tool)
+		}
+		if tool.Parameters["type"] != "object" {
+			t.Errorf("tool %s parameters should be an object schema", tool.Name)
+		}
+		if _, err := json.Marshal(tool.Parameters); err != nil {
+			t.Errorf("tool %s schema is not valid JSON: %v", tool.Name, err)
+		}
+	}
+}
+
+func TestRunTool_AsksBeforeChanges(t *testing.T) {
+	defer func(orig func(string) bool) { confirmTool = orig }(confirmTool)
+
+	asked := ""
+	confirmTool = func(prompt string) bool {
+		asked = prompt
+		return false
+	}
+
+	result, err := runTool(llm.ToolCall{Name: "todo_add", Arguments: json.RawMessage(`{"task":"write docs"}`)})
+	if err != nil {
+		t.Fatalf("runTool() error = %v", err)
+	}
+	if !strings.Contains(asked, "ellie todo add write docs") {
+		t.Errorf("confirmation prompt = %q, want the command line", asked)
+	}
+	if !strings.Contains(result, "declined") {
+		t.Errorf("runTool() = %q, want the model told the user declined", result)
+	}
+
+	if _, err := runTool(llm.ToolCall{Name: "rm_rf"}); err == nil {
+		t.Error("runTool() should reject unknown tools")
+	}
+}
+
+func TestCaptureOutput(t *testing.T) {
+	output := captureOutput(func() {
+		fmt.Println("plain line")
+		styles.SuccessStyle.Println("colored line")
+	})
+
+	if output != "plain line\ncolored line" {
+		t.Errorf("captureOutput() = %q", output)
+	}
+}
+
+func TestRunTool_PassesArguments(t *testing.T) {
+	defer func(orig func(string) bool) { confirmTool = orig }(confirmTool)
+	confirmTool = func(string) bool { return true }
+
+	savedDir, savedProjects := configs.ConfigDir, projects
+	configs.ConfigDir = t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.Chdir(wd)
+		configs.ConfigDir, projects = savedDir, savedProjects
+	})
+
+	dir, err := filepath.EvalSymlinks(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	projects = []Project{{Name: "site", Path: dir}}
+
+	result, err := runTool(llm.ToolCall{Name: "project_switch", Arguments: json.RawMessage(`{"name":"site"}`)})
+	if err != nil {
+		t.Fatalf("runTool() error = %v", err)
+	}
+	if !strings.Contains(result, "Switched to project 'site'") {
+		t.Errorf("runTool() = %q, want the project switched", result)
+	}
+	if cwd, _ := os.Getwd(); cwd != dir {
+		t.Errorf("working directory = %s, want %s", cwd, dir)
+	}
+
+	result, err = runTool(llm.ToolCall{Name: "disk", Arguments: json.RawMessage(`{"path":"` + filepath.Join(dir, "missing") + `"}`)})
+	if err != nil {
+		t.Fatalf("runTool() error = %v", err)
+	}
+	if !strings.Contains(result, dir) {
+		t.Errorf("runTool(disk) = %q, want %s checked", result, filepath.Join(dir, "missing"))
+	}
+}
@@ -0,0 +1,135 @@
+package actions
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/tacheraSasi/ellie/styles"
+	"github.com/tacheraSasi/ellie/usage"
+)
+
+// UsageReport shows this month's AI usage: totals, budget and top commands
+func UsageReport(_ []string) {
+	now := time.Now()
+	entries, ok := usageEntries(usage.MonthStart(now))
+	if !ok {
+		return
+	}
+
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	weekStart := today.AddDate(0, 0, -int((today.Weekday()+6)%7))
+
+	styles.InfoStyle.Println("📊 AI usage")
+	printTotal("Today", since(entries, today))
+	printTotal("This week", since(entries, weekStart))
+	printTotal("This month", entries)
+
+	budget := monthlyBudget()
+	if budget.Monthly > 0 {
+		spent := usage.Total(entries).Cost
+		action := "warn"
+		if budget.Block {
+			action = "block"
+		}
+		line := fmt.Sprintf("Budget: $%.2f of $%.2f spent (%.0f%%, %s when exceeded)", spent, budget.Monthly, spent/budget.Monthly*100, action)
+		if spent >= budget.Monthly {
+			styles.WarningStyle.Println("⚠️ " + line)
+		} else {
+			styles.DimText.Println(line)
+		}
+	}
+
+	if len(entries) > 0 {
+		fmt.Println()
+		printBreakdown("By command (this month)", usage.Breakdown(entries, usage.ByCommand))
+	}
+	styles.DimText.Println("\nMore: ellie usage daily|weekly|commands|models|users")
+}
+
+// UsageDaily shows usage per day for the last 30 days
+func UsageDaily(_ []string) {
+	entries, ok := usageEntries(time.Now().AddDate(0, 0, -30))
+	if ok {
+		printBreakdown("Daily usage (last 30 days)", usage.Breakdown(entries, usage.ByDay))
+	}
+}
+
+// UsageWeekly shows usage per ISO week for the last 12 weeks
+func UsageWeekly(_ []string) {
+	entries, ok := usageEntries(time.Now().AddDate(0, 0, -12*7))
+	if ok {
+		printBreakdown("Weekly usage (last 12 weeks)", usage.Breakdown(entries, usage.ByWeek))
+	}
+}
+
+// UsageCommands shows this month's usage per Ellie command
+func UsageCommands(_ []string) {
+	entries, ok := usageEntries(usage.MonthStart(time.Now()))
+	if ok {
+		printBreakdown("Usage by command (this month)", usage.Breakdown(entries, usage.ByCommand))
+	}
+}
+
+// UsageModels shows this month's usage per provider and model
+func UsageModels(_ []string) {
+	entries, ok := usageEntries(usage.MonthStart(time.Now()))
+	if ok {
+		printBreakdown("Usage by model (this month)", usage.Breakdown(entries, usage.ByModel))
+	}
+}
+
+// UsageUsers shows this month's usage per Ellie user, for shared API keys
+// whose ledger is collected in one place
+func UsageUsers(_ []string) {
+	entries, ok := usageEntries(usage.MonthStart(time.Now()))
+	if ok {
+		printBreakdown("Usage by user (this month)", usage.Breakdown(entries, usage.ByUser))
+	}
+}
+
+// usageEntries reads the ledger, reporting errors to the user
+func usageEntries(since time.Time) ([]usage.Entry, bool) {
+	entries, err := usageLedger().Entries(since)
+	if err != nil {
+		styles.ErrorStyle.Printf("Error reading usage: %v\n", err)
+		return nil, false
+	}
+	return entries, true
+}
+
+// since returns the entries at or after t; entries are sorted oldest first
+func since(entries []usage.Entry, t time.Time) []usage.Entry {
+	for i, e := range entries {
+		if !e.Time.Before(t) {
+			return entries[i:]
+		}
+	}
+	return nil
+}
+
+func printTotal(label string, entries []usage.Entry) {
+	total := usage.Total(entries)
+	fmt.Printf("  %-12s %s requests · %s tokens · %s\n", label,
+		styles.Cyan.Sprint(total.Requests), styles.Cyan.Sprint(total.Tokens), styles.Cyan.Sprintf("$%.4f", total.Cost))
+}
+
+func printBreakdown(title string, rows []usage.Row) {
+	if len(rows) == 0 {
+		styles.InfoStyle.Println("No AI usage recorded yet")
+		return
+	}
+
+	styles.InfoStyle.Println(title + ":")
+	fmt.Printf("  %-24s %10s %12s %12s\n", "", "requests", "tokens", "cost")
+	for _, row := range rows {
+		fmt.Printf("  %-24s %10d %12d %12s\n", row.Key, row.Requests, row.Tokens, fmt.Sprintf("$%.4f", row.Cost))
+	}
+
+	var total usage.Row
+	for _, row := range rows {
+		total.Requests += row.Requests
+		total.Tokens += row.Tokens
+		total.Cost += row.Cost
+	}
+	styles.DimText.Printf("  %-24s %10d %12d %12s\n", "total", total.Requests, total.Tokens, fmt.Sprintf("$%.4f", total.Cost))
+}
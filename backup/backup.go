@@ -0,0 +1,389 @@
+// Package backup snapshots a repository before destructive git operations
+// and restores those snapshots.
+//
+// A snapshot is a commit whose tree is the whole worktree, untracked files
+// included, and whose first parent is HEAD. It is kept under
+// refs/ellie/backup/ so it survives resets and garbage collection, and
+// records what it was taken for in trailers.
+package backup
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RefPrefix is where snapshots are kept
+const RefPrefix = "refs/ellie/backup/"
+
+// Keep is how many snapshots are kept; older ones are deleted when a new
+// one is taken
+const Keep = 50
+
+// Snapshot is a saved repository state
+type Snapshot struct {
+	// Name is the ref below RefPrefix, like 20240601-153012.345-reset
+	Name    string
+	Commit  string
+	Created time.Time
+	// Operation is the command the snapshot was taken before
+	Operation   string
+	Description string
+	// Branch is empty when HEAD was detached, and Head when nothing was
+	// committed yet
+	Branch string
+	Head   string
+	// Remote, RemoteBranch and RemoteHead record the remote branch a force
+	// push was about to overwrite
+	Remote       string
+	RemoteBranch string
+	RemoteHead   string
+}
+
+// Ref is the snapshot's full ref name
+func (s Snapshot) Ref() string {
+	return RefPrefix + s.Name
+}
+
+// IsRemote reports whether the snapshot restores a remote branch rather
+// than the local repository
+func (s Snapshot) IsRemote() bool {
+	return s.RemoteBranch != ""
+}
+
+// identity names the snapshot commits, so taking one never fails for want
+// of a configured user
+var identity = []string{
+	"GIT_AUTHOR_NAME=Ellie", "GIT_AUTHOR_EMAIL=ellie@localhost",
+	"GIT_COMMITTER_NAME=Ellie", "GIT_COMMITTER_EMAIL=ellie@localhost",
+}
+
+// git runs a git command with extra environment variables and stdin, and
+// returns its output with git's own message as the error
+var git = func(env []string, stdin string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(), env...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return stdout.String(), errors.New(msg)
+		}
+		return stdout.String(), fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
+
+// revParse resolves a ref, returning "" if it doesn't exist
+func revParse(ref string) string {
+	out, err := git(nil, "", "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+// Create snapshots the repository before an operation. Set Operation and
+// Description, and Remote and RemoteBranch before a force push; the rest
+// is filled in.
+func Create(s Snapshot) (Snapshot, error) {
+	s.Head = revParse("HEAD")
+	if out, err := git(nil, "", "symbolic-ref", "--quiet", "--short", "HEAD"); err == nil {
+		s.Branch = strings.TrimSpace(out)
+	}
+	if s.RemoteBranch != "" {
+		s.RemoteHead = revParse("refs/remotes/" + s.Remote + "/" + s.RemoteBranch)
+	}
+
+	tree, err := worktreeTree()
+	if err != nil {
+		return s, fmt.Errorf("reading the worktree: %w", err)
+	}
+	args := []string{"commit-tree", tree}
+	for _, parent := range []string{s.Head, s.RemoteHead} {
+		if parent != "" {
+			args = append(args, "-p", parent)
+		}
+	}
+	out, err := git(identity, s.message(), append(args, "-F", "-")...)
+	if err != nil {
+		return s, err
+	}
+	s.Commit = strings.TrimSpace(out)
+
+	now := time.Now()
+	// Names sort by time, so List can order by name
+	base := now.Format("20060102-150405.000") + "-" + s.Operation
+	for i := 1; ; i++ {
+		s.Name = base
+		if i > 1 {
+			s.Name = fmt.Sprintf("%s-%d", base, i)
+		}
+		// An empty old value makes update-ref refuse to replace an existing
+		// snapshot
+		if _, err := git(nil, "", "update-ref", s.Ref(), s.Commit, ""); err == nil {
+			break
+		} else if i >= 10 {
+			return s, err
+		}
+	}
+	s.Created = now
+	return s, prune()
+}
+
+// worktreeTree writes the worktree, untracked files included, as a tree
+// using a temporary index, leaving the real one alone
+func worktreeTree() (string, error) {
+	out, err := git(nil, "", "rev-parse", "--git-path", "ellie-backup-index")
+	if err != nil {
+		return "", err
+	}
+	index, err := filepath.Abs(strings.TrimSpace(out))
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(index)
+	os.Remove(index)
+
+	env := []string{"GIT_INDEX_FILE=" + index}
+	if revParse("HEAD") != "" {
+		if _, err := git(env, "", "read-tree", "HEAD"); err != nil {
+			return "", err
+		}
+	}
+	top, err := git(nil, "", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	// Ignored files are left out, as git clean leaves them too
+	if _, err := git(env, "", "-C", strings.TrimSpace(top), "add", "--all", "--", "."); err != nil {
+		return "", err
+	}
+	out, err = git(env, "", "write-tree")
+	return strings.TrimSpace(out), err
+}
+
+// message is the snapshot commit message, with the fields as trailers
+func (s Snapshot) message() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "ellie backup before %s", s.Operation)
+	if s.Description != "" {
+		fmt.Fprintf(&b, ": %s", s.Description)
+	}
+	b.WriteString("\n\n")
+	for _, field := range s.fields() {
+		if *field.value != "" {
+			fmt.Fprintf(&b, "%s: %s\n", field.key, *field.value)
+		}
+	}
+	return b.String()
+}
+
+func (s *Snapshot) fields() []struct {
+	key   string
+	value *string
+} {
+	return []struct {
+		key   string
+		value *string
+	}{
+		{"Ellie-Operation", &s.Operation},
+		{"Ellie-Description", &s.Description},
+		{"Ellie-Branch", &s.Branch},
+		{"Ellie-Head", &s.Head},
+		{"Ellie-Remote", &s.Remote},
+		{"Ellie-Remote-Branch", &s.RemoteBranch},
+		{"Ellie-Remote-Head", &s.RemoteHead},
+	}
+}
+
+// parse fills the fields from a snapshot commit message
+func (s *Snapshot) parse(message string) {
+	for _, line := range strings.Split(message, "\n") {
+		key, value, found := strings.Cut(line, ": ")
+		if !found {
+			continue
+		}
+		for _, field := range s.fields() {
+			if field.key == key {
+				*field.value = strings.TrimSpace(value)
+			}
+		}
+	}
+}
+
+const listFormat = "%(refname)%00%(objectname)%00%(creatordate:unix)%00%(contents)%1e"
+
+// List returns the snapshots, newest first
+func List() ([]Snapshot, error) {
+	out, err := git(nil, "", "for-each-ref", "--sort=-refname", "--format="+listFormat, RefPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return parseList(out), nil
+}
+
+func parseList(out string) []Snapshot {
+	var snapshots []Snapshot
+	for _, record := range strings.Split(out, "\x1e") {
+		fields := strings.SplitN(strings.TrimLeft(record, "\n"), "\x00", 4)
+		if len(fields) < 4 {
+			continue
+		}
+		s := Snapshot{Name: strings.TrimPrefix(fields[0], RefPrefix), Commit: fields[1]}
+		if unix, err := strconv.ParseInt(fields[2], 10, 64); err == nil {
+			s.Created = time.Unix(unix, 0)
+		}
+		s.parse(fields[3])
+		snapshots = append(snapshots, s)
+	}
+	return snapshots
+}
+
+// Find returns a snapshot by name, or by its number in List starting at 1
+func Find(id string) (Snapshot, error) {
+	snapshots, err := List()
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if n, err := strconv.Atoi(id); err == nil {
+		if n < 1 || n > len(snapshots) {
+			return Snapshot{}, fmt.Errorf("there is no snapshot %d; there are %d", n, len(snapshots))
+		}
+		return snapshots[n-1], nil
+	}
+	for _, s := range snapshots {
+		if s.Name == id || s.Ref() == id {
+			return s, nil
+		}
+	}
+	return Snapshot{}, fmt.Errorf("no snapshot named %q", id)
+}
+
+// prune deletes all but the newest Keep snapshots
+func prune() error {
+	snapshots, err := List()
+	if err != nil || len(snapshots) <= Keep {
+		return err
+	}
+	var stdin strings.Builder
+	for _, s := range snapshots[Keep:] {
+		fmt.Fprintf(&stdin, "delete %s\n", s.Ref())
+	}
+	_, err = git(nil, stdin.String(), "update-ref", "--stdin")
+	return err
+}
+
+// StashUntracked moves the untracked files into a new stash, so they can be
+// brought back with git stash pop. It returns false if there were none.
+func StashUntracked(message string) (bool, error) {
+	out, err := git(nil, "", "ls-files", "--others", "--exclude-standard", "--full-name", "-z", ":/")
+	if err != nil || out == "" {
+		return false, err
+	}
+	// Paths from ls-files --full-name are relative to the top level
+	top, err := git(nil, "", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return false, err
+	}
+	_, err = git(identity, out, "-C", strings.TrimSpace(top), "stash", "push", "--quiet", "--include-untracked",
+		"--message", message, "--pathspec-from-file=-", "--pathspec-file-nul")
+	return err == nil, err
+}
+
+// inProgress names an unfinished merge, rebase, cherry-pick or revert
+func inProgress() string {
+	for _, state := range []struct{ path, name string }{
+		{"rebase-merge", "rebase"},
+		{"rebase-apply", "rebase"},
+		{"MERGE_HEAD", "merge"},
+		{"CHERRY_PICK_HEAD", "cherry-pick"},
+		{"REVERT_HEAD", "revert"},
+	} {
+		out, err := git(nil, "", "rev-parse", "--git-path", state.path)
+		if err != nil {
+			continue
+		}
+		if _, err := os.Stat(strings.TrimSpace(out)); err == nil {
+			return state.name
+		}
+	}
+	return ""
+}
+
+// CheckRestore reports why Restore can't restore the snapshot now: a merge
+// or rebase is in progress, or a different branch is checked out
+func CheckRestore(s Snapshot) error {
+	if s.IsRemote() {
+		return errors.New("this snapshot restores a remote branch; use RestoreRemote")
+	}
+	if op := inProgress(); op != "" {
+		return fmt.Errorf("a %s is in progress; finish or abort it first", op)
+	}
+	branch := ""
+	if out, err := git(nil, "", "symbolic-ref", "--quiet", "--short", "HEAD"); err == nil {
+		branch = strings.TrimSpace(out)
+	}
+	if s.Branch != "" && branch != s.Branch {
+		return fmt.Errorf("the snapshot is of branch %s; switch to it first", s.Branch)
+	}
+	return nil
+}
+
+// Restore puts the repository back the way the snapshot saw it: the branch
+// at its old commit and the worktree, untracked files included, as it was.
+// Changes come back unstaged. Take a snapshot of the current state first,
+// since anything not in this one is overwritten.
+func Restore(s Snapshot) error {
+	if err := CheckRestore(s); err != nil {
+		return err
+	}
+	top, err := git(nil, "", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return err
+	}
+	dir := strings.TrimSpace(top)
+	if s.Head != "" {
+		if _, err := git(nil, "", "-C", dir, "reset", "--quiet", "--hard", s.Head); err != nil {
+			return err
+		}
+	}
+	if _, err := git(nil, "", "-C", dir, "read-tree", "-u", "--reset", s.Commit); err != nil {
+		return err
+	}
+	// Leave the index at HEAD, so the changes show as unstaged and files
+	// that were untracked are untracked again
+	if s.Head != "" {
+		_, err = git(nil, "", "-C", dir, "reset", "--quiet")
+	} else {
+		_, err = git(nil, "", "-C", dir, "read-tree", "--empty")
+	}
+	return err
+}
+
+// RestoreRemote pushes the commit a force push overwrote back to the remote
+// branch, as long as the remote still has what was pushed over it
+func RestoreRemote(s Snapshot) error {
+	if !s.IsRemote() {
+		return errors.New("this snapshot doesn't restore a remote branch")
+	}
+	if s.RemoteHead == "" {
+		return fmt.Errorf("%s/%s didn't exist before the force push, so there is nothing to restore", s.Remote, s.RemoteBranch)
+	}
+	target := "refs/heads/" + s.RemoteBranch
+	lease := "--force-with-lease=" + target
+	if current := revParse("refs/remotes/" + s.Remote + "/" + s.RemoteBranch); current != "" {
+		lease += ":" + current
+	}
+	_, err := git(nil, "", "push", lease, s.Remote, s.RemoteHead+":"+target)
+	return err
+}
@@ -0,0 +1,173 @@
+package backup
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestMessageRoundTrip(t *testing.T) {
+	s := Snapshot{Operation: "push-force", Description: "origin main", Branch: "main", Head: "abc", Remote: "origin", RemoteBranch: "main", RemoteHead: "def"}
+	message := s.message()
+	if !strings.HasPrefix(message, "ellie backup before push-force: origin main\n\n") {
+		t.Errorf("message() = %q", message)
+	}
+	var got Snapshot
+	got.parse(message)
+	if got != s {
+		t.Errorf("parse(message()) = %+v, want %+v", got, s)
+	}
+	if !got.IsRemote() {
+		t.Error("IsRemote() = false for a force push snapshot")
+	}
+}
+
+// newRepo creates a repository with two commits in a temporary directory
+// and changes into it
+func newRepo(t *testing.T) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	wd, _ := os.Getwd()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	for _, key := range []string{"AUTHOR", "COMMITTER"} {
+		t.Setenv("GIT_"+key+"_NAME", "Test")
+		t.Setenv("GIT_"+key+"_EMAIL", "test@example.com")
+	}
+	run(t, "init", "--quiet", "--initial-branch=main")
+	write(t, "a.txt", "one\n")
+	run(t, "add", "a.txt")
+	run(t, "commit", "--quiet", "-m", "one")
+	write(t, "a.txt", "two\n")
+	run(t, "commit", "--quiet", "-am", "two")
+}
+
+func run(t *testing.T, args ...string) string {
+	t.Helper()
+	out, err := exec.Command("git", args...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+	return string(out)
+}
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func read(path string) string {
+	data, _ := os.ReadFile(path)
+	return string(data)
+}
+
+func TestCreateAndRestore(t *testing.T) {
+	newRepo(t)
+	write(t, "a.txt", "edited\n")
+	write(t, "notes.txt", "untracked\n")
+	write(t, ".gitignore", "*.log\n")
+	write(t, "build.log", "ignored\n")
+	head := strings.TrimSpace(run(t, "rev-parse", "HEAD"))
+
+	s, err := Create(Snapshot{Operation: "reset", Description: "--hard HEAD~1"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if s.Branch != "main" || s.Head != head || s.Name == "" {
+		t.Errorf("Create() = %+v", s)
+	}
+	if status := run(t, "status", "--porcelain"); !strings.Contains(status, " M a.txt") || !strings.Contains(status, "?? notes.txt") {
+		t.Errorf("Create() changed the worktree or index:\n%s", status)
+	}
+
+	run(t, "reset", "--quiet", "--hard", "HEAD~1")
+	run(t, "clean", "--quiet", "-fd")
+	if read("notes.txt") != "" || read("build.log") != "ignored\n" {
+		t.Fatal("setup: clean didn't remove the untracked files")
+	}
+
+	found, err := Find("1")
+	if err != nil || found.Name != s.Name || found.Operation != "reset" || found.Head != head {
+		t.Fatalf("Find(1) = %+v, %v", found, err)
+	}
+	if err := Restore(found); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if got := strings.TrimSpace(run(t, "rev-parse", "HEAD")); got != head {
+		t.Errorf("HEAD = %s, want %s", got, head)
+	}
+	if read("a.txt") != "edited\n" || read("notes.txt") != "untracked\n" {
+		t.Errorf("worktree not restored: a.txt = %q, notes.txt = %q", read("a.txt"), read("notes.txt"))
+	}
+	status := run(t, "status", "--porcelain")
+	if !strings.Contains(status, " M a.txt") || !strings.Contains(status, "?? notes.txt") {
+		t.Errorf("changes should be unstaged after Restore:\n%s", status)
+	}
+}
+
+func TestRestoreChecksBranch(t *testing.T) {
+	newRepo(t)
+	s, err := Create(Snapshot{Operation: "rebase"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run(t, "switch", "--quiet", "--create", "other")
+	if err := Restore(s); err == nil || !strings.Contains(err.Error(), "switch to it first") {
+		t.Errorf("Restore() on another branch error = %v", err)
+	}
+}
+
+func TestList(t *testing.T) {
+	newRepo(t)
+	var names []string
+	for _, op := range []string{"reset", "clean", "rebase"} {
+		s, err := Create(Snapshot{Operation: op})
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, s.Name)
+	}
+	snapshots, err := List()
+	if err != nil || len(snapshots) != 3 {
+		t.Fatalf("List() = %+v, %v", snapshots, err)
+	}
+	for i, s := range snapshots {
+		if want := names[len(names)-1-i]; s.Name != want {
+			t.Errorf("List()[%d] = %s, want %s (newest first)", i, s.Name, want)
+		}
+	}
+	if _, err := Find("4"); err == nil {
+		t.Error("Find(4) should fail with three snapshots")
+	}
+	if s, err := Find(names[0]); err != nil || s.Operation != "reset" {
+		t.Errorf("Find(name) = %+v, %v", s, err)
+	}
+}
+
+func TestStashUntracked(t *testing.T) {
+	newRepo(t)
+	if stashed, err := StashUntracked("test"); stashed || err != nil {
+		t.Errorf("StashUntracked() with nothing untracked = %v, %v", stashed, err)
+	}
+
+	write(t, "a.txt", "edited\n")
+	os.Mkdir("dir", 0o755)
+	write(t, "dir/new.txt", "new\n")
+	if stashed, err := StashUntracked("test"); !stashed || err != nil {
+		t.Fatalf("StashUntracked() = %v, %v", stashed, err)
+	}
+	if read("dir/new.txt") != "" || read("a.txt") != "edited\n" {
+		t.Error("StashUntracked() should move only the untracked files")
+	}
+	run(t, "stash", "pop", "--quiet")
+	if read("dir/new.txt") != "new\n" {
+		t.Error("git stash pop didn't bring the untracked file back")
+	}
+}
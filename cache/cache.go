@@ -0,0 +1,212 @@
+// Package cache stores AI responses on disk, keyed by a hash of everything
+// that determines them, so repeated requests are answered without calling
+// the provider.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tacheraSasi/ellie/llm"
+	"github.com/tacheraSasi/ellie/store"
+)
+
+// Entry is one cached response
+type Entry struct {
+	Key       string    `json:"key"`
+	Command   string    `json:"command"`
+	Provider  string    `json:"provider"`
+	Model     string    `json:"model"`
+	Subject   string    `json:"subject,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at,omitempty"`
+	Response  string    `json:"response"`
+	// Usage is what the original request cost; every hit saves as much
+	Usage llm.Usage `json:"usage"`
+	Hits  int       `json:"hits"`
+}
+
+// Expired reports whether the entry's TTL has passed
+func (e *Entry) Expired(now time.Time) bool {
+	return !e.ExpiresAt.IsZero() && now.After(e.ExpiresAt)
+}
+
+// Key hashes the parts that determine a response, such as command, provider,
+// model, prompt and the hash of the file being sent
+func Key(parts ...string) string {
+	h := sha256.New()
+	for _, part := range parts {
+		// Length prefixes keep ("ab", "c") and ("a", "bc") apart
+		fmt.Fprintf(h, "%d:%s", len(part), part)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// HashContent returns the hex SHA-256 of data
+func HashContent(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// Store keeps one JSON file per entry in a directory
+type Store struct {
+	dir string
+	ttl time.Duration
+}
+
+// NewStore returns a store in dir whose entries live for ttl; zero means
+// entries never expire
+func NewStore(dir string, ttl time.Duration) *Store {
+	return &Store{dir: dir, ttl: ttl}
+}
+
+func (s *Store) path(key string) string {
+	return filepath.Join(s.dir, key+".json")
+}
+
+// Get returns the entry for key. Expired entries are removed and reported
+// as missing. A hit is counted on the entry.
+func (s *Store) Get(key string) (*Entry, bool) {
+	entry, err := s.read(s.path(key))
+	if err != nil {
+		return nil, false
+	}
+	if entry.Expired(time.Now()) {
+		os.Remove(s.path(key))
+		return nil, false
+	}
+
+	entry.Hits++
+	// Failing to count a hit shouldn't fail the lookup
+	s.write(entry)
+	return entry, true
+}
+
+// Put stores an entry under its key, setting its creation and expiry times
+func (s *Store) Put(entry *Entry) error {
+	if entry.Key == "" {
+		return fmt.Errorf("cache entry has no key")
+	}
+	entry.CreatedAt = time.Now()
+	if s.ttl > 0 {
+		entry.ExpiresAt = entry.CreatedAt.Add(s.ttl)
+	}
+	return s.write(entry)
+}
+
+func (s *Store) write(entry *Entry) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("error creating cache directory: %w", err)
+	}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding cache entry: %w", err)
+	}
+	// Written whole, as workers and counted hits write entries concurrently
+	if err := store.WriteAtomic(s.path(entry.Key), data, 0600); err != nil {
+		return fmt.Errorf("error writing cache entry: %w", err)
+	}
+	return nil
+}
+
+func (s *Store) read(path string) (*Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entry Entry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("error parsing cache entry %s: %w", filepath.Base(path), err)
+	}
+	return &entry, nil
+}
+
+// entries returns the paths of all entry files
+func (s *Store) entries() ([]string, error) {
+	files, err := os.ReadDir(s.dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading cache directory: %w", err)
+	}
+
+	var paths []string
+	for _, f := range files {
+		if !f.IsDir() && strings.HasSuffix(f.Name(), ".json") {
+			paths = append(paths, filepath.Join(s.dir, f.Name()))
+		}
+	}
+	return paths, nil
+}
+
+// Stats summarizes the cache
+type Stats struct {
+	Entries int
+	Expired int
+	Bytes   int64
+	Hits    int
+	// SavedTokens is the tokens the hits would have used
+	SavedTokens int
+	// Saved lists every live entry, for cost estimates per model
+	Saved []Entry
+}
+
+// Stats reads every entry and summarizes the cache
+func (s *Store) Stats() (Stats, error) {
+	var stats Stats
+	paths, err := s.entries()
+	if err != nil {
+		return stats, err
+	}
+
+	now := time.Now()
+	for _, path := range paths {
+		if info, err := os.Stat(path); err == nil {
+			stats.Bytes += info.Size()
+		}
+		entry, err := s.read(path)
+		if err != nil {
+			continue
+		}
+		if entry.Expired(now) {
+			stats.Expired++
+			continue
+		}
+		stats.Entries++
+		stats.Hits += entry.Hits
+		stats.SavedTokens += entry.Hits * entry.Usage.TotalTokens
+		stats.Saved = append(stats.Saved, *entry)
+	}
+	return stats, nil
+}
+
+// Clear removes entries and returns how many were removed. With expiredOnly
+// only entries past their TTL (or unreadable) are removed.
+func (s *Store) Clear(expiredOnly bool) (int, error) {
+	paths, err := s.entries()
+	if err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	removed := 0
+	for _, path := range paths {
+		if expiredOnly {
+			if entry, err := s.read(path); err == nil && !entry.Expired(now) {
+				continue
+			}
+		}
+		if err := os.Remove(path); err != nil {
+			return removed, fmt.Errorf("error removing cache entry: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
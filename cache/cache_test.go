@@ -0,0 +1,131 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tacheraSasi/ellie/llm"
+)
+
+func TestKey(t *testing.T) {
+	if Key("review", "openai", "gpt-4o") != Key("review", "openai", "gpt-4o") {
+		t.Error("Key() should be stable")
+	}
+	if Key("ab", "c") == Key("a", "bc") {
+		t.Error("Key() should keep part boundaries apart")
+	}
+	if Key("review", "openai", "gpt-4o") == Key("review", "openai", "gpt-4o-mini") {
+		t.Error("Key() should depend on every part")
+	}
+}
+
+func TestStore_PutGet(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "cache"), time.Hour)
+	key := Key("review", "openai", "gpt-4o", "prompt", HashContent([]byte("code")))
+
+	if _, ok := store.Get(key); ok {
+		t.Fatal("Get() on an empty cache should miss")
+	}
+
+	err := store.Put(&Entry{Key: key, Model: "gpt-4o", Response: "Looks good", Usage: llm.Usage{TotalTokens: 100}})
+	if err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		entry, ok := store.Get(key)
+		if !ok || entry.Response != "Looks good" {
+			t.Fatalf("Get() = %+v, %v", entry, ok)
+		}
+	}
+
+	stats, err := store.Stats()
+	if err != nil {
+		t.Fatalf("Stats() error = %v", err)
+	}
+	if stats.Entries != 1 || stats.Hits != 2 || stats.SavedTokens != 200 || stats.Bytes == 0 {
+		t.Errorf("Stats() = %+v, want one entry with two hits saving 200 tokens", stats)
+	}
+
+	if err := store.Put(&Entry{}); err == nil {
+		t.Error("Put() should reject entries without a key")
+	}
+}
+
+func TestStore_ConcurrentHits(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "cache"), time.Hour)
+	key := Key("review", "chunk")
+	response := string(strings.Repeat("x", 1<<20))
+	if err := store.Put(&Entry{Key: key, Response: response}); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if entry, ok := store.Get(key); !ok || entry.Response != response {
+					t.Error("Get() read a torn entry")
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestStore_Expiry(t *testing.T) {
+	dir := t.TempDir()
+	store := NewStore(dir, time.Hour)
+
+	store.Put(&Entry{Key: "fresh", Response: "a"})
+	stale := &Entry{Key: "stale", Response: "b"}
+	store.Put(stale)
+	stale.ExpiresAt = time.Now().Add(-time.Minute)
+	store.write(stale)
+	os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0600)
+
+	stats, _ := store.Stats()
+	if stats.Entries != 1 || stats.Expired != 1 {
+		t.Errorf("Stats() = %+v, want one live and one expired entry", stats)
+	}
+
+	removed, err := store.Clear(true)
+	if err != nil || removed != 2 {
+		t.Fatalf("Clear(expiredOnly) = %d, %v, want the expired and broken entries removed", removed, err)
+	}
+	if _, ok := store.Get("fresh"); !ok {
+		t.Error("Clear(expiredOnly) removed a live entry")
+	}
+
+	store.write(stale)
+	if _, ok := store.Get("stale"); ok {
+		t.Error("Get() should miss expired entries")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "stale.json")); !os.IsNotExist(err) {
+		t.Error("Get() should remove expired entries")
+	}
+
+	removed, _ = store.Clear(false)
+	if removed != 1 {
+		t.Errorf("Clear() removed %d entries, want 1", removed)
+	}
+}
+
+func TestStore_NoTTL(t *testing.T) {
+	store := NewStore(t.TempDir(), 0)
+	entry := &Entry{Key: "k", Response: "a"}
+	store.Put(entry)
+	if !entry.ExpiresAt.IsZero() {
+		t.Error("Put() without a TTL should not set an expiry")
+	}
+	if _, ok := store.Get("k"); !ok {
+		t.Error("Get() should find entries without an expiry")
+	}
+}
@@ -0,0 +1,156 @@
+package chat
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/tacheraSasi/ellie/llm"
+)
+
+// TokenEstimator returns the approximate number of tokens in text
+type TokenEstimator func(text string) int
+
+// EstimateTokens is the default TokenEstimator. It assumes roughly four
+// characters per token, which is close enough for English text and code
+// across the supported models.
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 3) / 4
+}
+
+// DefaultContextWindow is used for models missing from contextWindows
+const DefaultContextWindow = 8192
+
+// contextWindows lists context sizes by model name prefix. More specific
+// prefixes come first.
+var contextWindows = []struct {
+	prefix string
+	tokens int
+}{
+	{"gpt-4o", 128000},
+	{"gpt-4.1", 1000000},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4", 8192},
+	{"gpt-3.5-turbo", 16385},
+	{"o1", 128000},
+	{"o3", 200000},
+	{"o4", 200000},
+	{"gemini-1.5", 1000000},
+	{"gemini-2", 1000000},
+	{"gemini-pro", 32768},
+	{"claude", 200000},
+	{"llama3.1", 128000},
+	{"llama3.2", 128000},
+	{"llama3", 8192},
+	{"qwen2.5", 32768},
+	{"mistral", 32768},
+}
+
+// ContextWindow returns the context size in tokens for a model
+func ContextWindow(model string) int {
+	model = strings.ToLower(model)
+	for _, w := range contextWindows {
+		if strings.HasPrefix(model, w.prefix) {
+			return w.tokens
+		}
+	}
+	return DefaultContextWindow
+}
+
+const (
+	// messageOverhead approximates the tokens each message costs for its role
+	// and separators
+	messageOverhead = 4
+	// keepRecent is the number of latest messages never compacted away
+	keepRecent = 4
+)
+
+// SetTokenBudget sets how many tokens a request may use, answer included.
+// It defaults to the context window of the provider's model.
+func (s *ChatSession) SetTokenBudget(tokens int) {
+	s.budget = tokens
+}
+
+// SetTokenEstimator replaces EstimateTokens, e.g. with a real tokenizer
+func (s *ChatSession) SetTokenEstimator(estimate TokenEstimator) {
+	s.estimate = estimate
+}
+
+// Summary returns the rolling summary of turns no longer sent verbatim
+func (s *ChatSession) Summary() string {
+	return s.summary
+}
+
+// requestTokens estimates the size of the next request including incoming
+func (s *ChatSession) requestTokens(incoming string) int {
+	total := s.estimate(incoming) + messageOverhead
+	for _, msg := range s.request() {
+		total += s.estimate(msg.Content) + messageOverhead
+	}
+	return total
+}
+
+// fit keeps the next request within the token budget. A quarter of the
+// budget is left for the answer; when the request would use more than the
+// rest, the oldest turns are folded into the rolling summary (or just
+// dropped if summarizing fails) until it is down to half. It reports whether
+// any turns were compacted.
+func (s *ChatSession) fit(ctx context.Context, incoming string) bool {
+	limit := s.budget - s.budget/4
+	total := s.requestTokens(incoming)
+	if total <= limit {
+		return false
+	}
+
+	cut := s.start
+	for cut < len(s.messages)-keepRecent && total > limit/2 {
+		total -= s.estimate(s.messages[cut].Content) + messageOverhead
+		cut++
+	}
+	// Resume on a user turn so the window never opens with an answer
+	for cut < len(s.messages)-1 && s.messages[cut].Role != "user" {
+		cut++
+	}
+	if cut <= s.start {
+		return false
+	}
+
+	if summary, err := s.summarize(ctx, s.messages[s.start:cut]); err == nil {
+		s.summary = summary
+	}
+	s.start = cut
+	return true
+}
+
+// summarize asks the provider to fold turns into the rolling summary
+func (s *ChatSession) summarize(ctx context.Context, turns []llm.Message) (string, error) {
+	var transcript strings.Builder
+	if s.summary != "" {
+		fmt.Fprintf(&transcript, "Summary so far:\n%s\n\n", s.summary)
+	}
+	transcript.WriteString("New conversation turns:\n")
+	for _, msg := range turns {
+		fmt.Fprintf(&transcript, "%s: %s\n", msg.Role, msg.Content)
+	}
+
+	response, err := s.provider.Chat(ctx, []llm.Message{
+		{
+			Role: "system",
+			Content: "You maintain a running summary of a conversation between a user and Ellie, a CLI assistant. " +
+				"Merge the new turns into the summary. Keep facts, decisions, file names, commands and open questions. " +
+				"Answer with the updated summary only, in at most 200 words.",
+		},
+		{Role: "user", Content: transcript.String()},
+	})
+	if err != nil {
+		return "", err
+	}
+	s.addUsage(response.Usage)
+
+	summary := strings.TrimSpace(response.Content)
+	if summary == "" {
+		return "", fmt.Errorf("empty summary")
+	}
+	return summary, nil
+}
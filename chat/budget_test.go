@@ -0,0 +1,91 @@
+package chat
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestContextWindow(t *testing.T) {
+	tests := []struct {
+		model string
+		want  int
+	}{
+		{"gpt-4o-mini", 128000},
+		{"gpt-4", 8192},
+		{"GPT-3.5-Turbo", 16385},
+		{"claude-3-5-sonnet-latest", 200000},
+		{"gemini-1.5-flash", 1000000},
+		{"llama3.2", 128000},
+		{"ellie-api", DefaultContextWindow},
+	}
+
+	for _, tt := range tests {
+		if got := ContextWindow(tt.model); got != tt.want {
+			t.Errorf("ContextWindow(%q) = %d, want %d", tt.model, got, tt.want)
+		}
+	}
+}
+
+func wordCount(text string) int {
+	return len(strings.Fields(text))
+}
+
+func TestChatSession_CompactsOldTurns(t *testing.T) {
+	provider := &MockProvider{responses: []string{"a short answer here"}}
+	session := NewChatSession(provider)
+	session.SetTokenEstimator(wordCount)
+	session.SetTokenBudget(120)
+
+	for i := 0; i < 6; i++ {
+		if _, err := session.SendMessage("please explain this rather long question about the build again"); err != nil {
+			t.Fatalf("SendMessage() error = %v", err)
+		}
+	}
+
+	if session.Summary() == "" {
+		t.Fatal("old turns should have been summarized")
+	}
+	if len(session.Messages()) != 12 {
+		t.Errorf("Messages() = %d, the full conversation should be kept", len(session.Messages()))
+	}
+
+	sent := session.request()
+	if sent[0].Role != "system" || !strings.Contains(sent[0].Content, session.Summary()) {
+		t.Errorf("request should start with the summary, got %+v", sent[0])
+	}
+	if len(sent) >= 12 {
+		t.Errorf("request has %d messages, compacted turns should not be resent", len(sent))
+	}
+	if sent[1].Role != "user" {
+		t.Errorf("window should open on a user turn, got %q", sent[1].Role)
+	}
+	if total := session.requestTokens(""); total > 120 {
+		t.Errorf("request uses %d tokens, over the budget", total)
+	}
+}
+
+func TestChatSession_DeduplicatesUserContext(t *testing.T) {
+	provider := &MockProvider{responses: []string{"ok"}}
+	session := NewChatSession(provider)
+
+	send := func(msg string) string {
+		t.Helper()
+		if _, err := session.SendMessage(msg); err != nil {
+			t.Fatalf("SendMessage() error = %v", err)
+		}
+		return session.messages[len(session.messages)-2].Content
+	}
+
+	session.SetUserContext("cwd: /repo")
+	if got := send("first"); !strings.Contains(got, "cwd: /repo") {
+		t.Errorf("first turn should carry the context, got %q", got)
+	}
+	if got := send("second"); got != "second" {
+		t.Errorf("unchanged context should not be resent, got %q", got)
+	}
+
+	session.SetUserContext("cwd: /other")
+	if got := send("third"); !strings.Contains(got, "cwd: /other") {
+		t.Errorf("changed context should be sent, got %q", got)
+	}
+}
@@ -1,6 +1,8 @@
 package chat
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -10,9 +12,26 @@ import (
 
 // ChatSession represents an ongoing chat session
 type ChatSession struct {
-	provider llm.Provider
-	messages []llm.Message
-	history  []string
+	provider     llm.Provider
+	systemPrompt string
+	messages     []llm.Message
+	history      []string
+	usage        llm.Usage
+
+	// budget and estimate bound the size of each request. Messages before
+	// start are no longer sent; summary stands in for them.
+	budget   int
+	estimate TokenEstimator
+	start    int
+	summary  string
+
+	// userContext is attached to the next user turn when it differs from
+	// sentContext, the last context the model has seen
+	userContext string
+	sentContext string
+
+	tools      []llm.Tool
+	handleTool ToolHandler
 }
 
 // NewChatSession creates a new chat session with the specified provider
@@ -21,11 +40,83 @@ func NewChatSession(provider llm.Provider) *ChatSession {
 		provider: provider,
 		messages: make([]llm.Message, 0),
 		history:  make([]string, 0),
+		budget:   ContextWindow(provider.GetModel()),
+		estimate: EstimateTokens,
+	}
+}
+
+// SetSystemPrompt sets the instructions sent ahead of every request as a
+// system message. It is not part of the history and survives ClearHistory.
+func (s *ChatSession) SetSystemPrompt(prompt string) {
+	s.systemPrompt = prompt
+}
+
+// SystemPrompt returns the session's system prompt
+func (s *ChatSession) SystemPrompt() string {
+	return s.systemPrompt
+}
+
+// SetUserContext sets environment details (working directory, git branch...)
+// for the model. They are attached to the next message only when they changed
+// since the model last saw them, so unchanged context isn't resent each turn.
+func (s *ChatSession) SetUserContext(context string) {
+	s.userContext = context
+}
+
+// request returns the messages to send to the provider: the system prompt,
+// the summary of compacted turns, then the turns still in the window. Tool
+// exchanges are left out when tools are not in use.
+func (s *ChatSession) request() []llm.Message {
+	window := s.messages[s.start:]
+	if _, ok := s.toolProvider(); !ok {
+		window = withoutToolCalls(window)
+	}
+	messages := make([]llm.Message, 0, len(window)+2)
+	if s.systemPrompt != "" {
+		messages = append(messages, llm.Message{Role: "system", Content: s.systemPrompt})
 	}
+	if s.summary != "" {
+		messages = append(messages, llm.Message{
+			Role:    "system",
+			Content: "Summary of the earlier conversation:\n" + s.summary,
+		})
+	}
+	return append(messages, window...)
+}
+
+// withoutToolCalls strips tool calls and results from messages, e.g. for a
+// resumed session whose provider doesn't support them
+func withoutToolCalls(messages []llm.Message) []llm.Message {
+	stripped := make([]llm.Message, 0, len(messages))
+	for _, msg := range messages {
+		if msg.Role == "tool" || (len(msg.ToolCalls) > 0 && msg.Content == "") {
+			continue
+		}
+		msg.ToolCalls = nil
+		stripped = append(stripped, msg)
+	}
+	return stripped
+}
+
+// beginTurn makes room for content within the token budget and attaches the
+// user context if the model hasn't seen it yet
+func (s *ChatSession) beginTurn(ctx context.Context, content string) string {
+	if s.fit(ctx, content+s.userContext) {
+		// The turn that carried the context may have been compacted away
+		s.sentContext = ""
+	}
+	if s.userContext == "" || s.userContext == s.sentContext {
+		return content
+	}
+	s.sentContext = s.userContext
+	return fmt.Sprintf("%s\n\nCurrent Context:\n%s", content, s.userContext)
 }
 
 // SendMessage sends a message to the LLM and returns the response
 func (s *ChatSession) SendMessage(content string) (string, error) {
+	content = s.beginTurn(context.Background(), content)
+	turn := len(s.messages)
+
 	// Add user message to history
 	s.messages = append(s.messages, llm.Message{
 		Role:    "user",
@@ -34,11 +125,16 @@ func (s *ChatSession) SendMessage(content string) (string, error) {
 	s.history = append(s.history, fmt.Sprintf("User: %s", content))
 
 	// Get response from LLM
-	response, err := s.provider.Chat(s.messages)
+	response, err := s.complete(context.Background(), nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to get response from LLM: %w", err) //Note: If this occurs
-		//Its probably because the user is offline
+		// Drop the unanswered turn, with any tool calls made for it, as
+		// SendMessageStream does
+		s.messages = s.messages[:turn]
+		s.history = s.history[:len(s.history)-1]
+		s.sentContext = ""
+		return "", fmt.Errorf("failed to get response from LLM: %w", err)
 	}
+	s.addUsage(response.Usage)
 
 	// Add assistant message to history
 	s.messages = append(s.messages, llm.Message{
@@ -50,6 +146,51 @@ func (s *ChatSession) SendMessage(content string) (string, error) {
 	return response.Content, nil
 }
 
+// SendMessageStream sends a message to the LLM and streams the answer through
+// onChunk as it is generated. If ctx is cancelled mid-answer, the partial
+// answer is kept in the history and returned together with ctx's error.
+func (s *ChatSession) SendMessageStream(ctx context.Context, content string, onChunk llm.StreamHandler) (string, error) {
+	content = s.beginTurn(ctx, content)
+	turn := len(s.messages)
+	s.messages = append(s.messages, llm.Message{
+		Role:    "user",
+		Content: content,
+	})
+	s.history = append(s.history, fmt.Sprintf("User: %s", content))
+
+	response, err := s.complete(ctx, onChunk)
+
+	partial := ""
+	if response != nil {
+		partial = response.Content
+		s.addUsage(response.Usage)
+	}
+
+	interrupted := err != nil && ctx.Err() != nil
+	if err != nil && (!interrupted || partial == "") {
+		// Drop the unanswered turn so the next message starts clean
+		s.messages = s.messages[:turn]
+		s.history = s.history[:len(s.history)-1]
+		// The dropped turn may have carried the context; send it again
+		s.sentContext = ""
+		if interrupted {
+			return "", ctx.Err()
+		}
+		return "", fmt.Errorf("failed to get response from LLM: %w", err)
+	}
+
+	s.messages = append(s.messages, llm.Message{
+		Role:    "assistant",
+		Content: partial,
+	})
+	s.history = append(s.history, fmt.Sprintf("Assistant: %s", partial))
+
+	if interrupted {
+		return partial, ctx.Err()
+	}
+	return partial, nil
+}
+
 // GetHistory returns the chat history
 func (s *ChatSession) GetHistory() []string {
 	return s.history
@@ -59,6 +200,60 @@ func (s *ChatSession) GetHistory() []string {
 func (s *ChatSession) ClearHistory() {
 	s.messages = make([]llm.Message, 0)
 	s.history = make([]string, 0)
+	s.start, s.summary, s.sentContext = 0, "", ""
+}
+
+// Messages returns a copy of the whole conversation, including turns that
+// were compacted into the summary, without the system prompt
+func (s *ChatSession) Messages() []llm.Message {
+	return append([]llm.Message(nil), s.messages...)
+}
+
+// Usage returns the tokens used by the session so far
+func (s *ChatSession) Usage() llm.Usage {
+	return s.usage
+}
+
+// Restore replaces the conversation with previously saved messages and usage,
+// e.g. to resume a session from a Transcript
+func (s *ChatSession) Restore(messages []llm.Message, usage llm.Usage) {
+	s.messages = append(make([]llm.Message, 0, len(messages)), messages...)
+	s.history = make([]string, 0, len(messages))
+	for _, msg := range messages {
+		if msg.Role == "tool" || (len(msg.ToolCalls) > 0 && msg.Content == "") {
+			continue
+		}
+		speaker := "User"
+		if msg.Role == "assistant" {
+			speaker = "Assistant"
+		}
+		s.history = append(s.history, fmt.Sprintf("%s: %s", speaker, msg.Content))
+	}
+	s.usage = usage
+	s.start, s.summary, s.sentContext = 0, "", ""
+}
+
+func (s *ChatSession) addUsage(usage llm.Usage) {
+	s.usage.PromptTokens += usage.PromptTokens
+	s.usage.CompletionTokens += usage.CompletionTokens
+	s.usage.TotalTokens += usage.TotalTokens
+}
+
+// DescribeError turns an error from SendMessage or SendMessageStream into a
+// short explanation fit for the terminal
+func DescribeError(err error) string {
+	switch {
+	case errors.Is(err, llm.ErrOffline):
+		return "You're offline: Ellie couldn't reach the AI service. Check your connection and try again."
+	case errors.Is(err, llm.ErrTimeout):
+		return "The AI service took too long to answer. Please try again."
+	case errors.Is(err, llm.ErrRateLimited):
+		return "The AI provider is rate limiting requests. Wait a moment and try again."
+	case errors.Is(err, llm.ErrUnauthorized):
+		return "The AI provider rejected the API key. Check the key in your Ellie config."
+	default:
+		return err.Error()
+	}
 }
 
 // FormatMessage formats a message with timestamp
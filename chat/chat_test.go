@@ -1,6 +1,8 @@
 package chat
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"testing"
@@ -13,9 +15,12 @@ import (
 type MockProvider struct {
 	responses []string
 	index     int
+	// lastMessages is the conversation received by the latest call
+	lastMessages []llm.Message
 }
 
-func (m *MockProvider) Chat(messages []llm.Message) (*llm.Response, error) {
+func (m *MockProvider) Chat(ctx context.Context, messages []llm.Message) (*llm.Response, error) {
+	m.lastMessages = messages
 	if m.index >= len(m.responses) {
 		m.index = 0
 	}
@@ -31,6 +36,22 @@ func (m *MockProvider) Chat(messages []llm.Message) (*llm.Response, error) {
 	}, nil
 }
 
+func (m *MockProvider) ChatStream(ctx context.Context, messages []llm.Message, onChunk llm.StreamHandler) (*llm.Response, error) {
+	response, err := m.Chat(ctx, messages)
+	if err != nil {
+		return nil, err
+	}
+	var sent strings.Builder
+	for _, word := range strings.SplitAfter(response.Content, " ") {
+		if ctx.Err() != nil {
+			return &llm.Response{Content: sent.String()}, ctx.Err()
+		}
+		sent.WriteString(word)
+		onChunk(word)
+	}
+	return response, nil
+}
+
 func (m *MockProvider) GetModel() string {
 	return "mock-model"
 }
@@ -87,6 +108,63 @@ func TestChatSession_SendMessage(t *testing.T) {
 	}
 }
 
+func TestChatSession_SendMessageStream(t *testing.T) {
+	provider := &MockProvider{
+		responses: []string{"Hello there friend"},
+	}
+	session := NewChatSession(provider)
+
+	var chunks []string
+	response, err := session.SendMessageStream(context.Background(), "Hi", func(chunk string) {
+		chunks = append(chunks, chunk)
+	})
+	if err != nil {
+		t.Fatalf("SendMessageStream() error = %v", err)
+	}
+	if response != "Hello there friend" {
+		t.Errorf("SendMessageStream() response = %v, want %v", response, "Hello there friend")
+	}
+	if strings.Join(chunks, "") != response {
+		t.Errorf("SendMessageStream() chunks = %q, want them to join to %q", chunks, response)
+	}
+	if len(session.messages) != 2 {
+		t.Errorf("SendMessageStream() messages length = %d, want 2", len(session.messages))
+	}
+}
+
+func TestChatSession_SendMessageStreamInterrupted(t *testing.T) {
+	provider := &MockProvider{
+		responses: []string{"one two three four"},
+	}
+	session := NewChatSession(provider)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	response, err := session.SendMessageStream(ctx, "Count", func(chunk string) {
+		if chunk == "two " {
+			cancel()
+		}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("SendMessageStream() error = %v, want context.Canceled", err)
+	}
+	if response != "one two " {
+		t.Errorf("SendMessageStream() partial response = %q, want %q", response, "one two ")
+	}
+	if len(session.messages) != 2 {
+		t.Errorf("SendMessageStream() should keep the partial answer, messages length = %d", len(session.messages))
+	}
+
+	// Cancelled before anything arrived: the turn is dropped entirely
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	if _, err := session.SendMessageStream(ctx, "Again", func(string) {}); !errors.Is(err, context.Canceled) {
+		t.Fatalf("SendMessageStream() error = %v, want context.Canceled", err)
+	}
+	if len(session.messages) != 2 {
+		t.Errorf("SendMessageStream() should drop an unanswered turn, messages length = %d", len(session.messages))
+	}
+}
+
 func TestChatSession_ClearHistory(t *testing.T) {
 	provider := &MockProvider{
 		responses: []string{"Hello!"},
@@ -110,6 +188,32 @@ func TestChatSession_ClearHistory(t *testing.T) {
 	}
 }
 
+func TestChatSession_SetSystemPrompt(t *testing.T) {
+	provider := &MockProvider{responses: []string{"Hi!"}}
+	session := NewChatSession(provider)
+	session.SetSystemPrompt("You are Ellie.")
+
+	if _, err := session.SendMessage("Hello"); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+
+	sent := provider.lastMessages
+	if len(sent) != 2 || sent[0].Role != "system" || sent[0].Content != "You are Ellie." || sent[1].Role != "user" {
+		t.Errorf("provider received %+v, want the system prompt followed by the user turn", sent)
+	}
+	if len(session.messages) != 2 || len(session.GetHistory()) != 2 {
+		t.Errorf("system prompt should not be stored in the history, got %d messages", len(session.messages))
+	}
+
+	session.ClearHistory()
+	if _, err := session.SendMessageStream(context.Background(), "Again", func(string) {}); err != nil {
+		t.Fatalf("SendMessageStream() error = %v", err)
+	}
+	if sent := provider.lastMessages; len(sent) != 2 || sent[0].Role != "system" {
+		t.Errorf("system prompt should survive ClearHistory, provider received %+v", sent)
+	}
+}
+
 func TestFormatMessage(t *testing.T) {
 	role := "User"
 	content := "Hello, world!"
@@ -154,3 +258,22 @@ func TestParseMessage(t *testing.T) {
 		t.Error("ParseMessage() expected error for invalid format")
 	}
 }
+
+func TestDescribeError(t *testing.T) {
+	tests := []struct {
+		err  error
+		want string
+	}{
+		{fmt.Errorf("failed: %w", llm.ErrOffline), "offline"},
+		{fmt.Errorf("failed: %w", llm.ErrTimeout), "too long"},
+		{&llm.APIError{StatusCode: 429}, "rate limiting"},
+		{&llm.APIError{StatusCode: 401}, "API key"},
+		{errors.New("boom"), "boom"},
+	}
+
+	for _, tt := range tests {
+		if got := DescribeError(tt.err); !strings.Contains(got, tt.want) {
+			t.Errorf("DescribeError(%v) = %q, want it to mention %q", tt.err, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,255 @@
+package chat
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tacheraSasi/ellie/llm"
+	"github.com/tacheraSasi/ellie/store"
+)
+
+// ErrNotFound is returned when no saved session matches an ID
+var ErrNotFound = errors.New("chat session not found")
+
+// Transcript is a chat session as saved on disk
+type Transcript struct {
+	ID        string        `json:"id"`
+	Title     string        `json:"title"`
+	Provider  string        `json:"provider"`
+	Model     string        `json:"model"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+	Usage     llm.Usage     `json:"usage"`
+	Messages  []llm.Message `json:"messages"`
+}
+
+// NewTranscript starts a transcript for a new session. IDs start with the
+// time so they sort and can be shortened to a date, and end with a random
+// suffix so sessions started in the same second don't overwrite each other.
+func NewTranscript(provider, model string) *Transcript {
+	now := time.Now()
+	suffix := make([]byte, 3)
+	rand.Read(suffix)
+	return &Transcript{
+		ID:        now.Format("20060102-150405") + "-" + hex.EncodeToString(suffix),
+		Provider:  provider,
+		Model:     model,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+}
+
+// Capture copies the session's conversation and token usage into the
+// transcript. The title is taken from the first user message if unset.
+func (t *Transcript) Capture(session *ChatSession) {
+	t.Messages = session.Messages()
+	t.Usage = session.Usage()
+	if t.Title == "" {
+		t.Title = titleFrom(t.Messages)
+	}
+}
+
+// Markdown renders the transcript as a Markdown document
+func (t *Transcript) Markdown() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n\n", t.Title)
+	fmt.Fprintf(&sb, "- ID: %s\n", t.ID)
+	fmt.Fprintf(&sb, "- Provider: %s (%s)\n", t.Provider, t.Model)
+	fmt.Fprintf(&sb, "- Created: %s\n", t.CreatedAt.Format(time.RFC1123))
+	fmt.Fprintf(&sb, "- Updated: %s\n", t.UpdatedAt.Format(time.RFC1123))
+	fmt.Fprintf(&sb, "- Tokens: %d\n", t.Usage.TotalTokens)
+
+	for _, msg := range t.Messages {
+		switch {
+		case msg.Role == "tool":
+			continue
+		case len(msg.ToolCalls) > 0:
+			for _, call := range msg.ToolCalls {
+				fmt.Fprintf(&sb, "\n_Ellie ran `%s %s`_\n", call.Name, call.Arguments)
+			}
+			if msg.Content == "" {
+				continue
+			}
+		}
+
+		speaker := "You"
+		if msg.Role == "assistant" {
+			speaker = "Ellie"
+		}
+		fmt.Fprintf(&sb, "\n## %s\n\n%s\n", speaker, strings.TrimSpace(msg.Content))
+	}
+	return sb.String()
+}
+
+// titleFrom uses the first line of the first user message as a title
+func titleFrom(messages []llm.Message) string {
+	for _, msg := range messages {
+		if msg.Role != "user" {
+			continue
+		}
+		title := strings.TrimSpace(strings.SplitN(strings.TrimSpace(msg.Content), "\n", 2)[0])
+		if runes := []rune(title); len(runes) > 60 {
+			title = string(runes[:57]) + "..."
+		}
+		return title
+	}
+	return "Untitled chat"
+}
+
+// Store keeps transcripts as one JSON file per session in a directory
+type Store struct {
+	dir string
+}
+
+// NewStore returns a store rooted at dir. The directory is created on the
+// first save.
+func NewStore(dir string) *Store {
+	return &Store{dir: dir}
+}
+
+func (s *Store) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+// Save writes the transcript, bumping its UpdatedAt time
+func (s *Store) Save(t *Transcript) error {
+	if err := validateID(t.ID); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("error creating chats directory: %w", err)
+	}
+
+	t.UpdatedAt = time.Now()
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding chat session: %w", err)
+	}
+	if err := store.WriteAtomic(s.path(t.ID), data, 0600); err != nil {
+		return fmt.Errorf("error writing chat session: %w", err)
+	}
+	return nil
+}
+
+// Load reads a transcript by ID. A unique prefix of an ID is accepted too.
+func (s *Store) Load(id string) (*Transcript, error) {
+	id, err := s.resolve(id)
+	if err != nil {
+		return nil, err
+	}
+	return s.read(s.path(id))
+}
+
+// UnreadableError lists saved sessions that couldn't be read. It is a
+// warning: the other sessions were read.
+type UnreadableError struct {
+	Errs []error
+}
+
+func (e *UnreadableError) Error() string {
+	return fmt.Sprintf("skipped %d unreadable chat session(s): %v", len(e.Errs), errors.Join(e.Errs...))
+}
+
+// List returns all saved transcripts, most recently updated first. Files
+// that can't be read are skipped and reported in an *UnreadableError
+// alongside the rest.
+func (s *Store) List() ([]*Transcript, error) {
+	paths, err := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	transcripts := make([]*Transcript, 0, len(paths))
+	var unreadable []error
+	for _, path := range paths {
+		t, err := s.read(path)
+		if err != nil {
+			unreadable = append(unreadable, err)
+			continue
+		}
+		transcripts = append(transcripts, t)
+	}
+
+	sort.Slice(transcripts, func(i, j int) bool {
+		return transcripts[i].UpdatedAt.After(transcripts[j].UpdatedAt)
+	})
+	if len(unreadable) > 0 {
+		return transcripts, &UnreadableError{Errs: unreadable}
+	}
+	return transcripts, nil
+}
+
+// Delete removes a saved transcript by ID or unique ID prefix
+func (s *Store) Delete(id string) error {
+	id, err := s.resolve(id)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(s.path(id)); err != nil {
+		return fmt.Errorf("error deleting chat session: %w", err)
+	}
+	return nil
+}
+
+// resolve maps an ID or unique ID prefix to a saved session's ID
+func (s *Store) resolve(id string) (string, error) {
+	if err := validateID(id); err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(s.path(id)); err == nil {
+		return id, nil
+	}
+
+	matches, err := filepath.Glob(filepath.Join(s.dir, id+"*.json"))
+	if err != nil {
+		return "", err
+	}
+	if len(matches) > 1 {
+		// An unreadable file doesn't make a prefix ambiguous
+		readable := matches[:0:0]
+		for _, path := range matches {
+			if _, err := s.read(path); err == nil {
+				readable = append(readable, path)
+			}
+		}
+		if len(readable) > 0 {
+			matches = readable
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("%w: %s", ErrNotFound, id)
+	case 1:
+		return strings.TrimSuffix(filepath.Base(matches[0]), ".json"), nil
+	default:
+		return "", fmt.Errorf("%q matches %d chat sessions, use more of the ID", id, len(matches))
+	}
+}
+
+func (s *Store) read(path string) (*Transcript, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading chat session: %w", err)
+	}
+	var t Transcript
+	if err := json.Unmarshal(data, &t); err != nil {
+		return nil, fmt.Errorf("error parsing chat session %s: %w", filepath.Base(path), err)
+	}
+	return &t, nil
+}
+
+// validateID rejects IDs that could escape the store directory
+func validateID(id string) error {
+	if id == "" || strings.ContainsAny(id, `/\*?[`) || strings.Contains(id, "..") {
+		return fmt.Errorf("invalid chat session ID %q", id)
+	}
+	return nil
+}
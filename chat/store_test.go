@@ -0,0 +1,136 @@
+package chat
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tacheraSasi/ellie/llm"
+)
+
+func TestStore_SaveLoadListDelete(t *testing.T) {
+	store := NewStore(filepath.Join(t.TempDir(), "chats"))
+
+	older := &Transcript{ID: "20260101-100000", Provider: "openai", Model: "gpt-4o", CreatedAt: time.Now()}
+	older.Messages = []llm.Message{{Role: "user", Content: "Why does the build fail?\n\nCurrent Context:\n..."}}
+	if err := store.Save(older); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	newer := &Transcript{ID: "20260102-100000", Title: "Second", CreatedAt: time.Now()}
+	if err := store.Save(newer); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	loaded, err := store.Load("20260101")
+	if err != nil {
+		t.Fatalf("Load() by prefix error = %v", err)
+	}
+	if loaded.Provider != "openai" || len(loaded.Messages) != 1 || loaded.UpdatedAt.IsZero() {
+		t.Errorf("Load() = %+v", loaded)
+	}
+
+	if _, err := store.Load("2026"); err == nil {
+		t.Error("Load() with an ambiguous prefix should fail")
+	}
+	if _, err := store.Load("1999"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Load() of a missing session error = %v, want ErrNotFound", err)
+	}
+	if _, err := store.Load("../secrets"); err == nil {
+		t.Error("Load() should reject IDs outside the store")
+	}
+
+	list, err := store.List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(list) != 2 || list[0].ID != newer.ID {
+		t.Errorf("List() should return the most recently updated first, got %d sessions", len(list))
+	}
+
+	if err := store.Delete(older.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := os.Stat(store.path(older.ID)); !os.IsNotExist(err) {
+		t.Error("Delete() should remove the session file")
+	}
+}
+
+func TestNewTranscript_UniqueIDs(t *testing.T) {
+	store := NewStore(t.TempDir())
+	first, second := NewTranscript("openai", "gpt-4o"), NewTranscript("openai", "gpt-4o")
+	if first.ID == second.ID {
+		t.Fatalf("sessions started together share the ID %s", first.ID)
+	}
+	if !strings.HasPrefix(first.ID, first.CreatedAt.Format("20060102-150405")+"-") {
+		t.Errorf("ID %s should start with the creation time", first.ID)
+	}
+
+	for _, transcript := range []*Transcript{first, second} {
+		if err := store.Save(transcript); err != nil {
+			t.Fatalf("Save() error = %v", err)
+		}
+	}
+	if list, _ := store.List(); len(list) != 2 {
+		t.Errorf("List() = %d sessions, want both kept", len(list))
+	}
+	if leftovers, _ := filepath.Glob(filepath.Join(store.dir, ".*")); len(leftovers) != 0 {
+		t.Errorf("Save() left temporary files behind: %v", leftovers)
+	}
+}
+
+func TestStore_SkipsUnreadableSessions(t *testing.T) {
+	store := NewStore(t.TempDir())
+	good := &Transcript{ID: "20260101-100000-aaaaaa", Title: "Good"}
+	if err := store.Save(good); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(store.path("20260101-100000-bbbbbb"), []byte(`{"id": "20260101-10`), 0600)
+
+	list, err := store.List()
+	var unreadable *UnreadableError
+	if !errors.As(err, &unreadable) || len(unreadable.Errs) != 1 {
+		t.Errorf("List() error = %v, want the broken session reported", err)
+	}
+	if len(list) != 1 || list[0].ID != good.ID {
+		t.Errorf("List() = %d sessions, want the readable one", len(list))
+	}
+
+	if loaded, err := store.Load("20260101-100000"); err != nil || loaded.ID != good.ID {
+		t.Errorf("Load() by prefix = %v, %v, want the readable session", loaded, err)
+	}
+}
+
+func TestTranscript_Capture(t *testing.T) {
+	provider := &MockProvider{responses: []string{"Check the go.mod file."}}
+	session := NewChatSession(provider)
+	if _, err := session.SendMessage("Why does the build fail?\n\nCurrent Context:\ncwd=/tmp"); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+
+	transcript := NewTranscript("openai", "gpt-4o")
+	transcript.Capture(session)
+
+	if transcript.Title != "Why does the build fail?" {
+		t.Errorf("Title = %q", transcript.Title)
+	}
+	if len(transcript.Messages) != 2 || transcript.Usage.TotalTokens != 30 {
+		t.Errorf("Capture() messages = %d, usage = %+v", len(transcript.Messages), transcript.Usage)
+	}
+
+	md := transcript.Markdown()
+	for _, want := range []string{"# Why does the build fail?", "## You", "## Ellie", "Check the go.mod file."} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown() missing %q", want)
+		}
+	}
+
+	resumed := NewChatSession(provider)
+	resumed.Restore(transcript.Messages, transcript.Usage)
+	if len(resumed.GetHistory()) != 2 || resumed.Usage() != transcript.Usage {
+		t.Errorf("Restore() history = %v, usage = %+v", resumed.GetHistory(), resumed.Usage())
+	}
+}
@@ -0,0 +1,81 @@
+package chat
+
+import (
+	"context"
+
+	"github.com/tacheraSasi/ellie/llm"
+)
+
+// ToolHandler runs a tool call and returns the result reported to the model.
+// An error is reported to the model as the result instead.
+type ToolHandler func(call llm.ToolCall) (string, error)
+
+// maxToolRounds bounds how many times in a row the model may call tools
+// before it has to answer
+const maxToolRounds = 5
+
+// SetTools offers tools to the model. Calls are run through handle and their
+// results sent back until the model answers. Providers without function
+// calling support ignore the tools.
+func (s *ChatSession) SetTools(tools []llm.Tool, handle ToolHandler) {
+	s.tools = tools
+	s.handleTool = handle
+}
+
+// toolProvider returns the provider as a ToolProvider when tools are in use
+func (s *ChatSession) toolProvider() (llm.ToolProvider, bool) {
+	if len(s.tools) == 0 || s.handleTool == nil {
+		return nil, false
+	}
+	provider, ok := s.provider.(llm.ToolProvider)
+	return provider, ok
+}
+
+// complete gets the answer to the pending user turn, streaming it through
+// onChunk when set. Tool calls are run and their results appended to the
+// conversation before asking the model again.
+func (s *ChatSession) complete(ctx context.Context, onChunk llm.StreamHandler) (*llm.Response, error) {
+	provider, ok := s.toolProvider()
+	if !ok {
+		if onChunk == nil {
+			return s.provider.Chat(ctx, s.request())
+		}
+		return s.provider.ChatStream(ctx, s.request(), onChunk)
+	}
+
+	for round := 1; ; round++ {
+		tools := s.tools
+		if round > maxToolRounds {
+			// Out of rounds: ask for a plain answer
+			tools = nil
+		}
+
+		response, err := provider.ChatWithTools(ctx, s.request(), tools, onChunk)
+		if err != nil || len(response.ToolCalls) == 0 || tools == nil {
+			return response, err
+		}
+		s.addUsage(response.Usage)
+
+		s.messages = append(s.messages, llm.Message{
+			Role:      "assistant",
+			Content:   response.Content,
+			ToolCalls: response.ToolCalls,
+		})
+		for _, call := range response.ToolCalls {
+			result, err := s.handleTool(call)
+			if err != nil {
+				result = "Error: " + err.Error()
+			}
+			s.messages = append(s.messages, llm.Message{
+				Role:       "tool",
+				Content:    result,
+				ToolCallID: call.ID,
+				Name:       call.Name,
+			})
+		}
+
+		if ctx.Err() != nil {
+			return &llm.Response{}, ctx.Err()
+		}
+	}
+}
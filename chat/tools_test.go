@@ -0,0 +1,139 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tacheraSasi/ellie/llm"
+)
+
+// MockToolProvider calls the "clock" tool once, then answers with the result
+type MockToolProvider struct {
+	MockProvider
+	calls int
+}
+
+func (m *MockToolProvider) ChatWithTools(ctx context.Context, messages []llm.Message, tools []llm.Tool, onChunk llm.StreamHandler) (*llm.Response, error) {
+	m.calls++
+	m.lastMessages = messages
+	last := messages[len(messages)-1]
+	if last.Role != "tool" && len(tools) > 0 {
+		return &llm.Response{
+			ToolCalls: []llm.ToolCall{{ID: "call_1", Name: "clock", Arguments: json.RawMessage(`{}`)}},
+			Usage:     llm.Usage{TotalTokens: 5},
+		}, nil
+	}
+	answer := "It is " + last.Content
+	if onChunk != nil {
+		onChunk(answer)
+	}
+	return &llm.Response{Content: answer, Usage: llm.Usage{TotalTokens: 7}}, nil
+}
+
+func TestChatSession_ToolCalls(t *testing.T) {
+	provider := &MockToolProvider{}
+	session := NewChatSession(provider)
+
+	var ran []string
+	session.SetTools([]llm.Tool{{Name: "clock"}}, func(call llm.ToolCall) (string, error) {
+		ran = append(ran, call.Name)
+		return "noon", nil
+	})
+
+	var streamed string
+	response, err := session.SendMessageStream(context.Background(), "What time is it?", func(chunk string) {
+		streamed += chunk
+	})
+	if err != nil {
+		t.Fatalf("SendMessageStream() error = %v", err)
+	}
+
+	if response != "It is noon" || streamed != response {
+		t.Errorf("response = %q, streamed = %q", response, streamed)
+	}
+	if len(ran) != 1 || provider.calls != 2 {
+		t.Errorf("ran %v with %d provider calls, want one tool run and two calls", ran, provider.calls)
+	}
+
+	roles := []string{}
+	for _, msg := range session.Messages() {
+		roles = append(roles, msg.Role)
+	}
+	if want := "user assistant tool assistant"; strings.Join(roles, " ") != want {
+		t.Errorf("messages = %v, want %s", roles, want)
+	}
+	if len(session.GetHistory()) != 2 {
+		t.Errorf("history = %v, tool messages should not be in it", session.GetHistory())
+	}
+	if session.Usage().TotalTokens != 12 {
+		t.Errorf("usage = %+v, want both rounds counted", session.Usage())
+	}
+}
+
+func TestChatSession_ToolErrorsAreReported(t *testing.T) {
+	provider := &MockToolProvider{}
+	session := NewChatSession(provider)
+	session.SetTools([]llm.Tool{{Name: "clock"}}, func(call llm.ToolCall) (string, error) {
+		return "", errors.New("clock is broken")
+	})
+
+	response, err := session.SendMessage("What time is it?")
+	if err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	if response != "It is Error: clock is broken" {
+		t.Errorf("response = %q, want the tool error passed to the model", response)
+	}
+}
+
+func TestChatSession_ToolMessagesHiddenWithoutTools(t *testing.T) {
+	provider := &MockProvider{responses: []string{"ok"}}
+	session := NewChatSession(provider)
+	session.Restore([]llm.Message{
+		{Role: "user", Content: "time?"},
+		{Role: "assistant", ToolCalls: []llm.ToolCall{{ID: "call_1", Name: "clock"}}},
+		{Role: "tool", ToolCallID: "call_1", Name: "clock", Content: "noon"},
+		{Role: "assistant", Content: "It is noon"},
+	}, llm.Usage{})
+
+	if _, err := session.SendMessage("thanks"); err != nil {
+		t.Fatalf("SendMessage() error = %v", err)
+	}
+	for _, msg := range provider.lastMessages {
+		if msg.Role == "tool" || len(msg.ToolCalls) > 0 {
+			t.Errorf("provider without tool support received %+v", msg)
+		}
+	}
+}
+
+// failingToolProvider calls the "clock" tool, then fails to answer
+type failingToolProvider struct {
+	MockToolProvider
+}
+
+func (m *failingToolProvider) ChatWithTools(ctx context.Context, messages []llm.Message, tools []llm.Tool, onChunk llm.StreamHandler) (*llm.Response, error) {
+	if messages[len(messages)-1].Role == "tool" {
+		return nil, errors.New("server error")
+	}
+	return m.MockToolProvider.ChatWithTools(ctx, messages, tools, onChunk)
+}
+
+func TestChatSession_SendMessageDropsFailedTurn(t *testing.T) {
+	session := NewChatSession(&failingToolProvider{})
+	session.SetTools([]llm.Tool{{Name: "clock"}}, func(call llm.ToolCall) (string, error) {
+		return "noon", nil
+	})
+
+	if _, err := session.SendMessage("What time is it?"); err == nil {
+		t.Fatal("SendMessage() should fail")
+	}
+	if msgs := session.Messages(); len(msgs) != 0 {
+		t.Errorf("messages after a failed turn = %+v, want none", msgs)
+	}
+	if history := session.GetHistory(); len(history) != 0 {
+		t.Errorf("history after a failed turn = %v, want none", history)
+	}
+}
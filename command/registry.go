@@ -16,7 +16,7 @@ var Registry = map[string]Command{
 		Handler: actions.Run,
 	},
 	"::": {
-		Usage:   "ellie :: run docker container for me please",
+		Usage:   "ellie :: run docker container for me please [--provider <name>] [--model <name>]",
 		MinArgs: 1,
 		Handler: actions.SmartRun,
 	},
@@ -131,110 +131,180 @@ var Registry = map[string]Command{
 		Handler: func(_ []string) { actions.Mailer() },
 	},
 	"chat": {
-		Handler: func(_ []string) { actions.Chat(configs.GetEnv("OPENAI_API_KEY")) },
+		Usage:   "chat [--provider <name>] [--model <name>]",
+		Handler: actions.Chat,
+		SubCommands: map[string]Command{
+			"list": {
+				Usage:   "chat list",
+				Handler: actions.ChatList,
+			},
+			"resume": {
+				MinArgs: 1,
+				Usage:   "chat resume <id> [--provider <name>] [--model <name>]",
+				Handler: actions.ChatResume,
+			},
+			"export": {
+				MinArgs: 1,
+				Usage:   "chat export <id> [--format md|json]",
+				Handler: actions.ChatExport,
+			},
+			"delete": {
+				MinArgs: 1,
+				Usage:   "chat delete <id>",
+				Handler: actions.ChatDelete,
+			},
+		},
+	},
+	"usage": {
+		Usage:   "usage [daily|weekly|commands|models|users]",
+		Handler: actions.UsageReport,
+		SubCommands: map[string]Command{
+			"daily":    {Handler: actions.UsageDaily},
+			"weekly":   {Handler: actions.UsageWeekly},
+			"commands": {Handler: actions.UsageCommands},
+			"models":   {Handler: actions.UsageModels},
+			"users":    {Handler: actions.UsageUsers},
+		},
+	},
+	"cache": {
+		Usage:   "cache [stats|clear [--expired]]",
+		Handler: actions.CacheStats,
+		SubCommands: map[string]Command{
+			"stats": {Handler: actions.CacheStats},
+			"clear": {
+				Usage:   "cache clear [--expired]",
+				Handler: actions.CacheClear,
+			},
+		},
 	},
 	"review": {
-		Usage:   "review <filename/filepath>",
+		Usage:   "review <file|dir|glob>... [--staged | --diff <ref>] [--provider <name>] [--model <name>] [--no-cache]",
 		MinArgs: 1,
-		Handler: func(args []string) { actions.Review(args[1]) },
+		Handler: actions.Review,
 		// PreHook: ,
 	},
 	"security-check": {
-		Usage:   "security-check <path>",
+		Usage:   "security-check <file|dir|glob>... [--format md|json|sarif] [--fail-on <severity>] [--offline] [--provider <name>] [--model <name>] [--no-cache]",
 		MinArgs: 1,
-		Handler: func(args []string) { actions.SecurityCheck(args[1]) },
+		Handler: actions.SecurityCheck,
 		// PreHook: ,
 	},
+	"release": {
+		Usage:   "release [--dry-run] [--as major|minor|patch] [--version <v>] [--yes]",
+		Handler: actions.Release,
+	},
+	"hooks": {
+		Usage:   "hooks [install [--fail-on <severity>] [--review]|uninstall|status]",
+		Handler: actions.HooksStatus,
+		SubCommands: map[string]Command{
+			"install": {
+				Usage:   "hooks install [--fail-on critical|high|medium|low] [--review]",
+				Handler: actions.HooksInstall,
+			},
+			"uninstall": {Handler: actions.HooksUninstall},
+			"status":    {Handler: actions.HooksStatus},
+			"run": {
+				Usage:   "hooks run pre-commit|commit-msg|pre-push [args]",
+				MinArgs: 1,
+				Handler: actions.HooksRun,
+			},
+		},
+	},
 	"git": {
 		SubCommands: map[string]Command{
 			// Basic operations
-			"status": {Handler: func(_ []string) { actions.GitStatus() }},
-			"push":   {Handler: func(_ []string) { actions.GitPush() }},
-			"commit": {Handler: func(args []string) { actions.GitConventionalCommit() }},
-			"pull":   {Handler: func(_ []string) { actions.GitPull() }},
-			"init":   {Handler: func(_ []string) { actions.GitInit() }},
-			"clone":  {Handler: func(_ []string) { actions.GitClone() }},
-			"fetch":  {Handler: func(_ []string) { actions.GitFetch() }},
+			"status": {Handler: actions.GitStatus},
+			"push":   {Handler: actions.GitPush},
+			"commit": {
+				Usage:   "git commit [--ai] [--provider <name>] [--model <name>] [--yes]",
+				Handler: actions.GitCommit,
+			},
+			"pull":  {Handler: actions.GitPull},
+			"init":  {Handler: actions.GitInit},
+			"clone": {Handler: actions.GitClone},
+			"fetch": {Handler: actions.GitFetch},
+			"ui":    {Handler: actions.GitUI},
 
 			// Branch operations
-			"branch-create":      {Handler: func(_ []string) { actions.GitBranchCreate() }},
-			"branch-switch":      {Handler: func(_ []string) { actions.GitBranchSwitch() }},
-			"branch-delete":      {Handler: func(_ []string) { actions.GitBranchDelete() }},
-			"branch-list":        {Handler: func(_ []string) { actions.GitBranchList() }},
-			"branch-list-remote": {Handler: func(_ []string) { actions.GitBranchListRemote() }},
-			"branch-rename":      {Handler: func(_ []string) { actions.GitBranchRename() }},
+			"branch-create":      {Handler: actions.GitBranchCreate},
+			"branch-switch":      {Handler: actions.GitBranchSwitch},
+			"branch-delete":      {Handler: actions.GitBranchDelete},
+			"branch-list":        {Handler: actions.GitBranchList},
+			"branch-list-remote": {Handler: actions.GitBranchListRemote},
+			"branch-rename":      {Handler: actions.GitBranchRename},
 
 			// Stash operations
-			"stash-save":  {Handler: func(_ []string) { actions.GitStashSave() }},
-			"stash-pop":   {Handler: func(_ []string) { actions.GitStashPop() }},
-			"stash-list":  {Handler: func(_ []string) { actions.GitStashList() }},
-			"stash-show":  {Handler: func(_ []string) { actions.GitStashShow() }},
-			"stash-drop":  {Handler: func(_ []string) { actions.GitStashDrop() }},
-			"stash-apply": {Handler: func(_ []string) { actions.GitStashApply() }},
+			"stash-save":  {Handler: actions.GitStashSave},
+			"stash-pop":   {Handler: actions.GitStashPop},
+			"stash-list":  {Handler: actions.GitStashList},
+			"stash-show":  {Handler: actions.GitStashShow},
+			"stash-drop":  {Handler: actions.GitStashDrop},
+			"stash-apply": {Handler: actions.GitStashApply},
 
 			// Tag operations
-			"tag-create": {Handler: func(_ []string) { actions.GitTagCreate() }},
-			"tag-list":   {Handler: func(_ []string) { actions.GitTagList() }},
-			"tag-delete": {Handler: func(_ []string) { actions.GitTagDelete() }},
+			"tag-create": {Handler: actions.GitTagCreate},
+			"tag-list":   {Handler: actions.GitTagList},
+			"tag-delete": {Handler: actions.GitTagDelete},
 
 			// Log and diff operations
-			"log":         {Handler: func(_ []string) { actions.GitLogPretty() }},
-			"log-search":  {Handler: func(_ []string) { actions.GitLogSearch() }},
-			"log-author":  {Handler: func(_ []string) { actions.GitLogAuthor() }},
-			"log-since":   {Handler: func(_ []string) { actions.GitLogSince() }},
-			"diff":        {Handler: func(_ []string) { actions.GitDiff() }},
-			"diff-staged": {Handler: func(_ []string) { actions.GitDiffStaged() }},
-			"diff-branch": {Handler: func(_ []string) { actions.GitDiffBranch() }},
+			"log":         {Handler: actions.GitLogPretty},
+			"log-search":  {Handler: actions.GitLogSearch},
+			"log-author":  {Handler: actions.GitLogAuthor},
+			"log-since":   {Handler: actions.GitLogSince},
+			"diff":        {Handler: actions.GitDiff},
+			"diff-staged": {Handler: actions.GitDiffStaged},
+			"diff-branch": {Handler: actions.GitDiffBranch},
 
 			// Merge and rebase operations
-			"merge":       {Handler: func(_ []string) { actions.GitMerge() }},
-			"rebase":      {Handler: func(_ []string) { actions.GitRebase() }},
-			"cherry-pick": {Handler: func(_ []string) { actions.GitCherryPick() }},
-			"reset":       {Handler: func(_ []string) { actions.GitReset() }},
-			"revert":      {Handler: func(_ []string) { actions.GitRevert() }},
+			"merge":       {Handler: actions.GitMerge},
+			"rebase":      {Handler: actions.GitRebase},
+			"cherry-pick": {Handler: actions.GitCherryPick},
+			"reset":       {Handler: actions.GitReset},
+			"undo":        {Handler: actions.GitUndo},
+			"revert":      {Handler: actions.GitRevert},
 
 			// Bisect operations
-			"bisect":       {Handler: func(_ []string) { actions.GitBisect() }},
-			"bisect-good":  {Handler: func(_ []string) { actions.GitBisectGood() }},
-			"bisect-bad":   {Handler: func(_ []string) { actions.GitBisectBad() }},
-			"bisect-reset": {Handler: func(_ []string) { actions.GitBisectReset() }},
+			"bisect":       {Handler: actions.GitBisect},
+			"bisect-good":  {Handler: actions.GitBisectGood},
+			"bisect-bad":   {Handler: actions.GitBisectBad},
+			"bisect-reset": {Handler: actions.GitBisectReset},
 
 			// Remote operations
-			"remote-list":   {Handler: func(_ []string) { actions.GitRemoteList() }},
-			"remote-add":    {Handler: func(_ []string) { actions.GitRemoteAdd() }},
-			"remote-remove": {Handler: func(_ []string) { actions.GitRemoteRemove() }},
+			"remote-list":   {Handler: actions.GitRemoteList},
+			"remote-add":    {Handler: actions.GitRemoteAdd},
+			"remote-remove": {Handler: actions.GitRemoteRemove},
 
 			// Push operations
-			"push-tags":     {Handler: func(_ []string) { actions.GitPushTags() }},
-			"push-force":    {Handler: func(_ []string) { actions.GitPushForce() }},
-			"push-upstream": {Handler: func(_ []string) { actions.GitPushUpstream() }},
+			"push-tags":     {Handler: actions.GitPushTags},
+			"push-force":    {Handler: actions.GitPushForce},
+			"push-upstream": {Handler: actions.GitPushUpstream},
 
 			// Submodule operations
-			"submodule-add":    {Handler: func(_ []string) { actions.GitSubmoduleAdd() }},
-			"submodule-update": {Handler: func(_ []string) { actions.GitSubmoduleUpdate() }},
-			"submodule-status": {Handler: func(_ []string) { actions.GitSubmoduleStatus() }},
+			"submodule-add":    {Handler: actions.GitSubmoduleAdd},
+			"submodule-update": {Handler: actions.GitSubmoduleUpdate},
+			"submodule-status": {Handler: actions.GitSubmoduleStatus},
 
 			// Configuration operations
-			"config-set-user":  {Handler: func(_ []string) { actions.GitConfigSetUser() }},
-			"config-list":      {Handler: func(_ []string) { actions.GitConfigList() }},
-			"config-set-alias": {Handler: func(_ []string) { actions.GitConfigSetAlias() }},
+			"config-set-user":  {Handler: actions.GitConfigSetUser},
+			"config-list":      {Handler: actions.GitConfigList},
+			"config-set-alias": {Handler: actions.GitConfigSetAlias},
 
 			// Worktree operations
-			"worktree-add":    {Handler: func(_ []string) { actions.GitWorktreeAdd() }},
-			"worktree-list":   {Handler: func(_ []string) { actions.GitWorktreeList() }},
-			"worktree-remove": {Handler: func(_ []string) { actions.GitWorktreeRemove() }},
-			"worktree-prune":  {Handler: func(_ []string) { actions.GitWorktreePrune() }},
+			"worktree-add":    {Handler: actions.GitWorktreeAdd},
+			"worktree-list":   {Handler: actions.GitWorktreeList},
+			"worktree-remove": {Handler: actions.GitWorktreeRemove},
+			"worktree-prune":  {Handler: actions.GitWorktreePrune},
 
 			// Maintenance operations
-			"reflog": {Handler: func(_ []string) { actions.GitReflog() }},
-			"clean":  {Handler: func(_ []string) { actions.GitClean() }},
-			"gc":     {Handler: func(_ []string) { actions.GitGC() }},
-			"fsck":   {Handler: func(_ []string) { actions.GitFsck() }},
+			"reflog": {Handler: actions.GitReflog},
+			"clean":  {Handler: actions.GitClean},
+			"gc":     {Handler: actions.GitGC},
+			"fsck":   {Handler: actions.GitFsck},
 
 			// Information operations
-			"show":    {Handler: func(_ []string) { actions.GitShow() }},
-			"blame":   {Handler: func(_ []string) { actions.GitBlame() }},
-			"archive": {Handler: func(_ []string) { actions.GitArchive() }},
+			"show":    {Handler: actions.GitShow},
+			"blame":   {Handler: actions.GitBlame},
+			"archive": {Handler: actions.GitArchive},
 		},
 	},
 	"docker": {
@@ -372,6 +442,24 @@ var Registry = map[string]Command{
 			},
 		},
 	},
+	"repos": {
+		Usage:   "repos [status|fetch|pull] [name...] [--tag <tag>]",
+		Handler: actions.ReposStatus,
+		SubCommands: map[string]Command{
+			"status": {
+				Usage:   "repos status [name...] [--tag <tag>]",
+				Handler: actions.ReposStatus,
+			},
+			"fetch": {
+				Usage:   "repos fetch [name...] [--tag <tag>] [--prune]",
+				Handler: actions.ReposFetch,
+			},
+			"pull": {
+				Usage:   "repos pull [name...] [--tag <tag>]",
+				Handler: actions.ReposPull,
+			},
+		},
+	},
 	"switch": {
 		MinArgs: 1,
 		Usage:   "switch <project-name>",
@@ -491,7 +579,7 @@ var Registry = map[string]Command{
 		SubCommands: map[string]Command{
 			"add": {
 				MinArgs: 3,
-				Usage:   "automate add <name> <schedule> <command>",
+				Usage:   "automate add <name> <schedule> [--timeout <duration>] [--skip-missed] [--on-failure <action>]... <command>",
 				Handler: actions.AutomationAdd,
 			},
 			"list": {
@@ -512,13 +600,50 @@ var Registry = map[string]Command{
 				Handler: actions.AutomationRun,
 			},
 			"daemon": {
-				Usage:   "automate daemon - Start automation daemon",
+				Usage:   "automate daemon [--log <file>] - Start automation daemon",
 				Handler: actions.AutomationDaemon,
 			},
+			"service": {
+				Usage:   "automate service <install|uninstall|status|logs> - Run the daemon as a user service",
+				Handler: actions.AutomationService,
+				SubCommands: map[string]Command{
+					"install": {
+						Usage:   "automate service install - Install and start the daemon service",
+						Handler: actions.AutomationServiceInstall,
+					},
+					"uninstall": {
+						Usage:   "automate service uninstall - Stop and remove the daemon service",
+						Handler: actions.AutomationServiceUninstall,
+					},
+					"status": {
+						Usage:   "automate service status - Show the daemon service status",
+						Handler: actions.AutomationServiceStatus,
+					},
+					"logs": {
+						Usage:   "automate service logs [-n <lines>] [--tail]",
+						Handler: actions.AutomationServiceLogs,
+					},
+				},
+			},
 			"quick": {
 				Usage:   "automate quick - Quick setup for common automations",
 				Handler: actions.QuickAutomations,
 			},
+			"history": {
+				MinArgs: 1,
+				Usage:   "automate history <id> [-n <runs>]",
+				Handler: actions.AutomationHistory,
+			},
+			"logs": {
+				MinArgs: 1,
+				Usage:   "automate logs <id> [-n <runs>] [--tail]",
+				Handler: actions.AutomationLogs,
+			},
+			"on-failure": {
+				MinArgs: 1,
+				Usage:   "automate on-failure <id> [notify|email:<address>|webhook:<url>]...",
+				Handler: actions.AutomationOnFailure,
+			},
 		},
 	},
 }
@@ -0,0 +1,56 @@
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	actions "github.com/tacheraSasi/ellie/action"
+)
+
+func init() {
+	actions.SetCommandResolver(Resolve)
+}
+
+// Resolve finds the handler for a command line such as
+// []string{"git", "status"} the way main does, and returns it with the
+// arguments it expects: the command's own name first. Pre-hooks run before
+// the handler.
+func Resolve(args []string) (func([]string), []string, error) {
+	if len(args) == 0 {
+		return nil, nil, fmt.Errorf("empty command")
+	}
+	cmd, exists := Registry[args[0]]
+	if !exists {
+		return nil, nil, fmt.Errorf("unknown command: %s", args[0])
+	}
+
+	hooks := []func(){cmd.PreHook}
+	path := args[0]
+	// Flags such as "chat --provider x" go to the command itself
+	for len(cmd.SubCommands) > 0 && len(args) > 1 && !strings.HasPrefix(args[1], "-") {
+		sub, exists := cmd.SubCommands[args[1]]
+		if !exists {
+			return nil, nil, fmt.Errorf("unknown subcommand: %s %s", path, args[1])
+		}
+		path += " " + args[1]
+		cmd, args = sub, args[1:]
+		hooks = append(hooks, cmd.PreHook)
+	}
+
+	if cmd.Handler == nil {
+		return nil, nil, fmt.Errorf("%s needs a subcommand", path)
+	}
+	if len(args)-1 < cmd.MinArgs {
+		return nil, nil, fmt.Errorf("invalid usage for %s; usage: %s", path, cmd.Usage)
+	}
+
+	handler := cmd.Handler
+	return func(args []string) {
+		for _, hook := range hooks {
+			if hook != nil {
+				hook()
+			}
+		}
+		handler(args)
+	}, args, nil
+}
@@ -99,7 +99,27 @@ func createExampleEnv() {
 USERNAME="your_username"
 EMAIL="your@email.com"
 OPENAI_API_KEY="sk-...your-openai-key"
+GEMINI_API_KEY="your-gemini-key"
+ANTHROPIC_API_KEY="sk-ant-...your-anthropic-key"
 RELAY_API_KEY="your-relay-key"
+
+# AI provider used by chat, review, security-check and ::
+# (ellieapi, openai, gemini, anthropic or local). Override per command with
+# --provider/--model. "local" talks to an OpenAI-compatible server such as
+# Ollama (the default, http://localhost:11434/v1), llama.cpp or LM Studio.
+ELLIE_PROVIDER="ellieapi"
+ELLIE_MODEL=""
+# Custom endpoint for the provider above, e.g. http://localhost:1234/v1 for LM Studio
+ELLIE_BASE_URL=""
+
+# Optional monthly AI budget in USD, checked against ~/ellie/usage.jsonl.
+# ELLIE_BUDGET_ACTION is warn (default) or block.
+ELLIE_MONTHLY_BUDGET=""
+ELLIE_BUDGET_ACTION="warn"
+
+# How long review and security-check answers stay cached in ~/ellie/cache,
+# e.g. 12h or 7d (default). "off" disables the cache.
+ELLIE_CACHE_TTL="7d"
 `
 		if err := os.WriteFile(examplePath, []byte(content), 0644); err != nil {
 			styles.WarningStyle.Println("⚠️ Warning: Failed to create example config:", err)
@@ -0,0 +1,236 @@
+// Package daemon installs the automation daemon as a user service: a systemd
+// user unit on Linux, a launchd agent on macOS and a scheduled task on
+// Windows. It also keeps a PID file so only one daemon runs at a time.
+package daemon
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Spec is the service to install
+type Spec struct {
+	// Name names the systemd unit, the scheduled task and the definition files
+	Name string
+	// Label is the launchd label, in reverse domain form
+	Label       string
+	Description string
+	// Program is the absolute path of the executable and Args its arguments
+	Program string
+	Args    []string
+	// Path is the PATH the daemon runs with. Service managers start
+	// programs with a minimal one, which would hide most tools.
+	Path string
+	// LogFile is where the daemon's output goes when the service manager
+	// doesn't keep it itself. The scheduled task passes it to the daemon
+	// with --log.
+	LogFile string
+}
+
+// Manager installs and controls the service with one service manager
+type Manager interface {
+	// Kind names the service manager
+	Kind() string
+	// Path is where the service definition is written
+	Path() string
+	// Render returns the service definition
+	Render() ([]byte, error)
+	// Install writes the definition, registers it and starts the service
+	Install() error
+	// Uninstall stops the service, unregisters it and removes the definition
+	Uninstall() error
+	// Status is the service manager's report on the service
+	Status() (string, error)
+	// Logs writes the daemon's last lines of output to w, and with follow
+	// keeps writing new ones
+	Logs(w io.Writer, lines int, follow bool) error
+}
+
+// ErrNotInstalled is returned when uninstalling a service that isn't installed
+var ErrNotInstalled = errors.New("service is not installed")
+
+// run runs a service manager command and returns its combined output. Tests
+// replace it.
+var run = func(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).CombinedOutput()
+}
+
+// stream runs a command with its output going to w
+var stream = func(w io.Writer, name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = w
+	cmd.Stderr = w
+	return cmd.Run()
+}
+
+// runChecked runs a command and turns a failure into an error carrying the
+// command's output
+func runChecked(name string, args ...string) error {
+	out, err := run(name, args...)
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%s %s: %w: %s", name, strings.Join(args, " "), err, msg)
+		}
+		return fmt.Errorf("%s %s: %w", name, strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+// ForOS returns the manager for the running operating system, writing
+// definitions where that service manager looks for them
+func ForOS(spec Spec) (Manager, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	switch runtime.GOOS {
+	case "linux":
+		dir := filepath.Join(home, ".config", "systemd", "user")
+		if config := os.Getenv("XDG_CONFIG_HOME"); config != "" {
+			dir = filepath.Join(config, "systemd", "user")
+		}
+		return NewSystemd(spec, dir), nil
+	case "darwin":
+		return NewLaunchd(spec, filepath.Join(home, "Library", "LaunchAgents")), nil
+	case "windows":
+		// The task scheduler keeps its own copy; ours sits next to the log
+		return NewTaskScheduler(spec, filepath.Dir(spec.LogFile), currentUser()), nil
+	}
+	return nil, fmt.Errorf("services are not supported on %s", runtime.GOOS)
+}
+
+// Write renders a manager's service definition to its path
+func Write(m Manager) error {
+	data, err := m.Render()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(m.Path()), 0755); err != nil {
+		return fmt.Errorf("error creating %s: %w", filepath.Dir(m.Path()), err)
+	}
+	if err := os.WriteFile(m.Path(), data, 0644); err != nil {
+		return fmt.Errorf("error writing %s: %w", m.Path(), err)
+	}
+	return nil
+}
+
+// Installed says whether a manager's service definition exists
+func Installed(m Manager) bool {
+	_, err := os.Stat(m.Path())
+	return err == nil
+}
+
+// removeDefinition deletes the definition file, reporting ErrNotInstalled
+// when there is none
+func removeDefinition(path string) error {
+	err := os.Remove(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ErrNotInstalled
+	}
+	return err
+}
+
+// filePollInterval is how often a followed log file is checked for output
+const filePollInterval = time.Second
+
+// tailFile writes the last lines of a log file to w, and with follow keeps
+// writing what is appended to it
+func tailFile(w io.Writer, path string, lines int, follow bool) error {
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) && follow {
+		f, err = waitForFile(path)
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("no daemon output yet in %s", path)
+	}
+	if err != nil {
+		return err
+	}
+	defer func() { f.Close() }()
+
+	var last []string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1<<20)
+	for scanner.Scan() {
+		last = append(last, scanner.Text())
+		if len(last) > lines {
+			last = last[1:]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	for _, line := range last {
+		fmt.Fprintln(w, line)
+	}
+	if !follow {
+		return nil
+	}
+
+	offset, err := f.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	for {
+		time.Sleep(filePollInterval)
+		info, err := os.Stat(path)
+		if err != nil {
+			continue
+		}
+		if info.Size() < offset {
+			// The log was started again
+			f.Close()
+			if f, err = os.Open(path); err != nil {
+				return err
+			}
+			offset = 0
+		}
+		if info.Size() == offset {
+			continue
+		}
+		if _, err := f.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+		n, err := io.Copy(w, f)
+		if err != nil {
+			return err
+		}
+		offset += n
+	}
+}
+
+// waitForFile waits until a file exists and opens it
+func waitForFile(path string) (*os.File, error) {
+	for {
+		time.Sleep(filePollInterval)
+		f, err := os.Open(path)
+		if !errors.Is(err, os.ErrNotExist) {
+			return f, err
+		}
+	}
+}
+
+// MaxLogSize is how large a daemon log grows before OpenLog starts a new one
+const MaxLogSize = 1 << 20
+
+// OpenLog opens a daemon log for appending. A log over MaxLogSize is kept
+// as path.1 and a new one is started.
+func OpenLog(path string) (*os.File, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(path); err == nil && info.Size() > MaxLogSize {
+		if err := os.Rename(path, path+".1"); err != nil {
+			return nil, err
+		}
+	}
+	return os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+}
@@ -0,0 +1,297 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+)
+
+var testSpec = Spec{
+	Name:        "ellie-automate",
+	Label:       "com.ekilie.ellie.automate",
+	Description: "Ellie automations & 100% uptime",
+	Program:     "/opt/my tools/ellie",
+	Args:        []string{"automate", "daemon"},
+	Path:        "/usr/local/bin:/usr/bin",
+	LogFile:     "/home/me/ellie/automations/daemon.log",
+}
+
+// stubCommands records service manager commands instead of running them
+func stubCommands(t *testing.T) *[]string {
+	t.Helper()
+	var commands []string
+	saved := run
+	run = func(name string, args ...string) ([]byte, error) {
+		commands = append(commands, strings.Join(append([]string{name}, args...), " "))
+		return nil, nil
+	}
+	t.Cleanup(func() { run = saved })
+	return &commands
+}
+
+func TestSystemdUnit(t *testing.T) {
+	dir := t.TempDir()
+	m := NewSystemd(testSpec, dir)
+	if err := Write(m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Path() != filepath.Join(dir, "ellie-automate.service") {
+		t.Errorf("Path() = %s", m.Path())
+	}
+	data, err := os.ReadFile(m.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	unit := string(data)
+	for _, want := range []string{
+		"Description=Ellie automations & 100%% uptime\n",
+		`ExecStart="/opt/my tools/ellie" automate daemon` + "\n",
+		`Environment=PATH=/usr/local/bin:/usr/bin` + "\n",
+		"Restart=on-failure\n",
+		"WantedBy=default.target\n",
+	} {
+		if !strings.Contains(unit, want) {
+			t.Errorf("unit is missing %q:\n%s", want, unit)
+		}
+	}
+
+	spec := testSpec
+	spec.Program = "ellie"
+	if _, err := NewSystemd(spec, dir).Render(); err == nil {
+		t.Error("Render() with a relative program succeeded")
+	}
+}
+
+func TestSystemdQuote(t *testing.T) {
+	tests := map[string]string{
+		"plain":      "plain",
+		"":           `""`,
+		"two words":  `"two words"`,
+		`say "hi"`:   `"say \"hi\""`,
+		"$HOME":      "$$HOME",
+		"50%":        "50%%",
+		`C:\windows`: `"C:\\windows"`,
+	}
+	for in, want := range tests {
+		if got := systemdQuote(in); got != want {
+			t.Errorf("systemdQuote(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestLaunchdPlist(t *testing.T) {
+	dir := t.TempDir()
+	m := NewLaunchd(testSpec, dir)
+	if err := Write(m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Path() != filepath.Join(dir, "com.ekilie.ellie.automate.plist") {
+		t.Errorf("Path() = %s", m.Path())
+	}
+	data, err := os.ReadFile(m.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var plist struct {
+		Dict struct {
+			Keys    []string `xml:"key"`
+			Strings []string `xml:"string"`
+			Args    []string `xml:"array>string"`
+		} `xml:"dict"`
+	}
+	if err := xml.Unmarshal(data, &plist); err != nil {
+		t.Fatalf("plist isn't valid XML: %v\n%s", err, data)
+	}
+	if got := strings.Join(plist.Dict.Args, "|"); got != "/opt/my tools/ellie|automate|daemon" {
+		t.Errorf("ProgramArguments = %s", got)
+	}
+	if len(plist.Dict.Strings) == 0 || plist.Dict.Strings[0] != "com.ekilie.ellie.automate" {
+		t.Errorf("Label = %v", plist.Dict.Strings)
+	}
+	for _, key := range []string{"RunAtLoad", "KeepAlive", "StandardOutPath", "StandardErrorPath", "EnvironmentVariables"} {
+		if !strings.Contains(string(data), "<key>"+key+"</key>") {
+			t.Errorf("plist is missing %s", key)
+		}
+	}
+}
+
+func TestTaskSchedulerXML(t *testing.T) {
+	dir := t.TempDir()
+	spec := testSpec
+	spec.Program = `C:\Program Files\ellie\ellie.exe`
+	spec.LogFile = `C:\Users\me\ellie\automations\daemon.log`
+	m := NewTaskScheduler(spec, dir, `PC\me`)
+	if err := Write(m); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "ellie-automate.xml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte{0xFF, 0xFE}) {
+		t.Fatal("task definition has no UTF-16 byte order mark")
+	}
+	units := make([]uint16, (len(data)-2)/2)
+	for i := range units {
+		units[i] = uint16(data[2+2*i]) | uint16(data[3+2*i])<<8
+	}
+	task := string(utf16.Decode(units))
+
+	for _, want := range []string{
+		`<Command>C:\Program Files\ellie\ellie.exe</Command>`,
+		`<Arguments>automate daemon --log C:\Users\me\ellie\automations\daemon.log</Arguments>`,
+		`<UserId>PC\me</UserId>`,
+		"<LogonTrigger>",
+		"<ExecutionTimeLimit>PT0S</ExecutionTimeLimit>",
+	} {
+		if !strings.Contains(task, want) {
+			t.Errorf("task is missing %q:\n%s", want, task)
+		}
+	}
+}
+
+func TestWindowsQuote(t *testing.T) {
+	tests := map[string]string{
+		"plain":          "plain",
+		"":               `""`,
+		`C:\My Files\`:   `"C:\My Files\\"`,
+		`say "hi"`:       `"say \"hi\""`,
+		`back\"slash me`: `"back\\\"slash me"`,
+	}
+	for in, want := range tests {
+		if got := windowsQuote(in); got != want {
+			t.Errorf("windowsQuote(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestSystemdInstallAndUninstall(t *testing.T) {
+	commands := stubCommands(t)
+	m := NewSystemd(testSpec, t.TempDir())
+
+	if err := m.Uninstall(); !errors.Is(err, ErrNotInstalled) {
+		t.Errorf("Uninstall() before Install() = %v, want ErrNotInstalled", err)
+	}
+	if err := m.Install(); err != nil {
+		t.Fatal(err)
+	}
+	if !Installed(m) {
+		t.Error("unit file wasn't written")
+	}
+	if err := m.Uninstall(); err != nil {
+		t.Fatal(err)
+	}
+	if Installed(m) {
+		t.Error("unit file wasn't removed")
+	}
+
+	want := []string{
+		"systemctl --user daemon-reload",
+		"systemctl --user enable ellie-automate.service",
+		"systemctl --user restart ellie-automate.service",
+		"systemctl --user disable --now ellie-automate.service",
+		"systemctl --user daemon-reload",
+	}
+	if got := strings.Join(*commands, "\n"); got != strings.Join(want, "\n") {
+		t.Errorf("commands:\n%s\nwant:\n%s", got, strings.Join(want, "\n"))
+	}
+}
+
+func TestAcquire(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "automations", "daemon.pid")
+	lock, err := Acquire(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pid, running := Holder(path); !running || pid != os.Getpid() {
+		t.Errorf("Holder() = %d, %v", pid, running)
+	}
+
+	var locked *LockedError
+	if _, err := Acquire(path); !errors.As(err, &locked) || locked.PID != os.Getpid() {
+		t.Errorf("second Acquire() error = %v, want it held by this process", err)
+	}
+
+	if err := lock.Release(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Release() left the lock behind")
+	}
+}
+
+func TestAcquireTakesOverStaleLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "daemon.pid")
+	old := time.Now().Add(-time.Minute)
+	for _, stale := range []string{"999999999\n", "garbage"} {
+		if err := os.WriteFile(path, []byte(stale), 0600); err != nil {
+			t.Fatal(err)
+		}
+		os.Chtimes(path, old, old)
+		lock, err := Acquire(path)
+		if err != nil {
+			t.Fatalf("Acquire() over %q: %v", stale, err)
+		}
+		lock.Release()
+	}
+
+	// A lock just created by another daemon that hasn't written its PID yet
+	// is held
+	if err := os.WriteFile(path, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	var locked *LockedError
+	if _, err := Acquire(path); !errors.As(err, &locked) {
+		t.Errorf("Acquire() over a lock being written: %v, want it held", err)
+	}
+	os.Remove(path)
+
+	// A lock taken over by another process isn't released by the old holder
+	lock, err := Acquire(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(path, []byte("999999999\n"), 0600)
+	lock.Release()
+	if _, err := os.Stat(path); err != nil {
+		t.Error("Release() removed a lock it no longer held")
+	}
+}
+
+func TestTailFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "daemon.log")
+	if err := tailFile(&bytes.Buffer{}, path, 5, false); err == nil {
+		t.Error("tailFile() of a missing log succeeded")
+	}
+	os.WriteFile(path, []byte("one\ntwo\nthree\nfour\n"), 0600)
+	var out bytes.Buffer
+	if err := tailFile(&out, path, 2, false); err != nil {
+		t.Fatal(err)
+	}
+	if out.String() != "three\nfour\n" {
+		t.Errorf("tailFile() = %q", out.String())
+	}
+}
+
+func TestOpenLogRotates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "daemon.log")
+	os.WriteFile(path, bytes.Repeat([]byte("x"), MaxLogSize+1), 0600)
+	f, err := OpenLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	if info, err := os.Stat(path); err != nil || info.Size() != 0 {
+		t.Errorf("OpenLog() didn't start a new log: %v", err)
+	}
+	if _, err := os.Stat(path + ".1"); err != nil {
+		t.Errorf("OpenLog() didn't keep the old log: %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+package daemon
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// launchd runs the service as a launchd agent of the logged in user
+type launchd struct {
+	spec Spec
+	dir  string
+	uid  int
+}
+
+// NewLaunchd returns the manager for a launchd agent written to dir
+func NewLaunchd(spec Spec, dir string) Manager {
+	return &launchd{spec: spec, dir: dir, uid: os.Getuid()}
+}
+
+func (l *launchd) Kind() string { return "launchd" }
+
+func (l *launchd) Path() string { return filepath.Join(l.dir, l.spec.Label+".plist") }
+
+// target is the agent's name in the user's launchd domain
+func (l *launchd) target() string { return fmt.Sprintf("gui/%d/%s", l.uid, l.spec.Label) }
+
+func (l *launchd) Render() ([]byte, error) {
+	if !filepath.IsAbs(l.spec.Program) {
+		return nil, fmt.Errorf("program path %q is not absolute", l.spec.Program)
+	}
+	if l.spec.Label == "" {
+		return nil, fmt.Errorf("launchd agents need a label")
+	}
+
+	var b strings.Builder
+	b.WriteString(xml.Header)
+	b.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	b.WriteString("<plist version=\"1.0\">\n<dict>\n")
+	plistString(&b, "Label", l.spec.Label)
+	b.WriteString("\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, arg := range append([]string{l.spec.Program}, l.spec.Args...) {
+		fmt.Fprintf(&b, "\t\t<string>%s</string>\n", xmlEscape(arg))
+	}
+	b.WriteString("\t</array>\n")
+	if l.spec.Path != "" {
+		b.WriteString("\t<key>EnvironmentVariables</key>\n\t<dict>\n")
+		fmt.Fprintf(&b, "\t\t<key>PATH</key>\n\t\t<string>%s</string>\n", xmlEscape(l.spec.Path))
+		b.WriteString("\t</dict>\n")
+	}
+	b.WriteString("\t<key>RunAtLoad</key>\n\t<true/>\n")
+	// Restart after a crash but not after a clean exit; the throttle keeps a
+	// daemon that finds another holding the lock from restarting constantly
+	b.WriteString("\t<key>KeepAlive</key>\n\t<dict>\n\t\t<key>SuccessfulExit</key>\n\t\t<false/>\n\t</dict>\n")
+	b.WriteString("\t<key>ThrottleInterval</key>\n\t<integer>30</integer>\n")
+	if l.spec.LogFile != "" {
+		plistString(&b, "StandardOutPath", l.spec.LogFile)
+		plistString(&b, "StandardErrorPath", l.spec.LogFile)
+	}
+	b.WriteString("</dict>\n</plist>\n")
+	return []byte(b.String()), nil
+}
+
+func (l *launchd) Install() error {
+	if err := Write(l); err != nil {
+		return err
+	}
+	if l.spec.LogFile != "" {
+		if err := os.MkdirAll(filepath.Dir(l.spec.LogFile), 0700); err != nil {
+			return err
+		}
+	}
+	// Unload an earlier version first; it's fine if there is none
+	run("launchctl", "bootout", l.target())
+	return runChecked("launchctl", "bootstrap", fmt.Sprintf("gui/%d", l.uid), l.Path())
+}
+
+func (l *launchd) Uninstall() error {
+	if !Installed(l) {
+		return ErrNotInstalled
+	}
+	run("launchctl", "bootout", l.target())
+	return removeDefinition(l.Path())
+}
+
+func (l *launchd) Status() (string, error) {
+	out, err := run("launchctl", "list", l.spec.Label)
+	if err != nil {
+		if !Installed(l) {
+			return "", ErrNotInstalled
+		}
+		return "", fmt.Errorf("the agent is installed but not loaded: %w", err)
+	}
+	return string(out), nil
+}
+
+func (l *launchd) Logs(w io.Writer, lines int, follow bool) error {
+	return tailFile(w, l.spec.LogFile, lines, follow)
+}
+
+// plistString writes a key with a string value
+func plistString(b *strings.Builder, key, value string) {
+	fmt.Fprintf(b, "\t<key>%s</key>\n\t<string>%s</string>\n", xmlEscape(key), xmlEscape(value))
+}
+
+// xmlEscape escapes text for an XML element
+func xmlEscape(value string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
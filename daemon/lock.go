@@ -0,0 +1,50 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/tacheraSasi/ellie/store"
+)
+
+// LockedError is returned when another running process holds the lock
+type LockedError struct {
+	PID int
+}
+
+func (e *LockedError) Error() string {
+	if e.PID == 0 {
+		return "another daemon is starting"
+	}
+	return fmt.Sprintf("another daemon is already running (PID %d)", e.PID)
+}
+
+// Lock is a PID file held by the running daemon
+type Lock struct {
+	lock *store.PIDLock
+}
+
+// Acquire takes the lock at path by creating it with this process's PID. A
+// lock left by a process that is no longer running is taken over.
+func Acquire(path string) (*Lock, error) {
+	lock, err := store.LockPID(path)
+	var held *store.HeldError
+	if errors.As(err, &held) {
+		return nil, &LockedError{PID: held.PID}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &Lock{lock: lock}, nil
+}
+
+// Release removes the lock if it is still this process's
+func (l *Lock) Release() error {
+	return l.lock.Release()
+}
+
+// Holder returns the PID in the lock at path and whether that process is
+// running; see store.LockHolder.
+func Holder(path string) (int, bool) {
+	return store.LockHolder(path)
+}
@@ -0,0 +1,166 @@
+package daemon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+)
+
+// taskScheduler runs the service as a Windows scheduled task started at logon
+type taskScheduler struct {
+	spec Spec
+	dir  string
+	user string
+}
+
+// NewTaskScheduler returns the manager for a scheduled task of user, whose
+// definition is written to dir before it is registered
+func NewTaskScheduler(spec Spec, dir, user string) Manager {
+	return &taskScheduler{spec: spec, dir: dir, user: user}
+}
+
+// currentUser is the DOMAIN\name the task runs as
+func currentUser() string {
+	u, err := user.Current()
+	if err != nil {
+		return ""
+	}
+	return u.Username
+}
+
+func (t *taskScheduler) Kind() string { return "task scheduler" }
+
+func (t *taskScheduler) Path() string { return filepath.Join(t.dir, t.spec.Name+".xml") }
+
+// args are the daemon's arguments; the task scheduler drops a task's
+// output, so the daemon writes it to the log itself
+func (t *taskScheduler) args() []string {
+	args := append([]string{}, t.spec.Args...)
+	if t.spec.LogFile != "" {
+		args = append(args, "--log", t.spec.LogFile)
+	}
+	return args
+}
+
+func (t *taskScheduler) Render() ([]byte, error) {
+	if !filepath.IsAbs(t.spec.Program) && !strings.Contains(t.spec.Program, `:\`) {
+		return nil, fmt.Errorf("program path %q is not absolute", t.spec.Program)
+	}
+	quoted := make([]string, len(t.args()))
+	for i, arg := range t.args() {
+		quoted[i] = windowsQuote(arg)
+	}
+
+	var b strings.Builder
+	b.WriteString("<?xml version=\"1.0\" encoding=\"UTF-16\"?>\n")
+	b.WriteString("<Task version=\"1.2\" xmlns=\"http://schemas.microsoft.com/windows/2004/02/mit/task\">\n")
+	fmt.Fprintf(&b, "  <RegistrationInfo>\n    <Description>%s</Description>\n  </RegistrationInfo>\n", xmlEscape(t.spec.Description))
+	b.WriteString("  <Triggers>\n    <LogonTrigger>\n      <Enabled>true</Enabled>\n")
+	if t.user != "" {
+		fmt.Fprintf(&b, "      <UserId>%s</UserId>\n", xmlEscape(t.user))
+	}
+	b.WriteString("    </LogonTrigger>\n  </Triggers>\n")
+	b.WriteString("  <Principals>\n    <Principal id=\"Author\">\n")
+	if t.user != "" {
+		fmt.Fprintf(&b, "      <UserId>%s</UserId>\n", xmlEscape(t.user))
+	}
+	b.WriteString("      <LogonType>InteractiveToken</LogonType>\n      <RunLevel>LeastPrivilege</RunLevel>\n")
+	b.WriteString("    </Principal>\n  </Principals>\n")
+	b.WriteString("  <Settings>\n")
+	b.WriteString("    <MultipleInstancesPolicy>IgnoreNew</MultipleInstancesPolicy>\n")
+	b.WriteString("    <DisallowStartIfOnBatteries>false</DisallowStartIfOnBatteries>\n")
+	b.WriteString("    <StopIfGoingOnBatteries>false</StopIfGoingOnBatteries>\n")
+	b.WriteString("    <ExecutionTimeLimit>PT0S</ExecutionTimeLimit>\n")
+	b.WriteString("    <RestartOnFailure>\n      <Interval>PT1M</Interval>\n      <Count>3</Count>\n    </RestartOnFailure>\n")
+	b.WriteString("  </Settings>\n")
+	b.WriteString("  <Actions Context=\"Author\">\n    <Exec>\n")
+	fmt.Fprintf(&b, "      <Command>%s</Command>\n", xmlEscape(t.spec.Program))
+	if len(quoted) > 0 {
+		fmt.Fprintf(&b, "      <Arguments>%s</Arguments>\n", xmlEscape(strings.Join(quoted, " ")))
+	}
+	b.WriteString("    </Exec>\n  </Actions>\n</Task>\n")
+	return encodeUTF16(b.String()), nil
+}
+
+func (t *taskScheduler) Install() error {
+	if err := Write(t); err != nil {
+		return err
+	}
+	if err := runChecked("schtasks", "/Create", "/TN", t.spec.Name, "/XML", t.Path(), "/F"); err != nil {
+		return err
+	}
+	// Stop a daemon from an earlier install so the new one starts
+	run("schtasks", "/End", "/TN", t.spec.Name)
+	return runChecked("schtasks", "/Run", "/TN", t.spec.Name)
+}
+
+func (t *taskScheduler) Uninstall() error {
+	if _, err := run("schtasks", "/Query", "/TN", t.spec.Name); err != nil {
+		return removeDefinition(t.Path())
+	}
+	run("schtasks", "/End", "/TN", t.spec.Name)
+	if err := runChecked("schtasks", "/Delete", "/TN", t.spec.Name, "/F"); err != nil {
+		return err
+	}
+	if err := removeDefinition(t.Path()); err != nil && err != ErrNotInstalled {
+		return err
+	}
+	return nil
+}
+
+func (t *taskScheduler) Status() (string, error) {
+	out, err := run("schtasks", "/Query", "/TN", t.spec.Name, "/V", "/FO", "LIST")
+	if err != nil {
+		return "", ErrNotInstalled
+	}
+	return string(out), nil
+}
+
+func (t *taskScheduler) Logs(w io.Writer, lines int, follow bool) error {
+	return tailFile(w, t.spec.LogFile, lines, follow)
+}
+
+// windowsQuote quotes an argument the way Windows programs split their
+// command line
+func windowsQuote(arg string) string {
+	if arg != "" && !strings.ContainsAny(arg, " \t\"") {
+		return arg
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	slashes := 0
+	for _, r := range arg {
+		switch r {
+		case '\\':
+			slashes++
+			continue
+		case '"':
+			// Backslashes before a quote are escaped, and so is the quote
+			b.WriteString(strings.Repeat(`\`, 2*slashes+1))
+		default:
+			b.WriteString(strings.Repeat(`\`, slashes))
+		}
+		slashes = 0
+		b.WriteRune(r)
+	}
+	// Backslashes before the closing quote are escaped too
+	b.WriteString(strings.Repeat(`\`, 2*slashes))
+	b.WriteByte('"')
+	return b.String()
+}
+
+// encodeUTF16 encodes text as UTF-16LE with a byte order mark, the encoding
+// schtasks expects of task definitions
+func encodeUTF16(text string) []byte {
+	var buf bytes.Buffer
+	buf.Write([]byte{0xFF, 0xFE})
+	for _, unit := range utf16.Encode([]rune(strings.ReplaceAll(text, "\n", "\r\n"))) {
+		binary.Write(&buf, binary.LittleEndian, unit)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,115 @@
+package daemon
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// systemd runs the service as a systemd user unit
+type systemd struct {
+	spec Spec
+	dir  string
+}
+
+// NewSystemd returns the manager for a systemd user unit written to dir
+func NewSystemd(spec Spec, dir string) Manager {
+	return &systemd{spec: spec, dir: dir}
+}
+
+func (s *systemd) Kind() string { return "systemd" }
+
+func (s *systemd) unit() string { return s.spec.Name + ".service" }
+
+func (s *systemd) Path() string { return filepath.Join(s.dir, s.unit()) }
+
+func (s *systemd) Render() ([]byte, error) {
+	if !filepath.IsAbs(s.spec.Program) {
+		return nil, fmt.Errorf("program path %q is not absolute", s.spec.Program)
+	}
+	command := []string{systemdQuote(s.spec.Program)}
+	for _, arg := range s.spec.Args {
+		command = append(command, systemdQuote(arg))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "[Unit]\n")
+	fmt.Fprintf(&b, "Description=%s\n", systemdEscape(s.spec.Description))
+	fmt.Fprintf(&b, "\n[Service]\n")
+	fmt.Fprintf(&b, "Type=simple\n")
+	fmt.Fprintf(&b, "ExecStart=%s\n", strings.Join(command, " "))
+	if s.spec.Path != "" {
+		fmt.Fprintf(&b, "Environment=%s\n", systemdQuote("PATH="+s.spec.Path))
+	}
+	// A second daemon exits with an error while the first holds the lock, so
+	// the delay also keeps that from becoming a busy loop
+	fmt.Fprintf(&b, "Restart=on-failure\n")
+	fmt.Fprintf(&b, "RestartSec=30\n")
+	fmt.Fprintf(&b, "\n[Install]\n")
+	fmt.Fprintf(&b, "WantedBy=default.target\n")
+	return []byte(b.String()), nil
+}
+
+func (s *systemd) Install() error {
+	if err := Write(s); err != nil {
+		return err
+	}
+	if err := runChecked("systemctl", "--user", "daemon-reload"); err != nil {
+		return err
+	}
+	if err := runChecked("systemctl", "--user", "enable", s.unit()); err != nil {
+		return err
+	}
+	// restart rather than start, so reinstalling picks up a changed unit
+	return runChecked("systemctl", "--user", "restart", s.unit())
+}
+
+func (s *systemd) Uninstall() error {
+	if !Installed(s) {
+		return ErrNotInstalled
+	}
+	if err := runChecked("systemctl", "--user", "disable", "--now", s.unit()); err != nil {
+		return err
+	}
+	if err := removeDefinition(s.Path()); err != nil {
+		return err
+	}
+	return runChecked("systemctl", "--user", "daemon-reload")
+}
+
+func (s *systemd) Status() (string, error) {
+	out, err := run("systemctl", "--user", "status", "--no-pager", s.unit())
+	// status exits non-zero for a stopped unit but still describes it
+	if len(out) > 0 {
+		return string(out), nil
+	}
+	return "", err
+}
+
+func (s *systemd) Logs(w io.Writer, lines int, follow bool) error {
+	args := []string{"--user", "-u", s.unit(), "-n", strconv.Itoa(lines), "--no-pager"}
+	if follow {
+		args = append(args, "-f")
+	}
+	return stream(w, "journalctl", args...)
+}
+
+// systemdEscape escapes the specifiers systemd expands in unit values
+func systemdEscape(value string) string {
+	return strings.ReplaceAll(value, "%", "%%")
+}
+
+// systemdQuote makes one word of a command line or an assignment for a unit
+// file, quoting it when it has spaces or quotes and escaping the specifiers
+// and variables systemd would expand
+func systemdQuote(value string) string {
+	value = strings.ReplaceAll(systemdEscape(value), "$", "$$")
+	if value != "" && !strings.ContainsAny(value, " \t\"'\\;") {
+		return value
+	}
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return `"` + value + `"`
+}
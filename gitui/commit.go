@@ -0,0 +1,114 @@
+package gitui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+type formAction int
+
+const (
+	formEditing formAction = iota
+	formSubmit
+	formCancel
+)
+
+// commitFields are the parts of a conventional commit, in the order the
+// commit builder asks for them
+var commitFields = []struct{ label, placeholder string }{
+	{"Type", ""},
+	{"Scope", "optional, e.g. authentication"},
+	{"Description", "imperative summary, e.g. add token refresh"},
+	{"Body", "optional"},
+	{"Breaking change", "optional: what breaks and how to migrate"},
+}
+
+// commitForm asks for a conventional commit message
+type commitForm struct {
+	inputs []textinput.Model
+	focus  int
+	err    string
+}
+
+func newCommitForm(types []string, width int) commitForm {
+	f := commitForm{}
+	for i, field := range commitFields {
+		input := textinput.New()
+		input.Prompt = ""
+		input.Placeholder = field.placeholder
+		if i == 0 {
+			input.Placeholder = strings.Join(types, ", ")
+		}
+		f.inputs = append(f.inputs, input)
+	}
+	f.setWidth(width)
+	return f
+}
+
+func (f *commitForm) setWidth(width int) {
+	for i := range f.inputs {
+		f.inputs[i].Width = width - 20
+	}
+}
+
+func (f *commitForm) focusCmd() tea.Cmd {
+	for i := range f.inputs {
+		f.inputs[i].Blur()
+	}
+	return f.inputs[f.focus].Focus()
+}
+
+// update moves between fields with tab and the arrow keys. Enter on the last
+// field or ctrl+s commits; esc cancels.
+func (f *commitForm) update(msg tea.KeyMsg) (tea.Cmd, formAction) {
+	switch msg.String() {
+	case "esc":
+		return nil, formCancel
+	case "ctrl+s":
+		return nil, formSubmit
+	case "enter":
+		if f.focus == len(f.inputs)-1 {
+			return nil, formSubmit
+		}
+		f.focus++
+		return f.focusCmd(), formEditing
+	case "tab", "down":
+		f.focus = (f.focus + 1) % len(f.inputs)
+		return f.focusCmd(), formEditing
+	case "shift+tab", "up":
+		f.focus = (f.focus + len(f.inputs) - 1) % len(f.inputs)
+		return f.focusCmd(), formEditing
+	}
+	var cmd tea.Cmd
+	f.inputs[f.focus], cmd = f.inputs[f.focus].Update(msg)
+	return cmd, formEditing
+}
+
+// values returns the trimmed field values in commitFields order
+func (f *commitForm) values() []string {
+	var values []string
+	for _, input := range f.inputs {
+		values = append(values, strings.TrimSpace(input.Value()))
+	}
+	return values
+}
+
+func (f commitForm) view() string {
+	var b strings.Builder
+	b.WriteString(titleStyle.Render("Conventional Commit") + "\n\n")
+	for i, field := range commitFields {
+		label := lipgloss.NewStyle().Width(18).Render(field.label)
+		if i == f.focus {
+			label = headStyle.Width(18).Render(field.label)
+		}
+		b.WriteString(label + f.inputs[i].View() + "\n")
+	}
+	if f.err != "" {
+		b.WriteString("\n" + errorStyle.Render(f.err) + "\n")
+	}
+	b.WriteString("\n" + dimStyle.Render("tab next field • enter on the last field or ctrl+s commit • esc cancel"))
+	return b.String()
+}
@@ -0,0 +1,300 @@
+package gitui
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/tacheraSasi/ellie/review"
+)
+
+// repoRoot is the top of the work tree. git status reports paths relative
+// to it, so every command runs there, wherever the UI was started.
+var repoRoot string
+
+// openRepo finds the repository the current directory belongs to and makes
+// it the one git runs in
+func openRepo() error {
+	repoRoot = ""
+	out, err := git("", "rev-parse", "--show-toplevel")
+	if err != nil {
+		return fmt.Errorf("not a git repository")
+	}
+	repoRoot = strings.TrimSpace(out)
+	return nil
+}
+
+// git runs a git command in repoRoot, feeding it stdin, and returns its
+// output with git's own message as the error
+var git = func(stdin string, args ...string) (string, error) {
+	cmdArgs := args
+	if repoRoot != "" {
+		cmdArgs = append([]string{"-C", repoRoot}, args...)
+	}
+	cmd := exec.Command("git", cmdArgs...)
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return stdout.String(), errors.New(msg)
+		}
+		return stdout.String(), fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
+
+// File is a changed path. A file with both staged and unstaged changes
+// appears in both lists.
+type File struct {
+	Path string
+	// Orig is the old path of a rename
+	Orig string
+	// Code is the status letter: M, A, D, R, C, T, U or ? for untracked
+	Code      byte
+	Staged    bool
+	Untracked bool
+}
+
+// Label is the file as shown in the list
+func (f File) Label() string {
+	if f.Orig != "" {
+		return fmt.Sprintf("%c %s → %s", f.Code, f.Orig, f.Path)
+	}
+	return fmt.Sprintf("%c %s", f.Code, f.Path)
+}
+
+// parseStatus reads git status --porcelain=v1 -z into staged and unstaged
+// files
+func parseStatus(out string) (staged, unstaged []File) {
+	entries := strings.Split(out, "\x00")
+	for i := 0; i < len(entries); i++ {
+		entry := entries[i]
+		if len(entry) < 4 {
+			continue
+		}
+		x, y, path := entry[0], entry[1], entry[3:]
+		orig := ""
+		if x == 'R' || x == 'C' {
+			// The old path follows in its own entry
+			if i+1 < len(entries) {
+				orig = entries[i+1]
+				i++
+			}
+		}
+
+		switch {
+		case x == '?':
+			unstaged = append(unstaged, File{Path: path, Code: '?', Untracked: true})
+			continue
+		case x == '!':
+			continue
+		case x == 'U' || y == 'U' || (x == 'A' && y == 'A') || (x == 'D' && y == 'D'):
+			// Merge conflicts are resolved in the worktree
+			unstaged = append(unstaged, File{Path: path, Code: 'U'})
+			continue
+		}
+		if x != ' ' {
+			staged = append(staged, File{Path: path, Orig: orig, Code: x, Staged: true})
+		}
+		if y != ' ' {
+			unstaged = append(unstaged, File{Path: path, Code: y})
+		}
+	}
+	return staged, unstaged
+}
+
+// Branch is a local branch and how far it is from its upstream
+type Branch struct {
+	Name     string
+	Upstream string
+	Current  bool
+	Ahead    int
+	Behind   int
+	// Gone means the upstream branch was deleted
+	Gone    bool
+	Subject string
+}
+
+// Track describes the branch's position relative to its upstream
+func (b Branch) Track() string {
+	switch {
+	case b.Upstream == "":
+		return "no upstream"
+	case b.Gone:
+		return "upstream gone"
+	case b.Ahead == 0 && b.Behind == 0:
+		return "up to date"
+	}
+	var parts []string
+	if b.Ahead > 0 {
+		parts = append(parts, fmt.Sprintf("↑%d", b.Ahead))
+	}
+	if b.Behind > 0 {
+		parts = append(parts, fmt.Sprintf("↓%d", b.Behind))
+	}
+	return strings.Join(parts, " ")
+}
+
+const branchFormat = "%(HEAD)%00%(refname:short)%00%(upstream:short)%00%(upstream:track,nobracket)%00%(contents:subject)"
+
+// parseBranches reads git for-each-ref output in branchFormat, one branch
+// per line, with the current branch first
+func parseBranches(out string) []Branch {
+	var branches []Branch
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Split(line, "\x00")
+		if len(fields) < 5 {
+			continue
+		}
+		b := Branch{
+			Current:  fields[0] == "*",
+			Name:     fields[1],
+			Upstream: fields[2],
+			Subject:  fields[4],
+		}
+		for _, part := range strings.Split(fields[3], ",") {
+			part = strings.TrimSpace(part)
+			switch {
+			case part == "gone":
+				b.Gone = true
+			case strings.HasPrefix(part, "ahead "):
+				b.Ahead, _ = strconv.Atoi(strings.TrimPrefix(part, "ahead "))
+			case strings.HasPrefix(part, "behind "):
+				b.Behind, _ = strconv.Atoi(strings.TrimPrefix(part, "behind "))
+			}
+		}
+		branches = append(branches, b)
+	}
+	sort.SliceStable(branches, func(i, j int) bool { return branches[i].Current && !branches[j].Current })
+	return branches
+}
+
+// Stash is one entry of git stash list
+type Stash struct {
+	Ref     string
+	Subject string
+}
+
+// parseStashes reads git stash list --format=%gd%x00%s
+func parseStashes(out string) []Stash {
+	var stashes []Stash
+	for _, line := range strings.Split(out, "\n") {
+		ref, subject, ok := strings.Cut(line, "\x00")
+		if ok {
+			stashes = append(stashes, Stash{Ref: ref, Subject: subject})
+		}
+	}
+	return stashes
+}
+
+// Hunk is one hunk of a file's diff together with the file header, which
+// makes it a patch git apply accepts on its own
+type Hunk struct {
+	Header string
+	Body   string
+}
+
+// Patch returns the hunk as a patch for git apply
+func (h Hunk) Patch() string {
+	return h.Header + h.Body
+}
+
+// splitHunks splits a single file's diff into hunks
+func splitHunks(diff string) []Hunk {
+	var hunks []Hunk
+	for _, file := range review.ParseDiff(diff) {
+		if file.Binary {
+			continue
+		}
+		for _, body := range file.Hunks {
+			hunks = append(hunks, Hunk{Header: file.Header, Body: body})
+		}
+	}
+	return hunks
+}
+
+// status lists the changed files
+func status() (staged, unstaged []File, err error) {
+	out, err := git("", "status", "--porcelain=v1", "-z", "--untracked-files=all")
+	if err != nil {
+		return nil, nil, err
+	}
+	staged, unstaged = parseStatus(out)
+	return staged, unstaged, nil
+}
+
+// fileDiff returns the diff of a file's staged or unstaged changes.
+// Untracked files are compared with an empty file.
+func fileDiff(f File) (string, error) {
+	switch {
+	case f.Untracked:
+		// git diff --no-index exits with 1 when the files differ
+		out, err := git("", "diff", "--no-color", "--no-ext-diff", "--no-index", "--", "/dev/null", f.Path)
+		if out != "" {
+			return out, nil
+		}
+		return out, err
+	case f.Staged:
+		return git("", "diff", "--no-color", "--no-ext-diff", "--cached", "--", f.Path)
+	default:
+		return git("", "diff", "--no-color", "--no-ext-diff", "--", f.Path)
+	}
+}
+
+// toggleFile stages an unstaged file or unstages a staged one
+func toggleFile(f File) error {
+	if !f.Staged {
+		_, err := git("", "add", "--", f.Path)
+		return err
+	}
+	paths := []string{f.Path}
+	if f.Orig != "" {
+		paths = append(paths, f.Orig)
+	}
+	if _, err := git("", "rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		// Nothing is committed yet, so there is no HEAD to reset to
+		_, err := git("", append([]string{"rm", "--cached", "--quiet", "--"}, paths...)...)
+		return err
+	}
+	_, err := git("", append([]string{"reset", "--quiet", "--"}, paths...)...)
+	return err
+}
+
+// toggleHunk stages a hunk of unstaged changes or unstages a staged one
+func toggleHunk(f File, h Hunk) error {
+	if f.Untracked {
+		// A new file's diff is a single hunk
+		return toggleFile(f)
+	}
+	args := []string{"apply", "--cached", "--recount", "--whitespace=nowarn"}
+	if f.Staged {
+		args = append(args, "--reverse")
+	}
+	_, err := git(h.Patch(), append(args, "-")...)
+	return err
+}
+
+// branches lists the local branches
+func branches() ([]Branch, error) {
+	out, err := git("", "for-each-ref", "--format="+branchFormat, "refs/heads")
+	if err != nil {
+		return nil, err
+	}
+	return parseBranches(out), nil
+}
+
+// stashes lists the stashes
+func stashes() ([]Stash, error) {
+	out, err := git("", "stash", "list", "--format=%gd%x00%s")
+	if err != nil {
+		return nil, err
+	}
+	return parseStashes(out), nil
+}
@@ -0,0 +1,247 @@
+package gitui
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestParseStatus(t *testing.T) {
+	out := "M  staged.go\x00 M changed.go\x00MM both.go\x00R  new.go\x00old.go\x00?? notes.txt\x00UU conflict.go\x00A  added.go\x00"
+	staged, unstaged := parseStatus(out)
+
+	wantStaged := []File{
+		{Path: "staged.go", Code: 'M', Staged: true},
+		{Path: "both.go", Code: 'M', Staged: true},
+		{Path: "new.go", Orig: "old.go", Code: 'R', Staged: true},
+		{Path: "added.go", Code: 'A', Staged: true},
+	}
+	wantUnstaged := []File{
+		{Path: "changed.go", Code: 'M'},
+		{Path: "both.go", Code: 'M'},
+		{Path: "notes.txt", Code: '?', Untracked: true},
+		{Path: "conflict.go", Code: 'U'},
+	}
+	if !reflect.DeepEqual(staged, wantStaged) {
+		t.Errorf("staged = %+v", staged)
+	}
+	if !reflect.DeepEqual(unstaged, wantUnstaged) {
+		t.Errorf("unstaged = %+v", unstaged)
+	}
+	if got := staged[2].Label(); got != "R old.go → new.go" {
+		t.Errorf("Label() = %q", got)
+	}
+}
+
+func TestParseBranches(t *testing.T) {
+	out := " \x00feature\x00origin/feature\x00ahead 2, behind 1\x00wip\n" +
+		"*\x00main\x00origin/main\x00\x00release\n" +
+		" \x00old\x00origin/old\x00gone\x00old work\n" +
+		" \x00local\x00\x00\x00local only\n"
+	branches := parseBranches(out)
+
+	if len(branches) != 4 || branches[0].Name != "main" || !branches[0].Current {
+		t.Fatalf("parseBranches() = %+v, want main first", branches)
+	}
+	tracks := map[string]string{}
+	for _, b := range branches {
+		tracks[b.Name] = b.Track()
+	}
+	want := map[string]string{"main": "up to date", "feature": "↑2 ↓1", "old": "upstream gone", "local": "no upstream"}
+	if !reflect.DeepEqual(tracks, want) {
+		t.Errorf("Track() = %v, want %v", tracks, want)
+	}
+}
+
+func TestParseStashes(t *testing.T) {
+	got := parseStashes("stash@{0}\x00WIP on main: abc fix\nstash@{1}\x00On main: try\n")
+	want := []Stash{{"stash@{0}", "WIP on main: abc fix"}, {"stash@{1}", "On main: try"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("parseStashes() = %+v", got)
+	}
+}
+
+// newRepo creates a repository with a committed file of ten lines and
+// changes the first and last line, giving two hunks
+func newRepo(t *testing.T) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	dir := t.TempDir()
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+
+	run := func(args ...string) {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+	run("init", "--quiet", "--initial-branch=main")
+	run("config", "user.email", "test@example.com")
+	run("config", "user.name", "Test")
+
+	lines := []string{"one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten"}
+	write := func() {
+		os.WriteFile(filepath.Join(dir, "file.txt"), []byte(strings.Join(lines, "\n")+"\n"), 0o644)
+	}
+	write()
+	run("add", "file.txt")
+	run("commit", "--quiet", "-m", "init")
+
+	lines[0], lines[9] = "ONE", "TEN"
+	write()
+	return dir
+}
+
+func TestToggleHunk(t *testing.T) {
+	newRepo(t)
+
+	_, unstaged, err := status()
+	if err != nil || len(unstaged) != 1 {
+		t.Fatalf("status() = %+v, %v", unstaged, err)
+	}
+	diff, _ := fileDiff(unstaged[0])
+	hunks := splitHunks(diff)
+	if len(hunks) != 2 {
+		t.Fatalf("splitHunks() = %d hunks, want 2", len(hunks))
+	}
+
+	if err := toggleHunk(unstaged[0], hunks[1]); err != nil {
+		t.Fatalf("staging the second hunk: %v", err)
+	}
+	cached, _ := git("", "diff", "--cached")
+	if !strings.Contains(cached, "+TEN") || strings.Contains(cached, "+ONE") {
+		t.Errorf("staged diff = %q, want only the second hunk", cached)
+	}
+
+	staged, _, _ := status()
+	diff, _ = fileDiff(staged[0])
+	if err := toggleHunk(staged[0], splitHunks(diff)[0]); err != nil {
+		t.Fatalf("unstaging: %v", err)
+	}
+	if cached, _ := git("", "diff", "--cached"); cached != "" {
+		t.Errorf("staged diff after unstaging = %q", cached)
+	}
+}
+
+func TestOpenRepo_FromSubdirectory(t *testing.T) {
+	dir := newRepo(t)
+	t.Cleanup(func() { repoRoot = "" })
+	sub := filepath.Join(dir, "sub")
+	os.Mkdir(sub, 0o755)
+	os.WriteFile(filepath.Join(sub, "f.txt"), []byte("new\n"), 0o644)
+	if err := os.Chdir(sub); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := openRepo(); err != nil {
+		t.Fatalf("openRepo() error = %v", err)
+	}
+	_, unstaged, err := status()
+	if err != nil {
+		t.Fatalf("status() error = %v", err)
+	}
+	var f File
+	for _, file := range unstaged {
+		if file.Path == "sub/f.txt" {
+			f = file
+		}
+	}
+	if f.Path == "" {
+		t.Fatalf("status() = %+v, want sub/f.txt", unstaged)
+	}
+
+	if diff, err := fileDiff(f); err != nil || !strings.Contains(diff, "+new") {
+		t.Errorf("fileDiff() = %q, %v", diff, err)
+	}
+	if err := toggleFile(f); err != nil {
+		t.Fatalf("toggleFile() error = %v", err)
+	}
+	if cached, _ := git("", "diff", "--cached", "--name-only"); strings.TrimSpace(cached) != "sub/f.txt" {
+		t.Errorf("staged = %q, want sub/f.txt", cached)
+	}
+}
+
+func press(m tea.Model, keys ...string) tea.Model {
+	for _, key := range keys {
+		var msg tea.KeyMsg
+		switch key {
+		case "enter":
+			msg = tea.KeyMsg{Type: tea.KeyEnter}
+		case "esc":
+			msg = tea.KeyMsg{Type: tea.KeyEsc}
+		case " ":
+			msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}}
+		default:
+			msg = tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+		}
+		m, _ = m.Update(msg)
+	}
+	return m
+}
+
+func TestModel_StageAndCommit(t *testing.T) {
+	newRepo(t)
+	os.WriteFile("new.txt", []byte("new\n"), 0o644)
+
+	var m tea.Model = newModel(Options{
+		CommitTypes: []string{"feat", "fix"},
+		BuildMessage: func(commitType, scope, description, body, breaking string) (string, error) {
+			return commitType + ": " + description, nil
+		},
+	})
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+	if view := m.View(); !strings.Contains(view, "file.txt") || !strings.Contains(view, "new.txt") {
+		t.Fatalf("View() doesn't list the changes:\n%s", view)
+	}
+
+	// Stage the first hunk of file.txt, then the untracked file
+	m = press(m, "enter", " ", "esc", "j", " ")
+	cached, _ := git("", "diff", "--cached", "--stat")
+	if !strings.Contains(cached, "file.txt") || !strings.Contains(cached, "new.txt") {
+		t.Errorf("staged = %q", cached)
+	}
+
+	m = press(m, "c", "fix", "enter", "enter", "stage hunks")
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	if got := m.(model); got.mode != browsing || got.err != nil {
+		t.Fatalf("commit form still open: %+v", got.form.err)
+	}
+	if subject, _ := git("", "log", "-1", "--format=%s"); strings.TrimSpace(subject) != "fix: stage hunks" {
+		t.Errorf("commit subject = %q", subject)
+	}
+	// The second hunk is still unstaged
+	if diff, _ := git("", "diff"); !strings.Contains(diff, "+TEN") || strings.Contains(diff, "+ONE") {
+		t.Errorf("unstaged diff = %q", diff)
+	}
+}
+
+func TestModel_Branches(t *testing.T) {
+	newRepo(t)
+	var m tea.Model = newModel(Options{})
+	m, _ = m.Update(tea.WindowSizeMsg{Width: 100, Height: 30})
+
+	m = press(m, "2", "n", "topic", "enter")
+	if branch, _ := git("", "branch", "--show-current"); strings.TrimSpace(branch) != "topic" {
+		t.Fatalf("current branch = %q, want topic", branch)
+	}
+
+	// main is listed after the current branch; deleting it needs a yes
+	m = press(m, "j", "D", "n")
+	if out, _ := git("", "branch", "--list", "main"); out == "" {
+		t.Fatal("branch deleted without confirmation")
+	}
+	press(m, "D", "y")
+	if out, _ := git("", "branch", "--list", "main"); out != "" {
+		t.Error("branch not deleted after confirmation")
+	}
+}
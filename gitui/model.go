@@ -0,0 +1,688 @@
+package gitui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+)
+
+type panel int
+
+const (
+	filesPanel panel = iota
+	branchesPanel
+	stashesPanel
+)
+
+var panelNames = []string{"Files", "Branches", "Stashes"}
+
+type mode int
+
+const (
+	browsing mode = iota
+	// selecting hunks of the current file
+	hunkMode
+	committing
+	// typing a new branch name
+	naming
+	// waiting for y/n
+	confirming
+)
+
+var (
+	accent      = lipgloss.Color("6")
+	titleStyle  = lipgloss.NewStyle().Bold(true).Foreground(accent)
+	tabStyle    = lipgloss.NewStyle().Padding(0, 1).Foreground(lipgloss.Color("8"))
+	activeTab   = tabStyle.Bold(true).Foreground(lipgloss.Color("0")).Background(accent)
+	headStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("3"))
+	dimStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	cursorStyle = lipgloss.NewStyle().Reverse(true)
+	addStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	delStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	hunkStyle   = lipgloss.NewStyle().Foreground(accent)
+	errorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	okStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	paneStyle   = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("8"))
+	focusPane   = paneStyle.BorderForeground(accent)
+)
+
+// Options connect the UI to the rest of Ellie
+type Options struct {
+	// CommitTypes are offered in the commit form
+	CommitTypes []string
+	// BuildMessage checks the commit form and builds the conventional
+	// commit message from it
+	BuildMessage func(commitType, scope, description, body, breaking string) (string, error)
+}
+
+// model is the state of the UI
+type model struct {
+	opts          Options
+	width, height int
+	panel         panel
+	mode          mode
+	cursor        [3]int
+
+	staged, unstaged []File
+	branches         []Branch
+	stashes          []Stash
+
+	// hunks of the selected file, and the one selected in hunk mode
+	hunks       []Hunk
+	hunk        int
+	hunkOffsets []int
+	preview     viewport.Model
+
+	input    textinput.Model
+	form     commitForm
+	question string
+	onYes    func() (string, error)
+
+	message string
+	err     error
+}
+
+func newModel(opts Options) model {
+	input := textinput.New()
+	input.Prompt = "New branch: "
+	input.Placeholder = "feature/my-feature"
+	m := model{opts: opts, input: input, preview: viewport.New(0, 0)}
+	m.refresh()
+	return m
+}
+
+// files returns the staged files followed by the unstaged ones, in the
+// order they are listed
+func (m *model) files() []File {
+	return append(append([]File{}, m.staged...), m.unstaged...)
+}
+
+func (m *model) count() int {
+	switch m.panel {
+	case branchesPanel:
+		return len(m.branches)
+	case stashesPanel:
+		return len(m.stashes)
+	}
+	return len(m.staged) + len(m.unstaged)
+}
+
+// refresh reloads everything from git and keeps the cursors in range
+func (m *model) refresh() {
+	var err error
+	if m.staged, m.unstaged, err = status(); err != nil {
+		m.err = err
+	}
+	if m.branches, err = branches(); err != nil {
+		m.err = err
+	}
+	if m.stashes, err = stashes(); err != nil {
+		m.err = err
+	}
+	for p := range m.cursor {
+		saved := m.panel
+		m.panel = panel(p)
+		m.cursor[p] = clamp(m.cursor[p], m.count())
+		m.panel = saved
+	}
+	m.loadPreview()
+}
+
+func clamp(i, n int) int {
+	if i >= n {
+		i = n - 1
+	}
+	if i < 0 {
+		i = 0
+	}
+	return i
+}
+
+// loadPreview shows what the cursor is on
+func (m *model) loadPreview() {
+	m.hunks = nil
+	i := m.cursor[m.panel]
+	var content string
+	switch m.panel {
+	case filesPanel:
+		files := m.files()
+		if len(files) == 0 {
+			content = dimStyle.Render("Nothing to commit, working tree clean")
+			break
+		}
+		diff, err := fileDiff(files[i])
+		if err != nil {
+			content = errorStyle.Render(err.Error())
+			break
+		}
+		m.hunks = splitHunks(diff)
+		m.hunk = clamp(m.hunk, len(m.hunks))
+		content = m.renderHunks(diff)
+	case branchesPanel:
+		if len(m.branches) == 0 {
+			break
+		}
+		out, err := git("", "log", "--oneline", "--decorate", "--no-color", "-n", "50", m.branches[i].Name, "--")
+		content = out
+		if err != nil {
+			content = errorStyle.Render(err.Error())
+		}
+	case stashesPanel:
+		if len(m.stashes) == 0 {
+			content = dimStyle.Render("No stashes")
+			break
+		}
+		out, err := git("", "stash", "show", "-p", "--no-color", m.stashes[i].Ref)
+		content = colorDiff(out)
+		if err != nil {
+			content = errorStyle.Render(err.Error())
+		}
+	}
+	m.preview.SetContent(m.fit(content))
+	m.preview.GotoTop()
+	m.scrollToHunk()
+}
+
+// renderHunks colors the diff and, in hunk mode, marks the selected hunk
+func (m *model) renderHunks(diff string) string {
+	if len(m.hunks) == 0 {
+		return colorDiff(diff)
+	}
+	m.hunkOffsets = nil
+	var b strings.Builder
+	b.WriteString(dimStyle.Render(strings.TrimRight(m.hunks[0].Header, "\n")) + "\n")
+	line := strings.Count(m.hunks[0].Header, "\n")
+	for i, h := range m.hunks {
+		m.hunkOffsets = append(m.hunkOffsets, line)
+		for _, l := range strings.Split(strings.TrimRight(h.Body, "\n"), "\n") {
+			marker := "  "
+			if m.mode == hunkMode && i == m.hunk {
+				marker = hunkStyle.Render("▌ ")
+			}
+			b.WriteString(marker + colorLine(l) + "\n")
+			line++
+		}
+	}
+	return b.String()
+}
+
+func (m *model) scrollToHunk() {
+	if m.mode == hunkMode && m.hunk < len(m.hunkOffsets) {
+		m.preview.SetYOffset(m.hunkOffsets[m.hunk])
+	}
+}
+
+// fit expands tabs and cuts lines to the preview width so long lines don't
+// wrap
+func (m *model) fit(content string) string {
+	lines := strings.Split(strings.ReplaceAll(content, "\t", "    "), "\n")
+	for i, l := range lines {
+		if m.preview.Width > 0 {
+			lines[i] = ansi.Truncate(l, m.preview.Width, "…")
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func colorDiff(diff string) string {
+	lines := strings.Split(strings.TrimRight(diff, "\n"), "\n")
+	for i, l := range lines {
+		lines[i] = colorLine(l)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func colorLine(l string) string {
+	switch {
+	case strings.HasPrefix(l, "+++"), strings.HasPrefix(l, "---"), strings.HasPrefix(l, "diff "), strings.HasPrefix(l, "index "):
+		return dimStyle.Render(l)
+	case strings.HasPrefix(l, "+"):
+		return addStyle.Render(l)
+	case strings.HasPrefix(l, "-"):
+		return delStyle.Render(l)
+	case strings.HasPrefix(l, "@@"):
+		return hunkStyle.Render(l)
+	}
+	return l
+}
+
+func (m model) Init() tea.Cmd {
+	return nil
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.preview.Width = m.width - m.listWidth() - 4
+		m.preview.Height = m.bodyHeight()
+		m.form.setWidth(m.width - 4)
+		m.loadPreview()
+		return m, nil
+	case tea.KeyMsg:
+		if msg.String() == "ctrl+c" {
+			return m, tea.Quit
+		}
+		switch m.mode {
+		case committing:
+			return m.updateCommit(msg)
+		case naming:
+			return m.updateNaming(msg)
+		case confirming:
+			return m.updateConfirm(msg)
+		case hunkMode:
+			return m.updateHunks(msg)
+		}
+		return m.updateBrowsing(msg)
+	}
+	return m, nil
+}
+
+// run reports the outcome of a git action and reloads
+func (m *model) run(done string, err error) {
+	m.message, m.err = "", err
+	if err == nil {
+		m.message = done
+	}
+	m.refresh()
+}
+
+// ask waits for y/n before running the action
+func (m *model) ask(question string, action func() (string, error)) {
+	m.mode, m.question, m.onYes = confirming, question, action
+}
+
+func (m model) updateBrowsing(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.message, m.err = "", nil
+	switch msg.String() {
+	case "q", "esc":
+		return m, tea.Quit
+	case "tab", "right", "l":
+		m.panel = (m.panel + 1) % 3
+		m.loadPreview()
+	case "shift+tab", "left", "h":
+		m.panel = (m.panel + 2) % 3
+		m.loadPreview()
+	case "1", "2", "3":
+		m.panel = panel(msg.String()[0] - '1')
+		m.loadPreview()
+	case "down", "j":
+		if m.cursor[m.panel] < m.count()-1 {
+			m.cursor[m.panel]++
+			m.hunk = 0
+			m.loadPreview()
+		}
+	case "up", "k":
+		if m.cursor[m.panel] > 0 {
+			m.cursor[m.panel]--
+			m.hunk = 0
+			m.loadPreview()
+		}
+	case "pgdown", "ctrl+d":
+		m.preview.HalfViewDown()
+	case "pgup", "ctrl+u":
+		m.preview.HalfViewUp()
+	case "r":
+		m.refresh()
+	case "c":
+		if len(m.staged) == 0 {
+			m.err = fmt.Errorf("nothing is staged; stage files with space first")
+			break
+		}
+		m.form = newCommitForm(m.opts.CommitTypes, m.width-4)
+		m.mode = committing
+		return m, m.form.focusCmd()
+	default:
+		return m.panelKey(msg.String())
+	}
+	return m, nil
+}
+
+// panelKey handles the keys that act on the selected item
+func (m model) panelKey(key string) (tea.Model, tea.Cmd) {
+	i := m.cursor[m.panel]
+	switch m.panel {
+	case filesPanel:
+		files := m.files()
+		switch key {
+		case " ":
+			if len(files) > 0 {
+				f := files[i]
+				verb := "Staged"
+				if f.Staged {
+					verb = "Unstaged"
+				}
+				m.run(fmt.Sprintf("%s %s", verb, f.Path), toggleFile(f))
+			}
+		case "a":
+			_, err := git("", "add", "--all")
+			m.run("Staged all changes", err)
+		case "enter":
+			if len(m.hunks) > 0 {
+				m.mode, m.hunk = hunkMode, 0
+				m.loadPreview()
+			}
+		case "s":
+			_, err := git("", "stash", "push", "--include-untracked")
+			m.run("Stashed all changes", err)
+		}
+	case branchesPanel:
+		if len(m.branches) == 0 {
+			if key == "n" {
+				m.mode = naming
+				return m, m.input.Focus()
+			}
+			break
+		}
+		b := m.branches[i]
+		switch key {
+		case "enter":
+			_, err := git("", "switch", b.Name)
+			m.run("Switched to "+b.Name, err)
+			m.cursor[branchesPanel] = 0
+		case "n":
+			m.input.Reset()
+			m.mode = naming
+			return m, m.input.Focus()
+		case "D":
+			if b.Current {
+				m.err = fmt.Errorf("can't delete the current branch")
+				break
+			}
+			m.ask(fmt.Sprintf("Delete branch %s?", b.Name), func() (string, error) {
+				_, err := git("", "branch", "--delete", b.Name)
+				return "Deleted " + b.Name, err
+			})
+		}
+	case stashesPanel:
+		if len(m.stashes) == 0 {
+			break
+		}
+		s := m.stashes[i]
+		switch key {
+		case "enter", "a":
+			_, err := git("", "stash", "apply", s.Ref)
+			m.run("Applied "+s.Ref, err)
+		case "p":
+			_, err := git("", "stash", "pop", s.Ref)
+			m.run("Popped "+s.Ref, err)
+		case "D":
+			m.ask(fmt.Sprintf("Drop %s (%s)?", s.Ref, s.Subject), func() (string, error) {
+				_, err := git("", "stash", "drop", s.Ref)
+				return "Dropped " + s.Ref, err
+			})
+		}
+	}
+	return m, nil
+}
+
+func (m model) updateHunks(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m.message, m.err = "", nil
+	switch msg.String() {
+	case "esc", "q", "left", "h":
+		m.mode = browsing
+		m.loadPreview()
+	case "down", "j":
+		if m.hunk < len(m.hunks)-1 {
+			m.hunk++
+			m.preview.SetContent(m.fit(m.renderHunks("")))
+			m.scrollToHunk()
+		}
+	case "up", "k":
+		if m.hunk > 0 {
+			m.hunk--
+			m.preview.SetContent(m.fit(m.renderHunks("")))
+			m.scrollToHunk()
+		}
+	case "pgdown", "ctrl+d":
+		m.preview.HalfViewDown()
+	case "pgup", "ctrl+u":
+		m.preview.HalfViewUp()
+	case " ":
+		files := m.files()
+		f := files[m.cursor[filesPanel]]
+		verb := "Staged"
+		if f.Staged {
+			verb = "Unstaged"
+		}
+		err := toggleHunk(f, m.hunks[m.hunk])
+		m.run(fmt.Sprintf("%s hunk %d of %s", verb, m.hunk+1, f.Path), err)
+		// Stay on the file while it has hunks left
+		if m.err == nil && !m.selectFile(f) {
+			m.mode = browsing
+		}
+		m.loadPreview()
+		if len(m.hunks) == 0 {
+			m.mode = browsing
+		}
+	}
+	return m, nil
+}
+
+// selectFile moves the cursor back to f after a reload, reporting false if
+// it is no longer in its list
+func (m *model) selectFile(f File) bool {
+	for i, other := range m.files() {
+		if other.Path == f.Path && other.Staged == f.Staged {
+			m.cursor[filesPanel] = i
+			return true
+		}
+	}
+	return false
+}
+
+func (m model) updateNaming(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.mode = browsing
+		m.input.Blur()
+		return m, nil
+	case "enter":
+		name := strings.TrimSpace(m.input.Value())
+		m.mode = browsing
+		m.input.Blur()
+		if name == "" {
+			return m, nil
+		}
+		_, err := git("", "switch", "--create", name)
+		m.run("Created and switched to "+name, err)
+		m.cursor[branchesPanel] = 0
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m model) updateConfirm(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch strings.ToLower(msg.String()) {
+	case "y":
+		m.mode = browsing
+		done, err := m.onYes()
+		m.run(done, err)
+	case "n", "esc", "q":
+		m.mode = browsing
+		m.message = "Canceled"
+	}
+	return m, nil
+}
+
+func (m model) updateCommit(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	cmd, action := m.form.update(msg)
+	switch action {
+	case formCancel:
+		m.mode = browsing
+	case formSubmit:
+		v := m.form.values()
+		message, err := m.opts.BuildMessage(v[0], v[1], v[2], v[3], v[4])
+		if err != nil {
+			m.form.err = err.Error()
+			return m, nil
+		}
+		if _, err := git("", "commit", "--quiet", "--message", message); err != nil {
+			m.form.err = err.Error()
+			return m, nil
+		}
+		m.mode = browsing
+		m.run("Committed: "+strings.SplitN(message, "\n", 2)[0], nil)
+	}
+	return m, cmd
+}
+
+func (m *model) listWidth() int {
+	w := m.width * 2 / 5
+	if w < 30 {
+		w = 30
+	}
+	if w > m.width-10 {
+		w = m.width - 10
+	}
+	return w
+}
+
+// bodyHeight is the height inside the panes, below the tabs and above the
+// two footer lines
+func (m *model) bodyHeight() int {
+	h := m.height - 5
+	if h < 3 {
+		h = 3
+	}
+	return h
+}
+
+func (m model) View() string {
+	if m.width == 0 {
+		return "Loading..."
+	}
+	if m.mode == committing {
+		return m.form.view()
+	}
+
+	var tabs []string
+	for i, name := range panelNames {
+		style := tabStyle
+		if panel(i) == m.panel {
+			style = activeTab
+		}
+		tabs = append(tabs, style.Render(fmt.Sprintf("%d %s", i+1, name)))
+	}
+	header := lipgloss.JoinHorizontal(lipgloss.Top, titleStyle.Render("ellie git ui "), strings.Join(tabs, " "), "  "+m.currentBranch())
+
+	listPane, previewPane := focusPane, paneStyle
+	if m.mode == hunkMode {
+		listPane, previewPane = paneStyle, focusPane
+	}
+	list := listPane.Width(m.listWidth()).Height(m.bodyHeight()).Render(m.listView())
+	preview := previewPane.Width(m.preview.Width).Height(m.bodyHeight()).Render(m.preview.View())
+	body := lipgloss.JoinHorizontal(lipgloss.Top, list, preview)
+
+	return lipgloss.JoinVertical(lipgloss.Left, header, body, m.statusLine(), dimStyle.Render(m.helpLine()))
+}
+
+func (m *model) currentBranch() string {
+	for _, b := range m.branches {
+		if b.Current {
+			return headStyle.Render(b.Name) + " " + dimStyle.Render(b.Track())
+		}
+	}
+	return ""
+}
+
+// listView renders the selected panel's list, scrolled to the cursor
+func (m *model) listView() string {
+	var lines []string
+	at := 0
+	add := func(text string, selected bool) {
+		text = ansi.Truncate(text, m.listWidth(), "…")
+		if selected {
+			at = len(lines)
+			text = cursorStyle.Render(text)
+		}
+		lines = append(lines, text)
+	}
+	i := m.cursor[m.panel]
+
+	switch m.panel {
+	case filesPanel:
+		add(headStyle.Render(fmt.Sprintf("Staged (%d)", len(m.staged))), false)
+		for n, f := range m.staged {
+			add("  "+addStyle.Render(f.Label()), n == i)
+		}
+		add("", false)
+		add(headStyle.Render(fmt.Sprintf("Unstaged (%d)", len(m.unstaged))), false)
+		for n, f := range m.unstaged {
+			add("  "+delStyle.Render(f.Label()), len(m.staged)+n == i)
+		}
+	case branchesPanel:
+		for n, b := range m.branches {
+			marker := "  "
+			if b.Current {
+				marker = "* "
+			}
+			add(fmt.Sprintf("%s%s %s", marker, b.Name, dimStyle.Render(b.Track())), n == i)
+		}
+		if len(m.branches) == 0 {
+			add(dimStyle.Render("No branches yet"), false)
+		}
+	case stashesPanel:
+		for n, s := range m.stashes {
+			add(fmt.Sprintf("%s %s", s.Ref, dimStyle.Render(s.Subject)), n == i)
+		}
+		if len(m.stashes) == 0 {
+			add(dimStyle.Render("No stashes"), false)
+		}
+	}
+
+	height := m.bodyHeight()
+	start := 0
+	if at >= height {
+		start = at - height + 1
+	}
+	end := start + height
+	if end > len(lines) {
+		end = len(lines)
+	}
+	return strings.Join(lines[start:end], "\n")
+}
+
+func (m *model) statusLine() string {
+	switch {
+	case m.mode == confirming:
+		return headStyle.Render(m.question + " (y/n)")
+	case m.mode == naming:
+		return m.input.View()
+	case m.err != nil:
+		return errorStyle.Render("Error: " + strings.ReplaceAll(m.err.Error(), "\n", " "))
+	case m.message != "":
+		return okStyle.Render(m.message)
+	}
+	return ""
+}
+
+func (m *model) helpLine() string {
+	if m.mode == hunkMode {
+		return "j/k hunk • space stage/unstage hunk • pgup/pgdn scroll • esc back"
+	}
+	common := "tab panel • j/k move • c commit • r refresh • q quit"
+	switch m.panel {
+	case branchesPanel:
+		return "enter switch • n new • D delete • " + common
+	case stashesPanel:
+		return "enter apply • p pop • D drop • " + common
+	}
+	return "space stage/unstage • enter hunks • a stage all • s stash • " + common
+}
+
+// Run starts the UI in the current repository
+func Run(opts Options) error {
+	if err := openRepo(); err != nil {
+		return err
+	}
+	_, err := tea.NewProgram(newModel(opts), tea.WithAltScreen()).Run()
+	return err
+}
@@ -0,0 +1,207 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// anthropicVersion is the Messages API version sent with every request
+const anthropicVersion = "2023-06-01"
+
+// AnthropicProvider implements the Provider interface for Anthropic's
+// Messages API
+type AnthropicProvider struct {
+	config Config
+	// maxTokens caps the answer length, which the Messages API requires
+	maxTokens int
+}
+
+// NewAnthropicProvider creates a new Anthropic provider
+func NewAnthropicProvider(config Config) (Provider, error) {
+	if config.APIKey == "" {
+		return nil, fmt.Errorf("API key is required for Anthropic provider")
+	}
+	if config.Model == "" {
+		config.Model = "claude-3-5-sonnet-latest"
+	}
+	return &AnthropicProvider{config: config, maxTokens: 4096}, nil
+}
+
+// GetModel returns the model being used
+func (p *AnthropicProvider) GetModel() string {
+	return p.config.Model
+}
+
+// endpoint returns the Messages API URL. BaseURL may be the API host, the
+// "/v1" root or the full messages URL.
+func (p *AnthropicProvider) endpoint() string {
+	base := strings.TrimRight(p.config.BaseURL, "/")
+	switch {
+	case base == "":
+		return "https://api.anthropic.com/v1/messages"
+	case strings.HasSuffix(base, "/messages"):
+		return base
+	case strings.HasSuffix(base, "/v1"):
+		return base + "/messages"
+	default:
+		return base + "/v1/messages"
+	}
+}
+
+func (p *AnthropicProvider) headers() map[string]string {
+	return map[string]string{
+		"x-api-key":         p.config.APIKey,
+		"anthropic-version": anthropicVersion,
+		"Content-Type":      "application/json",
+	}
+}
+
+// requestBody builds the Messages API payload. System messages are lifted
+// into the top-level system field since the API only accepts user and
+// assistant turns in the message list.
+func (p *AnthropicProvider) requestBody(messages []Message, stream bool) map[string]interface{} {
+	var system []string
+	turns := make([]Message, 0, len(messages))
+	for _, msg := range messages {
+		if msg.Role == "system" {
+			system = append(system, msg.Content)
+			continue
+		}
+		turns = append(turns, msg)
+	}
+
+	body := map[string]interface{}{
+		"model":      p.config.Model,
+		"max_tokens": p.maxTokens,
+		"messages":   turns,
+	}
+	if len(system) > 0 {
+		body["system"] = strings.Join(system, "\n\n")
+	}
+	if stream {
+		body["stream"] = true
+	}
+	return body
+}
+
+// anthropicUsage is the token accounting returned by the Messages API
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+// anthropicError is the error object returned in bodies and stream events
+type anthropicError struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// Chat sends a chat request to Anthropic
+func (p *AnthropicProvider) Chat(ctx context.Context, messages []Message) (*Response, error) {
+	responseBody, err := makeRequest(ctx, p.config, p.endpoint(), p.headers(), p.requestBody(messages, false))
+	if err != nil {
+		return nil, fmt.Errorf("Anthropic API request failed: %w", err)
+	}
+
+	var response struct {
+		Content []struct {
+			Type string `json:"type"`
+			Text string `json:"text"`
+		} `json:"content"`
+		Usage anthropicUsage  `json:"usage"`
+		Error *anthropicError `json:"error"`
+	}
+
+	if err := json.Unmarshal(responseBody, &response); err != nil {
+		return nil, fmt.Errorf("error parsing Anthropic response: %w", err)
+	}
+
+	if response.Error != nil {
+		return nil, fmt.Errorf("Anthropic API error: %s - %s", response.Error.Type, response.Error.Message)
+	}
+
+	var content strings.Builder
+	for _, block := range response.Content {
+		if block.Type == "text" {
+			content.WriteString(block.Text)
+		}
+	}
+	if content.Len() == 0 {
+		return nil, fmt.Errorf("no text content in Anthropic response")
+	}
+
+	return &Response{
+		Content: content.String(),
+		Usage: Usage{
+			PromptTokens:     response.Usage.InputTokens,
+			CompletionTokens: response.Usage.OutputTokens,
+			TotalTokens:      response.Usage.InputTokens + response.Usage.OutputTokens,
+		},
+	}, nil
+}
+
+// ChatStream sends a streaming chat request to Anthropic and emits text deltas
+func (p *AnthropicProvider) ChatStream(ctx context.Context, messages []Message, onChunk StreamHandler) (*Response, error) {
+	resp, err := makeStreamRequest(ctx, p.config, p.endpoint(), p.headers(), p.requestBody(messages, true))
+	if err != nil {
+		return nil, fmt.Errorf("Anthropic API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var content strings.Builder
+	result := &Response{}
+
+	err = readSSE(resp.Body, func(data string) error {
+		var event struct {
+			Type    string `json:"type"`
+			Message struct {
+				Usage anthropicUsage `json:"usage"`
+			} `json:"message"`
+			Delta struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"delta"`
+			Usage *anthropicUsage `json:"usage"`
+			Error *anthropicError `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(data), &event); err != nil {
+			return fmt.Errorf("error parsing Anthropic stream event: %w", err)
+		}
+
+		switch event.Type {
+		case "message_start":
+			result.Usage.PromptTokens = event.Message.Usage.InputTokens
+			result.Usage.CompletionTokens = event.Message.Usage.OutputTokens
+		case "content_block_delta":
+			if event.Delta.Type != "text_delta" || event.Delta.Text == "" {
+				return nil
+			}
+			content.WriteString(event.Delta.Text)
+			if onChunk != nil {
+				onChunk(event.Delta.Text)
+			}
+		case "message_delta":
+			// Output tokens in message_delta are cumulative
+			if event.Usage != nil {
+				result.Usage.CompletionTokens = event.Usage.OutputTokens
+			}
+		case "message_stop":
+			return errStreamDone
+		case "error":
+			if event.Error != nil {
+				return fmt.Errorf("Anthropic API error: %s - %s", event.Error.Type, event.Error.Message)
+			}
+			return fmt.Errorf("Anthropic API error")
+		}
+		return nil
+	})
+
+	result.Content = content.String()
+	result.Usage.TotalTokens = result.Usage.PromptTokens + result.Usage.CompletionTokens
+	if err != nil {
+		return result, fmt.Errorf("Anthropic stream failed: %w", err)
+	}
+	return result, nil
+}
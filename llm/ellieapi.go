@@ -1,8 +1,11 @@
 package llm
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"strings"
 )
 
 // EllieAPIProvider implements the Provider interface for Ellie's API
@@ -22,15 +25,40 @@ func NewEllieAPIProvider(config Config) (Provider, error) {
 	}, nil
 }
 
+// requestBody builds the Ellie API payload. The API takes OpenAI-style
+// messages, so system messages are merged into a single leading system turn.
+func (p *EllieAPIProvider) requestBody(messages []Message, stream bool) map[string]interface{} {
+	var system []string
+	turns := make([]Message, 0, len(messages)+1)
+	for _, msg := range messages {
+		if msg.Role == "system" {
+			system = append(system, msg.Content)
+			continue
+		}
+		turns = append(turns, msg)
+	}
+	if len(system) > 0 {
+		turns = append([]Message{{Role: "system", Content: strings.Join(system, "\n\n")}}, turns...)
+	}
+
+	body := map[string]interface{}{
+		"messages": turns,
+	}
+	if stream {
+		body["stream"] = true
+	}
+	return body
+}
+
 // Chat sends a chat request to the Ellie API
-func (p *EllieAPIProvider) Chat(messages []Message) (*Response, error) {
+func (p *EllieAPIProvider) Chat(ctx context.Context, messages []Message) (*Response, error) {
 	// Prepare request body
-	requestBody := map[string]interface{}{
-		"messages": messages,
-	}
+	requestBody := p.requestBody(messages, false)
 
 	// Make request to Ellie API
 	responseData, err := makeRequest(
+		ctx,
+		p.config,
 		fmt.Sprintf("%s/api/chat", p.config.BaseURL),
 		map[string]string{
 			"Content-Type": "application/json",
@@ -58,6 +86,77 @@ func (p *EllieAPIProvider) Chat(messages []Message) (*Response, error) {
 	}, nil
 }
 
+// ChatStream sends a streaming chat request to the Ellie API. Servers that do
+// not support streaming answer with a plain JSON body, which is emitted as a
+// single chunk.
+func (p *EllieAPIProvider) ChatStream(ctx context.Context, messages []Message, onChunk StreamHandler) (*Response, error) {
+	requestBody := p.requestBody(messages, true)
+
+	resp, err := makeStreamRequest(
+		ctx,
+		p.config,
+		fmt.Sprintf("%s/api/chat", p.config.BaseURL),
+		map[string]string{
+			"Content-Type": "application/json",
+		},
+		requestBody,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request to Ellie API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	type ellieChunk struct {
+		Content string `json:"content"`
+		Usage   *Usage `json:"usage"`
+	}
+
+	if !isEventStream(resp) {
+		responseData, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read Ellie API response: %w", err)
+		}
+		var apiResponse ellieChunk
+		if err := json.Unmarshal(responseData, &apiResponse); err != nil {
+			return nil, fmt.Errorf("failed to parse Ellie API response: %w", err)
+		}
+		if onChunk != nil && apiResponse.Content != "" {
+			onChunk(apiResponse.Content)
+		}
+		result := &Response{Content: apiResponse.Content}
+		if apiResponse.Usage != nil {
+			result.Usage = *apiResponse.Usage
+		}
+		return result, nil
+	}
+
+	var content strings.Builder
+	result := &Response{}
+
+	err = readSSE(resp.Body, func(data string) error {
+		var chunk ellieChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("failed to parse Ellie API stream chunk: %w", err)
+		}
+		if chunk.Usage != nil {
+			result.Usage = *chunk.Usage
+		}
+		if chunk.Content != "" {
+			content.WriteString(chunk.Content)
+			if onChunk != nil {
+				onChunk(chunk.Content)
+			}
+		}
+		return nil
+	})
+
+	result.Content = content.String()
+	if err != nil {
+		return result, fmt.Errorf("Ellie API stream failed: %w", err)
+	}
+	return result, nil
+}
+
 // GetModel returns the model name
 func (p *EllieAPIProvider) GetModel() string {
 	return "ellie-api"
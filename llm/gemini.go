@@ -1,8 +1,11 @@
 package llm
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"strings"
 )
 
 // GeminiProvider implements the Provider interface for Google's Gemini
@@ -16,7 +19,7 @@ func NewGeminiProvider(config Config) (Provider, error) {
 		return nil, fmt.Errorf("API key is required for Gemini provider")
 	}
 	if config.Model == "" {
-		config.Model = "gemini-pro"
+		config.Model = "gemini-1.5-flash"
 	}
 	return &GeminiProvider{config: config}, nil
 }
@@ -26,51 +29,199 @@ func (p *GeminiProvider) GetModel() string {
 	return p.config.Model
 }
 
-// Chat sends a chat request to Gemini
-func (p *GeminiProvider) Chat(messages []Message) (*Response, error) {
-	url := fmt.Sprintf("https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent", p.config.Model)
-	if p.config.BaseURL != "" {
-		url = p.config.BaseURL
+// geminiResponse is the payload returned by generateContent and by each
+// event of streamGenerateContent
+type geminiResponse struct {
+	Candidates []struct {
+		Content struct {
+			Parts []struct {
+				Text         string `json:"text"`
+				FunctionCall *struct {
+					Name string          `json:"name"`
+					Args json.RawMessage `json:"args"`
+				} `json:"functionCall"`
+			} `json:"parts"`
+		} `json:"content"`
+	} `json:"candidates"`
+	PromptFeedback struct {
+		TokenCount int `json:"tokenCount"`
+	} `json:"promptFeedback"`
+	UsageMetadata *struct {
+		PromptTokenCount     int `json:"promptTokenCount"`
+		CandidatesTokenCount int `json:"candidatesTokenCount"`
+		TotalTokenCount      int `json:"totalTokenCount"`
+	} `json:"usageMetadata"`
+}
+
+// text joins the text parts of the first candidate
+func (r *geminiResponse) text() string {
+	if len(r.Candidates) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	for _, part := range r.Candidates[0].Content.Parts {
+		sb.WriteString(part.Text)
+	}
+	return sb.String()
+}
+
+// toolCalls returns the function calls of the first candidate. Gemini does
+// not identify calls, so IDs are numbered from first.
+func (r *geminiResponse) toolCalls(first int) []ToolCall {
+	if len(r.Candidates) == 0 {
+		return nil
+	}
+	var calls []ToolCall
+	for _, part := range r.Candidates[0].Content.Parts {
+		if part.FunctionCall == nil {
+			continue
+		}
+		calls = append(calls, ToolCall{
+			ID:        fmt.Sprintf("call_%d", first+len(calls)),
+			Name:      part.FunctionCall.Name,
+			Arguments: part.FunctionCall.Args,
+		})
+	}
+	return calls
+}
+
+// usage converts Gemini's token accounting to Usage
+func (r *geminiResponse) usage() Usage {
+	if r.UsageMetadata == nil {
+		return Usage{PromptTokens: r.PromptFeedback.TokenCount}
+	}
+	return Usage{
+		PromptTokens:     r.UsageMetadata.PromptTokenCount,
+		CompletionTokens: r.UsageMetadata.CandidatesTokenCount,
+		TotalTokens:      r.UsageMetadata.TotalTokenCount,
+	}
+}
+
+// endpoint returns the URL of a model method such as "generateContent".
+// BaseURL may be the API host, a version root such as ".../v1beta", or a
+// model URL with or without a method; any method on it is replaced, so
+// Chat and streaming each reach their own endpoint.
+func (p *GeminiProvider) endpoint(method string) string {
+	base := strings.TrimRight(p.config.BaseURL, "/")
+	if base == "" {
+		base = "https://generativelanguage.googleapis.com/v1beta"
+	}
+	for _, suffix := range []string{":streamGenerateContent", ":generateContent"} {
+		if i := strings.Index(base, suffix); i >= 0 {
+			base = base[:i]
+		}
 	}
 
-	headers := map[string]string{
+	if !strings.Contains(base, "/models/") {
+		if u, err := url.Parse(base); err == nil && strings.Trim(u.Path, "/") == "" {
+			base += "/v1beta"
+		}
+		if !strings.HasSuffix(base, "/models") {
+			base += "/models"
+		}
+		base += "/" + p.config.Model
+	}
+	return base + ":" + method
+}
+
+func (p *GeminiProvider) headers() map[string]string {
+	return map[string]string{
 		"x-goog-api-key": p.config.APIKey,
 		"Content-Type":   "application/json",
 	}
+}
 
-	// Convert messages to Gemini format
+// requestBody converts messages to Gemini's contents format. System messages
+// become the systemInstruction, assistant turns use Gemini's "model" role and
+// tool calls and results become functionCall and functionResponse parts.
+func (p *GeminiProvider) requestBody(messages []Message, tools []Tool) map[string]interface{} {
+	var system []map[string]interface{}
 	var contents []map[string]interface{}
+	toolTurn := -1
 	for _, msg := range messages {
+		if msg.Role == "system" {
+			system = append(system, map[string]interface{}{"text": msg.Content})
+			continue
+		}
+
+		if msg.Role == "tool" {
+			part := map[string]interface{}{
+				"functionResponse": map[string]interface{}{
+					"name":     msg.Name,
+					"response": map[string]string{"result": msg.Content},
+				},
+			}
+			// Results of parallel calls go back together in one turn
+			if toolTurn >= 0 && toolTurn == len(contents)-1 {
+				contents[toolTurn]["parts"] = append(contents[toolTurn]["parts"].([]map[string]interface{}), part)
+				continue
+			}
+			toolTurn = len(contents)
+			contents = append(contents, map[string]interface{}{
+				"role":  "user",
+				"parts": []map[string]interface{}{part},
+			})
+			continue
+		}
+
+		role := msg.Role
+		if role == "assistant" {
+			role = "model"
+		}
+		var parts []map[string]interface{}
+		if msg.Content != "" || len(msg.ToolCalls) == 0 {
+			parts = append(parts, map[string]interface{}{"text": msg.Content})
+		}
+		for _, call := range msg.ToolCalls {
+			parts = append(parts, map[string]interface{}{
+				"functionCall": map[string]interface{}{
+					"name": call.Name,
+					"args": call.arguments(),
+				},
+			})
+		}
 		contents = append(contents, map[string]interface{}{
-			"role": msg.Role,
-			"parts": []map[string]string{
-				{"text": msg.Content},
-			},
+			"role":  role,
+			"parts": parts,
 		})
 	}
 
-	requestBody := map[string]interface{}{
+	body := map[string]interface{}{
 		"contents": contents,
 	}
+	if len(system) > 0 {
+		body["systemInstruction"] = map[string]interface{}{
+			"parts": system,
+		}
+	}
+	if len(tools) > 0 {
+		declarations := make([]map[string]interface{}, 0, len(tools))
+		for _, tool := range tools {
+			declaration := map[string]interface{}{
+				"name":        tool.Name,
+				"description": tool.Description,
+			}
+			// Gemini rejects an empty parameters object
+			if tool.hasProperties() {
+				declaration["parameters"] = tool.Parameters
+			}
+			declarations = append(declarations, declaration)
+		}
+		body["tools"] = []map[string]interface{}{
+			{"functionDeclarations": declarations},
+		}
+	}
+	return body
+}
 
-	responseBody, err := makeRequest(url, headers, requestBody)
+// Chat sends a chat request to Gemini
+func (p *GeminiProvider) Chat(ctx context.Context, messages []Message) (*Response, error) {
+	responseBody, err := makeRequest(ctx, p.config, p.endpoint("generateContent"), p.headers(), p.requestBody(messages, nil))
 	if err != nil {
 		return nil, fmt.Errorf("Gemini API request failed: %w", err)
 	}
 
-	var response struct {
-		Candidates []struct {
-			Content struct {
-				Parts []struct {
-					Text string `json:"text"`
-				} `json:"parts"`
-			} `json:"content"`
-		} `json:"candidates"`
-		PromptFeedback struct {
-			TokenCount int `json:"tokenCount"`
-		} `json:"promptFeedback"`
-	}
-
+	var response geminiResponse
 	if err := json.Unmarshal(responseBody, &response); err != nil {
 		return nil, fmt.Errorf("error parsing Gemini response: %w", err)
 	}
@@ -80,9 +231,54 @@ func (p *GeminiProvider) Chat(messages []Message) (*Response, error) {
 	}
 
 	return &Response{
-		Content: response.Candidates[0].Content.Parts[0].Text,
-		Usage: Usage{
-			PromptTokens: response.PromptFeedback.TokenCount,
-		},
+		Content:   response.text(),
+		Usage:     response.usage(),
+		ToolCalls: response.toolCalls(0),
 	}, nil
 }
+
+// ChatStream sends a streaming chat request to Gemini using server-sent events
+func (p *GeminiProvider) ChatStream(ctx context.Context, messages []Message, onChunk StreamHandler) (*Response, error) {
+	return p.stream(ctx, messages, nil, onChunk)
+}
+
+// ChatWithTools streams a chat request that offers tools as function
+// declarations
+func (p *GeminiProvider) ChatWithTools(ctx context.Context, messages []Message, tools []Tool, onChunk StreamHandler) (*Response, error) {
+	return p.stream(ctx, messages, tools, onChunk)
+}
+
+func (p *GeminiProvider) stream(ctx context.Context, messages []Message, tools []Tool, onChunk StreamHandler) (*Response, error) {
+	resp, err := makeStreamRequest(ctx, p.config, p.endpoint("streamGenerateContent?alt=sse"), p.headers(), p.requestBody(messages, tools))
+	if err != nil {
+		return nil, fmt.Errorf("Gemini API request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var content strings.Builder
+	result := &Response{}
+
+	err = readSSE(resp.Body, func(data string) error {
+		var chunk geminiResponse
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("error parsing Gemini stream chunk: %w", err)
+		}
+		if chunk.UsageMetadata != nil {
+			result.Usage = chunk.usage()
+		}
+		result.ToolCalls = append(result.ToolCalls, chunk.toolCalls(len(result.ToolCalls))...)
+		if text := chunk.text(); text != "" {
+			content.WriteString(text)
+			if onChunk != nil {
+				onChunk(text)
+			}
+		}
+		return nil
+	})
+
+	result.Content = content.String()
+	if err != nil {
+		return result, fmt.Errorf("Gemini stream failed: %w", err)
+	}
+	return result, nil
+}
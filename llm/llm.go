@@ -1,17 +1,18 @@
 package llm
 
 import (
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
-	"net/http"
 	"time"
 )
 
 // Provider defines the interface for different LLM providers
 type Provider interface {
-	Chat(messages []Message) (*Response, error)
+	Chat(ctx context.Context, messages []Message) (*Response, error)
+	// ChatStream behaves like Chat but calls onChunk with each piece of the
+	// answer as it arrives. Cancelling ctx aborts the request; the returned
+	// Response then holds whatever was received so far.
+	ChatStream(ctx context.Context, messages []Message, onChunk StreamHandler) (*Response, error)
 	GetModel() string
 }
 
@@ -19,12 +20,18 @@ type Provider interface {
 type Message struct {
 	Role    string `json:"role"`
 	Content string `json:"content"`
+	// ToolCalls holds the calls requested by an assistant message
+	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	// ToolCallID and Name identify the call a "tool" message answers
+	ToolCallID string `json:"tool_call_id,omitempty"`
+	Name       string `json:"name,omitempty"`
 }
 
 // Response represents the LLM response
 type Response struct {
-	Content string
-	Usage   Usage
+	Content   string
+	Usage     Usage
+	ToolCalls []ToolCall
 }
 
 // Usage represents token usage information
@@ -36,10 +43,14 @@ type Usage struct {
 
 // Config holds the configuration for LLM providers
 type Config struct {
-	APIKey     string
-	Model      string
-	BaseURL    string
-	Timeout    time.Duration
+	APIKey  string
+	Model   string
+	BaseURL string
+	// Timeout bounds each request attempt (DefaultTimeout when zero). For
+	// streamed answers it only covers the wait for the first response.
+	Timeout time.Duration
+	// MaxRetries is how often 429/5xx answers are retried
+	// (DefaultMaxRetries when zero, no retries when negative).
 	MaxRetries int
 }
 
@@ -52,46 +63,11 @@ func NewProvider(providerType string, config Config) (Provider, error) {
 		return NewGeminiProvider(config)
 	case "ellieapi":
 		return NewEllieAPIProvider(config)
+	case "anthropic":
+		return NewAnthropicProvider(config)
+	case "local", "ollama":
+		return NewLocalProvider(config)
 	default:
 		return nil, fmt.Errorf("unsupported provider type: %s", providerType)
 	}
 }
-
-// makeRequest is a helper function to make HTTP requests
-func makeRequest(url string, headers map[string]string, body interface{}) ([]byte, error) {
-	jsonBody, err := json.Marshal(body)
-	if err != nil {
-		return nil, fmt.Errorf("error marshaling request body: %w", err)
-	}
-
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return nil, fmt.Errorf("error creating request: %w", err)
-	}
-
-	for key, value := range headers {
-		req.Header.Set(key, value)
-	}
-
-	client := &http.Client{
-		Timeout: 30 * time.Second,
-	}
-
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("error making request: %w", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	responseBody, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("error reading response body: %w", err)
-	}
-
-	return responseBody, nil
-}
@@ -1,7 +1,12 @@
 package llm
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 )
 
@@ -30,6 +35,34 @@ func TestNewProvider(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name:         "valid Anthropic provider",
+			providerType: "anthropic",
+			config: Config{
+				APIKey: "test-key",
+			},
+			wantErr: false,
+		},
+		{
+			name:         "local provider without API key",
+			providerType: "local",
+			config:       Config{},
+			wantErr:      false,
+		},
+		{
+			name:         "ollama alias for local provider",
+			providerType: "ollama",
+			config: Config{
+				Model: "qwen2.5-coder",
+			},
+			wantErr: false,
+		},
+		{
+			name:         "missing API key for Anthropic",
+			providerType: "anthropic",
+			config:       Config{},
+			wantErr:      true,
+		},
 		{
 			name:         "invalid provider type",
 			providerType: "invalid",
@@ -85,6 +118,26 @@ func TestOpenAIProvider_GetModel(t *testing.T) {
 	}
 }
 
+func TestOpenAIProvider_Endpoint(t *testing.T) {
+	tests := []struct {
+		baseURL string
+		want    string
+	}{
+		{"", "https://api.openai.com/v1/chat/completions"},
+		// The ELLIE_BASE_URL example in the README
+		{"https://gw.internal/v1", "https://gw.internal/v1/chat/completions"},
+		{"https://gw.internal/v1/", "https://gw.internal/v1/chat/completions"},
+		{"https://gw.internal/v1/chat/completions", "https://gw.internal/v1/chat/completions"},
+	}
+
+	for _, tt := range tests {
+		provider := &OpenAIProvider{config: Config{BaseURL: tt.baseURL}}
+		if got := provider.endpoint(); got != tt.want {
+			t.Errorf("endpoint() with BaseURL %q = %q, want %q", tt.baseURL, got, tt.want)
+		}
+	}
+}
+
 func TestGeminiProvider_GetModel(t *testing.T) {
 	config := Config{
 		APIKey: "test-key",
@@ -100,6 +153,30 @@ func TestGeminiProvider_GetModel(t *testing.T) {
 	}
 }
 
+func TestGeminiProvider_Endpoint(t *testing.T) {
+	tests := []struct {
+		baseURL string
+		want    string
+	}{
+		{"", "https://generativelanguage.googleapis.com/v1beta/models/gemini-pro:%s"},
+		{"https://gw.internal", "https://gw.internal/v1beta/models/gemini-pro:%s"},
+		{"https://gw.internal/google/v1/", "https://gw.internal/google/v1/models/gemini-pro:%s"},
+		{"https://gw.internal/v1beta/models", "https://gw.internal/v1beta/models/gemini-pro:%s"},
+		{"https://gw.internal/v1beta/models/gemini-2.0-flash", "https://gw.internal/v1beta/models/gemini-2.0-flash:%s"},
+		{"https://gw.internal/v1beta/models/gemini-pro:generateContent", "https://gw.internal/v1beta/models/gemini-pro:%s"},
+		{"https://gw.internal/v1beta/models/gemini-pro:streamGenerateContent?alt=sse", "https://gw.internal/v1beta/models/gemini-pro:%s"},
+	}
+
+	for _, tt := range tests {
+		provider := &GeminiProvider{config: Config{Model: "gemini-pro", BaseURL: tt.baseURL}}
+		for _, method := range []string{"generateContent", "streamGenerateContent?alt=sse"} {
+			if got, want := provider.endpoint(method), fmt.Sprintf(tt.want, method); got != want {
+				t.Errorf("endpoint(%q) with BaseURL %q = %q, want %q", method, tt.baseURL, got, want)
+			}
+		}
+	}
+}
+
 func TestMessage_JSON(t *testing.T) {
 	msg := Message{
 		Role:    "user",
@@ -120,3 +197,169 @@ func TestMessage_JSON(t *testing.T) {
 		t.Errorf("Unmarshaled message = %+v, want %+v", unmarshaled, msg)
 	}
 }
+
+// recordingServer answers every request with body and keeps the last request
+// it received so tests can inspect headers and payload
+func recordingServer(t *testing.T, body string) (*httptest.Server, *http.Request, *[]byte) {
+	t.Helper()
+	var lastRequest http.Request
+	var lastBody []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lastRequest = *r
+		lastBody, _ = io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, body)
+	}))
+	t.Cleanup(server.Close)
+	return server, &lastRequest, &lastBody
+}
+
+func TestAnthropicProvider_Chat(t *testing.T) {
+	server, req, body := recordingServer(t, `{
+		"content": [{"type": "text", "text": "Hello from "}, {"type": "text", "text": "Claude"}],
+		"usage": {"input_tokens": 12, "output_tokens": 4}
+	}`)
+
+	provider, err := NewAnthropicProvider(Config{APIKey: "test-key", Model: "claude-test", BaseURL: server.URL})
+	if err != nil {
+		t.Fatalf("Failed to create Anthropic provider: %v", err)
+	}
+
+	resp, err := provider.Chat(context.Background(), []Message{
+		{Role: "system", Content: "Be brief."},
+		{Role: "user", Content: "hi"},
+	})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	if resp.Content != "Hello from Claude" {
+		t.Errorf("Chat() content = %q", resp.Content)
+	}
+	if want := (Usage{PromptTokens: 12, CompletionTokens: 4, TotalTokens: 16}); resp.Usage != want {
+		t.Errorf("Chat() usage = %+v, want %+v", resp.Usage, want)
+	}
+
+	if req.URL.Path != "/v1/messages" {
+		t.Errorf("request path = %q, want /v1/messages", req.URL.Path)
+	}
+	if req.Header.Get("x-api-key") != "test-key" || req.Header.Get("anthropic-version") == "" {
+		t.Errorf("request headers = %v, want x-api-key and anthropic-version", req.Header)
+	}
+
+	var sent struct {
+		Model     string    `json:"model"`
+		System    string    `json:"system"`
+		MaxTokens int       `json:"max_tokens"`
+		Messages  []Message `json:"messages"`
+	}
+	if err := json.Unmarshal(*body, &sent); err != nil {
+		t.Fatalf("request body is not JSON: %v", err)
+	}
+	if sent.System != "Be brief." {
+		t.Errorf("system = %q, want the system message at the top level", sent.System)
+	}
+	if len(sent.Messages) != 1 || sent.Messages[0].Role != "user" {
+		t.Errorf("messages = %+v, want only the user turn", sent.Messages)
+	}
+	if sent.Model != "claude-test" || sent.MaxTokens <= 0 {
+		t.Errorf("model = %q, max_tokens = %d", sent.Model, sent.MaxTokens)
+	}
+}
+
+func TestLocalProvider_Chat(t *testing.T) {
+	server, req, body := recordingServer(t, `{
+		"choices": [{"message": {"role": "assistant", "content": "local answer"}}],
+		"usage": {"prompt_tokens": 3, "completion_tokens": 2, "total_tokens": 5}
+	}`)
+
+	provider, err := NewProvider("ollama", Config{Model: "llama3.2", BaseURL: server.URL + "/v1"})
+	if err != nil {
+		t.Fatalf("Failed to create local provider: %v", err)
+	}
+
+	resp, err := provider.Chat(context.Background(), []Message{{Role: "user", Content: "hi"}})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	if resp.Content != "local answer" || resp.Usage.TotalTokens != 5 {
+		t.Errorf("Chat() = %+v", resp)
+	}
+	if req.URL.Path != "/v1/chat/completions" {
+		t.Errorf("request path = %q, want /v1/chat/completions", req.URL.Path)
+	}
+	if auth := req.Header.Get("Authorization"); auth != "" {
+		t.Errorf("Authorization = %q, want none without an API key", auth)
+	}
+
+	var sent struct {
+		Model string `json:"model"`
+	}
+	if err := json.Unmarshal(*body, &sent); err != nil || sent.Model != "llama3.2" {
+		t.Errorf("request model = %q (err %v), want llama3.2", sent.Model, err)
+	}
+}
+
+func TestGeminiProvider_SystemInstructionAndRoles(t *testing.T) {
+	server, _, body := recordingServer(t, `{"candidates":[{"content":{"parts":[{"text":"ok"}]}}]}`)
+
+	provider, _ := NewGeminiProvider(Config{APIKey: "test-key", BaseURL: server.URL})
+	_, err := provider.Chat(context.Background(), []Message{
+		{Role: "system", Content: "You are Ellie."},
+		{Role: "user", Content: "hi"},
+		{Role: "assistant", Content: "hello"},
+		{Role: "user", Content: "again"},
+	})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	var sent struct {
+		SystemInstruction struct {
+			Parts []struct {
+				Text string `json:"text"`
+			} `json:"parts"`
+		} `json:"systemInstruction"`
+		Contents []struct {
+			Role string `json:"role"`
+		} `json:"contents"`
+	}
+	if err := json.Unmarshal(*body, &sent); err != nil {
+		t.Fatalf("request body is not JSON: %v", err)
+	}
+
+	if len(sent.SystemInstruction.Parts) != 1 || sent.SystemInstruction.Parts[0].Text != "You are Ellie." {
+		t.Errorf("systemInstruction = %+v", sent.SystemInstruction)
+	}
+	var roles []string
+	for _, content := range sent.Contents {
+		roles = append(roles, content.Role)
+	}
+	if fmt.Sprint(roles) != "[user model user]" {
+		t.Errorf("content roles = %v, want [user model user]", roles)
+	}
+}
+
+func TestEllieAPIProvider_SystemMessage(t *testing.T) {
+	server, _, body := recordingServer(t, `{"content":"ok"}`)
+
+	provider, _ := NewEllieAPIProvider(Config{BaseURL: server.URL})
+	_, err := provider.Chat(context.Background(), []Message{
+		{Role: "user", Content: "hi"},
+		{Role: "system", Content: "You are Ellie."},
+	})
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+
+	var sent struct {
+		Messages []Message `json:"messages"`
+	}
+	if err := json.Unmarshal(*body, &sent); err != nil {
+		t.Fatalf("request body is not JSON: %v", err)
+	}
+	if len(sent.Messages) != 2 || sent.Messages[0].Role != "system" || sent.Messages[1].Role != "user" {
+		t.Errorf("messages = %+v, want a leading system message", sent.Messages)
+	}
+}
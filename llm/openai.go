@@ -1,13 +1,21 @@
 package llm
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 )
 
-// OpenAIProvider implements the Provider interface for OpenAI
+// OpenAIProvider implements the Provider interface for OpenAI and for any
+// server speaking the same chat completions API
 type OpenAIProvider struct {
 	config Config
+	// name identifies the backend in error messages
+	name string
+	// noTools is set for local servers, whose tool support depends on the
+	// model; tools offered to them are ignored
+	noTools bool
 }
 
 // NewOpenAIProvider creates a new OpenAI provider
@@ -18,7 +26,20 @@ func NewOpenAIProvider(config Config) (Provider, error) {
 	if config.Model == "" {
 		config.Model = "gpt-3.5-turbo"
 	}
-	return &OpenAIProvider{config: config}, nil
+	return &OpenAIProvider{config: config, name: "OpenAI"}, nil
+}
+
+// NewLocalProvider creates a provider for an OpenAI-compatible server such as
+// Ollama, the llama.cpp server or LM Studio. BaseURL defaults to Ollama on
+// localhost and the API key is optional.
+func NewLocalProvider(config Config) (Provider, error) {
+	if config.BaseURL == "" {
+		config.BaseURL = "http://localhost:11434/v1"
+	}
+	if config.Model == "" {
+		config.Model = "llama3.2"
+	}
+	return &OpenAIProvider{config: config, name: "Local model", noTools: true}, nil
 }
 
 // GetModel returns the model being used
@@ -26,31 +47,117 @@ func (p *OpenAIProvider) GetModel() string {
 	return p.config.Model
 }
 
-// Chat sends a chat request to OpenAI
-func (p *OpenAIProvider) Chat(messages []Message) (*Response, error) {
-	url := "https://api.openai.com/v1/chat/completions"
-	if p.config.BaseURL != "" {
-		url = p.config.BaseURL
+// endpoint returns the chat completions URL. BaseURL may be either the API
+// root (".../v1") or the full completions URL.
+func (p *OpenAIProvider) endpoint() string {
+	base := p.config.BaseURL
+	if base == "" {
+		base = "https://api.openai.com/v1"
+	}
+	if strings.HasSuffix(base, "/chat/completions") {
+		return base
 	}
+	return strings.TrimRight(base, "/") + "/chat/completions"
+}
 
+func (p *OpenAIProvider) headers() map[string]string {
 	headers := map[string]string{
-		"Authorization": "Bearer " + p.config.APIKey,
-		"Content-Type":  "application/json",
+		"Content-Type": "application/json",
 	}
+	if p.config.APIKey != "" {
+		headers["Authorization"] = "Bearer " + p.config.APIKey
+	}
+	return headers
+}
+
+// openAIMessage is a chat message in the chat completions wire format
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    string           `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+// openAIToolCall is a tool call in the chat completions wire format
+type openAIToolCall struct {
+	ID       string `json:"id,omitempty"`
+	Type     string `json:"type,omitempty"`
+	Function struct {
+		Name      string `json:"name,omitempty"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+// openAIToolCallDelta is a fragment of a streamed tool call. Index
+// identifies the call the fragment belongs to.
+type openAIToolCallDelta struct {
+	Index int `json:"index"`
+	openAIToolCall
+}
+
+// openAIMessages converts messages to the chat completions wire format
+func openAIMessages(messages []Message) []openAIMessage {
+	converted := make([]openAIMessage, 0, len(messages))
+	for _, msg := range messages {
+		m := openAIMessage{Role: msg.Role, Content: msg.Content, ToolCallID: msg.ToolCallID}
+		for _, call := range msg.ToolCalls {
+			tc := openAIToolCall{ID: call.ID, Type: "function"}
+			tc.Function.Name = call.Name
+			tc.Function.Arguments = string(call.arguments())
+			m.ToolCalls = append(m.ToolCalls, tc)
+		}
+		converted = append(converted, m)
+	}
+	return converted
+}
+
+// openAITools converts tools to function definitions
+func openAITools(tools []Tool) []map[string]interface{} {
+	defs := make([]map[string]interface{}, 0, len(tools))
+	for _, tool := range tools {
+		params := tool.Parameters
+		if params == nil {
+			params = map[string]interface{}{"type": "object", "properties": map[string]interface{}{}}
+		}
+		defs = append(defs, map[string]interface{}{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name":        tool.Name,
+				"description": tool.Description,
+				"parameters":  params,
+			},
+		})
+	}
+	return defs
+}
+
+// toolCall converts a wire tool call back to a ToolCall
+func (c openAIToolCall) toolCall() ToolCall {
+	return ToolCall{
+		ID:        c.ID,
+		Name:      c.Function.Name,
+		Arguments: json.RawMessage(c.Function.Arguments),
+	}
+}
+
+// Chat sends a chat completions request
+func (p *OpenAIProvider) Chat(ctx context.Context, messages []Message) (*Response, error) {
+	url := p.endpoint()
+	headers := p.headers()
 
 	requestBody := map[string]interface{}{
 		"model":    p.config.Model,
-		"messages": messages,
+		"messages": openAIMessages(messages),
 	}
 
-	responseBody, err := makeRequest(url, headers, requestBody)
+	responseBody, err := makeRequest(ctx, p.config, url, headers, requestBody)
 	if err != nil {
-		return nil, fmt.Errorf("OpenAI API request failed: %w", err)
+		return nil, fmt.Errorf("%s API request failed: %w", p.name, err)
 	}
 
 	var response struct {
 		Choices []struct {
-			Message Message `json:"message"`
+			Message openAIMessage `json:"message"`
 		} `json:"choices"`
 		Usage Usage `json:"usage"`
 		Error *struct {
@@ -60,22 +167,128 @@ func (p *OpenAIProvider) Chat(messages []Message) (*Response, error) {
 	}
 
 	if err := json.Unmarshal(responseBody, &response); err != nil {
-		return nil, fmt.Errorf("error parsing OpenAI response: %w", err)
+		return nil, fmt.Errorf("error parsing %s response: %w", p.name, err)
 	}
 
 	// Check for API errors
 	if response.Error != nil {
-		return nil, fmt.Errorf("OpenAI API error: %s - %s", response.Error.Type, response.Error.Message)
+		return nil, fmt.Errorf("%s API error: %s - %s", p.name, response.Error.Type, response.Error.Message)
 	}
 
 	if len(response.Choices) == 0 {
-		return nil, fmt.Errorf("no choices in OpenAI response")
+		return nil, fmt.Errorf("no choices in %s response", p.name)
 	}
 
-	content := response.Choices[0].Message.Content
-
-	return &Response{
-		Content: content,
+	message := response.Choices[0].Message
+	result := &Response{
+		Content: message.Content,
 		Usage:   response.Usage,
-	}, nil
+	}
+	for _, call := range message.ToolCalls {
+		result.ToolCalls = append(result.ToolCalls, call.toolCall())
+	}
+	return result, nil
+}
+
+// ChatStream sends a streaming chat completions request and emits content deltas
+func (p *OpenAIProvider) ChatStream(ctx context.Context, messages []Message, onChunk StreamHandler) (*Response, error) {
+	return p.stream(ctx, messages, nil, onChunk)
+}
+
+// ChatWithTools streams a chat completions request that offers tools
+func (p *OpenAIProvider) ChatWithTools(ctx context.Context, messages []Message, tools []Tool, onChunk StreamHandler) (*Response, error) {
+	if p.noTools {
+		tools = nil
+	}
+	return p.stream(ctx, messages, tools, onChunk)
+}
+
+// stream sends a streaming request, emitting content deltas and assembling
+// tool calls from their fragments
+func (p *OpenAIProvider) stream(ctx context.Context, messages []Message, tools []Tool, onChunk StreamHandler) (*Response, error) {
+	requestBody := map[string]interface{}{
+		"model":          p.config.Model,
+		"messages":       openAIMessages(messages),
+		"stream":         true,
+		"stream_options": map[string]bool{"include_usage": true},
+	}
+	if len(tools) > 0 {
+		requestBody["tools"] = openAITools(tools)
+	}
+
+	resp, err := makeStreamRequest(ctx, p.config, p.endpoint(), p.headers(), requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("%s API request failed: %w", p.name, err)
+	}
+	defer resp.Body.Close()
+
+	var content strings.Builder
+	var calls []openAIToolCallDelta
+	result := &Response{}
+
+	err = readSSE(resp.Body, func(data string) error {
+		var chunk struct {
+			Choices []struct {
+				Delta struct {
+					Content   string                `json:"content"`
+					ToolCalls []openAIToolCallDelta `json:"tool_calls"`
+				} `json:"delta"`
+			} `json:"choices"`
+			Usage *Usage `json:"usage"`
+			Error *struct {
+				Message string `json:"message"`
+				Type    string `json:"type"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			return fmt.Errorf("error parsing %s stream chunk: %w", p.name, err)
+		}
+		if chunk.Error != nil {
+			return fmt.Errorf("%s API error: %s - %s", p.name, chunk.Error.Type, chunk.Error.Message)
+		}
+		if chunk.Usage != nil {
+			result.Usage = *chunk.Usage
+		}
+		for _, choice := range chunk.Choices {
+			for _, fragment := range choice.Delta.ToolCalls {
+				calls = mergeToolCallFragment(calls, fragment)
+			}
+			if choice.Delta.Content == "" {
+				continue
+			}
+			content.WriteString(choice.Delta.Content)
+			if onChunk != nil {
+				onChunk(choice.Delta.Content)
+			}
+		}
+		return nil
+	})
+
+	result.Content = content.String()
+	for _, call := range calls {
+		result.ToolCalls = append(result.ToolCalls, call.toolCall())
+	}
+	if err != nil {
+		return result, fmt.Errorf("%s stream failed: %w", p.name, err)
+	}
+	return result, nil
+}
+
+// mergeToolCallFragment adds a streamed tool call fragment to the calls seen
+// so far. The first fragment of a call carries its ID and name; later ones
+// append to the arguments.
+func mergeToolCallFragment(calls []openAIToolCallDelta, fragment openAIToolCallDelta) []openAIToolCallDelta {
+	for i := range calls {
+		if calls[i].Index == fragment.Index {
+			if fragment.ID != "" {
+				calls[i].ID = fragment.ID
+			}
+			if fragment.Function.Name != "" {
+				calls[i].Function.Name = fragment.Function.Name
+			}
+			calls[i].Function.Arguments += fragment.Function.Arguments
+			return calls
+		}
+	}
+	return append(calls, fragment)
 }
@@ -0,0 +1,220 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// sseServer replies to every request with the given events as a text/event-stream
+func sseServer(t *testing.T, events ...string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range events {
+			fmt.Fprintf(w, "data: %s\n\n", event)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func collectStream(t *testing.T, provider Provider) (*Response, string) {
+	t.Helper()
+	var chunks strings.Builder
+	resp, err := provider.ChatStream(context.Background(), []Message{{Role: "user", Content: "hi"}}, func(chunk string) {
+		chunks.WriteString(chunk)
+	})
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+	return resp, chunks.String()
+}
+
+func TestReadSSE(t *testing.T) {
+	input := ": keep-alive\n\ndata: one\n\ndata: two\ndata: lines\n\nevent: ping\n\ndata: [DONE]\n\ndata: ignored\n\n"
+
+	var got []string
+	err := readSSE(strings.NewReader(input), func(data string) error {
+		got = append(got, data)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("readSSE() error = %v", err)
+	}
+
+	want := []string{"one", "two\nlines"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("readSSE() events = %q, want %q", got, want)
+	}
+}
+
+func TestOpenAIProvider_ChatStream(t *testing.T) {
+	server := sseServer(t,
+		`{"choices":[{"delta":{"role":"assistant"}}]}`,
+		`{"choices":[{"delta":{"content":"Hello"}}]}`,
+		`{"choices":[{"delta":{"content":", world"}}]}`,
+		`{"choices":[],"usage":{"prompt_tokens":5,"completion_tokens":3,"total_tokens":8}}`,
+		`[DONE]`,
+	)
+
+	provider, _ := NewOpenAIProvider(Config{APIKey: "test-key", BaseURL: server.URL})
+	resp, streamed := collectStream(t, provider)
+
+	if resp.Content != "Hello, world" || streamed != resp.Content {
+		t.Errorf("ChatStream() content = %q, streamed = %q", resp.Content, streamed)
+	}
+	if resp.Usage.TotalTokens != 8 {
+		t.Errorf("ChatStream() usage = %+v, want total 8", resp.Usage)
+	}
+}
+
+func TestGeminiProvider_ChatStream(t *testing.T) {
+	events := []string{
+		`{"candidates":[{"content":{"parts":[{"text":"Hi "}]}}]}`,
+		`{"candidates":[{"content":{"parts":[{"text":"there"}]}}],"usageMetadata":{"promptTokenCount":4,"candidatesTokenCount":2,"totalTokenCount":6}}`,
+	}
+	var path, alt string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, alt = r.URL.Path, r.URL.Query().Get("alt")
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range events {
+			fmt.Fprintf(w, "data: %s\n\n", event)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	// A gateway URL for generateContent still streams from streamGenerateContent
+	provider, _ := NewGeminiProvider(Config{APIKey: "test-key", BaseURL: server.URL + "/v1beta/models/gemini-pro:generateContent"})
+	resp, streamed := collectStream(t, provider)
+
+	if path != "/v1beta/models/gemini-pro:streamGenerateContent" || alt != "sse" {
+		t.Errorf("request path = %s?alt=%s, want the streaming endpoint", path, alt)
+	}
+
+	if resp.Content != "Hi there" || streamed != resp.Content {
+		t.Errorf("ChatStream() content = %q, streamed = %q", resp.Content, streamed)
+	}
+	if resp.Usage.PromptTokens != 4 || resp.Usage.CompletionTokens != 2 {
+		t.Errorf("ChatStream() usage = %+v", resp.Usage)
+	}
+}
+
+func TestAnthropicProvider_ChatStream(t *testing.T) {
+	server := sseServer(t,
+		`{"type":"message_start","message":{"usage":{"input_tokens":9,"output_tokens":1}}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`{"type":"ping"}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hi "}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"there"}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":5}}`,
+		`{"type":"message_stop"}`,
+	)
+
+	provider, _ := NewAnthropicProvider(Config{APIKey: "test-key", BaseURL: server.URL})
+	resp, streamed := collectStream(t, provider)
+
+	if resp.Content != "Hi there" || streamed != resp.Content {
+		t.Errorf("ChatStream() content = %q, streamed = %q", resp.Content, streamed)
+	}
+	if want := (Usage{PromptTokens: 9, CompletionTokens: 5, TotalTokens: 14}); resp.Usage != want {
+		t.Errorf("ChatStream() usage = %+v, want %+v", resp.Usage, want)
+	}
+}
+
+func TestAnthropicProvider_ChatStreamError(t *testing.T) {
+	server := sseServer(t,
+		`{"type":"message_start","message":{"usage":{"input_tokens":9}}}`,
+		`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`,
+	)
+
+	provider, _ := NewAnthropicProvider(Config{APIKey: "test-key", BaseURL: server.URL})
+	_, err := provider.ChatStream(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil)
+	if err == nil || !strings.Contains(err.Error(), "Overloaded") {
+		t.Errorf("ChatStream() error = %v, want the stream error event", err)
+	}
+}
+
+func TestLocalProvider_ChatStream(t *testing.T) {
+	server := sseServer(t,
+		`{"choices":[{"delta":{"content":"local "}}]}`,
+		`{"choices":[{"delta":{"content":"stream"}}]}`,
+		`{"choices":[],"usage":{"prompt_tokens":2,"completion_tokens":2,"total_tokens":4}}`,
+		`[DONE]`,
+	)
+
+	provider, _ := NewLocalProvider(Config{BaseURL: server.URL})
+	resp, streamed := collectStream(t, provider)
+
+	if resp.Content != "local stream" || streamed != resp.Content {
+		t.Errorf("ChatStream() content = %q, streamed = %q", resp.Content, streamed)
+	}
+	if resp.Usage.TotalTokens != 4 {
+		t.Errorf("ChatStream() usage = %+v, want total 4", resp.Usage)
+	}
+}
+
+func TestEllieAPIProvider_ChatStream(t *testing.T) {
+	server := sseServer(t,
+		`{"content":"Ellie "}`,
+		`{"content":"here","usage":{"prompt_tokens":1,"completion_tokens":2,"total_tokens":3}}`,
+		`[DONE]`,
+	)
+
+	provider, _ := NewEllieAPIProvider(Config{BaseURL: server.URL})
+	resp, streamed := collectStream(t, provider)
+
+	if resp.Content != "Ellie here" || streamed != resp.Content {
+		t.Errorf("ChatStream() content = %q, streamed = %q", resp.Content, streamed)
+	}
+	if resp.Usage.TotalTokens != 3 {
+		t.Errorf("ChatStream() usage = %+v, want total 3", resp.Usage)
+	}
+}
+
+func TestEllieAPIProvider_ChatStreamJSONFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"content":"whole answer","usage":{"total_tokens":7}}`)
+	}))
+	defer server.Close()
+
+	provider, _ := NewEllieAPIProvider(Config{BaseURL: server.URL})
+	resp, streamed := collectStream(t, provider)
+
+	if resp.Content != "whole answer" || streamed != "whole answer" {
+		t.Errorf("ChatStream() content = %q, streamed = %q", resp.Content, streamed)
+	}
+}
+
+func TestChatStream_Cancelled(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\":[{\"delta\":{\"content\":\"partial\"}}]}\n\n")
+		w.(http.Flusher).Flush()
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	provider, _ := NewOpenAIProvider(Config{APIKey: "test-key", BaseURL: server.URL})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	resp, err := provider.ChatStream(ctx, []Message{{Role: "user", Content: "hi"}}, func(chunk string) {
+		cancel()
+	})
+	if err == nil {
+		t.Fatal("ChatStream() expected an error after cancellation")
+	}
+	if resp == nil || resp.Content != "partial" {
+		t.Errorf("ChatStream() should return the partial answer, got %+v", resp)
+	}
+}
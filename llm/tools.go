@@ -0,0 +1,45 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+)
+
+// Tool describes a function the model may ask the caller to run
+type Tool struct {
+	Name        string
+	Description string
+	// Parameters is the JSON schema of the arguments object
+	Parameters map[string]interface{}
+}
+
+// ToolCall is a request from the model to run one of the offered tools
+type ToolCall struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// ToolProvider is implemented by providers that support function calling
+type ToolProvider interface {
+	Provider
+	// ChatWithTools behaves like ChatStream but offers tools to the model.
+	// When the model wants to call tools, the Response lists them in
+	// ToolCalls; their results are sent back as "tool" messages carrying
+	// the call's ID in ToolCallID and the tool's name in Name.
+	ChatWithTools(ctx context.Context, messages []Message, tools []Tool, onChunk StreamHandler) (*Response, error)
+}
+
+// hasProperties reports whether a tool takes any arguments
+func (t Tool) hasProperties() bool {
+	props, _ := t.Parameters["properties"].(map[string]interface{})
+	return len(props) > 0
+}
+
+// arguments returns the call's arguments, defaulting to an empty object
+func (c ToolCall) arguments() json.RawMessage {
+	if len(c.Arguments) == 0 {
+		return json.RawMessage("{}")
+	}
+	return c.Arguments
+}
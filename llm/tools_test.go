@@ -0,0 +1,142 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var weatherTool = Tool{
+	Name:        "weather",
+	Description: "Get the weather",
+	Parameters: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"city": map[string]interface{}{"type": "string"},
+		},
+	},
+}
+
+// toolConversation is a turn where the model called weather and got a result
+var toolConversation = []Message{
+	{Role: "user", Content: "Weather in Dar?"},
+	{Role: "assistant", ToolCalls: []ToolCall{{ID: "call_1", Name: "weather", Arguments: json.RawMessage(`{"city":"Dar"}`)}}},
+	{Role: "tool", ToolCallID: "call_1", Name: "weather", Content: "31C"},
+}
+
+// sseRecorder streams events and keeps the decoded request body
+func sseRecorder(t *testing.T, sent *map[string]interface{}, events ...string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		json.Unmarshal(body, sent)
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, event := range events {
+			fmt.Fprintf(w, "data: %s\n\n", event)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOpenAIProvider_ChatWithTools(t *testing.T) {
+	var sent map[string]interface{}
+	server := sseRecorder(t, &sent,
+		`{"choices":[{"delta":{"tool_calls":[{"index":0,"id":"call_2","type":"function","function":{"name":"weather","arguments":""}}]}}]}`,
+		`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"{\"city\":"}}]}}]}`,
+		`{"choices":[{"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Arusha\"}"}}]}}]}`,
+		`[DONE]`,
+	)
+
+	provider, _ := NewOpenAIProvider(Config{APIKey: "test-key", BaseURL: server.URL})
+	resp, err := provider.(ToolProvider).ChatWithTools(context.Background(), toolConversation, []Tool{weatherTool}, nil)
+	if err != nil {
+		t.Fatalf("ChatWithTools() error = %v", err)
+	}
+
+	if len(resp.ToolCalls) != 1 {
+		t.Fatalf("ToolCalls = %+v, want one call", resp.ToolCalls)
+	}
+	call := resp.ToolCalls[0]
+	if call.ID != "call_2" || call.Name != "weather" || string(call.Arguments) != `{"city":"Arusha"}` {
+		t.Errorf("ToolCall = %+v (arguments %s)", call, call.Arguments)
+	}
+
+	tools, _ := sent["tools"].([]interface{})
+	if len(tools) != 1 || !strings.Contains(fmt.Sprint(tools[0]), "weather") {
+		t.Errorf("request tools = %v", sent["tools"])
+	}
+	messages, _ := sent["messages"].([]interface{})
+	if len(messages) != 3 {
+		t.Fatalf("request messages = %v", sent["messages"])
+	}
+	assistant := messages[1].(map[string]interface{})
+	calls, _ := assistant["tool_calls"].([]interface{})
+	if len(calls) != 1 || !strings.Contains(fmt.Sprint(calls[0]), `{"city":"Dar"}`) {
+		t.Errorf("assistant tool_calls = %v, want the arguments as a JSON string", assistant["tool_calls"])
+	}
+	if tool := messages[2].(map[string]interface{}); tool["tool_call_id"] != "call_1" {
+		t.Errorf("tool message = %v, want tool_call_id", tool)
+	}
+}
+
+func TestLocalProvider_ChatWithToolsIgnoresTools(t *testing.T) {
+	var sent map[string]interface{}
+	server := sseRecorder(t, &sent, `{"choices":[{"delta":{"content":"no tools here"}}]}`, `[DONE]`)
+
+	provider, _ := NewLocalProvider(Config{BaseURL: server.URL})
+	resp, err := provider.(ToolProvider).ChatWithTools(context.Background(), []Message{{Role: "user", Content: "hi"}}, []Tool{weatherTool}, nil)
+	if err != nil {
+		t.Fatalf("ChatWithTools() error = %v", err)
+	}
+	if resp.Content != "no tools here" {
+		t.Errorf("Content = %q", resp.Content)
+	}
+	if _, ok := sent["tools"]; ok {
+		t.Error("local providers should not be sent tools")
+	}
+}
+
+func TestGeminiProvider_ChatWithTools(t *testing.T) {
+	var sent map[string]interface{}
+	server := sseRecorder(t, &sent,
+		`{"candidates":[{"content":{"parts":[{"functionCall":{"name":"weather","args":{"city":"Moshi"}}}]}}]}`,
+	)
+
+	conversation := append(append([]Message{}, toolConversation...),
+		Message{Role: "tool", ToolCallID: "call_9", Name: "weather", Content: "25C"})
+
+	provider, _ := NewGeminiProvider(Config{APIKey: "test-key", BaseURL: server.URL})
+	resp, err := provider.(ToolProvider).ChatWithTools(context.Background(), conversation, []Tool{weatherTool}, nil)
+	if err != nil {
+		t.Fatalf("ChatWithTools() error = %v", err)
+	}
+
+	if len(resp.ToolCalls) != 1 || resp.ToolCalls[0].Name != "weather" || string(resp.ToolCalls[0].Arguments) != `{"city":"Moshi"}` {
+		t.Errorf("ToolCalls = %+v", resp.ToolCalls)
+	}
+	if resp.ToolCalls[0].ID == "" {
+		t.Error("Gemini tool calls should be given an ID")
+	}
+
+	if !strings.Contains(fmt.Sprint(sent["tools"]), "functionDeclarations") {
+		t.Errorf("request tools = %v", sent["tools"])
+	}
+	contents, _ := sent["contents"].([]interface{})
+	if len(contents) != 3 {
+		t.Fatalf("contents = %v, want user, model call and one grouped function response turn", sent["contents"])
+	}
+	model := fmt.Sprint(contents[1])
+	if !strings.Contains(model, "role:model") || !strings.Contains(model, "functionCall") {
+		t.Errorf("model turn = %s", model)
+	}
+	results := contents[2].(map[string]interface{})["parts"].([]interface{})
+	if len(results) != 2 || !strings.Contains(fmt.Sprint(results[0]), "functionResponse") {
+		t.Errorf("function responses = %v", results)
+	}
+}
@@ -0,0 +1,192 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	// DefaultTimeout bounds a request when Config.Timeout is not set
+	DefaultTimeout = 30 * time.Second
+	// DefaultMaxRetries is used when Config.MaxRetries is zero
+	DefaultMaxRetries = 2
+)
+
+var (
+	// httpClient is shared by all providers. It has no overall timeout;
+	// each attempt is bounded through its context instead so streamed
+	// answers are not cut off mid-way.
+	httpClient = &http.Client{}
+
+	retryBaseDelay = 500 * time.Millisecond
+	retryMaxDelay  = 10 * time.Second
+	maxRetryAfter  = time.Minute
+)
+
+// timeout returns the per-attempt timeout, falling back to DefaultTimeout
+func (c Config) timeout() time.Duration {
+	if c.Timeout <= 0 {
+		return DefaultTimeout
+	}
+	return c.Timeout
+}
+
+// maxRetries returns how many times a failed request is retried. Zero falls
+// back to DefaultMaxRetries; a negative value disables retries.
+func (c Config) maxRetries() int {
+	switch {
+	case c.MaxRetries < 0:
+		return 0
+	case c.MaxRetries == 0:
+		return DefaultMaxRetries
+	default:
+		return c.MaxRetries
+	}
+}
+
+// makeRequest sends a JSON POST request and returns the full response body
+func makeRequest(ctx context.Context, config Config, url string, headers map[string]string, body interface{}) ([]byte, error) {
+	resp, err := send(ctx, config, url, headers, body, false)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %w", err)
+	}
+	return responseBody, nil
+}
+
+// makeStreamRequest sends a JSON POST request and returns the open response so
+// the caller can consume the body incrementally. The timeout only applies
+// until the server starts answering. The caller must close the body.
+func makeStreamRequest(ctx context.Context, config Config, url string, headers map[string]string, body interface{}) (*http.Response, error) {
+	return send(ctx, config, url, headers, body, true)
+}
+
+// send performs the request, retrying with exponential backoff on 429 and 5xx
+// answers. Only a 200 response is returned; anything else becomes an error.
+func send(ctx context.Context, config Config, url string, headers map[string]string, body interface{}, streaming bool) (*http.Response, error) {
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling request body: %w", err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		attemptCtx, cancel := context.WithCancelCause(ctx)
+		timer := time.AfterFunc(config.timeout(), func() { cancel(ErrTimeout) })
+		release := func() {
+			timer.Stop()
+			cancel(nil)
+		}
+
+		req, err := http.NewRequestWithContext(attemptCtx, "POST", url, bytes.NewReader(jsonBody))
+		if err != nil {
+			release()
+			return nil, fmt.Errorf("error creating request: %w", err)
+		}
+		for key, value := range headers {
+			req.Header.Set(key, value)
+		}
+		if streaming {
+			req.Header.Set("Accept", "text/event-stream")
+		}
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			err = classifyError(attemptCtx, err)
+			release()
+			return nil, err
+		}
+
+		if resp.StatusCode == http.StatusOK {
+			if streaming {
+				timer.Stop()
+			}
+			resp.Body = &attemptBody{ReadCloser: resp.Body, ctx: attemptCtx, release: release}
+			return resp, nil
+		}
+
+		errBody, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		release()
+
+		apiErr := newAPIError(resp, errBody)
+		if !apiErr.retryable() || attempt >= config.maxRetries() {
+			return nil, apiErr
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff(attempt, apiErr.RetryAfter)):
+		}
+	}
+}
+
+// backoff returns how long to wait before retry number attempt+1. A server
+// supplied Retry-After wins; otherwise the delay doubles each attempt with
+// jitter so parallel clients don't retry in lockstep.
+func backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return min(retryAfter, maxRetryAfter)
+	}
+	delay := min(retryBaseDelay<<attempt, retryMaxDelay)
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// classifyError maps low-level transport failures to the package's sentinel
+// errors while keeping the original error in the chain
+func classifyError(ctx context.Context, err error) error {
+	if errors.Is(context.Cause(ctx), ErrTimeout) {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+	if ctx.Err() != nil {
+		return err
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+
+	var dnsErr *net.DNSError
+	var opErr *net.OpError
+	if errors.As(err, &dnsErr) || errors.As(err, &opErr) {
+		return fmt.Errorf("%w: %w", ErrOffline, err)
+	}
+
+	return fmt.Errorf("error making request: %w", err)
+}
+
+// attemptBody releases the attempt's timer and context once the caller is
+// done with the body, and reports reads cut short by the timeout as such
+type attemptBody struct {
+	io.ReadCloser
+	ctx     context.Context
+	release func()
+}
+
+func (b *attemptBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil && err != io.EOF {
+		err = classifyError(b.ctx, err)
+	}
+	return n, err
+}
+
+func (b *attemptBody) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
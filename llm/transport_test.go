@@ -0,0 +1,188 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func init() {
+	// Keep retry tests fast
+	retryBaseDelay = time.Millisecond
+	retryMaxDelay = 5 * time.Millisecond
+}
+
+func TestMakeRequest_RetriesServerErrors(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, `{"ok":true}`)
+	}))
+	defer server.Close()
+
+	body, err := makeRequest(context.Background(), Config{MaxRetries: 2}, server.URL, map[string]string{}, nil)
+	if err != nil {
+		t.Fatalf("makeRequest() error = %v", err)
+	}
+	if string(body) != `{"ok":true}` {
+		t.Errorf("makeRequest() body = %s", body)
+	}
+	if calls != 3 {
+		t.Errorf("makeRequest() made %d calls, want 3", calls)
+	}
+}
+
+func TestMakeRequest_TypedErrors(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		want      error
+		wantCalls int32
+	}{
+		{
+			name:      "rate limited after retries",
+			status:    http.StatusTooManyRequests,
+			body:      `{"error":{"message":"slow down"}}`,
+			want:      ErrRateLimited,
+			wantCalls: 2,
+		},
+		{
+			name:      "unauthorized is not retried",
+			status:    http.StatusUnauthorized,
+			body:      `{"error":{"message":"bad key"}}`,
+			want:      ErrUnauthorized,
+			wantCalls: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			_, err := makeRequest(context.Background(), Config{MaxRetries: 1}, server.URL, map[string]string{}, nil)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("makeRequest() error = %v, want %v", err, tt.want)
+			}
+
+			var apiErr *APIError
+			if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status {
+				t.Errorf("makeRequest() error should be an APIError with status %d, got %v", tt.status, err)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("makeRequest() made %d calls, want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestMakeRequest_Offline(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	url := server.URL
+	server.Close()
+
+	_, err := makeRequest(context.Background(), Config{}, url, map[string]string{}, nil)
+	if !errors.Is(err, ErrOffline) {
+		t.Fatalf("makeRequest() error = %v, want ErrOffline", err)
+	}
+}
+
+func TestMakeRequest_Timeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	_, err := makeRequest(context.Background(), Config{Timeout: 50 * time.Millisecond}, server.URL, map[string]string{}, nil)
+	if !errors.Is(err, ErrTimeout) {
+		t.Fatalf("makeRequest() error = %v, want ErrTimeout", err)
+	}
+}
+
+func TestMakeStreamRequest_TimeoutOnlyCoversFirstResponse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.(http.Flusher).Flush()
+		time.Sleep(100 * time.Millisecond)
+		fmt.Fprint(w, "data: {\"content\":\"late\"}\n\n")
+	}))
+	defer server.Close()
+
+	provider, _ := NewEllieAPIProvider(Config{BaseURL: server.URL, Timeout: 50 * time.Millisecond})
+	resp, err := provider.ChatStream(context.Background(), []Message{{Role: "user", Content: "hi"}}, nil)
+	if err != nil {
+		t.Fatalf("ChatStream() error = %v", err)
+	}
+	if resp.Content != "late" {
+		t.Errorf("ChatStream() content = %q, want %q", resp.Content, "late")
+	}
+}
+
+func TestMakeStreamRequest_LeavesHeadersAlone(t *testing.T) {
+	var accept string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept = r.Header.Get("Accept")
+	}))
+	defer server.Close()
+
+	headers := map[string]string{"Content-Type": "application/json"}
+	resp, err := makeStreamRequest(context.Background(), Config{}, server.URL, headers, map[string]string{})
+	if err != nil {
+		t.Fatalf("makeStreamRequest() error = %v", err)
+	}
+	resp.Body.Close()
+
+	if accept != "text/event-stream" {
+		t.Errorf("Accept = %q, want text/event-stream", accept)
+	}
+	if len(headers) != 1 {
+		t.Errorf("makeStreamRequest() changed the caller's headers: %v", headers)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if got := parseRetryAfter("3"); got != 3*time.Second {
+		t.Errorf("parseRetryAfter(3) = %v", got)
+	}
+	future := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if got := parseRetryAfter(future); got <= 0 || got > time.Hour {
+		t.Errorf("parseRetryAfter(date) = %v", got)
+	}
+	if got := parseRetryAfter("soon"); got != 0 {
+		t.Errorf("parseRetryAfter(soon) = %v, want 0", got)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	if got := backoff(0, 2*time.Second); got != 2*time.Second {
+		t.Errorf("backoff() should honor Retry-After, got %v", got)
+	}
+	if got := backoff(10, time.Hour); got != maxRetryAfter {
+		t.Errorf("backoff() should cap Retry-After, got %v", got)
+	}
+	for attempt := 0; attempt < 8; attempt++ {
+		if got := backoff(attempt, 0); got <= 0 || got > retryMaxDelay {
+			t.Errorf("backoff(%d) = %v, want within (0, %v]", attempt, got, retryMaxDelay)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"flag"
 	"os"
+	"strings"
 	"time"
 
 	actions "github.com/tacheraSasi/ellie/action"
@@ -49,7 +50,7 @@ func main() {
 	if len(args) == 0 {
 		// Show welcome message before chat
 		actions.ShowWelcome()
-		actions.Chat(configs.GetEnv("OPENAI_API_KEY"))
+		actions.Chat(nil)
 		return
 	}
 
@@ -58,7 +59,7 @@ func main() {
 
 func handleCommand(args []string) {
 	if len(args) == 0 {
-		actions.Chat(configs.GetEnv("OPENAI_API_KEY"))
+		actions.Chat(nil)
 		return
 	}
 
@@ -90,7 +91,8 @@ func handleCommand(args []string) {
 		cmd.PreHook()
 	}
 
-	if len(cmd.SubCommands) > 0 && len(args) > 1 {
+	// Flags such as "chat --provider x" go to the command itself
+	if len(cmd.SubCommands) > 0 && len(args) > 1 && !strings.HasPrefix(args[1], "-") {
 		handleSubCommand(cmd, args[1:])
 		return
 	}
@@ -0,0 +1,106 @@
+package release
+
+import (
+	"fmt"
+	"strings"
+)
+
+// sections are the changelog headings for each commit type, in the order
+// they are listed
+var sections = []struct{ commitType, title string }{
+	{"feat", "Features"},
+	{"fix", "Bug Fixes"},
+	{"perf", "Performance"},
+	{"refactor", "Refactoring"},
+	{"revert", "Reverts"},
+	{"docs", "Documentation"},
+	{"style", "Styles"},
+	{"test", "Tests"},
+	{"chore", "Chores"},
+}
+
+// Section renders a version's changelog entry with the commits grouped by
+// type and breaking changes listed first. Types without a heading are
+// listed under Other Changes.
+func Section(version Version, date string, commits []Commit) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "## %s (%s)\n", version, date)
+
+	var breaking []string
+	for _, c := range commits {
+		if c.Breaking {
+			note := c.BreakingNote
+			if note == "" {
+				note = c.Description
+			}
+			breaking = append(breaking, entry(c, note))
+		}
+	}
+	writeGroup(&b, "⚠ BREAKING CHANGES", breaking)
+
+	known := map[string]bool{}
+	for _, s := range sections {
+		known[s.commitType] = true
+		var lines []string
+		for _, c := range commits {
+			if c.Type == s.commitType {
+				lines = append(lines, entry(c, c.Description))
+			}
+		}
+		writeGroup(&b, s.title, lines)
+	}
+	var other []string
+	for _, c := range commits {
+		if !known[c.Type] {
+			other = append(other, entry(c, c.Description))
+		}
+	}
+	writeGroup(&b, "Other Changes", other)
+	return b.String()
+}
+
+func writeGroup(b *strings.Builder, title string, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n### %s\n\n", title)
+	for _, line := range lines {
+		b.WriteString(line + "\n")
+	}
+}
+
+// entry is one changelog line: the scope, the text, the issues and the
+// short hash
+func entry(c Commit, text string) string {
+	var b strings.Builder
+	b.WriteString("- ")
+	if c.Scope != "" {
+		fmt.Fprintf(&b, "**%s:** ", c.Scope)
+	}
+	b.WriteString(text)
+	if len(c.Issues) > 0 {
+		fmt.Fprintf(&b, " (%s)", strings.Join(c.Issues, ", "))
+	}
+	if hash := c.Hash; hash != "" {
+		if len(hash) > 7 {
+			hash = hash[:7]
+		}
+		fmt.Fprintf(&b, " (%s)", hash)
+	}
+	return b.String()
+}
+
+// UpdateChangelog adds a section above the newest entry of an existing
+// changelog, or starts a new one
+func UpdateChangelog(existing, section string) string {
+	if strings.TrimSpace(existing) == "" {
+		return "# Changelog\n\n" + section
+	}
+	if strings.HasPrefix(existing, "## ") {
+		return section + "\n" + existing
+	}
+	if i := strings.Index(existing, "\n## "); i >= 0 {
+		return existing[:i+1] + section + "\n" + existing[i+1:]
+	}
+	return strings.TrimRight(existing, "\n") + "\n\n" + section
+}
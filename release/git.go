@@ -0,0 +1,82 @@
+package release
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// git runs a git command and returns its output with git's own message as
+// the error
+var git = func(args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", errors.New(msg)
+		}
+		return "", fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
+
+// Root returns the top of the work tree, where the changelog lives
+func Root() (string, error) {
+	out, err := git("rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(out), nil
+}
+
+// TagExists reports whether tag already names a tag
+func TagExists(tag string) bool {
+	_, err := git("rev-parse", "--verify", "--quiet", "refs/tags/"+tag)
+	return err == nil
+}
+
+// LastTag returns the newest version tag reachable from HEAD, or "" if
+// there is none
+func LastTag() (string, error) {
+	if _, err := git("rev-parse", "--verify", "--quiet", "HEAD"); err != nil {
+		return "", errors.New("there are no commits to release")
+	}
+	out, err := git("tag", "--merged", "HEAD", "--sort=-v:refname", "--list", "v[0-9]*", "[0-9]*")
+	if err != nil {
+		return "", err
+	}
+	for _, tag := range strings.Fields(out) {
+		if _, err := ParseVersion(tag); err == nil {
+			return tag, nil
+		}
+	}
+	return "", nil
+}
+
+// Commits lists the commits after tag, or all commits if tag is "", newest
+// first. Commits that aren't conventional are counted in skipped.
+func Commits(tag string) (commits []Commit, skipped int, err error) {
+	rangeArg := "HEAD"
+	if tag != "" {
+		rangeArg = tag + "..HEAD"
+	}
+	out, err := git("log", "--no-merges", "--format=%H%x00%B%x1e", rangeArg)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, record := range strings.Split(out, "\x1e") {
+		hash, message, found := strings.Cut(strings.TrimSpace(record), "\x00")
+		if !found {
+			continue
+		}
+		if c, ok := ParseCommit(hash, message); ok {
+			commits = append(commits, c)
+		} else {
+			skipped++
+		}
+	}
+	return commits, skipped, nil
+}
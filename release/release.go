@@ -0,0 +1,162 @@
+// Package release turns conventional commits into the next semantic version
+// and a changelog.
+package release
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Commit is a conventional commit
+type Commit struct {
+	Hash        string
+	Type        string
+	Scope       string
+	Description string
+	Breaking    bool
+	// BreakingNote is the text of the BREAKING CHANGE footer
+	BreakingNote string
+	// Issues are references such as #12 or PROJ-7 from the footers
+	Issues []string
+}
+
+var (
+	headerPattern = regexp.MustCompile(`^(\w+)(?:\(([^)]*)\))?(!)?: (.+)$`)
+	// footerPattern matches the issue footers buildCommitMessage writes,
+	// "Refs #12", and trailers such as "Closes: #12" or "Fixes: PROJ-7"
+	footerPattern = regexp.MustCompile(`(?i)^(refs?|close[sd]?|fix(es|ed)?|resolve[sd]?|issues?)\b:?\s*(.+)$`)
+	issuePattern  = regexp.MustCompile(`#\d+|\b[A-Z][A-Z0-9]+-\d+\b`)
+)
+
+// ParseCommit reads a commit message. ok is false when the header isn't a
+// conventional commit.
+func ParseCommit(hash, message string) (c Commit, ok bool) {
+	message = strings.TrimSpace(strings.ReplaceAll(message, "\r\n", "\n"))
+	header, body, _ := strings.Cut(message, "\n")
+	m := headerPattern.FindStringSubmatch(strings.TrimSpace(header))
+	if m == nil {
+		return Commit{}, false
+	}
+	c = Commit{
+		Hash:        hash,
+		Type:        strings.ToLower(m[1]),
+		Scope:       m[2],
+		Description: strings.TrimSpace(m[4]),
+		Breaking:    m[3] == "!",
+	}
+
+	for _, paragraph := range strings.Split(strings.TrimSpace(body), "\n\n") {
+		for _, prefix := range []string{"BREAKING CHANGE:", "BREAKING-CHANGE:"} {
+			if note, found := strings.CutPrefix(strings.TrimSpace(paragraph), prefix); found {
+				c.Breaking = true
+				c.BreakingNote = strings.Join(strings.Fields(note), " ")
+			}
+		}
+	}
+	for _, line := range strings.Split(body, "\n") {
+		if m := footerPattern.FindStringSubmatch(strings.TrimSpace(line)); m != nil {
+			for _, issue := range issuePattern.FindAllString(m[3], -1) {
+				if !contains(c.Issues, issue) {
+					c.Issues = append(c.Issues, issue)
+				}
+			}
+		}
+	}
+	return c, true
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Version is a semantic version. Prefix keeps the "v" of tags like v1.2.3.
+type Version struct {
+	Prefix              string
+	Major, Minor, Patch int
+}
+
+// ParseVersion reads a version such as 1.2.3 or v1.2.3
+func ParseVersion(s string) (Version, error) {
+	v := Version{}
+	rest := s
+	if strings.HasPrefix(rest, "v") {
+		v.Prefix, rest = "v", rest[1:]
+	}
+	parts := strings.Split(rest, ".")
+	if len(parts) != 3 {
+		return v, fmt.Errorf("%q is not a version like v1.2.3", s)
+	}
+	numbers := make([]int, 3)
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return v, fmt.Errorf("%q is not a version like v1.2.3", s)
+		}
+		numbers[i] = n
+	}
+	v.Major, v.Minor, v.Patch = numbers[0], numbers[1], numbers[2]
+	return v, nil
+}
+
+func (v Version) String() string {
+	return fmt.Sprintf("%s%d.%d.%d", v.Prefix, v.Major, v.Minor, v.Patch)
+}
+
+// Bump is the part of the version a release increments
+type Bump int
+
+const (
+	Patch Bump = iota
+	Minor
+	Major
+)
+
+func (b Bump) String() string {
+	return [...]string{"patch", "minor", "major"}[b]
+}
+
+// ParseBump reads major, minor or patch
+func ParseBump(s string) (Bump, error) {
+	switch strings.ToLower(s) {
+	case "major":
+		return Major, nil
+	case "minor":
+		return Minor, nil
+	case "patch":
+		return Patch, nil
+	}
+	return Patch, fmt.Errorf("unknown bump %q, use major, minor or patch", s)
+}
+
+// Next returns the version after v
+func (v Version) Next(b Bump) Version {
+	switch b {
+	case Major:
+		return Version{Prefix: v.Prefix, Major: v.Major + 1}
+	case Minor:
+		return Version{Prefix: v.Prefix, Major: v.Major, Minor: v.Minor + 1}
+	}
+	return Version{Prefix: v.Prefix, Major: v.Major, Minor: v.Minor, Patch: v.Patch + 1}
+}
+
+// BumpFor picks the bump for commits: major for a breaking change, minor
+// for a feature and patch for anything else
+func BumpFor(commits []Commit) Bump {
+	bump := Patch
+	for _, c := range commits {
+		if c.Breaking {
+			return Major
+		}
+		if c.Type == "feat" {
+			bump = Minor
+		}
+	}
+	return bump
+}
@@ -0,0 +1,167 @@
+package release
+
+import (
+	"os"
+	"os/exec"
+	"reflect"
+	"testing"
+)
+
+func TestParseCommit(t *testing.T) {
+	tests := []struct {
+		message string
+		want    Commit
+		ok      bool
+	}{
+		{"feat(auth): add token refresh", Commit{Type: "feat", Scope: "auth", Description: "add token refresh"}, true},
+		{"fix!: drop v1 endpoint", Commit{Type: "fix", Description: "drop v1 endpoint", Breaking: true}, true},
+		{
+			"feat: new config\n\nbody text\n\nBREAKING CHANGE: config moved\nto ~/ellie\n\nRefs #12\n\nCloses: #3, #12\nFixes: PROJ-7",
+			Commit{Type: "feat", Description: "new config", Breaking: true, BreakingNote: "config moved to ~/ellie", Issues: []string{"#12", "#3", "PROJ-7"}},
+			true,
+		},
+		{"Merge branch 'main'", Commit{}, false},
+		{"update readme", Commit{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseCommit("", tt.message)
+		if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseCommit(%q) = %+v, %v, want %+v, %v", tt.message, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestVersionNext(t *testing.T) {
+	v, err := ParseVersion("v1.4.2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for bump, want := range map[Bump]string{Major: "v2.0.0", Minor: "v1.5.0", Patch: "v1.4.3"} {
+		if got := v.Next(bump).String(); got != want {
+			t.Errorf("Next(%s) = %s, want %s", bump, got, want)
+		}
+	}
+	for _, bad := range []string{"1.2", "v1.x.0", "release-1"} {
+		if _, err := ParseVersion(bad); err == nil {
+			t.Errorf("ParseVersion(%q) should fail", bad)
+		}
+	}
+}
+
+func TestBumpFor(t *testing.T) {
+	fix := Commit{Type: "fix"}
+	feat := Commit{Type: "feat"}
+	breaking := Commit{Type: "refactor", Breaking: true}
+	tests := []struct {
+		commits []Commit
+		want    Bump
+	}{
+		{[]Commit{fix, {Type: "docs"}}, Patch},
+		{[]Commit{fix, feat}, Minor},
+		{[]Commit{feat, breaking, fix}, Major},
+	}
+	for _, tt := range tests {
+		if got := BumpFor(tt.commits); got != tt.want {
+			t.Errorf("BumpFor(%+v) = %s, want %s", tt.commits, got, tt.want)
+		}
+	}
+}
+
+func TestSection(t *testing.T) {
+	commits := []Commit{
+		{Hash: "abcdef123456", Type: "feat", Scope: "git", Description: "add release", Issues: []string{"#4"}},
+		{Hash: "1234567890", Type: "fix", Description: "handle empty tags"},
+		{Hash: "fedcba987654", Type: "refactor", Description: "split config", Breaking: true, BreakingNote: "config moved"},
+		{Hash: "0000000aaaa", Type: "build", Description: "bump go"},
+	}
+	want := `## v2.0.0 (2024-06-01)
+
+### ⚠ BREAKING CHANGES
+
+- config moved (fedcba9)
+
+### Features
+
+- **git:** add release (#4) (abcdef1)
+
+### Bug Fixes
+
+- handle empty tags (1234567)
+
+### Refactoring
+
+- split config (fedcba9)
+
+### Other Changes
+
+- bump go (0000000)
+`
+	if got := Section(Version{Prefix: "v", Major: 2}, "2024-06-01", commits); got != want {
+		t.Errorf("Section() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestUpdateChangelog(t *testing.T) {
+	section := "## v1.1.0 (2024-06-01)\n\n### Features\n\n- new\n"
+	if got := UpdateChangelog("", section); got != "# Changelog\n\n"+section {
+		t.Errorf("new changelog = %q", got)
+	}
+	existing := "# Changelog\n\nAll notable changes.\n\n## v1.0.0 (2024-01-01)\n\n- first\n"
+	want := "# Changelog\n\nAll notable changes.\n\n" + section + "\n## v1.0.0 (2024-01-01)\n\n- first\n"
+	if got := UpdateChangelog(existing, section); got != want {
+		t.Errorf("UpdateChangelog() =\n%s", got)
+	}
+}
+
+func TestCommitsSinceLastTag(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	wd, _ := os.Getwd()
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	t.Setenv("GIT_AUTHOR_NAME", "Test")
+	t.Setenv("GIT_AUTHOR_EMAIL", "test@example.com")
+	t.Setenv("GIT_COMMITTER_NAME", "Test")
+	t.Setenv("GIT_COMMITTER_EMAIL", "test@example.com")
+	run := func(args ...string) {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v\n%s", args, err, out)
+		}
+	}
+
+	run("init", "--quiet")
+	if _, err := LastTag(); err == nil {
+		t.Error("LastTag() without commits should fail")
+	}
+	run("commit", "--quiet", "--allow-empty", "-m", "feat: first")
+	run("tag", "v0.9.0")
+	run("tag", "v0.10.0")
+	run("tag", "not-a-version")
+	run("commit", "--quiet", "--allow-empty", "-m", "fix: a bug\n\nRefs #5")
+	run("commit", "--quiet", "--allow-empty", "-m", "wip")
+
+	tag, err := LastTag()
+	if err != nil || tag != "v0.10.0" {
+		t.Fatalf("LastTag() = %q, %v, want v0.10.0", tag, err)
+	}
+	commits, skipped, err := Commits(tag)
+	if err != nil || len(commits) != 1 || skipped != 1 {
+		t.Fatalf("Commits() = %+v, %d, %v", commits, skipped, err)
+	}
+	if c := commits[0]; c.Type != "fix" || len(c.Hash) != 40 || !reflect.DeepEqual(c.Issues, []string{"#5"}) {
+		t.Errorf("commit = %+v", c)
+	}
+
+	if !TagExists("v0.9.0") || TagExists("v0.11.0") {
+		t.Error("TagExists() should only find existing tags")
+	}
+	top, _ := Root()
+	os.Mkdir("sub", 0o755)
+	os.Chdir("sub")
+	if root, err := Root(); err != nil || root != top {
+		t.Errorf("Root() from a subdirectory = %q, %v, want %q", root, err, top)
+	}
+}
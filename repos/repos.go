@@ -0,0 +1,239 @@
+// Package repos inspects and updates many git repositories at once.
+package repos
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Repo is a repository to inspect
+type Repo struct {
+	Name string
+	Path string
+}
+
+// Status is a repository's state at a glance
+type Status struct {
+	Repo Repo
+	// Branch is empty when HEAD is detached
+	Branch   string
+	Upstream string
+	Ahead    int
+	Behind   int
+	// Changed counts staged, unstaged, untracked and conflicted paths
+	Changed int
+	Stashes int
+	// LastCommit is zero before the first commit
+	LastCommit time.Time
+	Err        error
+}
+
+// Dirty reports whether the worktree has any changes
+func (s Status) Dirty() bool {
+	return s.Changed > 0
+}
+
+// Sync describes the branch's position relative to its upstream
+func (s Status) Sync() string {
+	switch {
+	case s.Branch == "":
+		return "detached"
+	case s.Upstream == "":
+		return "no upstream"
+	case s.Ahead == 0 && s.Behind == 0:
+		return "up to date"
+	}
+	var parts []string
+	if s.Ahead > 0 {
+		parts = append(parts, fmt.Sprintf("↑%d", s.Ahead))
+	}
+	if s.Behind > 0 {
+		parts = append(parts, fmt.Sprintf("↓%d", s.Behind))
+	}
+	return strings.Join(parts, " ")
+}
+
+// batchEnv is the environment git runs in. Several repositories are updated
+// at once, so git must fail instead of asking for credentials on the
+// terminal; a repository that needs them is then reported as failed.
+func batchEnv() []string {
+	// A GIT_SSH_COMMAND of the user's, e.g. picking a key, is kept
+	ssh := os.Getenv("GIT_SSH_COMMAND")
+	if ssh == "" {
+		ssh = "ssh"
+	}
+	return append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_SSH_COMMAND="+ssh+" -o BatchMode=yes")
+}
+
+// git runs git in dir and returns its output with git's own message as the
+// error
+var git = func(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = batchEnv()
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return stdout.String(), errors.New(msg)
+		}
+		return stdout.String(), fmt.Errorf("git %s: %w", args[0], err)
+	}
+	return stdout.String() + stderr.String(), nil
+}
+
+// parseStatus reads git status --porcelain=v2 --branch into s
+func parseStatus(out string, s *Status) {
+	for _, line := range strings.Split(out, "\n") {
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "# branch.head "):
+			if head := strings.TrimPrefix(line, "# branch.head "); head != "(detached)" {
+				s.Branch = head
+			}
+		case strings.HasPrefix(line, "# branch.upstream "):
+			s.Upstream = strings.TrimPrefix(line, "# branch.upstream ")
+		case strings.HasPrefix(line, "# branch.ab "):
+			fmt.Sscanf(strings.TrimPrefix(line, "# branch.ab "), "+%d -%d", &s.Ahead, &s.Behind)
+		case strings.HasPrefix(line, "#"):
+		default:
+			s.Changed++
+		}
+	}
+}
+
+// Inspect reads a repository's branch, changes, upstream, stashes and last
+// commit. Problems are reported in Status.Err.
+func Inspect(repo Repo) Status {
+	s := Status{Repo: repo}
+	out, err := git(repo.Path, "status", "--porcelain=v2", "--branch", "--untracked-files=normal")
+	if err != nil {
+		s.Err = errors.New(errorLine(err.Error()))
+		return s
+	}
+	parseStatus(out, &s)
+
+	// Both fail harmlessly when there are no stashes or no commits yet
+	if out, err := git(repo.Path, "rev-list", "--walk-reflogs", "--count", "refs/stash"); err == nil {
+		s.Stashes, _ = strconv.Atoi(strings.TrimSpace(out))
+	}
+	if out, err := git(repo.Path, "log", "-1", "--format=%ct"); err == nil {
+		if unix, err := strconv.ParseInt(strings.TrimSpace(out), 10, 64); err == nil {
+			s.LastCommit = time.Unix(unix, 0)
+		}
+	}
+	return s
+}
+
+// Result is the outcome of updating one repository
+type Result struct {
+	Repo Repo
+	// Summary is git's last line of output, or its error
+	Summary string
+	Err     error
+}
+
+// Fetch fetches all remotes of the repository
+func Fetch(repo Repo, prune bool) Result {
+	args := []string{"fetch", "--all"}
+	if prune {
+		args = append(args, "--prune")
+	}
+	return update(repo, "fetched", args...)
+}
+
+// Pull fast-forwards the current branch, leaving branches that have
+// diverged for the user to merge or rebase
+func Pull(repo Repo) Result {
+	return update(repo, "pulled", "pull", "--ff-only")
+}
+
+func update(repo Repo, done string, args ...string) Result {
+	out, err := git(repo.Path, args...)
+	if err != nil {
+		return Result{Repo: repo, Summary: errorLine(err.Error()), Err: err}
+	}
+	summary := lastLine(out)
+	if summary == "" || strings.HasPrefix(summary, "Fetching ") {
+		summary = done
+	}
+	return Result{Repo: repo, Summary: summary}
+}
+
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// errorLine picks the line of a git error that says what went wrong,
+// skipping the hints around it
+func errorLine(msg string) string {
+	lines := strings.Split(strings.TrimSpace(msg), "\n")
+	for _, line := range lines {
+		for _, prefix := range []string{"fatal: ", "error: "} {
+			if strings.HasPrefix(line, prefix) {
+				return strings.TrimPrefix(line, prefix)
+			}
+		}
+	}
+	return strings.TrimSpace(lines[0])
+}
+
+// Each runs fn on every repository with at most workers running at once.
+// Results are in the order of repos.
+func Each[T any](repos []Repo, workers int, fn func(Repo) T) []T {
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make([]T, len(repos))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = fn(repos[i])
+			}
+		}()
+	}
+
+	for i := range repos {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// Age formats how long ago t was in its largest unit, like 5m, 3h, 2d, 6w,
+// 4mo or 2y
+func Age(t, now time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	d := now.Sub(t)
+	day := 24 * time.Hour
+	switch {
+	case d < time.Minute:
+		return "now"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d/time.Minute))
+	case d < day:
+		return fmt.Sprintf("%dh", int(d/time.Hour))
+	case d < 14*day:
+		return fmt.Sprintf("%dd", int(d/day))
+	case d < 60*day:
+		return fmt.Sprintf("%dw", int(d/(7*day)))
+	case d < 365*day:
+		return fmt.Sprintf("%dmo", int(d/(30*day)))
+	}
+	return fmt.Sprintf("%dy", int(d/(365*day)))
+}
@@ -0,0 +1,132 @@
+package repos
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseStatus(t *testing.T) {
+	out := "# branch.oid abc\n# branch.head main\n# branch.upstream origin/main\n# branch.ab +2 -1\n" +
+		"1 .M N... 100644 100644 100644 a b file.go\n? notes.txt\n"
+	var s Status
+	parseStatus(out, &s)
+	if s.Branch != "main" || s.Upstream != "origin/main" || s.Ahead != 2 || s.Behind != 1 || s.Changed != 2 {
+		t.Errorf("parseStatus() = %+v", s)
+	}
+	if got := s.Sync(); got != "↑2 ↓1" {
+		t.Errorf("Sync() = %q", got)
+	}
+
+	s = Status{}
+	parseStatus("# branch.oid abc\n# branch.head (detached)\n", &s)
+	if s.Branch != "" || s.Dirty() || s.Sync() != "detached" {
+		t.Errorf("detached parseStatus() = %+v", s)
+	}
+}
+
+func TestAge(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	tests := map[time.Duration]string{
+		30 * time.Second:     "now",
+		5 * time.Minute:      "5m",
+		3 * time.Hour:        "3h",
+		50 * time.Hour:       "2d",
+		21 * 24 * time.Hour:  "3w",
+		90 * 24 * time.Hour:  "3mo",
+		800 * 24 * time.Hour: "2y",
+	}
+	for ago, want := range tests {
+		if got := Age(now.Add(-ago), now); got != want {
+			t.Errorf("Age(%v ago) = %q, want %q", ago, got, want)
+		}
+	}
+	if got := Age(time.Time{}, now); got != "never" {
+		t.Errorf("Age(zero) = %q", got)
+	}
+}
+
+func TestEach(t *testing.T) {
+	repos := []Repo{{Name: "a"}, {Name: "b"}, {Name: "c"}}
+	got := Each(repos, 2, func(r Repo) string { return r.Name + "!" })
+	if got[0] != "a!" || got[1] != "b!" || got[2] != "c!" {
+		t.Errorf("Each() = %v, want results in order", got)
+	}
+}
+
+// gitRun runs git in dir, failing the test on error
+func gitRun(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+		"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com")
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func TestInspectFetchPull(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not installed")
+	}
+	root := t.TempDir()
+	origin := filepath.Join(root, "origin")
+	clone := filepath.Join(root, "clone")
+	os.Mkdir(origin, 0o755)
+	gitRun(t, origin, "init", "--quiet", "--initial-branch=main")
+	gitRun(t, origin, "commit", "--quiet", "--allow-empty", "-m", "first")
+	gitRun(t, root, "clone", "--quiet", origin, clone)
+	gitRun(t, origin, "commit", "--quiet", "--allow-empty", "-m", "second")
+
+	os.WriteFile(filepath.Join(clone, "stashed.txt"), []byte("x\n"), 0o644)
+	gitRun(t, clone, "stash", "push", "--quiet", "--include-untracked")
+	os.WriteFile(filepath.Join(clone, "new.txt"), []byte("x\n"), 0o644)
+
+	repo := Repo{Name: "clone", Path: clone}
+	if r := Fetch(repo, true); r.Err != nil {
+		t.Fatalf("Fetch() = %+v", r)
+	}
+	s := Inspect(repo)
+	if s.Err != nil || s.Branch != "main" || s.Behind != 1 || s.Changed != 1 || s.Stashes != 1 || s.LastCommit.IsZero() {
+		t.Fatalf("Inspect() = %+v", s)
+	}
+
+	if r := Pull(repo); r.Err != nil {
+		t.Fatalf("Pull() = %+v", r)
+	}
+	if s := Inspect(repo); s.Sync() != "up to date" {
+		t.Errorf("after Pull, Sync() = %q", s.Sync())
+	}
+
+	if s := Inspect(Repo{Name: "missing", Path: filepath.Join(root, "missing")}); s.Err == nil {
+		t.Error("Inspect() of a missing repository should fail")
+	}
+	if r := Pull(Repo{Name: "origin", Path: origin}); r.Err == nil || r.Summary == "" {
+		t.Errorf("Pull() without an upstream = %+v, want an error", r)
+	}
+}
+
+func TestBatchEnv(t *testing.T) {
+	has := func(env []string, want string) bool {
+		for _, kv := range env {
+			if kv == want {
+				return true
+			}
+		}
+		return false
+	}
+
+	t.Setenv("GIT_SSH_COMMAND", "")
+	env := batchEnv()
+	if !has(env, "GIT_TERMINAL_PROMPT=0") || !has(env, "GIT_SSH_COMMAND=ssh -o BatchMode=yes") {
+		t.Errorf("batchEnv() should disable credential prompts, got %v", env)
+	}
+
+	t.Setenv("GIT_SSH_COMMAND", "ssh -i ~/.ssh/deploy")
+	if !has(batchEnv(), "GIT_SSH_COMMAND=ssh -i ~/.ssh/deploy -o BatchMode=yes") {
+		t.Error("batchEnv() should keep the user's GIT_SSH_COMMAND")
+	}
+}
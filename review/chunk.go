@@ -0,0 +1,95 @@
+package review
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Chunk is a piece of a file, or of its diff, small enough to review in one
+// request
+type Chunk struct {
+	Path    string
+	Content string
+	// Diff chunks hold unified diff hunks instead of file content
+	Diff bool
+	// StartLine and EndLine are 1-based and inclusive. For diff chunks they
+	// number the hunks instead.
+	StartLine int
+	EndLine   int
+	// Total is the file's line count, or its hunk count for diffs
+	Total int
+	// Part and Parts number the pieces of a diff hunk too large for one
+	// chunk; Parts is 0 for a whole hunk
+	Part  int
+	Parts int
+}
+
+// Partial reports whether the chunk covers only part of its file
+func (c Chunk) Partial() bool {
+	return c.Parts > 1 || c.StartLine > 1 || c.EndLine < c.Total
+}
+
+// Label names the chunk in progress output and the report
+func (c Chunk) Label() string {
+	if c.Parts > 1 {
+		return fmt.Sprintf("%s (hunk %d, part %d/%d)", c.Path, c.StartLine, c.Part, c.Parts)
+	}
+	if !c.Partial() {
+		return c.Path
+	}
+	unit := "lines"
+	if c.Diff {
+		unit = "hunks"
+	}
+	return fmt.Sprintf("%s (%s %d-%d of %d)", c.Path, unit, c.StartLine, c.EndLine, c.Total)
+}
+
+// Estimator counts the tokens of a text, such as chat.EstimateTokens
+type Estimator func(text string) int
+
+// SplitFile cuts content into chunks of at most maxTokens, on line
+// boundaries. A single line longer than maxTokens becomes its own chunk.
+// Empty content has no chunks.
+func SplitFile(path, content string, maxTokens int, estimate Estimator) []Chunk {
+	lines := strings.SplitAfter(content, "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	groups := group(lines, maxTokens, estimate)
+	chunks := make([]Chunk, 0, len(groups))
+	for _, g := range groups {
+		chunks = append(chunks, Chunk{
+			Path:      path,
+			Content:   strings.Join(lines[g.start:g.end], ""),
+			StartLine: g.start + 1,
+			EndLine:   g.end,
+			Total:     len(lines),
+		})
+	}
+	return chunks
+}
+
+// span is a half-open range of indexes
+type span struct{ start, end int }
+
+// group packs consecutive parts into spans of at most maxTokens each
+func group(parts []string, maxTokens int, estimate Estimator) []span {
+	if len(parts) == 0 {
+		return nil
+	}
+	var spans []span
+	current := span{}
+	tokens := 0
+	for i, part := range parts {
+		n := estimate(part)
+		if i > current.start && tokens+n > maxTokens {
+			spans = append(spans, current)
+			current = span{start: i}
+			tokens = 0
+		}
+		current.end = i + 1
+		tokens += n
+	}
+	return append(spans, current)
+}
@@ -0,0 +1,138 @@
+package review
+
+import (
+	"strings"
+)
+
+// FileDiff is the part of a unified diff that changes one file
+type FileDiff struct {
+	Path string
+	// Header holds the "diff --git", index and ---/+++ lines
+	Header string
+	Hunks  []string
+	// Deleted files have nothing left to review
+	Deleted bool
+	Binary  bool
+}
+
+// ParseDiff splits the output of git diff into files and hunks
+func ParseDiff(diff string) []FileDiff {
+	var files []FileDiff
+	var current *FileDiff
+	var hunk strings.Builder
+	var header strings.Builder
+
+	flushHunk := func() {
+		if current != nil && hunk.Len() > 0 {
+			current.Hunks = append(current.Hunks, hunk.String())
+		}
+		hunk.Reset()
+	}
+	flushFile := func() {
+		flushHunk()
+		if current != nil {
+			current.Header = header.String()
+			files = append(files, *current)
+		}
+		header.Reset()
+	}
+
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if line == "" {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(line, "diff --git "):
+			flushFile()
+			current = &FileDiff{Path: diffPath(line)}
+			header.WriteString(line)
+		case current == nil:
+			// Text before the first file, e.g. from git show
+		case strings.HasPrefix(line, "@@"):
+			flushHunk()
+			hunk.WriteString(line)
+		case hunk.Len() > 0:
+			hunk.WriteString(line)
+		default:
+			header.WriteString(line)
+			switch {
+			case strings.HasPrefix(line, "+++ "):
+				if path := strings.TrimSpace(strings.TrimPrefix(line, "+++ ")); path == "/dev/null" {
+					current.Deleted = true
+				} else {
+					current.Path = strings.TrimPrefix(path, "b/")
+				}
+			case strings.HasPrefix(line, "deleted file mode"):
+				current.Deleted = true
+			case strings.HasPrefix(line, "Binary files"):
+				current.Binary = true
+			}
+		}
+	}
+	flushFile()
+	return files
+}
+
+// diffPath reads the new path from a "diff --git a/x b/x" line
+func diffPath(line string) string {
+	line = strings.TrimSpace(strings.TrimPrefix(line, "diff --git "))
+	if i := strings.LastIndex(line, " b/"); i >= 0 {
+		return line[i+3:]
+	}
+	return line
+}
+
+// SplitDiff groups a file's hunks into chunks of at most maxTokens. A hunk
+// larger than that is split on line boundaries into numbered parts, each
+// starting with the hunk's @@ header.
+func SplitDiff(fd FileDiff, maxTokens int, estimate Estimator) []Chunk {
+	var chunks []Chunk
+	for _, g := range group(fd.Hunks, maxTokens, estimate) {
+		content := strings.Join(fd.Hunks[g.start:g.end], "")
+		if g.end-g.start == 1 && estimate(content) > maxTokens {
+			parts := splitHunk(content, maxTokens, estimate)
+			for i, part := range parts {
+				chunks = append(chunks, Chunk{
+					Path:      fd.Path,
+					Content:   part,
+					Diff:      true,
+					StartLine: g.start + 1,
+					EndLine:   g.end,
+					Total:     len(fd.Hunks),
+					Part:      i + 1,
+					Parts:     len(parts),
+				})
+			}
+			continue
+		}
+		chunks = append(chunks, Chunk{
+			Path:      fd.Path,
+			Content:   content,
+			Diff:      true,
+			StartLine: g.start + 1,
+			EndLine:   g.end,
+			Total:     len(fd.Hunks),
+		})
+	}
+	return chunks
+}
+
+// splitHunk cuts a hunk's lines into parts of at most maxTokens, repeating
+// its @@ header at the start of each so every part reads as a hunk
+func splitHunk(hunk string, maxTokens int, estimate Estimator) []string {
+	header, body, _ := strings.Cut(hunk, "\n")
+	header += "\n"
+	budget := maxTokens - estimate(header)
+	if budget < 1 {
+		budget = 1
+	}
+	pieces := SplitFile("", body, budget, estimate)
+	if len(pieces) == 0 {
+		return []string{hunk}
+	}
+	parts := make([]string, len(pieces))
+	for i, piece := range pieces {
+		parts[i] = header + piece.Content
+	}
+	return parts
+}
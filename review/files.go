@@ -0,0 +1,268 @@
+// Package review prepares code for AI review: it collects files while
+// respecting .gitignore, splits files and git diffs into chunks that fit the
+// model's context, reviews chunks concurrently and merges the findings.
+package review
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// MaxFileSize is the largest file worth reviewing; bigger files are usually
+// generated or data
+const MaxFileSize = 1 << 20
+
+// Collect expands files, directories and glob patterns into the list of
+// files to review. Directories and globs skip what .gitignore ignores.
+func Collect(targets []string) ([]string, error) {
+	seen := map[string]bool{}
+	var files []string
+	add := func(file string) {
+		file = filepath.Clean(file)
+		if !seen[file] {
+			seen[file] = true
+			files = append(files, file)
+		}
+	}
+
+	for _, target := range targets {
+		if isGlob(target) {
+			matches, err := expandGlob(target)
+			if err != nil {
+				return nil, err
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no files match %s", target)
+			}
+			for _, m := range matches {
+				add(m)
+			}
+			continue
+		}
+
+		info, err := os.Stat(target)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			add(target)
+			continue
+		}
+
+		dirFiles, err := listFiles(target)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range dirFiles {
+			add(f)
+		}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// IsBinary reports whether data looks like a binary file
+func IsBinary(data []byte) bool {
+	if len(data) > 8000 {
+		data = data[:8000]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+func isGlob(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// expandGlob matches a pattern such as "src/**/*.go" against the files under
+// its fixed leading directory
+func expandGlob(pattern string) ([]string, error) {
+	pattern = filepath.ToSlash(filepath.Clean(pattern))
+	parts := strings.Split(pattern, "/")
+	base := "."
+	for i, part := range parts {
+		if isGlob(part) {
+			if i > 0 {
+				base = strings.Join(parts[:i], "/")
+			}
+			if base == "" {
+				base = "/"
+			}
+			break
+		}
+	}
+
+	files, err := listFiles(filepath.FromSlash(base))
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []string
+	for _, f := range files {
+		rel := filepath.ToSlash(filepath.Clean(f))
+		if matchGlob(pattern, rel) {
+			matches = append(matches, f)
+		}
+	}
+	return matches, nil
+}
+
+// matchGlob matches a slash-separated path against a pattern in which "**"
+// stands for any number of directories
+func matchGlob(pattern, name string) bool {
+	return matchParts(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchParts(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchParts(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
+
+// listFiles returns the files under dir that git doesn't ignore. Outside a
+// git work tree it walks dir and applies dir/.gitignore itself.
+func listFiles(dir string) ([]string, error) {
+	cmd := exec.Command("git", "ls-files", "--cached", "--others", "--exclude-standard", "-z")
+	cmd.Dir = dir
+	output, err := cmd.Output()
+	if err != nil {
+		return walkFiles(dir)
+	}
+
+	var files []string
+	for _, rel := range strings.Split(string(output), "\x00") {
+		if rel == "" {
+			continue
+		}
+		file := filepath.Join(dir, filepath.FromSlash(rel))
+		// Tracked files deleted from the work tree are still listed
+		if info, err := os.Lstat(file); err == nil && info.Mode().IsRegular() {
+			files = append(files, file)
+		}
+	}
+	return files, nil
+}
+
+// alwaysSkipped are directories never worth reviewing
+var alwaysSkipped = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+	"vendor":       true,
+}
+
+// walkFiles lists regular files under dir, skipping alwaysSkipped and what
+// dir/.gitignore ignores
+func walkFiles(dir string) ([]string, error) {
+	var rules []ignoreRule
+	if data, err := os.ReadFile(filepath.Join(dir, ".gitignore")); err == nil {
+		rules = parseGitignore(string(data))
+	}
+
+	var files []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == dir {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, p)
+		rel = filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			if alwaysSkipped[d.Name()] || ignored(rules, rel, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Type().IsRegular() && !ignored(rules, rel, false) {
+			files = append(files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error listing %s: %w", dir, err)
+	}
+	return files, nil
+}
+
+// ignoreRule is one .gitignore pattern
+type ignoreRule struct {
+	pattern string
+	negate  bool
+	dirOnly bool
+	// anchored patterns match from the .gitignore's directory; others
+	// match the file name at any depth
+	anchored bool
+}
+
+// parseGitignore reads the patterns of a .gitignore file
+func parseGitignore(data string) []ignoreRule {
+	var rules []ignoreRule
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(line, " \r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var rule ignoreRule
+		if strings.HasPrefix(line, "!") {
+			rule.negate = true
+			line = line[1:]
+		}
+		if strings.HasSuffix(line, "/") {
+			rule.dirOnly = true
+			line = strings.TrimSuffix(line, "/")
+		}
+		if strings.Contains(line, "/") {
+			rule.anchored = true
+			line = strings.TrimPrefix(line, "/")
+		}
+		rule.pattern = line
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// ignored applies the rules to a slash-separated path relative to the
+// .gitignore's directory. The last matching rule wins.
+func ignored(rules []ignoreRule, rel string, isDir bool) bool {
+	result := false
+	for _, rule := range rules {
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		var match bool
+		if rule.anchored {
+			match = matchGlob(rule.pattern, rel)
+		} else {
+			match, _ = path.Match(rule.pattern, path.Base(rel))
+		}
+		if match {
+			result = !rule.negate
+		}
+	}
+	return result
+}
@@ -0,0 +1,99 @@
+package review
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Result is the review of one chunk
+type Result struct {
+	Chunk    Chunk
+	Findings string
+	Err      error
+}
+
+// Run reviews chunks with at most workers running at once. Results are in
+// the order of chunks. Chunks not started before ctx is cancelled get its
+// error.
+func Run(ctx context.Context, chunks []Chunk, workers int, review func(context.Context, Chunk) (string, error)) []Result {
+	if workers < 1 {
+		workers = 1
+	}
+
+	results := make([]Result, len(chunks))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i].Chunk = chunks[i]
+				if err := ctx.Err(); err != nil {
+					results[i].Err = err
+					continue
+				}
+				results[i].Findings, results[i].Err = review(ctx, chunks[i])
+			}
+		}()
+	}
+
+	for i := range chunks {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// Report merges the results into one Markdown report with a section per
+// file, in path order
+func Report(title string, results []Result) string {
+	sorted := make([]Result, len(results))
+	copy(sorted, results)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Chunk.Path < sorted[j].Chunk.Path
+	})
+
+	files, failed := 0, 0
+	for i, r := range sorted {
+		if i == 0 || r.Chunk.Path != sorted[i-1].Chunk.Path {
+			files++
+		}
+		if r.Err != nil {
+			failed++
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", title)
+	fmt.Fprintf(&b, "Reviewed %d file(s) in %d chunk(s)", files, len(sorted))
+	if failed > 0 {
+		fmt.Fprintf(&b, "; %d chunk(s) failed", failed)
+	}
+	b.WriteString(".\n")
+
+	for i, r := range sorted {
+		if i == 0 || r.Chunk.Path != sorted[i-1].Chunk.Path {
+			fmt.Fprintf(&b, "\n## %s\n", r.Chunk.Path)
+		}
+		if r.Chunk.Partial() {
+			unit := "Lines"
+			if r.Chunk.Diff {
+				unit = "Hunks"
+			}
+			fmt.Fprintf(&b, "\n### %s %d-%d\n", unit, r.Chunk.StartLine, r.Chunk.EndLine)
+		}
+		b.WriteString("\n")
+		if r.Err != nil {
+			fmt.Fprintf(&b, "> ⚠️ Review failed: %v\n", r.Err)
+			continue
+		}
+		b.WriteString(strings.TrimSpace(r.Findings))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,287 @@
+package review
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countLines estimates one token per line, which keeps chunk sizes obvious
+func countLines(text string) int {
+	return strings.Count(text, "\n")
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func relative(t *testing.T, dir string, files []string) []string {
+	t.Helper()
+	var rel []string
+	for _, f := range files {
+		r, err := filepath.Rel(dir, f)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rel = append(rel, filepath.ToSlash(r))
+	}
+	return rel
+}
+
+func TestWalkFiles_Gitignore(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		".gitignore":           "# build output\nbuild/\n*.log\n/secret.txt\n!keep.log\n",
+		"main.go":              "package main\n",
+		"debug.log":            "x",
+		"keep.log":             "x",
+		"secret.txt":           "x",
+		"docs/secret.txt":      "x",
+		"build/out.go":         "x",
+		"src/build/gen.go":     "x",
+		"src/app.go":           "x",
+		"node_modules/m.js":    "x",
+		".git/HEAD":            "x",
+		"src/nested/util.go":   "x",
+		"src/nested/trace.log": "x",
+	})
+
+	files, err := walkFiles(dir)
+	if err != nil {
+		t.Fatalf("walkFiles() error = %v", err)
+	}
+	want := []string{".gitignore", "docs/secret.txt", "keep.log", "main.go", "src/app.go", "src/nested/util.go"}
+	if got := relative(t, dir, files); !reflect.DeepEqual(got, want) {
+		t.Errorf("walkFiles() = %v, want %v", got, want)
+	}
+}
+
+func TestMatchGlob(t *testing.T) {
+	tests := []struct {
+		pattern, name string
+		want          bool
+	}{
+		{"*.go", "main.go", true},
+		{"*.go", "cmd/main.go", false},
+		{"cmd/**/*.go", "cmd/main.go", true},
+		{"cmd/**/*.go", "cmd/a/b/main.go", true},
+		{"cmd/**/*.go", "pkg/main.go", false},
+		{"**/test_*.py", "a/b/test_x.py", true},
+		{"src/*.ts", "src/a/b.ts", false},
+	}
+	for _, tt := range tests {
+		if got := matchGlob(tt.pattern, tt.name); got != tt.want {
+			t.Errorf("matchGlob(%q, %q) = %v, want %v", tt.pattern, tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCollect(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.go":     "x",
+		"b.txt":    "x",
+		"sub/c.go": "x",
+	})
+
+	files, err := Collect([]string{filepath.Join(dir, "**", "*.go"), filepath.Join(dir, "a.go")})
+	if err != nil {
+		t.Fatalf("Collect() error = %v", err)
+	}
+	if got := relative(t, dir, files); !reflect.DeepEqual(got, []string{"a.go", "sub/c.go"}) {
+		t.Errorf("Collect() = %v, want deduplicated glob matches", got)
+	}
+
+	if _, err := Collect([]string{filepath.Join(dir, "*.rs")}); err == nil {
+		t.Error("Collect() should fail when a glob matches nothing")
+	}
+	if _, err := Collect([]string{filepath.Join(dir, "missing.go")}); err == nil {
+		t.Error("Collect() should fail for missing files")
+	}
+}
+
+func TestSplitFile(t *testing.T) {
+	content := "1\n2\n3\n4\n5\n"
+
+	chunks := SplitFile("f.go", content, 2, countLines)
+	if len(chunks) != 3 {
+		t.Fatalf("SplitFile() = %d chunks, want 3", len(chunks))
+	}
+	if chunks[0].Content != "1\n2\n" || chunks[2].Content != "5\n" {
+		t.Errorf("SplitFile() contents = %q, %q", chunks[0].Content, chunks[2].Content)
+	}
+	if chunks[1].StartLine != 3 || chunks[1].EndLine != 4 || chunks[1].Total != 5 {
+		t.Errorf("SplitFile() chunk = %+v, want lines 3-4 of 5", chunks[1])
+	}
+	if got := chunks[1].Label(); got != "f.go (lines 3-4 of 5)" {
+		t.Errorf("Label() = %q", got)
+	}
+
+	whole := SplitFile("f.go", content, 100, countLines)
+	if len(whole) != 1 || whole[0].Partial() || whole[0].Label() != "f.go" {
+		t.Errorf("SplitFile() = %+v, want the whole file in one chunk", whole)
+	}
+
+	if chunks := SplitFile("empty.go", "", 100, countLines); len(chunks) != 0 {
+		t.Errorf("SplitFile() on empty content = %+v", chunks)
+	}
+}
+
+const sampleDiff = `diff --git a/main.go b/main.go
+index 1111111..2222222 100644
+--- a/main.go
++++ b/main.go
+@@ -1,3 +1,4 @@
+ package main
++import "fmt"
+
+ func main() {
+@@ -10,2 +11,2 @@ func helper() {
+-	return 1
++	return 2
+diff --git a/old.go b/old.go
+deleted file mode 100644
+index 3333333..0000000
+--- a/old.go
++++ /dev/null
+@@ -1 +0,0 @@
+-package old
+diff --git a/logo.png b/logo.png
+index 4444444..5555555 100644
+Binary files a/logo.png and b/logo.png differ
+`
+
+func TestParseDiff(t *testing.T) {
+	files := ParseDiff(sampleDiff)
+	if len(files) != 3 {
+		t.Fatalf("ParseDiff() = %d files, want 3", len(files))
+	}
+
+	main := files[0]
+	if main.Path != "main.go" || len(main.Hunks) != 2 || main.Deleted || main.Binary {
+		t.Errorf("ParseDiff() main.go = %+v", main)
+	}
+	if !strings.HasPrefix(main.Hunks[1], "@@ -10,2") || !strings.Contains(main.Hunks[1], "+\treturn 2") {
+		t.Errorf("ParseDiff() second hunk = %q", main.Hunks[1])
+	}
+	if !files[1].Deleted || files[1].Path != "old.go" {
+		t.Errorf("ParseDiff() old.go = %+v, want deleted", files[1])
+	}
+	if !files[2].Binary {
+		t.Errorf("ParseDiff() logo.png = %+v, want binary", files[2])
+	}
+}
+
+func TestSplitDiff(t *testing.T) {
+	fd := ParseDiff(sampleDiff)[0]
+
+	chunks := SplitDiff(fd, 100, countLines)
+	if len(chunks) != 1 || !chunks[0].Diff || chunks[0].Partial() {
+		t.Fatalf("SplitDiff() = %+v, want both hunks in one chunk", chunks)
+	}
+
+	chunks = SplitDiff(fd, 5, countLines)
+	if len(chunks) != 2 || chunks[1].StartLine != 2 || chunks[1].Label() != "main.go (hunks 2-2 of 2)" {
+		t.Errorf("SplitDiff() = %+v, want one chunk per hunk", chunks)
+	}
+
+	chunks = SplitDiff(fd, 3, countLines)
+	if len(chunks) != 3 {
+		t.Fatalf("SplitDiff() = %d chunks, want the large first hunk split on lines", len(chunks))
+	}
+	for i, want := range []string{"main.go (hunk 1, part 1/2)", "main.go (hunk 1, part 2/2)", "main.go (hunks 2-2 of 2)"} {
+		if got := chunks[i].Label(); got != want {
+			t.Errorf("chunk %d Label() = %q, want %q", i, got, want)
+		}
+	}
+	for _, c := range chunks[:2] {
+		if !strings.HasPrefix(c.Content, "@@ -1,3 +1,4 @@\n") || countLines(c.Content) > 3 {
+			t.Errorf("hunk part = %q, want the @@ header and at most 3 lines", c.Content)
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	chunks := make([]Chunk, 10)
+	for i := range chunks {
+		chunks[i] = Chunk{Path: string(rune('a' + i))}
+	}
+
+	var running, peak int32
+	results := Run(context.Background(), chunks, 3, func(ctx context.Context, c Chunk) (string, error) {
+		n := atomic.AddInt32(&running, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		if c.Path == "c" {
+			return "", errors.New("boom")
+		}
+		return "ok " + c.Path, nil
+	})
+
+	if peak > 3 {
+		t.Errorf("Run() ran %d reviews at once, want at most 3", peak)
+	}
+	for i, r := range results {
+		if r.Chunk.Path != chunks[i].Path {
+			t.Fatalf("Run() results out of order at %d: %+v", i, r)
+		}
+	}
+	if results[2].Err == nil || results[3].Findings != "ok d" {
+		t.Errorf("Run() results = %+v", results[2:4])
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results = Run(ctx, chunks, 2, func(ctx context.Context, c Chunk) (string, error) {
+		t.Error("Run() should not start reviews after cancellation")
+		return "", nil
+	})
+	if !errors.Is(results[0].Err, context.Canceled) {
+		t.Errorf("Run() error = %v, want context.Canceled", results[0].Err)
+	}
+}
+
+func TestReport(t *testing.T) {
+	results := []Result{
+		{Chunk: Chunk{Path: "b.go", StartLine: 1, EndLine: 1, Total: 1}, Findings: "Fine.\n"},
+		{Chunk: Chunk{Path: "a.go", StartLine: 1, EndLine: 50, Total: 80}, Findings: "Bug on line 3."},
+		{Chunk: Chunk{Path: "a.go", StartLine: 51, EndLine: 80, Total: 80}, Err: errors.New("timeout")},
+	}
+
+	report := Report("Code review", results)
+	for _, want := range []string{
+		"# Code review",
+		"Reviewed 2 file(s) in 3 chunk(s); 1 chunk(s) failed.",
+		"## a.go\n\n### Lines 1-50\n\nBug on line 3.",
+		"### Lines 51-80\n\n> ⚠️ Review failed: timeout",
+		"## b.go\n\nFine.\n",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("Report() missing %q in:\n%s", want, report)
+		}
+	}
+	if strings.Index(report, "## a.go") > strings.Index(report, "## b.go") {
+		t.Error("Report() should order files by path")
+	}
+}
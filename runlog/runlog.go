@@ -0,0 +1,168 @@
+// Package runlog keeps a rotating history of automation runs, one JSON
+// Lines file per task.
+package runlog
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+const (
+	// DefaultMaxSize is how large a log file grows before it is rotated
+	DefaultMaxSize = 1 << 20
+	// DefaultKeep is how many rotated files are kept besides the current one
+	DefaultKeep = 3
+	// DefaultMaxOutput is how much of each stream of a run is kept; the end
+	// of the output, where errors usually are
+	DefaultMaxOutput = 64 << 10
+)
+
+// Run is one run of an automation
+type Run struct {
+	TaskID   string        `json:"task_id"`
+	TaskName string        `json:"task_name"`
+	Command  string        `json:"command"`
+	Start    time.Time     `json:"start"`
+	End      time.Time     `json:"end"`
+	Duration time.Duration `json:"duration"`
+	ExitCode int           `json:"exit_code"`
+	TimedOut bool          `json:"timed_out,omitempty"`
+	// Error says why the run failed, empty when it succeeded
+	Error  string `json:"error,omitempty"`
+	Stdout string `json:"stdout,omitempty"`
+	Stderr string `json:"stderr,omitempty"`
+}
+
+// Failed says whether the run didn't succeed
+func (r Run) Failed() bool {
+	return r.Error != "" || r.ExitCode != 0
+}
+
+// Log is a directory of run logs
+type Log struct {
+	dir       string
+	MaxSize   int64
+	Keep      int
+	MaxOutput int
+}
+
+// New returns the run log stored in dir
+func New(dir string) *Log {
+	return &Log{dir: dir, MaxSize: DefaultMaxSize, Keep: DefaultKeep, MaxOutput: DefaultMaxOutput}
+}
+
+var unsafeName = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// path is the file of a task's log; rotation 0 is the current file
+func (l *Log) path(taskID string, rotation int) string {
+	name := unsafeName.ReplaceAllString(taskID, "_")
+	if rotation > 0 {
+		name += "." + strconv.Itoa(rotation)
+	}
+	return filepath.Join(l.dir, name+".jsonl")
+}
+
+// Record appends a run to its task's log, rotating the log when it is full
+func (l *Log) Record(run Run) error {
+	if err := os.MkdirAll(l.dir, 0700); err != nil {
+		return fmt.Errorf("error creating run log directory: %w", err)
+	}
+	run.Stdout = lastBytes(run.Stdout, l.MaxOutput)
+	run.Stderr = lastBytes(run.Stderr, l.MaxOutput)
+	data, err := json.Marshal(run)
+	if err != nil {
+		return fmt.Errorf("error encoding run: %w", err)
+	}
+
+	current := l.path(run.TaskID, 0)
+	if info, err := os.Stat(current); err == nil && info.Size() > 0 && info.Size()+int64(len(data)) >= l.MaxSize {
+		if err := l.rotate(run.TaskID); err != nil {
+			return fmt.Errorf("error rotating run log: %w", err)
+		}
+	}
+
+	f, err := os.OpenFile(current, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("error opening run log: %w", err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("error writing run log: %w", err)
+	}
+	return nil
+}
+
+// rotate shifts a task's log files up by one, dropping the oldest
+func (l *Log) rotate(taskID string) error {
+	if err := os.Remove(l.path(taskID, l.Keep)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for i := l.Keep - 1; i >= 0; i-- {
+		err := os.Rename(l.path(taskID, i), l.path(taskID, i+1))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// Runs returns a task's recorded runs, oldest first. Lines that can't be
+// parsed are skipped.
+func (l *Log) Runs(taskID string) ([]Run, error) {
+	var runs []Run
+	for i := l.Keep; i >= 0; i-- {
+		f, err := os.Open(l.path(taskID, i))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error opening run log: %w", err)
+		}
+		scanner := bufio.NewScanner(f)
+		scanner.Buffer(make([]byte, 0, 64*1024), 8*l.MaxOutput+64*1024)
+		for scanner.Scan() {
+			var run Run
+			if err := json.Unmarshal(scanner.Bytes(), &run); err == nil {
+				runs = append(runs, run)
+			}
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("error reading run log: %w", err)
+		}
+	}
+	return runs, nil
+}
+
+// Remove deletes a task's log
+func (l *Log) Remove(taskID string) error {
+	for i := 0; i <= l.Keep; i++ {
+		if err := os.Remove(l.path(taskID, i)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+	return nil
+}
+
+// lastBytes is the end of s, at most limit bytes, cut at a line start when
+// one is near
+func lastBytes(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	s = s[len(s)-limit:]
+	for i := 0; i < len(s) && i < 1024; i++ {
+		if s[i] == '\n' {
+			return "...\n" + s[i+1:]
+		}
+	}
+	return "..." + s
+}
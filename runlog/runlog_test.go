@@ -0,0 +1,105 @@
+package runlog
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRecordAndRuns(t *testing.T) {
+	l := New(filepath.Join(t.TempDir(), "runs"))
+	start := time.Date(2026, 3, 4, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		run := Run{TaskID: "auto_1", Command: "ellie todo list", Start: start.Add(time.Duration(i) * time.Hour), ExitCode: i}
+		if err := l.Record(run); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := l.Record(Run{TaskID: "auto_2"}); err != nil {
+		t.Fatal(err)
+	}
+
+	runs, err := l.Runs("auto_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) != 3 || runs[0].ExitCode != 0 || runs[2].ExitCode != 2 || !runs[2].Start.Equal(start.Add(2*time.Hour)) {
+		t.Fatalf("Runs() = %+v", runs)
+	}
+	if runs[0].Failed() || !runs[1].Failed() {
+		t.Errorf("Failed() = %v, %v", runs[0].Failed(), runs[1].Failed())
+	}
+
+	if runs, _ := l.Runs("missing"); len(runs) != 0 {
+		t.Errorf("Runs(missing) = %+v", runs)
+	}
+	if err := l.Remove("auto_1"); err != nil {
+		t.Fatal(err)
+	}
+	if runs, _ := l.Runs("auto_1"); len(runs) != 0 {
+		t.Errorf("Runs() after Remove = %+v", runs)
+	}
+	if runs, _ := l.Runs("auto_2"); len(runs) != 1 {
+		t.Errorf("Remove took another task's runs: %+v", runs)
+	}
+}
+
+func TestRotation(t *testing.T) {
+	dir := t.TempDir()
+	l := New(dir)
+	l.MaxSize = 300
+	l.Keep = 2
+	for i := 0; i < 20; i++ {
+		if err := l.Record(Run{TaskID: "auto_1", ExitCode: i, Stdout: strings.Repeat("x", 50)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "auto_1*.jsonl"))
+	if len(files) != 3 {
+		t.Errorf("files = %v, want the current one and 2 rotated", files)
+	}
+	for _, f := range files {
+		if info, _ := os.Stat(f); info.Size() > l.MaxSize {
+			t.Errorf("%s is %d bytes, over %d", f, info.Size(), l.MaxSize)
+		}
+	}
+
+	runs, err := l.Runs("auto_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(runs) == 0 || len(runs) >= 20 || runs[len(runs)-1].ExitCode != 19 {
+		t.Fatalf("got %d runs", len(runs))
+	}
+	for i := 1; i < len(runs); i++ {
+		if runs[i].ExitCode != runs[i-1].ExitCode+1 {
+			t.Fatalf("runs out of order: %d after %d", runs[i].ExitCode, runs[i-1].ExitCode)
+		}
+	}
+}
+
+func TestRecordKeepsTheEndOfLongOutput(t *testing.T) {
+	l := New(t.TempDir())
+	l.MaxOutput = 100
+	out := strings.Repeat("line\n", 100) + "the error\n"
+	if err := l.Record(Run{TaskID: "a", Stderr: out}); err != nil {
+		t.Fatal(err)
+	}
+	runs, _ := l.Runs("a")
+	if len(runs) != 1 || !strings.HasPrefix(runs[0].Stderr, "...\nline\n") || !strings.HasSuffix(runs[0].Stderr, "the error\n") || len(runs[0].Stderr) > 110 {
+		t.Errorf("Stderr = %q", runs[0].Stderr)
+	}
+}
+
+func TestPathIsSafe(t *testing.T) {
+	l := New("/logs")
+	if got := l.path("../../etc/x", 0); got != "/logs/.._.._etc_x.jsonl" {
+		t.Errorf("path() = %q", got)
+	}
+	if got := l.path("auto_1", 2); got != "/logs/auto_1.2.jsonl" {
+		t.Errorf("path() = %q", got)
+	}
+}
@@ -0,0 +1,136 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// macros are the cron shorthands
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+var monthNames = map[string]int{
+	"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+	"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+}
+
+// daysInMonth is the most days each month can have
+var daysInMonth = [13]int{0, 31, 29, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31}
+
+// cronRange is what a cron field accepts
+type cronRange struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var cronRanges = [5]cronRange{
+	{"minute", 0, 59, nil},
+	{"hour", 0, 23, nil},
+	{"day of month", 1, 31, nil},
+	{"month", 1, 12, monthNames},
+	// 7 is Sunday too
+	{"day of week", 0, 7, weekdayNames},
+}
+
+// parseCron reads the five fields of a cron expression: minute, hour, day
+// of month, month and day of week
+func (s *Schedule) parseCron(fields []string) error {
+	var values [5][]bool
+	for i, field := range fields {
+		var err error
+		if values[i], err = cronRanges[i].parse(field); err != nil {
+			return err
+		}
+	}
+
+	for h, hourSet := range values[1] {
+		for m, minuteSet := range values[0] {
+			s.minutes[h*60+m] = hourSet && minuteSet
+		}
+	}
+	copy(s.days[:], values[2])
+	copy(s.months[:], values[3])
+	copy(s.weekdays[:], values[4])
+	s.weekdays[0] = s.weekdays[0] || values[4][7]
+	s.anyDay = strings.HasPrefix(fields[2], "*")
+	s.anyWeekday = strings.HasPrefix(fields[4], "*")
+
+	// A day of month that no chosen month has never comes round, unless the
+	// day of week can match instead
+	if s.anyWeekday || s.anyDay {
+		for month := 1; month <= 12; month++ {
+			for day := 1; day <= daysInMonth[month]; day++ {
+				if s.months[month] && s.days[day] {
+					return nil
+				}
+			}
+		}
+		return fmt.Errorf("%q never runs: none of its months has those days", strings.Join(fields, " "))
+	}
+	return nil
+}
+
+// parse reads a field's comma separated list of *, values and ranges, each
+// with an optional /step, as the set of values it matches
+func (r cronRange) parse(field string) ([]bool, error) {
+	set := make([]bool, r.max+1)
+	for _, item := range strings.Split(field, ",") {
+		span, stepText, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepText); err != nil || step < 1 {
+				return nil, fmt.Errorf("invalid step %q in %s field %q", stepText, r.name, field)
+			}
+		}
+
+		first, last := r.min, r.max
+		if span != "*" {
+			from, to, isRange := strings.Cut(span, "-")
+			var err error
+			if first, err = r.value(from); err != nil {
+				return nil, fmt.Errorf("%v in %s field %q", err, r.name, field)
+			}
+			last = first
+			if isRange {
+				if last, err = r.value(to); err != nil {
+					return nil, fmt.Errorf("%v in %s field %q", err, r.name, field)
+				}
+			} else if hasStep {
+				// 5/15 means from 5 to the end, every 15
+				last = r.max
+			}
+			if last < first {
+				return nil, fmt.Errorf("backwards range %q in %s field", span, r.name)
+			}
+		}
+		for v := first; v <= last; v += step {
+			set[v] = true
+		}
+	}
+	return set, nil
+}
+
+// value reads a number or a name such as jan or mon
+func (r cronRange) value(text string) (int, error) {
+	if v, ok := r.names[text]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(text)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", text)
+	}
+	if v < r.min || v > r.max {
+		return 0, fmt.Errorf("%d is out of range %d-%d", v, r.min, r.max)
+	}
+	return v, nil
+}
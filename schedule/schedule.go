@@ -0,0 +1,376 @@
+// Package schedule parses automation schedules, from "hourly" to cron
+// expressions, and works out when they next run.
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const minutesPerDay = 24 * 60
+
+// searchDays bounds how far ahead Next looks. Rare cron dates such as
+// February 29th on a Monday come round every few decades.
+const searchDays = 50 * 366
+
+// Schedule is a set of times, to the minute, in a time zone
+type Schedule struct {
+	spec string
+	loc  *time.Location
+	// minutes holds the minutes of the day it runs at
+	minutes [minutesPerDay]bool
+	// days holds days of the month 1-31, months 1-12 and weekdays from
+	// Sunday = 0
+	days     [32]bool
+	months   [13]bool
+	weekdays [7]bool
+	// anyDay and anyWeekday are cron's * in the day fields. When both day
+	// fields are restricted, a day matching either runs, as in cron.
+	anyDay, anyWeekday bool
+	// year limits a one-off schedule to its date
+	year int
+}
+
+var (
+	cronField = regexp.MustCompile(`^[0-9*,/-]+$`)
+	dateWord  = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}$`)
+	clockWord = regexp.MustCompile(`^(\d{1,2}):(\d{2})$`)
+)
+
+// Parse reads a schedule. It is one of
+//
+//	a cron expression      "30 9 * * 1-5", "@daily", "@monthly"
+//	a frequency            "hourly", "daily", "weekly", "every 15m"
+//	a time and days        "@09:00", "daily at 18:30 on weekdays", "mon,thu at 08:00"
+//	a date, run once       "2026-12-24 09:00"
+//
+// any of which may name a time zone with TZ=<zone>, such as
+// "daily at 09:00 TZ=Africa/Nairobi". Times are in the local zone
+// otherwise.
+func Parse(spec string) (*Schedule, error) {
+	s := &Schedule{spec: strings.Join(strings.Fields(spec), " "), loc: time.Local}
+	var words []string
+	for _, word := range strings.Fields(spec) {
+		zone, found := strings.CutPrefix(word, "TZ=")
+		if !found {
+			zone, found = strings.CutPrefix(word, "CRON_TZ=")
+		}
+		if !found {
+			words = append(words, strings.ToLower(word))
+			continue
+		}
+		loc, err := time.LoadLocation(zone)
+		if err != nil {
+			return nil, fmt.Errorf("unknown time zone %q", zone)
+		}
+		s.loc = loc
+	}
+
+	var err error
+	switch {
+	case len(words) == 0:
+		err = errors.New("empty schedule")
+	case len(words) == 1 && macros[words[0]] != "":
+		err = s.parseCron(strings.Fields(macros[words[0]]))
+	case len(words) == 5 && cronField.MatchString(words[0]) && cronField.MatchString(words[1]):
+		err = s.parseCron(words)
+	case dateWord.MatchString(words[0]):
+		err = s.parseDate(words)
+	default:
+		err = s.parsePhrase(words)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// String is the schedule as it was written
+func (s *Schedule) String() string {
+	return s.spec
+}
+
+// Location is the time zone the schedule's times are in
+func (s *Schedule) Location() *time.Location {
+	return s.loc
+}
+
+// Next is the first time the schedule runs after a time, or the zero time
+// if it doesn't run again
+func (s *Schedule) Next(after time.Time) time.Time {
+	local := after.In(s.loc)
+	first := local.Hour()*60 + local.Minute() + 1
+	for i := 0; i < searchDays; i++ {
+		day := time.Date(local.Year(), local.Month(), local.Day()+i, 0, 0, 0, 0, s.loc)
+		if s.year != 0 && day.Year() > s.year {
+			break
+		}
+		if !s.runsOn(day) {
+			continue
+		}
+		from := 0
+		if i == 0 {
+			from = first
+		}
+		for m := from; m < minutesPerDay; m++ {
+			if !s.minutes[m] {
+				continue
+			}
+			next := wallClock(day, m)
+			if next.After(after) {
+				return next
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// wallClock is the moment a day's clocks show a minute of the day. A minute
+// skipped when clocks go forward falls as far after the jump as it would
+// have been, so 02:30 is 03:30 when clocks jump from 02:00 to 03:00.
+func wallClock(day time.Time, minute int) time.Time {
+	t := time.Date(day.Year(), day.Month(), day.Day(), minute/60, minute%60, 0, 0, day.Location())
+	if t.Hour()*60+t.Minute() == minute {
+		return t
+	}
+	// time.Date may pick the offset from either side of the gap, so use the
+	// one that puts the time after it
+	wall := time.Date(day.Year(), day.Month(), day.Day(), minute/60, minute%60, 0, 0, time.UTC)
+	_, before := t.Add(-12 * time.Hour).Zone()
+	_, after := t.Add(12 * time.Hour).Zone()
+	earlier, later := wall.Add(-time.Duration(before)*time.Second), wall.Add(-time.Duration(after)*time.Second)
+	if earlier.After(later) {
+		return earlier.In(day.Location())
+	}
+	return later.In(day.Location())
+}
+
+// runsOn says whether the schedule runs on a day
+func (s *Schedule) runsOn(day time.Time) bool {
+	if s.year != 0 && day.Year() != s.year || !s.months[day.Month()] {
+		return false
+	}
+	dayMatches, weekdayMatches := s.days[day.Day()], s.weekdays[day.Weekday()]
+	if s.anyDay || s.anyWeekday {
+		return dayMatches && weekdayMatches
+	}
+	return dayMatches || weekdayMatches
+}
+
+// everyDay makes the schedule run on every day of every month
+func (s *Schedule) everyDay() {
+	for i := range s.days {
+		s.days[i] = i > 0
+	}
+	for i := range s.months {
+		s.months[i] = i > 0
+	}
+	for i := range s.weekdays {
+		s.weekdays[i] = true
+	}
+	s.anyDay, s.anyWeekday = true, true
+}
+
+// parseDate reads a one-off "2026-12-24 [at] [09:00]"
+func (s *Schedule) parseDate(words []string) error {
+	date, err := time.ParseInLocation("2006-01-02", words[0], s.loc)
+	if err != nil {
+		return fmt.Errorf("invalid date %q", words[0])
+	}
+	rest := words[1:]
+	if len(rest) > 0 && rest[0] == "at" {
+		rest = rest[1:]
+	}
+	minute := 0
+	switch len(rest) {
+	case 0:
+	case 1:
+		if minute, err = parseClock(rest[0]); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("a date takes only a time, as in \"%s 09:00\"", words[0])
+	}
+	s.minutes[minute] = true
+	s.days[date.Day()] = true
+	s.months[date.Month()] = true
+	s.anyWeekday = true
+	for i := range s.weekdays {
+		s.weekdays[i] = true
+	}
+	s.year = date.Year()
+	return nil
+}
+
+// parsePhrase reads frequencies, times and days such as
+// "every 30m on weekdays" or "weekly on fri at 17:00"
+func (s *Schedule) parsePhrase(words []string) error {
+	frequency := ""
+	interval := 0
+	clock := -1
+	var weekdays []bool
+	for i := 0; i < len(words); i++ {
+		word := words[i]
+		next := func(what string) (string, error) {
+			if i+1 >= len(words) {
+				return "", fmt.Errorf("%q needs %s after it", word, what)
+			}
+			i++
+			return words[i], nil
+		}
+		setFrequency := func(f string) error {
+			if frequency != "" {
+				return fmt.Errorf("%q and %q can't be combined", frequency, f)
+			}
+			frequency = f
+			return nil
+		}
+
+		switch {
+		case word == "hourly" || word == "daily" || word == "weekly":
+			if err := setFrequency(word); err != nil {
+				return err
+			}
+		case word == "every":
+			value, err := next("an interval such as 15m")
+			if err != nil {
+				return err
+			}
+			if err := setFrequency("every " + value); err != nil {
+				return err
+			}
+			if interval, err = parseInterval(value); err != nil {
+				return err
+			}
+		case word == "at" || strings.HasPrefix(word, "@") && len(word) > 1:
+			value := strings.TrimPrefix(word, "@")
+			if word == "at" {
+				var err error
+				if value, err = next("a time such as 09:00"); err != nil {
+					return err
+				}
+			}
+			if clock >= 0 {
+				return errors.New("only one time of day can be given; use a cron expression for more")
+			}
+			var err error
+			if clock, err = parseClock(value); err != nil {
+				return err
+			}
+		case word == "on":
+			value, err := next("days such as weekdays or mon,wed")
+			if err != nil {
+				return err
+			}
+			if weekdays != nil {
+				return errors.New("days are given twice")
+			}
+			if weekdays, err = parseWeekdays(value); err != nil {
+				return err
+			}
+		default:
+			days, err := parseWeekdays(word)
+			if err != nil {
+				return fmt.Errorf("unknown schedule word %q", word)
+			}
+			if weekdays != nil {
+				return errors.New("days are given twice")
+			}
+			weekdays = days
+		}
+	}
+
+	if clock >= 0 && (frequency == "hourly" || interval > 0) {
+		return fmt.Errorf("%q runs through the day, so it takes no time; use a cron expression for other times", frequency)
+	}
+	s.everyDay()
+	if weekdays != nil {
+		s.anyWeekday = false
+		copy(s.weekdays[:], weekdays)
+	} else if frequency == "weekly" {
+		s.anyWeekday = false
+		s.weekdays = [7]bool{time.Monday: true}
+	}
+	switch {
+	case frequency == "hourly":
+		for m := 0; m < minutesPerDay; m += 60 {
+			s.minutes[m] = true
+		}
+	case interval > 0:
+		// Intervals count from midnight, so every 15m runs on the quarter
+		// hour and every 7h at 00:00, 07:00, 14:00 and 21:00
+		for m := 0; m < minutesPerDay; m += interval {
+			s.minutes[m] = true
+		}
+	default:
+		s.minutes[max(clock, 0)] = true
+	}
+	return nil
+}
+
+// parseClock reads a time of day such as 09:00 as minutes since midnight
+func parseClock(value string) (int, error) {
+	m := clockWord.FindStringSubmatch(value)
+	if m == nil {
+		return 0, fmt.Errorf("invalid time %q; use HH:MM such as 09:00", value)
+	}
+	hour, _ := strconv.Atoi(m[1])
+	minute, _ := strconv.Atoi(m[2])
+	if hour > 23 || minute > 59 {
+		return 0, fmt.Errorf("invalid time %q; use HH:MM such as 09:00", value)
+	}
+	return hour*60 + minute, nil
+}
+
+// parseInterval reads an interval such as 15m or 2h as minutes
+func parseInterval(value string) (int, error) {
+	d, err := time.ParseDuration(value)
+	if err != nil || d < time.Minute || d > 24*time.Hour || d%time.Minute != 0 {
+		return 0, fmt.Errorf("invalid interval %q; use whole minutes or hours up to 24h, such as 15m or 2h", value)
+	}
+	return int(d / time.Minute), nil
+}
+
+// parseWeekdays reads weekdays, weekends or a list such as mon,wed or
+// mon-fri
+func parseWeekdays(value string) ([]bool, error) {
+	days := make([]bool, 7)
+	switch value {
+	case "weekdays":
+		value = "mon-fri"
+	case "weekends":
+		value = "sat,sun"
+	}
+	for _, item := range strings.Split(value, ",") {
+		from, to, isRange := strings.Cut(item, "-")
+		if !isRange {
+			to = from
+		}
+		first, knownFirst := weekdayNames[from]
+		last, knownLast := weekdayNames[to]
+		if !knownFirst || !knownLast {
+			return nil, fmt.Errorf("unknown day %q", item)
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+var weekdayNames = map[string]int{
+	"sun": 0, "sunday": 0,
+	"mon": 1, "monday": 1,
+	"tue": 2, "tuesday": 2,
+	"wed": 3, "wednesday": 3,
+	"thu": 4, "thursday": 4,
+	"fri": 5, "friday": 5,
+	"sat": 6, "saturday": 6,
+}
@@ -0,0 +1,193 @@
+package schedule
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	utc := "2026-03-04 10:07 UTC"
+	tests := []struct {
+		spec  string
+		after string
+		want  []string
+	}{
+		// Frequencies
+		{"hourly", utc, []string{"2026-03-04 11:00", "2026-03-04 12:00"}},
+		{"daily", utc, []string{"2026-03-05 00:00", "2026-03-06 00:00"}},
+		{"weekly", utc, []string{"2026-03-09 00:00", "2026-03-16 00:00"}},
+		{"every 15m", utc, []string{"2026-03-04 10:15", "2026-03-04 10:30"}},
+		{"every 7h", "2026-03-04 20:00 UTC", []string{"2026-03-04 21:00", "2026-03-05 00:00", "2026-03-05 07:00"}},
+		{"every 1h30m", utc, []string{"2026-03-04 10:30", "2026-03-04 12:00"}},
+
+		// Times and days
+		{"@09:00", utc, []string{"2026-03-05 09:00", "2026-03-06 09:00"}},
+		{"@12:30", utc, []string{"2026-03-04 12:30", "2026-03-05 12:30"}},
+		{"daily at 10:07", utc, []string{"2026-03-05 10:07"}},
+		{"daily at 10:08", utc, []string{"2026-03-04 10:08"}},
+		{"at 18:00 on weekdays", "2026-03-06 18:00 UTC", []string{"2026-03-09 18:00", "2026-03-10 18:00"}},
+		{"weekends", utc, []string{"2026-03-07 00:00", "2026-03-08 00:00", "2026-03-14 00:00"}},
+		{"every 30m on sat", utc, []string{"2026-03-07 00:00", "2026-03-07 00:30"}},
+		{"weekly on fri at 17:00", utc, []string{"2026-03-06 17:00", "2026-03-13 17:00"}},
+		{"mon,thu at 08:00", utc, []string{"2026-03-05 08:00", "2026-03-09 08:00"}},
+		{"fri-mon @06:00", utc, []string{"2026-03-06 06:00", "2026-03-07 06:00", "2026-03-08 06:00", "2026-03-09 06:00", "2026-03-13 06:00"}},
+		{"Daily AT 09:00", utc, []string{"2026-03-05 09:00"}},
+
+		// Cron
+		{"*/20 * * * *", utc, []string{"2026-03-04 10:20", "2026-03-04 10:40", "2026-03-04 11:00"}},
+		{"30 9 * * 1-5", "2026-03-06 10:00 UTC", []string{"2026-03-09 09:30"}},
+		{"0 9-17/4 * * *", utc, []string{"2026-03-04 13:00", "2026-03-04 17:00", "2026-03-05 09:00"}},
+		{"5/20 0 * * *", utc, []string{"2026-03-05 00:05", "2026-03-05 00:25", "2026-03-05 00:45", "2026-03-06 00:05"}},
+		{"0 0 1,15 * *", utc, []string{"2026-03-15 00:00", "2026-04-01 00:00"}},
+		{"0 0 31 * *", utc, []string{"2026-03-31 00:00", "2026-05-31 00:00"}},
+		{"0 12 * jan,jul sun", utc, []string{"2026-07-05 12:00"}},
+		{"0 0 * * 7", utc, []string{"2026-03-08 00:00"}},
+		// Both day fields restricted: either one matches, as in cron
+		{"0 0 13 * fri", utc, []string{"2026-03-06 00:00", "2026-03-13 00:00", "2026-03-20 00:00"}},
+		{"0 0 29 2 *", utc, []string{"2028-02-29 00:00", "2032-02-29 00:00"}},
+		{"@monthly", utc, []string{"2026-04-01 00:00"}},
+		{"@yearly", utc, []string{"2027-01-01 00:00"}},
+		{"@weekly", utc, []string{"2026-03-08 00:00"}},
+
+		// Dates
+		{"2026-12-24 09:00", utc, []string{"2026-12-24 09:00", ""}},
+		{"2026-12-24 at 09:00", utc, []string{"2026-12-24 09:00"}},
+		{"2026-03-05", utc, []string{"2026-03-05 00:00", ""}},
+		{"2026-03-04 10:00", utc, []string{""}},
+
+		// Time zones
+		{"daily at 09:00 TZ=Asia/Tokyo", utc, []string{"2026-03-05 09:00 JST", "2026-03-06 09:00 JST"}},
+		{"CRON_TZ=America/New_York 0 9 * * *", utc, []string{"2026-03-04 09:00 EST"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			s, err := Parse(tt.spec)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.spec, err)
+			}
+			if !strings.Contains(tt.spec, "TZ=") {
+				s.loc = time.UTC
+			}
+			after := parseTime(t, tt.after, time.UTC)
+			for i, want := range tt.want {
+				got := s.Next(after)
+				if want == "" {
+					if !got.IsZero() {
+						t.Fatalf("run %d: Next() = %s, want none", i+1, got)
+					}
+					return
+				}
+				if w := parseTime(t, want, s.loc); !got.Equal(w) {
+					t.Fatalf("run %d: Next(%s) = %s, want %s", i+1, after, got, w)
+				}
+				after = got
+			}
+		})
+	}
+}
+
+func TestNextAcrossDaylightSaving(t *testing.T) {
+	s, err := Parse("30 2 * * * TZ=America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ny := s.Location()
+
+	// 02:30 doesn't exist on March 8th 2026, so it runs at 03:30
+	got := s.Next(time.Date(2026, 3, 7, 12, 0, 0, 0, ny))
+	if want := time.Date(2026, 3, 8, 3, 30, 0, 0, ny); !got.Equal(want) {
+		t.Errorf("spring forward: Next() = %s, want %s", got, want)
+	}
+	got = s.Next(got)
+	if want := time.Date(2026, 3, 9, 2, 30, 0, 0, ny); !got.Equal(want) {
+		t.Errorf("after spring forward: Next() = %s, want %s", got, want)
+	}
+
+	// 01:30 happens twice on November 1st 2026, and runs once
+	s, _ = Parse("30 1 * * * TZ=America/New_York")
+	first := s.Next(time.Date(2026, 10, 31, 12, 0, 0, 0, ny))
+	if first.Day() != 1 || first.Hour() != 1 {
+		t.Fatalf("fall back: Next() = %s", first)
+	}
+	if got := s.Next(first); got.Day() != 2 {
+		t.Errorf("fall back: second Next() = %s, want November 2nd", got)
+	}
+	if got := s.Next(first.Add(time.Hour)); got.Day() != 2 {
+		t.Errorf("fall back: Next() from the repeated hour = %s, want November 2nd", got)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		spec string
+		want string
+	}{
+		{"", "empty schedule"},
+		{"@ab:cd", "invalid time"},
+		{"@24:00", "invalid time"},
+		{"@9:5", "invalid time"},
+		{"daily at", "needs a time"},
+		{"daily at 09:00 at 10:00", "only one time"},
+		{"sometimes", "unknown schedule word"},
+		{"daily hourly", "can't be combined"},
+		{"hourly at 09:00", "takes no time"},
+		{"every 15m at 09:00", "takes no time"},
+		{"every 30s", "invalid interval"},
+		{"every 25h", "invalid interval"},
+		{"every 90s", "invalid interval"},
+		{"every soon", "invalid interval"},
+		{"on funday", "unknown day"},
+		{"weekdays on weekends", "days are given twice"},
+		{"60 * * * *", "out of range"},
+		{"* 24 * * *", "out of range"},
+		{"* * 0 * *", "out of range"},
+		{"* * * 13 *", "out of range"},
+		{"* * * * 8", "out of range"},
+		{"*/0 * * * *", "invalid step"},
+		{"5-1 * * * *", "backwards range"},
+		{"* * * foo *", "invalid value"},
+		{"0 0 30 2 *", "never runs"},
+		{"0 0 31 4,6 *", "never runs"},
+		{"2026-02-30", "invalid date"},
+		{"2026-12-24 09:00 10:00", "takes only a time"},
+		{"daily TZ=Mars/Olympus", "unknown time zone"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := Parse(tt.spec)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("Parse(%q) error = %v, want it to mention %q", tt.spec, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	s, err := Parse("  daily   at 09:00  TZ=UTC ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := s.String(); got != "daily at 09:00 TZ=UTC" {
+		t.Errorf("String() = %q", got)
+	}
+	if s.Location() != time.UTC {
+		t.Errorf("Location() = %s", s.Location())
+	}
+}
+
+// parseTime reads "2006-01-02 15:04" with an optional zone abbreviation,
+// which is only there to make the tests readable
+func parseTime(t *testing.T, value string, loc *time.Location) time.Time {
+	t.Helper()
+	if i := strings.LastIndex(value, " "); i > 10 {
+		value = value[:i]
+	}
+	tm, err := time.ParseInLocation("2006-01-02 15:04", value, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tm
+}
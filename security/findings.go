@@ -0,0 +1,268 @@
+// Package security defines the findings reported by Ellie's security checks,
+// validates findings returned by the AI, scans files offline with a
+// configurable rule pack and writes findings as Markdown, JSON or SARIF.
+package security
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Severity ranks how serious a finding is
+type Severity string
+
+const (
+	Critical Severity = "critical"
+	High     Severity = "high"
+	Medium   Severity = "medium"
+	Low      Severity = "low"
+)
+
+// severityRank orders severities; higher is worse
+var severityRank = map[Severity]int{
+	Low:      1,
+	Medium:   2,
+	High:     3,
+	Critical: 4,
+}
+
+// ParseSeverity reads a severity case-insensitively. "info" and
+// "informational" count as low.
+func ParseSeverity(s string) (Severity, error) {
+	sev := Severity(strings.ToLower(strings.TrimSpace(s)))
+	switch sev {
+	case "info", "informational":
+		return Low, nil
+	}
+	if _, ok := severityRank[sev]; !ok {
+		return "", fmt.Errorf("unknown severity %q (use critical, high, medium or low)", s)
+	}
+	return sev, nil
+}
+
+// AtLeast reports whether s is as serious as min or worse
+func (s Severity) AtLeast(min Severity) bool {
+	return severityRank[s] >= severityRank[min]
+}
+
+// Finding is one security issue in a file
+type Finding struct {
+	Rule        string   `json:"rule"`
+	Severity    Severity `json:"severity"`
+	File        string   `json:"file"`
+	StartLine   int      `json:"start_line"`
+	EndLine     int      `json:"end_line"`
+	Description string   `json:"description"`
+	Remediation string   `json:"remediation"`
+	// Source is "static" for the offline scanner and "ai" for the model
+	Source string `json:"source,omitempty"`
+}
+
+// Sources of findings
+const (
+	SourceStatic = "static"
+	SourceAI     = "ai"
+)
+
+// Report is the JSON object the AI is asked to reply with
+type Report struct {
+	Summary  string    `json:"summary,omitempty"`
+	Findings []Finding `json:"findings"`
+}
+
+// ParseReport extracts and validates a Report from an AI answer. Code
+// fences and text around the JSON object are ignored. The error lists every
+// problem so it can be sent back to the model.
+func ParseReport(answer string) (Report, error) {
+	var report Report
+	start := strings.Index(answer, "{")
+	end := strings.LastIndex(answer, "}")
+	if start < 0 || end < start {
+		return report, errors.New("no JSON object found")
+	}
+
+	var raw struct {
+		Summary  string `json:"summary"`
+		Findings []struct {
+			Rule        string `json:"rule"`
+			Severity    string `json:"severity"`
+			File        string `json:"file"`
+			StartLine   int    `json:"start_line"`
+			EndLine     int    `json:"end_line"`
+			Description string `json:"description"`
+			Remediation string `json:"remediation"`
+		} `json:"findings"`
+	}
+	if err := json.Unmarshal([]byte(answer[start:end+1]), &raw); err != nil {
+		return report, fmt.Errorf("invalid JSON: %w", err)
+	}
+
+	var problems []string
+	report.Summary = strings.TrimSpace(raw.Summary)
+	for i, f := range raw.Findings {
+		finding := Finding{
+			Rule:        normalizeRule(f.Rule),
+			File:        strings.TrimSpace(f.File),
+			StartLine:   f.StartLine,
+			EndLine:     f.EndLine,
+			Description: strings.TrimSpace(f.Description),
+			Remediation: strings.TrimSpace(f.Remediation),
+		}
+
+		sev, err := ParseSeverity(f.Severity)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("findings[%d]: %v", i, err))
+		}
+		finding.Severity = sev
+		if finding.Rule == "" {
+			problems = append(problems, fmt.Sprintf("findings[%d]: rule is required", i))
+		}
+		if finding.Description == "" {
+			problems = append(problems, fmt.Sprintf("findings[%d]: description is required", i))
+		}
+		if finding.StartLine < 1 {
+			problems = append(problems, fmt.Sprintf("findings[%d]: start_line must be 1 or more", i))
+		}
+		if finding.EndLine < finding.StartLine {
+			finding.EndLine = finding.StartLine
+		}
+		report.Findings = append(report.Findings, finding)
+	}
+
+	if len(problems) > 0 {
+		return report, errors.New(strings.Join(problems, "; "))
+	}
+	return report, nil
+}
+
+// normalizeRule turns "SQL Injection" into "sql-injection"
+func normalizeRule(rule string) string {
+	fields := strings.FieldsFunc(strings.ToLower(rule), func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '.' || r == '/')
+	})
+	return strings.Join(fields, "-")
+}
+
+// Place pins the report's findings to a file and clamps their lines to the
+// range that was audited, since the model may echo paths or lines loosely
+func (r *Report) Place(file string, firstLine, lastLine int) {
+	for i := range r.Findings {
+		f := &r.Findings[i]
+		f.File = file
+		f.StartLine = clamp(f.StartLine, firstLine, lastLine)
+		f.EndLine = clamp(f.EndLine, f.StartLine, lastLine)
+	}
+}
+
+func clamp(n, lo, hi int) int {
+	if hi < lo {
+		hi = lo
+	}
+	if n < lo {
+		return lo
+	}
+	if n > hi {
+		return hi
+	}
+	return n
+}
+
+// Sort orders findings from most to least severe, then by file and line
+func Sort(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		a, b := findings[i], findings[j]
+		if a.Severity != b.Severity {
+			return severityRank[a.Severity] > severityRank[b.Severity]
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.StartLine < b.StartLine
+	})
+}
+
+// Merge combines findings from several sources, dropping those that repeat
+// a rule on the same line, and sorts them. Language variants of a rule count
+// as the same rule, so go-sql-injection repeats an AI's sql-injection.
+func Merge(lists ...[]Finding) []Finding {
+	type key struct {
+		rule, file string
+		line       int
+	}
+	seen := map[key]bool{}
+	var merged []Finding
+	for _, list := range lists {
+		for _, f := range list {
+			k := key{ruleFamily(f.Rule), f.File, f.StartLine}
+			if seen[k] {
+				continue
+			}
+			seen[k] = true
+			merged = append(merged, f)
+		}
+	}
+	Sort(merged)
+	return merged
+}
+
+// Exceeds reports whether any finding is at least as serious as min
+func Exceeds(findings []Finding, min Severity) bool {
+	for _, f := range findings {
+		if f.Severity.AtLeast(min) {
+			return true
+		}
+	}
+	return false
+}
+
+// Markdown renders findings for the terminal, most severe first
+func Markdown(findings []Finding, summaries []string) string {
+	Sort(findings)
+
+	var b strings.Builder
+	b.WriteString("# Security audit\n\n")
+	for _, s := range summaries {
+		if s != "" {
+			b.WriteString(s + "\n\n")
+		}
+	}
+
+	if len(findings) == 0 {
+		b.WriteString("No findings.\n")
+		return b.String()
+	}
+
+	counts := map[Severity]int{}
+	for _, f := range findings {
+		counts[f.Severity]++
+	}
+	b.WriteString("| Severity | Findings |\n|---|---|\n")
+	for _, sev := range []Severity{Critical, High, Medium, Low} {
+		if counts[sev] > 0 {
+			fmt.Fprintf(&b, "| %s | %d |\n", strings.ToUpper(string(sev)), counts[sev])
+		}
+	}
+
+	for _, f := range findings {
+		fmt.Fprintf(&b, "\n## [%s] %s\n\n", strings.ToUpper(string(f.Severity)), f.Rule)
+		source := ""
+		if f.Source != "" {
+			source = " (" + f.Source + ")"
+		}
+		fmt.Fprintf(&b, "`%s:%s`%s\n\n%s\n", f.File, lineRange(f), source, f.Description)
+		if f.Remediation != "" {
+			fmt.Fprintf(&b, "\n**Remediation:** %s\n", f.Remediation)
+		}
+	}
+	return b.String()
+}
+
+func lineRange(f Finding) string {
+	if f.EndLine > f.StartLine {
+		return fmt.Sprintf("%d-%d", f.StartLine, f.EndLine)
+	}
+	return fmt.Sprintf("%d", f.StartLine)
+}
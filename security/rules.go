@@ -0,0 +1,389 @@
+package security
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Rule is a pattern the offline scanner looks for, one line at a time
+type Rule struct {
+	ID          string   `json:"id"`
+	Severity    Severity `json:"severity"`
+	Description string   `json:"description"`
+	Remediation string   `json:"remediation,omitempty"`
+	// Pattern is a Go regular expression matched against each line
+	Pattern string `json:"pattern"`
+	// MinEntropy skips matches whose secret looks like a placeholder: the
+	// Shannon entropy (bits per character) of SecretGroup must reach it
+	MinEntropy float64 `json:"min_entropy,omitempty"`
+	// SecretGroup is the submatch holding the secret; 0 is the whole match
+	SecretGroup int `json:"secret_group,omitempty"`
+	// Extensions limits the rule to files with these extensions, e.g. ".go"
+	Extensions []string `json:"extensions,omitempty"`
+	// Files limits the rule to file names matching these globs, e.g. ".env*"
+	Files []string `json:"files,omitempty"`
+	// Exclude skips file names matching these globs
+	Exclude []string `json:"exclude,omitempty"`
+
+	re *regexp.Regexp
+}
+
+// Rule IDs of the checks that look at file permissions instead of content
+const (
+	RuleWorldWritable     = "world-writable-file"
+	RulePermissiveSecrets = "permissive-secret-file"
+)
+
+var (
+	goFiles     = []string{".go"}
+	pythonFiles = []string{".py"}
+	jsFiles     = []string{".js", ".jsx", ".ts", ".tsx", ".mjs", ".cjs"}
+	phpFiles    = []string{".php"}
+	rubyFiles   = []string{".rb"}
+)
+
+// languagePrefixes start the IDs of rules that exist once per language,
+// such as go-sql-injection and python-sql-injection, so a rule pack can
+// replace or disable one language's variant alone
+var languagePrefixes = []string{"go-", "python-", "js-", "php-", "ruby-"}
+
+// ruleFamily is a rule ID without its language prefix: the name the AI
+// review uses for the same problem
+func ruleFamily(id string) string {
+	for _, prefix := range languagePrefixes {
+		if rest, ok := strings.CutPrefix(id, prefix); ok {
+			return rest
+		}
+	}
+	return id
+}
+
+// DefaultRules is the built-in rule pack
+func DefaultRules() []Rule {
+	return []Rule{
+		// Secrets
+		{
+			ID:          "aws-access-key-id",
+			Severity:    Critical,
+			Description: "AWS access key ID committed to the source.",
+			Remediation: "Deactivate the key in IAM, remove it from the code and history, and load credentials from the environment or an AWS profile.",
+			Pattern:     `\b(?:AKIA|ASIA|AGPA|AIDA|AROA)[0-9A-Z]{16}\b`,
+		},
+		{
+			ID:          "aws-secret-access-key",
+			Severity:    Critical,
+			Description: "AWS secret access key committed to the source.",
+			Remediation: "Rotate the key in IAM and load it from the environment or a secrets manager.",
+			Pattern:     `(?i)aws.{0,20}(?:secret|private).{0,20}['"=:\s]([A-Za-z0-9/+]{40})\b`,
+			SecretGroup: 1,
+			MinEntropy:  4.0,
+		},
+		{
+			ID:          "github-token",
+			Severity:    Critical,
+			Description: "GitHub access token committed to the source.",
+			Remediation: "Revoke the token in GitHub settings and read it from the environment or a secrets manager.",
+			Pattern:     `\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{60,})\b`,
+		},
+		{
+			ID:          "slack-token",
+			Severity:    High,
+			Description: "Slack token committed to the source.",
+			Remediation: "Revoke the token in Slack and read it from the environment.",
+			Pattern:     `\bxox[abposr]-[0-9A-Za-z-]{10,}\b`,
+		},
+		{
+			ID:          "stripe-secret-key",
+			Severity:    Critical,
+			Description: "Stripe secret key committed to the source.",
+			Remediation: "Roll the key in the Stripe dashboard and read it from the environment.",
+			Pattern:     `\b[sr]k_live_[0-9a-zA-Z]{20,}\b`,
+		},
+		{
+			ID:          "openai-api-key",
+			Severity:    High,
+			Description: "OpenAI API key committed to the source.",
+			Remediation: "Revoke the key and read it from the environment.",
+			Pattern:     `\bsk-(?:proj-)?[A-Za-z0-9_-]{32,}\b`,
+			MinEntropy:  3.5,
+		},
+		{
+			ID:          "private-key",
+			Severity:    Critical,
+			Description: "Private key committed to the source.",
+			Remediation: "Remove the key, treat it as compromised and issue a new one. Keep keys outside the repository.",
+			Pattern:     `-----BEGIN (?:RSA |EC |DSA |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY(?: BLOCK)?-----`,
+		},
+		{
+			ID:          "generic-secret",
+			Severity:    High,
+			Description: "Hard-coded credential assigned to a secret-looking name.",
+			Remediation: "Load the value from the environment or a secrets manager instead of the source.",
+			Pattern:     `(?i)\b[\w.-]*(?:api[_-]?key|secret|token|passwd|password|credential)s?\b["']?\s*(?::=|=>|[:=])\s*["']([^"'\s]{12,})["']`,
+			SecretGroup: 1,
+			MinEntropy:  3.5,
+			Exclude:     []string{"*_test.go", "*.md", "*.lock", "package-lock.json", "go.sum"},
+		},
+		{
+			ID:          "env-file-secret",
+			Severity:    High,
+			Description: "Secret value in an environment file that may be committed.",
+			Remediation: "Add the file to .gitignore, rotate the secret and commit a .env.example with placeholders instead.",
+			Pattern:     `(?i)^\s*(?:export\s+)?[A-Z0-9_]*(?:KEY|SECRET|TOKEN|PASSWORD|PASSWD)[A-Z0-9_]*\s*=\s*["']?([^"'\s#]{8,})`,
+			SecretGroup: 1,
+			MinEntropy:  3.0,
+			Files:       []string{".env", ".env.*", "*.env"},
+			Exclude:     []string{".env.example", ".env.sample", ".env.template", "*.example.env"},
+		},
+
+		// Injection sinks
+		{
+			ID:          "go-sql-injection",
+			Severity:    High,
+			Description: "SQL query built with string formatting or concatenation.",
+			Remediation: "Use placeholders (?, $1) and pass values as query arguments.",
+			Pattern:     `\.(?:Query|QueryRow|Exec|Prepare)(?:Context)?\((?:ctx,\s*)?(?:fmt\.Sprintf\(|"[^"]*"\s*\+)`,
+			Extensions:  goFiles,
+		},
+		{
+			ID:          "go-command-injection",
+			Severity:    Medium,
+			Description: "Command run through a shell; arguments built from input can inject commands.",
+			Remediation: "Run the program directly with exec.Command(name, args...) and validate arguments.",
+			Pattern:     `exec\.Command(?:Context)?\((?:ctx,\s*)?"(?:sh|bash|zsh|cmd|cmd\.exe|powershell)",\s*"(?:-c|/c|/C|-Command)"`,
+			Extensions:  goFiles,
+		},
+		{
+			ID:          "python-sql-injection",
+			Severity:    High,
+			Description: "SQL query built with string formatting or concatenation.",
+			Remediation: "Pass values as query parameters: cursor.execute(sql, params).",
+			Pattern:     `\.execute(?:many)?\(\s*(?:f["']|["'][^"']*["']\s*(?:%|\+|\.format\())`,
+			Extensions:  pythonFiles,
+		},
+		{
+			ID:          "python-command-injection",
+			Severity:    High,
+			Description: "Command run through a shell with subprocess or os.system.",
+			Remediation: "Pass an argument list to subprocess.run without shell=True.",
+			Pattern:     `subprocess\.\w+\(.*shell\s*=\s*True|\bos\.(?:system|popen)\(`,
+			Extensions:  pythonFiles,
+		},
+		{
+			ID:          "python-code-injection",
+			Severity:    Medium,
+			Description: "Dynamic code execution with eval or exec.",
+			Remediation: "Avoid eval/exec; parse data with json or ast.literal_eval.",
+			Pattern:     `(?:^|[^.\w])(?:eval|exec)\s*\(`,
+			Extensions:  pythonFiles,
+		},
+		{
+			ID:          "insecure-deserialization",
+			Severity:    Medium,
+			Description: "Unsafe deserialization of possibly untrusted data.",
+			Remediation: "Use json, or yaml.safe_load, for untrusted data.",
+			Pattern:     `\b(?:pickle|cPickle|marshal)\.loads?\(|\byaml\.(?:unsafe_)?load\([^,)]*\)`,
+			Extensions:  pythonFiles,
+		},
+		{
+			ID:          "js-code-injection",
+			Severity:    Medium,
+			Description: "Dynamic code execution with eval or new Function.",
+			Remediation: "Avoid eval; use JSON.parse or explicit logic.",
+			Pattern:     `(?:^|[^.\w])eval\s*\(|\bnew Function\s*\(`,
+			Extensions:  jsFiles,
+		},
+		{
+			ID:          "xss",
+			Severity:    Medium,
+			Description: "HTML inserted without escaping.",
+			Remediation: "Use textContent, or sanitize the HTML with a library such as DOMPurify.",
+			Pattern:     `\.(?:innerHTML|outerHTML)\s*=|\bdocument\.write\s*\(|dangerouslySetInnerHTML`,
+			Extensions:  jsFiles,
+		},
+		{
+			ID:          "js-command-injection",
+			Severity:    High,
+			Description: "Shell command built from a template or concatenation.",
+			Remediation: "Use execFile or spawn with an argument array.",
+			Pattern:     "\\b(?:exec|execSync)\\(\\s*(?:`[^`]*\\$\\{|[\"'][^\"']*[\"']\\s*\\+)",
+			Extensions:  jsFiles,
+		},
+		{
+			ID:          "php-command-injection",
+			Severity:    High,
+			Description: "Shell command built from a variable.",
+			Remediation: "Avoid shell functions; if unavoidable, escape every argument with escapeshellarg.",
+			Pattern:     `\b(?:system|exec|shell_exec|passthru|popen|proc_open)\s*\(\s*\$`,
+			Extensions:  phpFiles,
+		},
+		{
+			ID:          "php-code-injection",
+			Severity:    High,
+			Description: "Dynamic code execution with eval or assert.",
+			Remediation: "Remove eval; use explicit logic.",
+			Pattern:     `\b(?:eval|assert)\s*\(\s*\$`,
+			Extensions:  phpFiles,
+		},
+		{
+			ID:          "php-sql-injection",
+			Severity:    Critical,
+			Description: "Request data used directly in a SQL query.",
+			Remediation: "Use prepared statements with bound parameters (PDO or mysqli).",
+			Pattern:     `(?:mysqli?_query|->query)\s*\([^;]*\$_(?:GET|POST|REQUEST|COOKIE)`,
+			Extensions:  phpFiles,
+		},
+		{
+			ID:          "ruby-code-injection",
+			Severity:    Medium,
+			Description: "Dynamic code execution with eval, send or constantize.",
+			Remediation: "Avoid eval; whitelist methods before calling send.",
+			Pattern:     `(?:^|[^.\w])eval\s*[\(\s]|\.constantize\b`,
+			Extensions:  rubyFiles,
+		},
+	}
+}
+
+// RuleSet is a compiled rule pack plus the permission checks
+type RuleSet struct {
+	rules    []Rule
+	disabled map[string]bool
+}
+
+// NewRuleSet compiles rules. Rules with an invalid pattern or severity are
+// left out and reported in the error.
+func NewRuleSet(rules []Rule, disabled []string) (*RuleSet, error) {
+	set := &RuleSet{disabled: map[string]bool{}}
+	for _, id := range disabled {
+		set.disabled[id] = true
+	}
+
+	var errs []error
+	for _, rule := range rules {
+		if set.disabled[rule.ID] {
+			continue
+		}
+		if err := rule.compile(); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		set.rules = append(set.rules, rule)
+	}
+	return set, errors.Join(errs...)
+}
+
+func (r *Rule) compile() error {
+	if r.ID == "" {
+		return fmt.Errorf("rule without an id")
+	}
+	sev, err := ParseSeverity(string(r.Severity))
+	if err != nil {
+		return fmt.Errorf("rule %s: %w", r.ID, err)
+	}
+	r.Severity = sev
+
+	r.re, err = regexp.Compile(r.Pattern)
+	if err != nil {
+		return fmt.Errorf("rule %s: invalid pattern: %w", r.ID, err)
+	}
+	if r.SecretGroup > r.re.NumSubexp() {
+		return fmt.Errorf("rule %s: secret_group %d but the pattern has %d group(s)", r.ID, r.SecretGroup, r.re.NumSubexp())
+	}
+	return nil
+}
+
+// Rules returns the enabled rules
+func (s *RuleSet) Rules() []Rule {
+	return s.rules
+}
+
+// Enabled reports whether a rule, or permission check, is turned on
+func (s *RuleSet) Enabled(id string) bool {
+	return !s.disabled[id]
+}
+
+// rulePack is a JSON file in the rules directory
+type rulePack struct {
+	// Disable turns off rules, built-in or not, by id
+	Disable []string `json:"disable"`
+	// Rules are added to the pack; a rule replaces the built-in rules with
+	// the same id
+	Rules []Rule `json:"rules"`
+}
+
+// LoadRules builds the rule set from DefaultRules and the *.json rule packs
+// in dir, in name order. A missing dir is not an error. Broken files and
+// rules are skipped and reported in the error.
+func LoadRules(dir string) (*RuleSet, error) {
+	rules := DefaultRules()
+	var disabled []string
+	var errs []error
+
+	paths, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	sort.Strings(paths)
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		var pack rulePack
+		if err := json.Unmarshal(data, &pack); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", filepath.Base(path), err))
+			continue
+		}
+
+		disabled = append(disabled, pack.Disable...)
+		replaced := map[string]bool{}
+		for _, r := range pack.Rules {
+			replaced[r.ID] = true
+		}
+		kept := rules[:0:0]
+		for _, r := range rules {
+			if !replaced[r.ID] {
+				kept = append(kept, r)
+			}
+		}
+		rules = append(kept, pack.Rules...)
+	}
+
+	set, err := NewRuleSet(rules, disabled)
+	if err != nil {
+		errs = append(errs, err)
+	}
+	return set, errors.Join(errs...)
+}
+
+// appliesTo reports whether the rule should scan the file
+func (r *Rule) appliesTo(path string) bool {
+	name := filepath.Base(path)
+	for _, pattern := range r.Exclude {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return false
+		}
+	}
+	if len(r.Files) > 0 {
+		for _, pattern := range r.Files {
+			if ok, _ := filepath.Match(pattern, name); ok {
+				return true
+			}
+		}
+		return false
+	}
+	if len(r.Extensions) > 0 {
+		ext := strings.ToLower(filepath.Ext(name))
+		for _, e := range r.Extensions {
+			if ext == strings.ToLower(e) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
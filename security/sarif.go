@@ -0,0 +1,150 @@
+package security
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"sort"
+)
+
+// SARIF 2.1.0, the subset code-scanning dashboards read
+
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri,omitempty"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID               string            `json:"id"`
+	ShortDescription sarifText         `json:"shortDescription"`
+	Help             *sarifText        `json:"help,omitempty"`
+	Properties       map[string]string `json:"properties,omitempty"`
+}
+
+type sarifText struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID     string            `json:"ruleId"`
+	Level      string            `json:"level"`
+	Message    sarifText         `json:"message"`
+	Locations  []sarifLocation   `json:"locations"`
+	Properties map[string]string `json:"properties,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           sarifRegion   `json:"region"`
+}
+
+type sarifArtifact struct {
+	URI string `json:"uri"`
+}
+
+type sarifRegion struct {
+	StartLine int `json:"startLine"`
+	EndLine   int `json:"endLine,omitempty"`
+}
+
+// sarifLevels maps severities to SARIF result levels
+var sarifLevels = map[Severity]string{
+	Critical: "error",
+	High:     "error",
+	Medium:   "warning",
+	Low:      "note",
+}
+
+// securitySeverity is the CVSS-like score code-scanning dashboards use to
+// rank security results
+var securitySeverity = map[Severity]string{
+	Critical: "9.5",
+	High:     "8.0",
+	Medium:   "5.5",
+	Low:      "2.0",
+}
+
+// SARIF encodes findings as a SARIF 2.1.0 log for code-scanning tools
+func SARIF(findings []Finding, tool, version string) ([]byte, error) {
+	Sort(findings)
+
+	rules := map[string]sarifRule{}
+	results := make([]sarifResult, 0, len(findings))
+	for _, f := range findings {
+		rule, ok := rules[f.Rule]
+		if !ok || severityRank[f.Severity] > severityRank[Severity(rule.Properties["severity"])] {
+			rule = sarifRule{
+				ID:               f.Rule,
+				ShortDescription: sarifText{Text: f.Rule},
+				Properties: map[string]string{
+					"severity":          string(f.Severity),
+					"security-severity": securitySeverity[f.Severity],
+				},
+			}
+			if f.Remediation != "" {
+				rule.Help = &sarifText{Text: f.Remediation}
+			}
+			rules[f.Rule] = rule
+		}
+
+		message := f.Description
+		if f.Remediation != "" {
+			message += "\n\nRemediation: " + f.Remediation
+		}
+		properties := map[string]string{"severity": string(f.Severity)}
+		if f.Source != "" {
+			properties["source"] = f.Source
+		}
+		results = append(results, sarifResult{
+			RuleID:  f.Rule,
+			Level:   sarifLevels[f.Severity],
+			Message: sarifText{Text: message},
+			Locations: []sarifLocation{{
+				PhysicalLocation: sarifPhysicalLocation{
+					ArtifactLocation: sarifArtifact{URI: filepath.ToSlash(f.File)},
+					Region:           sarifRegion{StartLine: f.StartLine, EndLine: f.EndLine},
+				},
+			}},
+			Properties: properties,
+		})
+	}
+
+	driver := sarifDriver{
+		Name:           tool,
+		Version:        version,
+		InformationURI: "https://github.com/tacheraSasi/ellie",
+		Rules:          make([]sarifRule, 0, len(rules)),
+	}
+	for _, rule := range rules {
+		driver.Rules = append(driver.Rules, rule)
+	}
+	sort.Slice(driver.Rules, func(i, j int) bool {
+		return driver.Rules[i].ID < driver.Rules[j].ID
+	})
+
+	return json.MarshalIndent(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	}, "", "  ")
+}
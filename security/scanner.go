@@ -0,0 +1,137 @@
+package security
+
+import (
+	"io/fs"
+	"math"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// IgnoreMarker on a line stops the scanner from reporting it
+const IgnoreMarker = "ellie:ignore"
+
+// Entropy is the Shannon entropy of s in bits per character. Random keys
+// score around 4 and more; words and placeholders score lower.
+func Entropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+	counts := map[rune]int{}
+	n := 0
+	for _, r := range s {
+		counts[r]++
+		n++
+	}
+	var entropy float64
+	for _, c := range counts {
+		p := float64(c) / float64(n)
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// Scan checks a file's content against the rules. Each rule is reported
+// at most once per line.
+func (s *RuleSet) Scan(path, content string) []Finding {
+	var rules []Rule
+	for _, r := range s.rules {
+		if r.appliesTo(path) {
+			rules = append(rules, r)
+		}
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+
+	var findings []Finding
+	for i, line := range strings.Split(content, "\n") {
+		if strings.Contains(line, IgnoreMarker) {
+			continue
+		}
+		for _, r := range rules {
+			if r.matches(line) {
+				findings = append(findings, Finding{
+					Rule:        r.ID,
+					Severity:    r.Severity,
+					File:        path,
+					StartLine:   i + 1,
+					EndLine:     i + 1,
+					Description: r.Description,
+					Remediation: r.Remediation,
+					Source:      SourceStatic,
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// matches reports whether the line has a match whose secret is random
+// enough for the rule
+func (r *Rule) matches(line string) bool {
+	for _, m := range r.re.FindAllStringSubmatch(line, -1) {
+		if r.MinEntropy == 0 || Entropy(m[r.SecretGroup]) >= r.MinEntropy {
+			return true
+		}
+	}
+	return false
+}
+
+// secretFiles are names that usually hold credentials
+var secretFiles = []string{
+	".env", ".env.*", "*.env", "*.pem", "*.key", "*.p12", "*.pfx", "*.keystore",
+	"id_rsa", "id_dsa", "id_ecdsa", "id_ed25519", "credentials", ".netrc", ".pgpass",
+}
+
+// ScanPermissions reports world-writable files, and files that usually hold
+// credentials but can be read by other users. Windows has no such mode
+// bits, so nothing is reported there.
+func (s *RuleSet) ScanPermissions(path string, mode fs.FileMode) []Finding {
+	if runtime.GOOS == "windows" || !mode.IsRegular() {
+		return nil
+	}
+
+	var findings []Finding
+	perm := mode.Perm()
+	if perm&0o002 != 0 && s.Enabled(RuleWorldWritable) {
+		findings = append(findings, Finding{
+			Rule:        RuleWorldWritable,
+			Severity:    High,
+			File:        path,
+			StartLine:   1,
+			EndLine:     1,
+			Description: "File is writable by every user (mode " + perm.String() + "), so anyone on the machine can change it.",
+			Remediation: "Remove write access for others: chmod o-w " + path,
+			Source:      SourceStatic,
+		})
+	}
+	if perm&0o044 != 0 && isSecretFile(path) && s.Enabled(RulePermissiveSecrets) {
+		findings = append(findings, Finding{
+			Rule:        RulePermissiveSecrets,
+			Severity:    Medium,
+			File:        path,
+			StartLine:   1,
+			EndLine:     1,
+			Description: "File usually holds credentials but is readable by other users (mode " + perm.String() + ").",
+			Remediation: "Restrict it to its owner: chmod 600 " + path,
+			Source:      SourceStatic,
+		})
+	}
+	return findings
+}
+
+func isSecretFile(path string) bool {
+	name := filepath.Base(path)
+	for _, pattern := range []string{".env.example", ".env.sample", ".env.template"} {
+		if name == pattern {
+			return false
+		}
+	}
+	for _, pattern := range secretFiles {
+		if ok, _ := filepath.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,186 @@
+package security
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseSeverity(t *testing.T) {
+	for input, want := range map[string]Severity{
+		"HIGH":     High,
+		" medium ": Medium,
+		"info":     Low,
+		"Critical": Critical,
+	} {
+		got, err := ParseSeverity(input)
+		if err != nil || got != want {
+			t.Errorf("ParseSeverity(%q) = %q, %v, want %q", input, got, err, want)
+		}
+	}
+	if _, err := ParseSeverity("severe"); err == nil {
+		t.Error("ParseSeverity() should reject unknown severities")
+	}
+	if !Critical.AtLeast(High) || Medium.AtLeast(High) || !High.AtLeast(High) {
+		t.Error("AtLeast() ordering is wrong")
+	}
+}
+
+func TestParseReport(t *testing.T) {
+	answer := "Here you go:\n```json\n" + `{
+  "summary": "Mostly fine.",
+  "findings": [
+    {"rule": "SQL Injection", "severity": "High", "file": "db.go", "start_line": 12, "end_line": 10,
+     "description": "Query built with fmt.Sprintf.", "remediation": "Use placeholders."}
+  ]
+}` + "\n```"
+
+	report, err := ParseReport(answer)
+	if err != nil {
+		t.Fatalf("ParseReport() error = %v", err)
+	}
+	if report.Summary != "Mostly fine." || len(report.Findings) != 1 {
+		t.Fatalf("ParseReport() = %+v", report)
+	}
+	f := report.Findings[0]
+	if f.Rule != "sql-injection" || f.Severity != High || f.StartLine != 12 || f.EndLine != 12 {
+		t.Errorf("ParseReport() finding = %+v", f)
+	}
+
+	empty, err := ParseReport(`{"summary": "Clean.", "findings": []}`)
+	if err != nil || len(empty.Findings) != 0 {
+		t.Errorf("ParseReport() on no findings = %+v, %v", empty, err)
+	}
+}
+
+func TestParseReport_Invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		answer string
+		want   string
+	}{
+		{"no json", "Everything looks fine!", "no JSON object"},
+		{"broken json", `{"findings": [}`, "invalid JSON"},
+		{"bad severity", `{"findings": [{"rule": "x", "severity": "urgent", "start_line": 1, "description": "d"}]}`, "findings[0]: unknown severity"},
+		{"missing fields", `{"findings": [{"severity": "low"}]}`, "rule is required; findings[0]: description is required; findings[0]: start_line"},
+	}
+	for _, tt := range tests {
+		_, err := ParseReport(tt.answer)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: ParseReport() error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestReport_Place(t *testing.T) {
+	report := Report{Findings: []Finding{
+		{File: "wrong.go", StartLine: 5, EndLine: 500},
+		{File: "", StartLine: 1, EndLine: 2},
+	}}
+	report.Place("main.go", 100, 200)
+
+	if f := report.Findings[0]; f.File != "main.go" || f.StartLine != 100 || f.EndLine != 200 {
+		t.Errorf("Place() = %+v, want lines clamped to 100-200", f)
+	}
+	if f := report.Findings[1]; f.StartLine != 100 || f.EndLine != 100 {
+		t.Errorf("Place() = %+v", f)
+	}
+}
+
+func sampleFindings() []Finding {
+	return []Finding{
+		{Rule: "weak-hash", Severity: Low, File: "b.go", StartLine: 3, EndLine: 3, Description: "MD5 used."},
+		{Rule: "sql-injection", Severity: Critical, File: "a.go", StartLine: 10, EndLine: 12, Description: "Raw SQL.", Remediation: "Use placeholders."},
+		{Rule: "weak-hash", Severity: Medium, File: "a.go", StartLine: 1, EndLine: 1, Description: "SHA1 used."},
+	}
+}
+
+func TestSortAndExceeds(t *testing.T) {
+	findings := sampleFindings()
+	Sort(findings)
+	if findings[0].Severity != Critical || findings[2].Severity != Low {
+		t.Errorf("Sort() = %+v, want most severe first", findings)
+	}
+
+	if !Exceeds(findings, High) || Exceeds(findings[1:], High) || !Exceeds(findings[1:], Medium) {
+		t.Error("Exceeds() threshold is wrong")
+	}
+}
+
+func TestSARIF(t *testing.T) {
+	data, err := SARIF(sampleFindings(), "ellie", "1.2.3")
+	if err != nil {
+		t.Fatalf("SARIF() error = %v", err)
+	}
+
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name  string `json:"name"`
+					Rules []struct {
+						ID         string            `json:"id"`
+						Properties map[string]string `json:"properties"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				Level     string `json:"level"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region struct {
+							StartLine int `json:"startLine"`
+							EndLine   int `json:"endLine"`
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(data, &log); err != nil {
+		t.Fatalf("SARIF() is not valid JSON: %v", err)
+	}
+
+	if log.Version != "2.1.0" || len(log.Runs) != 1 {
+		t.Fatalf("SARIF() = %s", data)
+	}
+	run := log.Runs[0]
+	if run.Tool.Driver.Name != "ellie" || len(run.Tool.Driver.Rules) != 2 {
+		t.Errorf("SARIF() driver = %+v, want one rule per rule id", run.Tool.Driver)
+	}
+	for _, rule := range run.Tool.Driver.Rules {
+		if rule.ID == "weak-hash" && rule.Properties["severity"] != "medium" {
+			t.Errorf("SARIF() rule %s = %v, want its most severe finding", rule.ID, rule.Properties)
+		}
+	}
+
+	if len(run.Results) != 3 {
+		t.Fatalf("SARIF() has %d results, want 3", len(run.Results))
+	}
+	first := run.Results[0]
+	loc := first.Locations[0].PhysicalLocation
+	if first.RuleID != "sql-injection" || first.Level != "error" || loc.ArtifactLocation.URI != "a.go" || loc.Region.StartLine != 10 || loc.Region.EndLine != 12 {
+		t.Errorf("SARIF() first result = %+v", first)
+	}
+	if run.Results[2].Level != "note" {
+		t.Errorf("SARIF() low severity level = %q, want note", run.Results[2].Level)
+	}
+}
+
+func TestMarkdown(t *testing.T) {
+	md := Markdown(sampleFindings(), []string{"Needs work."})
+	for _, want := range []string{"Needs work.", "| CRITICAL | 1 |", "## [CRITICAL] sql-injection", "`a.go:10-12`", "**Remediation:** Use placeholders.", "`b.go:3`"} {
+		if !strings.Contains(md, want) {
+			t.Errorf("Markdown() missing %q in:\n%s", want, md)
+		}
+	}
+
+	if md := Markdown(nil, nil); !strings.Contains(md, "No findings.") {
+		t.Errorf("Markdown() without findings = %q", md)
+	}
+}
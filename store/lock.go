@@ -0,0 +1,148 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// lockPoll is how often a writer checks whether the lock is free
+	lockPoll = 25 * time.Millisecond
+	// unwrittenLockAge is how old a lock without a PID must be to be stale;
+	// younger ones are still being written by their holder
+	unwrittenLockAge = 5 * time.Second
+)
+
+// HeldError is returned by LockPID when a running process holds the lock.
+// PID is 0 when the holder hasn't written its PID yet.
+type HeldError struct {
+	Path string
+	PID  int
+}
+
+func (e *HeldError) Error() string {
+	if e.PID == 0 {
+		return fmt.Sprintf("%s is being locked by another process", e.Path)
+	}
+	return fmt.Sprintf("%s is locked by process %d", e.Path, e.PID)
+}
+
+// PIDLock is a lock file holding the PID of the process that holds it
+type PIDLock struct {
+	path    string
+	content string
+}
+
+// LockPID takes the lock at path by creating it with this process's PID,
+// only if there is none. A lock left by a process that is no longer
+// running is taken over; one held by a running process is a *HeldError.
+func LockPID(path string) (*PIDLock, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("error creating lock directory: %w", err)
+	}
+	content := fmt.Sprintln(os.Getpid())
+	for attempt := 0; attempt < 3; attempt++ {
+		lf, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			_, err = lf.WriteString(content)
+			if closeErr := lf.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				os.Remove(path)
+				return nil, fmt.Errorf("error writing lock %s: %w", path, err)
+			}
+			return &PIDLock{path: path, content: content}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("error creating lock %s: %w", path, err)
+		}
+
+		found, pid, held := readLock(path)
+		if held {
+			return nil, &HeldError{Path: path, PID: pid}
+		}
+		// Only remove the lock that was found stale, not a new one another
+		// process has taken since
+		if current, err := os.ReadFile(path); err == nil && string(current) == found {
+			os.Remove(path)
+		}
+	}
+	return nil, fmt.Errorf("error creating lock %s: it keeps reappearing", path)
+}
+
+// Release removes the lock if it is still this process's
+func (l *PIDLock) Release() error {
+	if current, err := os.ReadFile(l.path); err != nil || string(current) != l.content {
+		return nil
+	}
+	return os.Remove(l.path)
+}
+
+// LockHolder returns the PID in the lock at path and whether that process
+// is running. A lock without a valid PID counts as held, with PID 0, until
+// it is older than unwrittenLockAge, as its holder may still be writing it.
+func LockHolder(path string) (int, bool) {
+	_, pid, held := readLock(path)
+	return pid, held
+}
+
+// readLock reads a lock file and says who holds it, if anyone
+func readLock(path string) (string, int, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", 0, false
+	}
+	if pid, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil && pid > 0 {
+		return string(data), pid, ProcessRunning(pid)
+	}
+	info, err := os.Stat(path)
+	return string(data), 0, err == nil && time.Since(info.ModTime()) <= unwrittenLockAge
+}
+
+// lock takes the file's lock with LockPID, waiting for another writer for
+// up to LockTimeout. It returns the function that releases the lock.
+func (f *File) lock() (func(), error) {
+	path := f.path + ".lock"
+	deadline := time.Now().Add(f.LockTimeout)
+	for {
+		l, err := LockPID(path)
+		if err == nil {
+			return func() { l.Release() }, nil
+		}
+		var held *HeldError
+		if !errors.As(err, &held) {
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("%s is locked by another ellie (PID %d); remove %s if that process is gone", filepath.Base(f.path), held.PID, path)
+		}
+		time.Sleep(lockPoll)
+	}
+}
+
+// ProcessRunning says whether a process with the PID exists
+func ProcessRunning(pid int) bool {
+	if pid == os.Getpid() {
+		return true
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	// On Windows, finding the process opens it, so it exists; elsewhere
+	// FindProcess always succeeds and signal 0 checks for the process
+	if runtime.GOOS == "windows" {
+		p.Release()
+		return true
+	}
+	err = p.Signal(syscall.Signal(0))
+	return err == nil || errors.Is(err, syscall.EPERM)
+}
@@ -0,0 +1,312 @@
+// Package store keeps ellie's JSON files safe when several ellie processes,
+// such as the automation daemon and a command in a terminal, use them at
+// once. Writers take a lock file, files are replaced atomically, the last
+// good copy is kept as a backup to recover from corruption, and data is
+// saved with a schema version so older files can be migrated.
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"time"
+)
+
+const (
+	// DefaultLockTimeout is how long a writer waits for another one
+	DefaultLockTimeout = 10 * time.Second
+	// backupSuffix names the last good copy of a file
+	backupSuffix = ".bak"
+)
+
+// Migration upgrades the data of a file from one schema version to the next
+type Migration func(data json.RawMessage) (json.RawMessage, error)
+
+// ErrUnchanged is returned by an Update change to leave the file as it is
+var ErrUnchanged = errors.New("unchanged")
+
+// RecoveredError is returned when a file couldn't be read. The unreadable
+// file is kept beside it, and the data is the backup's or, without a
+// usable backup, empty. It is a warning: the data can be used.
+type RecoveredError struct {
+	Path string
+	// Corrupt is where the unreadable file was moved
+	Corrupt string
+	// FromBackup says whether the backup was restored
+	FromBackup bool
+	Err        error
+}
+
+func (e *RecoveredError) Error() string {
+	if e.FromBackup {
+		return fmt.Sprintf("%s was unreadable (%v); restored the previous copy and kept the unreadable one as %s", e.Path, e.Err, e.Corrupt)
+	}
+	return fmt.Sprintf("%s was unreadable (%v) and had no usable backup; starting empty and keeping the unreadable one as %s", e.Path, e.Err, e.Corrupt)
+}
+
+func (e *RecoveredError) Unwrap() error { return e.Err }
+
+// File is a JSON file holding one value
+type File struct {
+	path string
+	// Version is the schema version the data is saved with
+	Version int
+	// Migrations upgrade data saved at a version, the key, to the next one.
+	// Files from before versioning are version 0. A version without a
+	// migration only needs its number raised.
+	Migrations map[int]Migration
+	// Perm is the permission of the file and its backup
+	Perm        os.FileMode
+	LockTimeout time.Duration
+}
+
+// New returns the file at path, saved at schema version
+func New(path string, version int) *File {
+	return &File{path: path, Version: version, Perm: 0600, LockTimeout: DefaultLockTimeout}
+}
+
+// Path is where the file is stored
+func (f *File) Path() string {
+	return f.path
+}
+
+// envelope is how data is saved: with its schema version
+type envelope struct {
+	Version int             `json:"version"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// Load replaces v with the file's data, or leaves it as it is when there is
+// no file yet. It returns a *RecoveredError when the file had to be recovered.
+func (f *File) Load(v any) error {
+	err := f.read(v)
+	if err == nil || !isCorrupt(err) {
+		return err
+	}
+
+	// Recover while holding the lock, since another process may be at it
+	unlock, err := f.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return f.load(v)
+}
+
+// Save writes v to the file, keeping the file it replaces as the backup
+func (f *File) Save(v any) error {
+	unlock, err := f.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return f.save(v)
+}
+
+// Update reloads the file into v, applies change and saves v, holding the
+// lock throughout so that no other process's changes are lost in between.
+// When change returns an error the file is left alone; ErrUnchanged makes
+// Update return nil. A *RecoveredError from reloading is returned after
+// saving.
+func (f *File) Update(v any, change func() error) error {
+	unlock, err := f.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	var recovered *RecoveredError
+	if err := f.load(v); err != nil && !errors.As(err, &recovered) {
+		return err
+	}
+	if err := change(); err != nil {
+		if errors.Is(err, ErrUnchanged) {
+			err = nil
+		}
+		if err == nil && recovered != nil {
+			return recovered
+		}
+		return err
+	}
+	if err := f.save(v); err != nil {
+		return err
+	}
+	if recovered != nil {
+		return recovered
+	}
+	return nil
+}
+
+// corruptError marks a file that can't be read back
+type corruptError struct{ err error }
+
+func (e *corruptError) Error() string { return e.err.Error() }
+func (e *corruptError) Unwrap() error { return e.err }
+
+func isCorrupt(err error) bool {
+	var corrupt *corruptError
+	var syntax *json.SyntaxError
+	var typ *json.UnmarshalTypeError
+	return errors.As(err, &corrupt) || errors.As(err, &syntax) || errors.As(err, &typ)
+}
+
+// decode returns the data of a saved file, migrated to the current version
+func (f *File) decode(raw []byte) (json.RawMessage, error) {
+	if len(bytes.TrimSpace(raw)) == 0 {
+		return nil, &corruptError{errors.New("the file is empty")}
+	}
+	if !json.Valid(raw) {
+		return nil, &corruptError{errors.New("invalid JSON")}
+	}
+
+	version, data := 0, json.RawMessage(raw)
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(raw, &fields) == nil && len(fields) == 2 && fields["version"] != nil && fields["data"] != nil {
+		var env envelope
+		if err := json.Unmarshal(raw, &env); err != nil {
+			return nil, &corruptError{fmt.Errorf("invalid version: %w", err)}
+		}
+		version, data = env.Version, env.Data
+	}
+	if version > f.Version {
+		return nil, fmt.Errorf("%s was saved by a newer ellie (schema version %d; this one reads up to %d)", f.path, version, f.Version)
+	}
+
+	for ; version < f.Version; version++ {
+		migrate := f.Migrations[version]
+		if migrate == nil {
+			continue
+		}
+		migrated, err := migrate(data)
+		if err != nil {
+			return nil, &corruptError{fmt.Errorf("migrating from schema version %d: %w", version, err)}
+		}
+		data = migrated
+	}
+	return data, nil
+}
+
+// read replaces v with the file's data
+func (f *File) read(v any) error {
+	raw, err := os.ReadFile(f.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	data, err := f.decode(raw)
+	if err != nil {
+		return err
+	}
+	reset(v)
+	return json.Unmarshal(data, v)
+}
+
+// load reads the file into v, recovering it if it is unreadable. The caller
+// holds the lock.
+func (f *File) load(v any) error {
+	err := f.read(v)
+	if err == nil || !isCorrupt(err) {
+		return err
+	}
+	return f.recover(v, err)
+}
+
+// recover moves an unreadable file aside and restores the backup in its
+// place, or starts empty without one
+func (f *File) recover(v any, cause error) error {
+	recovered := &RecoveredError{
+		Path:    f.path,
+		Corrupt: fmt.Sprintf("%s.corrupt-%s", f.path, time.Now().Format("20060102-150405")),
+		Err:     cause,
+	}
+	if err := os.Rename(f.path, recovered.Corrupt); err != nil {
+		return fmt.Errorf("%s is unreadable (%v) and couldn't be moved aside: %w", f.path, cause, err)
+	}
+
+	// The data may be half decoded; start again from nothing
+	reset(v)
+	backup, err := os.ReadFile(f.path + backupSuffix)
+	if err == nil {
+		data, err := f.decode(backup)
+		if err == nil {
+			err = json.Unmarshal(data, v)
+		}
+		if err == nil {
+			recovered.FromBackup = true
+			if err := WriteAtomic(f.path, backup, f.Perm); err != nil {
+				return fmt.Errorf("error restoring %s: %w", f.path, err)
+			}
+			return recovered
+		}
+		reset(v)
+	}
+	return recovered
+}
+
+// reset sets what v points to to its zero value
+func reset(v any) {
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Pointer && !rv.IsNil() {
+		rv.Elem().Set(reflect.Zero(rv.Elem().Type()))
+	}
+}
+
+// save writes v atomically, first making the current file the backup if it
+// is readable. The caller holds the lock.
+func (f *File) save(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("error encoding %s: %w", filepath.Base(f.path), err)
+	}
+	content, err := json.MarshalIndent(envelope{Version: f.Version, Data: data}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding %s: %w", filepath.Base(f.path), err)
+	}
+
+	if current, err := os.ReadFile(f.path); err == nil {
+		if _, err := f.decode(current); err == nil {
+			if err := WriteAtomic(f.path+backupSuffix, current, f.Perm); err != nil {
+				return fmt.Errorf("error backing up %s: %w", filepath.Base(f.path), err)
+			}
+		}
+	}
+	if err := WriteAtomic(f.path, append(content, '\n'), f.Perm); err != nil {
+		return fmt.Errorf("error writing %s: %w", filepath.Base(f.path), err)
+	}
+	return nil
+}
+
+// WriteAtomic writes a file so that it is either all there or not changed:
+// the data goes to a temporary file in the same directory, which then
+// replaces the file
+func WriteAtomic(path string, data []byte, perm os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
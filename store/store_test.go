@@ -0,0 +1,261 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type counter struct {
+	Count int      `json:"count"`
+	Names []string `json:"names,omitempty"`
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counter.json")
+	f := New(path, 2)
+
+	var got counter
+	if err := f.Load(&got); err != nil || got.Count != 0 {
+		t.Fatalf("Load() of a missing file = %+v, %v", got, err)
+	}
+	if err := f.Save(counter{Count: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path + backupSuffix); !os.IsNotExist(err) {
+		t.Error("the first save made a backup")
+	}
+	if err := f.Save(counter{Count: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.Load(&got); err != nil || got.Count != 2 {
+		t.Errorf("Load() = %+v, %v", got, err)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), `"version": 2`) {
+		t.Errorf("saved file has no version:\n%s", data)
+	}
+	backup, _ := os.ReadFile(path + backupSuffix)
+	if !strings.Contains(string(backup), `"count":1`) && !strings.Contains(string(backup), `"count": 1`) {
+		t.Errorf("backup isn't the previous copy:\n%s", backup)
+	}
+	if matches, _ := filepath.Glob(filepath.Join(filepath.Dir(path), ".*tmp*")); len(matches) > 0 {
+		t.Errorf("temporary files left behind: %v", matches)
+	}
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Error("the lock wasn't released")
+	}
+}
+
+func TestMigrations(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counter.json")
+	// A file from before versioning
+	os.WriteFile(path, []byte(`{"total": 3}`), 0600)
+
+	f := New(path, 3)
+	var steps []int
+	f.Migrations = map[int]Migration{
+		0: func(data json.RawMessage) (json.RawMessage, error) {
+			steps = append(steps, 0)
+			var old struct{ Total int }
+			if err := json.Unmarshal(data, &old); err != nil {
+				return nil, err
+			}
+			return json.Marshal(counter{Count: old.Total})
+		},
+		2: func(data json.RawMessage) (json.RawMessage, error) {
+			steps = append(steps, 2)
+			var c counter
+			json.Unmarshal(data, &c)
+			c.Names = []string{"migrated"}
+			return json.Marshal(c)
+		},
+	}
+
+	var got counter
+	if err := f.Load(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Count != 3 || len(got.Names) != 1 || fmt.Sprint(steps) != "[0 2]" {
+		t.Errorf("Load() = %+v after migrations %v", got, steps)
+	}
+
+	// Saved data is at the current version and isn't migrated again
+	if err := f.Save(got); err != nil {
+		t.Fatal(err)
+	}
+	steps = nil
+	if err := f.Load(&got); err != nil || len(steps) != 0 {
+		t.Errorf("Load() after saving ran migrations %v, err %v", steps, err)
+	}
+}
+
+func TestNewerVersionIsLeftAlone(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counter.json")
+	saved := `{"version": 5, "data": {"count": 9}}`
+	os.WriteFile(path, []byte(saved), 0600)
+
+	var got counter
+	err := New(path, 1).Load(&got)
+	if err == nil || !strings.Contains(err.Error(), "newer ellie") {
+		t.Errorf("Load() error = %v, want it to mention a newer ellie", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != saved {
+		t.Error("Load() changed a file from a newer version")
+	}
+}
+
+func TestRecoverFromBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counter.json")
+	f := New(path, 1)
+	f.Save(counter{Count: 1})
+	f.Save(counter{Count: 2})
+	os.WriteFile(path, []byte(`{"version": 1, "data": {"count": 3`), 0600)
+
+	got := counter{Names: []string{"stale"}}
+	err := f.Load(&got)
+	var recovered *RecoveredError
+	if !errors.As(err, &recovered) || !recovered.FromBackup {
+		t.Fatalf("Load() error = %v, want it recovered from the backup", err)
+	}
+	if got.Count != 1 || got.Names != nil {
+		t.Errorf("Load() = %+v, want the backup's data", got)
+	}
+	if data, _ := os.ReadFile(recovered.Corrupt); !strings.Contains(string(data), `"count": 3`) {
+		t.Error("the unreadable file wasn't kept")
+	}
+
+	// The restored file reads normally
+	if err := f.Load(&got); err != nil || got.Count != 1 {
+		t.Errorf("Load() after recovery = %+v, %v", got, err)
+	}
+}
+
+func TestRecoverWithoutBackup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counter.json")
+	for _, content := range []string{"", `{"count": "many"}`, "[1, 2"} {
+		os.WriteFile(path, []byte(content), 0600)
+		got := counter{Count: 7}
+		err := New(path, 1).Load(&got)
+		var recovered *RecoveredError
+		if !errors.As(err, &recovered) || recovered.FromBackup {
+			t.Fatalf("Load(%q) error = %v, want it recovered without a backup", content, err)
+		}
+		if got.Count != 0 {
+			t.Errorf("Load(%q) = %+v, want empty data", content, got)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Load(%q) left the unreadable file in place", content)
+		}
+		os.Remove(recovered.Corrupt)
+	}
+}
+
+func TestUpdateLosesNoChanges(t *testing.T) {
+	f := New(filepath.Join(t.TempDir(), "counter.json"), 1)
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5; j++ {
+				var c counter
+				if err := f.Update(&c, func() error { c.Count++; return nil }); err != nil {
+					t.Error(err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	var got counter
+	if err := f.Load(&got); err != nil || got.Count != 50 {
+		t.Errorf("count = %d, %v; want 50", got.Count, err)
+	}
+}
+
+func TestUpdateUnchanged(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counter.json")
+	f := New(path, 1)
+	var c counter
+	if err := f.Update(&c, func() error { return ErrUnchanged }); err != nil {
+		t.Errorf("Update() = %v", err)
+	}
+	failure := errors.New("no")
+	if err := f.Update(&c, func() error { c.Count = 1; return failure }); err != failure {
+		t.Errorf("Update() = %v, want the change's error", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Update() saved a change that didn't happen")
+	}
+}
+
+func TestLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "counter.json")
+	f := New(path, 1)
+	f.LockTimeout = 100 * time.Millisecond
+
+	// A lock held by a process that is gone is taken over
+	os.WriteFile(path+".lock", []byte("999999999\n"), 0600)
+	if err := f.Save(counter{Count: 1}); err != nil {
+		t.Fatalf("Save() with a stale lock: %v", err)
+	}
+
+	// A lock held by a running process is waited for
+	os.WriteFile(path+".lock", []byte(fmt.Sprintln(os.Getpid())), 0600)
+	if err := f.Save(counter{Count: 2}); err == nil || !strings.Contains(err.Error(), "locked") {
+		t.Errorf("Save() with a held lock = %v, want a lock error", err)
+	}
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		os.Remove(path + ".lock")
+	}()
+	if err := f.Save(counter{Count: 3}); err != nil {
+		t.Errorf("Save() after the lock was released: %v", err)
+	}
+}
+
+func TestLockPID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "locks", "daemon.pid")
+	lock, err := LockPID(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var held *HeldError
+	if _, err := LockPID(path); !errors.As(err, &held) || held.PID != os.Getpid() {
+		t.Errorf("second LockPID() error = %v, want it held by this process", err)
+	}
+
+	// A lock taken over by another process isn't released by the old holder
+	os.WriteFile(path, []byte("999999999\n"), 0600)
+	lock.Release()
+	if pid, held := LockHolder(path); held || pid != 999999999 {
+		t.Errorf("LockHolder() = %d, %v, want the gone process", pid, held)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Error("Release() removed a lock it no longer held")
+	}
+
+	// A lock without a PID is held until it is old enough to be abandoned
+	os.WriteFile(path, nil, 0600)
+	if _, err := LockPID(path); !errors.As(err, &held) || held.PID != 0 {
+		t.Errorf("LockPID() over a lock being written = %v, want it held", err)
+	}
+	old := time.Now().Add(-time.Minute)
+	os.Chtimes(path, old, old)
+	lock, err = LockPID(path)
+	if err != nil {
+		t.Fatalf("LockPID() over an abandoned lock: %v", err)
+	}
+	lock.Release()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Release() left the lock behind")
+	}
+}
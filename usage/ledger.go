@@ -0,0 +1,172 @@
+// Package usage records the tokens and estimated cost of every LLM request
+// in a local ledger and enforces an optional monthly budget.
+package usage
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Entry is one request recorded in the ledger
+type Entry struct {
+	Time             time.Time `json:"time"`
+	User             string    `json:"user,omitempty"`
+	Command          string    `json:"command"`
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	TotalTokens      int       `json:"total_tokens"`
+	// Cost is the estimated price in USD; zero when the model has no price
+	Cost float64 `json:"cost"`
+}
+
+// Ledger is an append-only JSON Lines file of entries
+type Ledger struct {
+	path string
+}
+
+// NewLedger returns a ledger stored at path
+func NewLedger(path string) *Ledger {
+	return &Ledger{path: path}
+}
+
+// Record appends an entry to the ledger
+func (l *Ledger) Record(e Entry) error {
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("error creating usage ledger directory: %w", err)
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("error encoding usage entry: %w", err)
+	}
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("error opening usage ledger: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("error writing usage ledger: %w", err)
+	}
+	return nil
+}
+
+// Entries returns the entries recorded since the given time, oldest first.
+// Lines that can't be parsed are skipped.
+func (l *Ledger) Entries(since time.Time) ([]Entry, error) {
+	f, err := os.Open(l.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening usage ledger: %w", err)
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		if !e.Time.Before(since) {
+			entries = append(entries, e)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading usage ledger: %w", err)
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].Time.Before(entries[j].Time)
+	})
+	return entries, nil
+}
+
+// MonthStart returns the first instant of t's month
+func MonthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// Row is one line of a usage breakdown
+type Row struct {
+	Key      string
+	Requests int
+	Tokens   int
+	Cost     float64
+}
+
+// Breakdown groups entries by key, in key order
+func Breakdown(entries []Entry, key func(Entry) string) []Row {
+	rows := map[string]*Row{}
+	for _, e := range entries {
+		k := key(e)
+		row, ok := rows[k]
+		if !ok {
+			row = &Row{Key: k}
+			rows[k] = row
+		}
+		row.Requests++
+		row.Tokens += e.TotalTokens
+		row.Cost += e.Cost
+	}
+
+	result := make([]Row, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, *row)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Key < result[j].Key
+	})
+	return result
+}
+
+// Total sums all entries into a single row
+func Total(entries []Entry) Row {
+	total := Row{Key: "total"}
+	for _, e := range entries {
+		total.Requests++
+		total.Tokens += e.TotalTokens
+		total.Cost += e.Cost
+	}
+	return total
+}
+
+// ByDay keys entries by calendar day
+func ByDay(e Entry) string {
+	return e.Time.Local().Format("2006-01-02")
+}
+
+// ByWeek keys entries by ISO week
+func ByWeek(e Entry) string {
+	year, week := e.Time.Local().ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// ByCommand keys entries by the Ellie command that made the request
+func ByCommand(e Entry) string {
+	return e.Command
+}
+
+// ByModel keys entries by provider and model
+func ByModel(e Entry) string {
+	return e.Provider + "/" + e.Model
+}
+
+// ByUser keys entries by the Ellie user that made the request
+func ByUser(e Entry) string {
+	if e.User == "" {
+		return "unknown"
+	}
+	return e.User
+}
@@ -0,0 +1,88 @@
+package usage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Price is the cost of a model in USD per million tokens
+type Price struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+}
+
+// PriceTable maps model names, or prefixes of them, to prices
+type PriceTable map[string]Price
+
+// DefaultPrices are list prices at the time of writing. Override or extend
+// them with a prices file (see LoadPrices) when they change.
+var DefaultPrices = PriceTable{
+	"gpt-4o-mini":       {Input: 0.15, Output: 0.60},
+	"gpt-4o":            {Input: 2.50, Output: 10.00},
+	"gpt-4.1-nano":      {Input: 0.10, Output: 0.40},
+	"gpt-4.1-mini":      {Input: 0.40, Output: 1.60},
+	"gpt-4.1":           {Input: 2.00, Output: 8.00},
+	"gpt-4-turbo":       {Input: 10.00, Output: 30.00},
+	"gpt-4":             {Input: 30.00, Output: 60.00},
+	"gpt-3.5-turbo":     {Input: 0.50, Output: 1.50},
+	"gemini-1.5-flash":  {Input: 0.075, Output: 0.30},
+	"gemini-1.5-pro":    {Input: 1.25, Output: 5.00},
+	"gemini-2.0-flash":  {Input: 0.10, Output: 0.40},
+	"gemini-pro":        {Input: 0.50, Output: 1.50},
+	"claude-3-5-haiku":  {Input: 0.80, Output: 4.00},
+	"claude-3-5-sonnet": {Input: 3.00, Output: 15.00},
+	"claude-3-7-sonnet": {Input: 3.00, Output: 15.00},
+	"claude-sonnet-4":   {Input: 3.00, Output: 15.00},
+	"claude-3-opus":     {Input: 15.00, Output: 75.00},
+	"claude-opus-4":     {Input: 15.00, Output: 75.00},
+}
+
+// LoadPrices returns DefaultPrices merged with the overrides in a JSON file
+// such as {"gpt-4o": {"input": 2.5, "output": 10}}. A missing file is not
+// an error.
+func LoadPrices(path string) (PriceTable, error) {
+	prices := PriceTable{}
+	for model, price := range DefaultPrices {
+		prices[model] = price
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return prices, nil
+	}
+	if err != nil {
+		return prices, fmt.Errorf("error reading prices: %w", err)
+	}
+
+	var overrides PriceTable
+	if err := json.Unmarshal(data, &overrides); err != nil {
+		return prices, fmt.Errorf("error parsing prices: %w", err)
+	}
+	for model, price := range overrides {
+		prices[strings.ToLower(model)] = price
+	}
+	return prices, nil
+}
+
+// Lookup finds the price of a model by its longest matching prefix
+func (t PriceTable) Lookup(model string) (Price, bool) {
+	model = strings.ToLower(model)
+	best, found := "", false
+	for prefix := range t {
+		if strings.HasPrefix(model, prefix) && len(prefix) >= len(best) {
+			best, found = prefix, true
+		}
+	}
+	return t[best], found
+}
+
+// Cost estimates the price in USD of a request
+func (t PriceTable) Cost(model string, promptTokens, completionTokens int) float64 {
+	price, ok := t.Lookup(model)
+	if !ok {
+		return 0
+	}
+	return (float64(promptTokens)*price.Input + float64(completionTokens)*price.Output) / 1e6
+}
@@ -0,0 +1,172 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/tacheraSasi/ellie/llm"
+)
+
+// ErrBudgetExceeded is returned instead of making a request once the monthly
+// budget is spent and the budget is set to block
+var ErrBudgetExceeded = errors.New("monthly AI budget exceeded")
+
+// Budget caps the estimated spend per calendar month
+type Budget struct {
+	// Monthly is the limit in USD; zero means no budget
+	Monthly float64
+	// Block refuses further requests once the limit is reached; otherwise
+	// the tracker only warns
+	Block bool
+}
+
+// Tracker records the requests of one Ellie command in the ledger
+type Tracker struct {
+	Ledger   *Ledger
+	Prices   PriceTable
+	Budget   Budget
+	User     string
+	Command  string
+	Provider string
+	// OnBudgetExceeded is called once, when the month's spend first reaches
+	// the budget
+	OnBudgetExceeded func(spent, budget float64)
+	// OnError is called when an entry can't be recorded
+	OnError func(err error)
+
+	once   sync.Once
+	mu     sync.Mutex
+	spent  float64
+	warned bool
+}
+
+// Spent returns the estimated spend this month, including this process
+func (t *Tracker) Spent() float64 {
+	t.load()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.spent
+}
+
+// load reads the month's spend from the ledger the first time it is needed
+func (t *Tracker) load() {
+	t.once.Do(func() {
+		entries, err := t.Ledger.Entries(MonthStart(time.Now()))
+		if err != nil && t.OnError != nil {
+			t.OnError(err)
+		}
+		t.mu.Lock()
+		t.spent += Total(entries).Cost
+		t.mu.Unlock()
+	})
+}
+
+// check returns ErrBudgetExceeded when a blocking budget is spent
+func (t *Tracker) check() error {
+	if t.Budget.Monthly <= 0 {
+		return nil
+	}
+	if spent := t.Spent(); spent >= t.Budget.Monthly {
+		t.notify(spent)
+		if t.Budget.Block {
+			return fmt.Errorf("%w: spent $%.2f of $%.2f", ErrBudgetExceeded, spent, t.Budget.Monthly)
+		}
+	}
+	return nil
+}
+
+// notify calls OnBudgetExceeded the first time the budget is reached
+func (t *Tracker) notify(spent float64) {
+	t.mu.Lock()
+	first := !t.warned
+	t.warned = true
+	t.mu.Unlock()
+	if first && t.OnBudgetExceeded != nil {
+		t.OnBudgetExceeded(spent, t.Budget.Monthly)
+	}
+}
+
+// record adds a response's usage to the ledger
+func (t *Tracker) record(model string, resp *llm.Response) {
+	if resp == nil || resp.Usage == (llm.Usage{}) {
+		return
+	}
+	t.load()
+
+	u := resp.Usage
+	if u.TotalTokens == 0 {
+		u.TotalTokens = u.PromptTokens + u.CompletionTokens
+	}
+	entry := Entry{
+		Time:             time.Now(),
+		User:             t.User,
+		Command:          t.Command,
+		Provider:         t.Provider,
+		Model:            model,
+		PromptTokens:     u.PromptTokens,
+		CompletionTokens: u.CompletionTokens,
+		TotalTokens:      u.TotalTokens,
+		Cost:             t.Prices.Cost(model, u.PromptTokens, u.CompletionTokens),
+	}
+	if err := t.Ledger.Record(entry); err != nil && t.OnError != nil {
+		t.OnError(err)
+	}
+
+	t.mu.Lock()
+	t.spent += entry.Cost
+	spent := t.spent
+	t.mu.Unlock()
+	if t.Budget.Monthly > 0 && spent >= t.Budget.Monthly {
+		t.notify(spent)
+	}
+}
+
+// Track wraps a provider so every request is checked against the budget and
+// recorded in the ledger. Tool support of the wrapped provider is kept.
+func Track(provider llm.Provider, tracker *Tracker) llm.Provider {
+	tracked := &trackedProvider{Provider: provider, tracker: tracker}
+	if tools, ok := provider.(llm.ToolProvider); ok {
+		return &trackedToolProvider{trackedProvider: tracked, tools: tools}
+	}
+	return tracked
+}
+
+type trackedProvider struct {
+	llm.Provider
+	tracker *Tracker
+}
+
+func (p *trackedProvider) Chat(ctx context.Context, messages []llm.Message) (*llm.Response, error) {
+	if err := p.tracker.check(); err != nil {
+		return nil, err
+	}
+	resp, err := p.Provider.Chat(ctx, messages)
+	p.tracker.record(p.GetModel(), resp)
+	return resp, err
+}
+
+func (p *trackedProvider) ChatStream(ctx context.Context, messages []llm.Message, onChunk llm.StreamHandler) (*llm.Response, error) {
+	if err := p.tracker.check(); err != nil {
+		return nil, err
+	}
+	resp, err := p.Provider.ChatStream(ctx, messages, onChunk)
+	p.tracker.record(p.GetModel(), resp)
+	return resp, err
+}
+
+type trackedToolProvider struct {
+	*trackedProvider
+	tools llm.ToolProvider
+}
+
+func (p *trackedToolProvider) ChatWithTools(ctx context.Context, messages []llm.Message, tools []llm.Tool, onChunk llm.StreamHandler) (*llm.Response, error) {
+	if err := p.tracker.check(); err != nil {
+		return nil, err
+	}
+	resp, err := p.tools.ChatWithTools(ctx, messages, tools, onChunk)
+	p.tracker.record(p.GetModel(), resp)
+	return resp, err
+}
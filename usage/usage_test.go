@@ -0,0 +1,225 @@
+package usage
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/tacheraSasi/ellie/llm"
+)
+
+// mockProvider answers every request with a fixed usage
+type mockProvider struct {
+	usage llm.Usage
+	calls int
+}
+
+func (m *mockProvider) Chat(ctx context.Context, messages []llm.Message) (*llm.Response, error) {
+	m.calls++
+	return &llm.Response{Content: "ok", Usage: m.usage}, nil
+}
+
+func (m *mockProvider) ChatStream(ctx context.Context, messages []llm.Message, onChunk llm.StreamHandler) (*llm.Response, error) {
+	return m.Chat(ctx, messages)
+}
+
+func (m *mockProvider) GetModel() string {
+	return "gpt-4o-mini"
+}
+
+type mockToolProvider struct {
+	mockProvider
+}
+
+func (m *mockToolProvider) ChatWithTools(ctx context.Context, messages []llm.Message, tools []llm.Tool, onChunk llm.StreamHandler) (*llm.Response, error) {
+	return m.Chat(ctx, messages)
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestLedger_RecordAndEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "usage.jsonl")
+	ledger := NewLedger(path)
+
+	entries, err := ledger.Entries(time.Time{})
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("Entries() on a missing ledger = %v, %v", entries, err)
+	}
+
+	now := time.Now()
+	for _, e := range []Entry{
+		{Time: now, Command: "chat", TotalTokens: 30},
+		{Time: now.Add(-48 * time.Hour), Command: "review", TotalTokens: 10},
+	} {
+		if err := ledger.Record(e); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("not json\n")
+	f.Close()
+
+	entries, err = ledger.Entries(time.Time{})
+	if err != nil {
+		t.Fatalf("Entries() error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].Command != "review" || entries[1].Command != "chat" {
+		t.Fatalf("Entries() = %+v, want both entries oldest first", entries)
+	}
+
+	entries, _ = ledger.Entries(now.Add(-time.Hour))
+	if len(entries) != 1 || entries[0].Command != "chat" {
+		t.Errorf("Entries(since) = %+v, want only the recent entry", entries)
+	}
+}
+
+func TestBreakdown(t *testing.T) {
+	day := time.Date(2026, 10, 11, 10, 0, 0, 0, time.Local)
+	entries := []Entry{
+		{Time: day, Command: "chat", TotalTokens: 10, Cost: 0.1},
+		{Time: day.Add(time.Hour), Command: "chat", TotalTokens: 20, Cost: 0.2},
+		{Time: day.AddDate(0, 0, 1), Command: "review", TotalTokens: 5, Cost: 0.05},
+	}
+
+	rows := Breakdown(entries, ByCommand)
+	if len(rows) != 2 || rows[0].Key != "chat" || rows[0].Requests != 2 || rows[0].Tokens != 30 || !almostEqual(rows[0].Cost, 0.3) {
+		t.Errorf("Breakdown(ByCommand) = %+v", rows)
+	}
+
+	rows = Breakdown(entries, ByWeek)
+	if len(rows) != 2 || rows[0].Key != "2026-W41" || rows[1].Key != "2026-W42" {
+		t.Errorf("Breakdown(ByWeek) = %+v, want Sunday and Monday in different weeks", rows)
+	}
+
+	if total := Total(entries); total.Requests != 3 || total.Tokens != 35 {
+		t.Errorf("Total() = %+v", total)
+	}
+}
+
+func TestPrices(t *testing.T) {
+	prices := PriceTable{
+		"gpt-4o":      {Input: 2.5, Output: 10},
+		"gpt-4o-mini": {Input: 0.15, Output: 0.6},
+	}
+
+	if price, _ := prices.Lookup("gpt-4o-mini-2024-07-18"); price.Input != 0.15 {
+		t.Errorf("Lookup() = %+v, want the longest matching prefix", price)
+	}
+	if _, ok := prices.Lookup("llama3.2"); ok {
+		t.Error("Lookup() should not find unknown models")
+	}
+	if cost := prices.Cost("gpt-4o", 1000, 500); !almostEqual(cost, 0.0075) {
+		t.Errorf("Cost() = %v, want 0.0075", cost)
+	}
+
+	path := filepath.Join(t.TempDir(), "prices.json")
+	if err := os.WriteFile(path, []byte(`{"GPT-4o": {"input": 1, "output": 2}, "local-model": {"input": 0.1, "output": 0.1}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadPrices(path)
+	if err != nil {
+		t.Fatalf("LoadPrices() error = %v", err)
+	}
+	if loaded["gpt-4o"].Input != 1 || loaded["local-model"].Output != 0.1 {
+		t.Errorf("LoadPrices() did not apply overrides: %+v", loaded["gpt-4o"])
+	}
+	if loaded["gpt-4o-mini"] != DefaultPrices["gpt-4o-mini"] {
+		t.Error("LoadPrices() should keep defaults that aren't overridden")
+	}
+
+	if _, err := LoadPrices(filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Errorf("LoadPrices() on a missing file error = %v", err)
+	}
+}
+
+func TestTrack_RecordsRequests(t *testing.T) {
+	ledger := NewLedger(filepath.Join(t.TempDir(), "usage.jsonl"))
+	tracker := &Tracker{
+		Ledger:   ledger,
+		Prices:   PriceTable{"gpt-4o-mini": {Input: 1, Output: 2}},
+		User:     "amina",
+		Command:  "chat",
+		Provider: "openai",
+	}
+
+	provider := Track(&mockToolProvider{mockProvider{usage: llm.Usage{PromptTokens: 1000, CompletionTokens: 500}}}, tracker)
+	toolProvider, ok := provider.(llm.ToolProvider)
+	if !ok {
+		t.Fatal("Track() should keep tool support")
+	}
+	if _, ok := Track(&mockProvider{}, tracker).(llm.ToolProvider); ok {
+		t.Error("Track() should not add tool support")
+	}
+
+	if _, err := provider.Chat(context.Background(), nil); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if _, err := toolProvider.ChatWithTools(context.Background(), nil, nil, nil); err != nil {
+		t.Fatalf("ChatWithTools() error = %v", err)
+	}
+
+	entries, _ := ledger.Entries(time.Time{})
+	if len(entries) != 2 {
+		t.Fatalf("recorded %d entries, want 2", len(entries))
+	}
+	e := entries[0]
+	if e.User != "amina" || e.Command != "chat" || e.Provider != "openai" || e.Model != "gpt-4o-mini" {
+		t.Errorf("entry = %+v", e)
+	}
+	if e.TotalTokens != 1500 || !almostEqual(e.Cost, 0.002) {
+		t.Errorf("entry tokens = %d, cost = %v, want 1500 and 0.002", e.TotalTokens, e.Cost)
+	}
+	if !almostEqual(tracker.Spent(), 0.004) {
+		t.Errorf("Spent() = %v, want 0.004", tracker.Spent())
+	}
+}
+
+func TestTrack_Budget(t *testing.T) {
+	ledger := NewLedger(filepath.Join(t.TempDir(), "usage.jsonl"))
+	ledger.Record(Entry{Time: time.Now().AddDate(0, -2, 0), Cost: 100})
+	ledger.Record(Entry{Time: time.Now(), Cost: 0.9})
+
+	newTracker := func(block bool, warnings *int) *Tracker {
+		return &Tracker{
+			Ledger: ledger,
+			Prices: PriceTable{"gpt-4o-mini": {Input: 1000, Output: 1000}},
+			Budget: Budget{Monthly: 1, Block: block},
+			OnBudgetExceeded: func(spent, budget float64) {
+				*warnings++
+			},
+		}
+	}
+
+	var warnings int
+	mock := &mockProvider{usage: llm.Usage{PromptTokens: 100}}
+	provider := Track(mock, newTracker(false, &warnings))
+	for i := 0; i < 3; i++ {
+		if _, err := provider.Chat(context.Background(), nil); err != nil {
+			t.Fatalf("Chat() error = %v, want warn-only budget to allow requests", err)
+		}
+	}
+	if warnings != 1 {
+		t.Errorf("warned %d times, want once", warnings)
+	}
+
+	warnings = 0
+	mock.calls = 0
+	provider = Track(mock, newTracker(true, &warnings))
+	_, err := provider.Chat(context.Background(), nil)
+	if !errors.Is(err, ErrBudgetExceeded) {
+		t.Fatalf("Chat() error = %v, want ErrBudgetExceeded", err)
+	}
+	if mock.calls != 0 || warnings != 1 {
+		t.Errorf("blocked request reached the provider %d times, warned %d times", mock.calls, warnings)
+	}
+}
@@ -269,8 +269,7 @@ func Error(message string, details ...any) {
 
 // Sends a desktop Notification
 func Notify(message string) {
-	icon := "static/icon.png"
-	err := beeep.Notify("🔔 Ellie Reminder", message, icon)
+	err := NotifyWithTitle("🔔 Ellie Reminder", message)
 
 	if err != nil {
 		Error("❌ Failed to send notification: " + err.Error())
@@ -279,6 +278,11 @@ func Notify(message string) {
 	}
 }
 
+// NotifyWithTitle sends a desktop notification without printing anything
+func NotifyWithTitle(title, message string) error {
+	return beeep.Notify(title, message, "static/icon.png")
+}
+
 // Schedules a native reminder using the 'at' command.
 func ScheduleNativeReminder(title string, durationMinutes int) {
 	cmd := fmt.Sprintf(`echo "notify-send 'Ellie Reminder' '%s'" | at now + %d minutes`, title, durationMinutes)